@@ -27,7 +27,7 @@ func init() {
 	addCmd.Flags().StringP("sitemap", "s", "", "Sitemap URL (optional)")
 	addCmd.Flags().BoolP("auto-discover", "a", false, "Auto-discover sitemap")
 	addCmd.Flags().BoolP("validate", "v", true, "Validate sitemap before adding")
-	addCmd.Flags().StringP("user-agent", "", "ScraperBot/1.0", "User agent for requests")
+	addCmd.Flags().StringP("user-agent", "", "", "User agent for requests (default default_user_agent)")
 	if err := addCmd.MarkFlagRequired("url"); err != nil {
 		panic(err)
 	}
@@ -50,7 +50,11 @@ func runAddTarget(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid website URL: %w", err)
 	}
 
-	manager, err := cli.NewTargetManager()
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize target manager: %w", err)
 	}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"app/internal/scraper/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var classifyCmd = &cobra.Command{
+	Use:   "classify",
+	Short: "Re-classify stored pages without re-crawling",
+	Long: `Re-run the quote page classifier over the HTML stored in scraper_pages and
+update the stored classifier results. Pages are classified on their own with
+the target's profile, learned selector templates are only updated by crawls.
+Prints how many decisions flipped.
+
+Examples:
+  scraper-cli classify
+  scraper-cli classify --target-id 1
+  scraper-cli classify --reason SHORT_MAIN_TEXT --processable false
+  scraper-cli classify --since 2024-06-01 --dry-run`,
+	RunE: runClassify,
+}
+
+func init() {
+	classifyCmd.Flags().Int64P("target-id", "t", 0, "Only pages of this target (0 = all targets)")
+	classifyCmd.Flags().StringP("reason", "r", "", "Only pages with this previous decision reason (UNCLASSIFIED for pages without a result)")
+	classifyCmd.Flags().String("since", "", "Only pages visited since this date (YYYY-MM-DD or RFC3339)")
+	classifyCmd.Flags().String("processable", "", "Only pages with this previous processable flag (true/false)")
+	classifyCmd.Flags().IntP("workers", "w", 4, "Number of classifier workers")
+	classifyCmd.Flags().BoolP("dry-run", "d", false, "Report changes without writing results")
+}
+
+func runClassify(cmd *cobra.Command, args []string) error {
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+	reason, _ := cmd.Flags().GetString("reason")
+	sinceStr, _ := cmd.Flags().GetString("since")
+	processableStr, _ := cmd.Flags().GetString("processable")
+	workers, _ := cmd.Flags().GetInt("workers")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if workers < 1 || workers > 32 {
+		return fmt.Errorf("workers must be between 1 and 32")
+	}
+
+	opts := cli.ReclassifyOptions{TargetID: targetID, Reason: reason, DryRun: dryRun}
+	if sinceStr != "" {
+		since, err := parseTimeFlag("since", sinceStr)
+		if err != nil {
+			return err
+		}
+		opts.Since = since
+	}
+	if processableStr != "" {
+		processable, err := strconv.ParseBool(processableStr)
+		if err != nil {
+			return fmt.Errorf("invalid --processable value %q: expected true or false", processableStr)
+		}
+		opts.Processable = &processable
+	}
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	reclassifier, err := cli.NewReclassifier(cfg, workers)
+	if err != nil {
+		return fmt.Errorf("failed to initialize reclassifier: %w", err)
+	}
+	defer func() {
+		if err := reclassifier.Close(); err != nil {
+			fmt.Printf("failed to close reclassifier: %v\n", err)
+		}
+	}()
+
+	if dryRun {
+		fmt.Printf("🧪 DRY RUN MODE - Results will not be written\n")
+	}
+	summary, err := reclassifier.Run(context.Background(), opts)
+	if summary != nil {
+		summary.Print()
+	}
+	return err
+}
+
+// parseTimeFlag accepts a date or a full RFC3339 timestamp
+func parseTimeFlag(flag, value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s value %q: expected YYYY-MM-DD or RFC3339", flag, value)
+	}
+	return t, nil
+}
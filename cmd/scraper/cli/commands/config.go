@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the settings in effect and where they come from",
+	Long: `Show every setting with its value and source: flag, env, file, database
+or default. The first source set wins in that order.
+
+Examples:
+  scraper-cli config show
+  SCRAPER_CONCURRENT_WORKERS=8 scraper-cli config show`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in the scraper_config table",
+	Long: `Store a setting in the scraper_config table, shared by every host using the
+database. Flags, environment variables and the config file still override it.
+
+Examples:
+  scraper-cli config set max_concurrent_workers 8
+  scraper-cli config set default_user_agent "MyBot/2.0 (+https://example.com/bot)"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+}
+
+// withConfigStore opens the configured database and loads its settings into cfg
+func withConfigStore(cmd *cobra.Command, fn func(cfg *config.Config, queries db.Querier) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	store, err := cli.OpenStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			fmt.Printf("failed to close store: %v\n", err)
+		}
+	}()
+	return fn(cfg, store.Queries())
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	return withConfigStore(cmd, func(cfg *config.Config, _ db.Querier) error {
+		fmt.Printf("%-28s %-36s %s\n", "Key", "Source", "Value")
+		fmt.Printf("%-28s %-36s %s\n", "----------------------------", "------------------------------------", "-----")
+		for _, v := range cfg.Values() {
+			source := string(v.Source)
+			if v.Source == config.SourceEnv || v.Source == config.SourceFile {
+				source += " (" + v.Origin + ")"
+			}
+			fmt.Printf("%-28s %-36s %s\n", v.Key, source, v.Value)
+		}
+		return nil
+	})
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	setting, ok := config.Lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q, see `scraper-cli config show`", key)
+	}
+	if !setting.Database {
+		return fmt.Errorf("%s can't be stored in the database, set it with a flag, %s or the config file", key, setting.Env[0])
+	}
+	if err := setting.Validate(value); err != nil {
+		return err
+	}
+
+	return withConfigStore(cmd, func(cfg *config.Config, queries db.Querier) error {
+		err := queries.SetConfig(context.Background(), db.SetConfigParams{
+			Key:         key,
+			Value:       value,
+			Description: sql.NullString{String: setting.Description, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		fmt.Printf("✅ %s = %s\n", key, value)
+		if v, overridden := cfg.Overrides(key); overridden {
+			fmt.Printf("⚠️  Overridden here by the %s value %s (%s)\n", v.Source, v.Value, v.Origin)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance",
+}
+
+var dbCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Remove unreferenced page bodies and shrink the database",
+	Long: `Compact the scraper database:
+  - stored bodies no page refers to anymore are removed
+  - the database file is vacuumed
+
+Compaction is refused while a run is recorded as running, pass --force when
+that run crashed without finishing.
+
+Examples:
+  scraper-cli db compact
+  scraper-cli db compact --no-vacuum
+  scraper-cli db compact --force`,
+	RunE: runDBCompact,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply, revert or list schema migrations",
+	Long: `Manage the schema migrations embedded in the binary. The database is
+selected by SCRAPER_DATABASE_URL (default data/scraper.db).
+
+The CLI and the UI apply pending migrations on startup; set
+SCRAPER_AUTO_MIGRATE=false to make them refuse to start instead, e.g. when
+several crawler hosts share one database and migrations are rolled out by hand.
+
+Examples:
+  scraper-cli db migrate status
+  scraper-cli db migrate up
+  scraper-cli db migrate down --steps 2`,
+}
+
+var dbMigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	RunE:  runDBMigrateUp,
+}
+
+var dbMigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert the newest applied migrations",
+	RunE:  runDBMigrateDown,
+}
+
+var dbMigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	RunE:  runDBMigrateStatus,
+}
+
+func init() {
+	dbCompactCmd.Flags().Bool("no-vacuum", false, "Skip VACUUM, the file keeps its size until the next vacuum")
+	dbCompactCmd.Flags().Bool("force", false, "Compact even though a run is recorded as running")
+	dbCmd.AddCommand(dbCompactCmd)
+
+	dbMigrateDownCmd.Flags().Int("steps", 1, "Number of migrations to revert")
+	dbMigrateCmd.AddCommand(dbMigrateUpCmd, dbMigrateDownCmd, dbMigrateStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+}
+
+// withMigrator opens the database without migrating it on startup
+func withMigrator(cmd *cobra.Command, fn func(ctx context.Context, m *migrate.Migrator) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	store, err := storage.Open(cfg.DatabaseURL())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			fmt.Printf("failed to close database: %v\n", err)
+		}
+	}()
+	m, err := migrate.New(store)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database: %s (%s)\n", cfg.DatabaseURL(), store.Dialect())
+	return fn(context.Background(), m)
+}
+
+func runDBMigrateUp(cmd *cobra.Command, args []string) error {
+	return withMigrator(cmd, func(ctx context.Context, m *migrate.Migrator) error {
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("✅ Applied %03d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		return nil
+	})
+}
+
+func runDBMigrateDown(cmd *cobra.Command, args []string) error {
+	steps, _ := cmd.Flags().GetInt("steps")
+	if steps < 1 {
+		return fmt.Errorf("--steps must be at least 1")
+	}
+	return withMigrator(cmd, func(ctx context.Context, m *migrate.Migrator) error {
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Printf("↩️  Reverted %03d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations to revert")
+		}
+		return nil
+	})
+}
+
+func runDBMigrateStatus(cmd *cobra.Command, args []string) error {
+	return withMigrator(cmd, func(ctx context.Context, m *migrate.Migrator) error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		fmt.Printf("\n%-7s %-30s %s\n", "Version", "Name", "Applied")
+		fmt.Printf("%-7s %-30s %s\n", "-------", "------------------------------", "-------------------")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied() {
+				applied = s.AppliedAt.Local().Format(time.DateTime)
+			} else {
+				pending++
+			}
+			fmt.Printf("%03d     %-30s %s\n", s.Version, s.Name, applied)
+		}
+		fmt.Printf("\n%d of %d migrations applied\n", len(statuses)-pending, len(statuses))
+		return nil
+	})
+}
+
+func runDBCompact(cmd *cobra.Command, args []string) error {
+	noVacuum, _ := cmd.Flags().GetBool("no-vacuum")
+	force, _ := cmd.Flags().GetBool("force")
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	compactor, err := cli.NewCompactor(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize compactor: %w", err)
+	}
+	defer func() {
+		if err := compactor.Close(); err != nil {
+			fmt.Printf("failed to close compactor: %v\n", err)
+		}
+	}()
+
+	summary, err := compactor.Run(context.Background(), cli.CompactOptions{SkipVacuum: noVacuum, Force: force})
+	if summary != nil {
+		summary.Print()
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/service/export"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export pages, classifier results or quotes",
+	Long: `Export stored data as JSONL, CSV or Parquet. Pages are read in batches and
+written as they are read, so exports of any size run in constant memory.
+
+Datasets:
+  pages            page metadata, with --html also the page HTML
+  classifications  the classifier decision of each classified page
+  quotes           one row per extracted quote
+
+Dates filter on the time a page was last visited, --until is exclusive.
+
+Examples:
+  scraper-cli export --dataset quotes --format csv --output quotes.csv
+  scraper-cli export --dataset pages --html --target-id 1 > pages.jsonl
+  scraper-cli export --dataset classifications --format parquet --since 2024-06-01 -o decisions.parquet`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().String("dataset", export.DatasetPages, "Dataset to export ("+strings.Join(export.Datasets, ", ")+")")
+	exportCmd.Flags().StringP("format", "f", export.FormatJSONL, "Output format ("+strings.Join(export.Formats, ", ")+")")
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	exportCmd.Flags().Int64P("target-id", "t", 0, "Only pages of this target (0 = all targets)")
+	exportCmd.Flags().String("since", "", "Only pages visited since this date (YYYY-MM-DD or RFC3339)")
+	exportCmd.Flags().String("until", "", "Only pages visited before this date (YYYY-MM-DD or RFC3339)")
+	exportCmd.Flags().String("processable", "", "Only pages with this processable flag (true/false)")
+	exportCmd.Flags().Bool("html", false, "Include the page HTML in the pages dataset")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	dataset, _ := cmd.Flags().GetString("dataset")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+	sinceStr, _ := cmd.Flags().GetString("since")
+	untilStr, _ := cmd.Flags().GetString("until")
+	processableStr, _ := cmd.Flags().GetString("processable")
+	includeHTML, _ := cmd.Flags().GetBool("html")
+
+	opts := export.Options{Dataset: dataset, Format: format, TargetID: targetID, IncludeHTML: includeHTML}
+	if sinceStr != "" {
+		since, err := parseTimeFlag("since", sinceStr)
+		if err != nil {
+			return err
+		}
+		opts.Since = since
+	}
+	if untilStr != "" {
+		until, err := parseTimeFlag("until", untilStr)
+		if err != nil {
+			return err
+		}
+		opts.Until = until
+	}
+	if processableStr != "" {
+		processable, err := strconv.ParseBool(processableStr)
+		if err != nil {
+			return fmt.Errorf("invalid --processable value %q: expected true or false", processableStr)
+		}
+		opts.Processable = &processable
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	store, err := cli.OpenStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close store: %v\n", err)
+		}
+	}()
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	buf := bufio.NewWriter(out)
+
+	// Progress goes to stderr, stdout may be the export itself
+	n, err := export.NewExporter(store.Queries()).Export(context.Background(), buf, opts)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		if output != "" {
+			_ = os.Remove(output)
+		}
+		return fmt.Errorf("export failed after %d records: %w", n, err)
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "✅ Exported %d %s records to %s\n", n, dataset, output)
+	} else {
+		fmt.Fprintf(os.Stderr, "✅ Exported %d %s records\n", n, dataset)
+	}
+	return nil
+}
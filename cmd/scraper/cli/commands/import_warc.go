@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/scraper/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var importWARCCmd = &cobra.Command{
+	Use:   "import-warc <file>...",
+	Short: "Rebuild stored pages from WARC files",
+	Long: `Import the HTTP responses archived in WARC files, as written by "run --warc-dir"
+or other crawlers, into scraper_pages. Responses are matched to targets by
+host unless --target-id is given. A page already visited after the capture
+is kept. Run "process" or "classify" afterwards to reprocess the pages.
+
+Examples:
+  scraper-cli import-warc data/warc/*.warc.gz
+  scraper-cli import-warc --target-id 1 old-crawl.warc
+  scraper-cli import-warc --dry-run data/warc/scraper-20240601120000-00001.warc.gz`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImportWARC,
+}
+
+func init() {
+	importWARCCmd.Flags().Int64P("target-id", "t", 0, "Import every response into this target (0 = match targets by host)")
+	importWARCCmd.Flags().BoolP("dry-run", "d", false, "Read and match records without writing pages")
+}
+
+func runImportWARC(cmd *cobra.Command, args []string) error {
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	importer, err := cli.NewWARCImporter(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize WARC importer: %w", err)
+	}
+	defer func() {
+		if err := importer.Close(); err != nil {
+			fmt.Printf("failed to close importer: %v\n", err)
+		}
+	}()
+
+	if dryRun {
+		fmt.Printf("🧪 DRY RUN MODE - Pages will not be written\n")
+	}
+	summary, err := importer.Run(context.Background(), args, cli.WARCImportOptions{TargetID: targetID, DryRun: dryRun})
+	if summary != nil {
+		summary.Print()
+	}
+	return err
+}
@@ -31,7 +31,11 @@ func runListTargets(cmd *cobra.Command, args []string) error {
 	activeOnly, _ := cmd.Flags().GetBool("active")
 	format, _ := cmd.Flags().GetString("format")
 
-	manager, err := cli.NewTargetManager()
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize target manager: %w", err)
 	}
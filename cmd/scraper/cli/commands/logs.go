@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/queue"
+
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Maintain the scraper_logs table",
+	Long: `Maintain the scraper_logs table.
+
+Crawls prune the table automatically, at most hourly, by the log_retention_days
+and log_max_rows settings. prune applies them on demand, or the retention given
+by its flags instead.
+
+Examples:
+  scraper-cli logs prune
+  scraper-cli logs prune --older-than 7d
+  scraper-cli logs prune --keep 100000 -f`,
+}
+
+var logsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old log rows, by the configured retention unless flags give one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var retention logger.Retention
+		if raw, _ := cmd.Flags().GetString("older-than"); raw != "" {
+			age, err := queue.ParseAge(raw)
+			if err != nil {
+				return err
+			}
+			retention.MaxAge = age
+		}
+		retention.MaxRows, _ = cmd.Flags().GetInt64("keep")
+		if retention.MaxRows < 0 {
+			return fmt.Errorf("--keep must not be negative")
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		return withLogManager(cmd, func(lm *cli.LogManager) error {
+			if !cmd.Flags().Changed("older-than") && !cmd.Flags().Changed("keep") {
+				retention = lm.Retention()
+			}
+			return lm.Prune(retention, force)
+		})
+	},
+}
+
+func init() {
+	logsPruneCmd.Flags().String("older-than", "", "Delete rows logged longer ago than this, like 36h or 30d")
+	logsPruneCmd.Flags().Int64("keep", 0, "Delete all but this many newest rows")
+	logsPruneCmd.Flags().BoolP("force", "f", false, "Delete without confirmation")
+
+	logsCmd.AddCommand(logsPruneCmd)
+	rootCmd.AddCommand(logsCmd)
+}
+
+func withLogManager(cmd *cobra.Command, fn func(*cli.LogManager) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewLogManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize log manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+	return fn(manager)
+}
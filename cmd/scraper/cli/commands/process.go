@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/scraper/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var processCmd = &cobra.Command{
+	Use:   "process",
+	Short: "Re-run post-processing pipeline stages over stored pages",
+	Long: `Run the page post-processing pipeline (content_extraction, classification,
+quote_extraction, link_discovery) over the HTML stored in scraper_pages and
+record the per-stage status on each page. Without --stage all stages run.
+
+Examples:
+  scraper-cli process
+  scraper-cli process --stage quote_extraction --target-id 1
+  scraper-cli process --stage classification --stage quote_extraction
+  scraper-cli process --stage link_discovery --failed`,
+	RunE: runProcess,
+}
+
+func init() {
+	processCmd.Flags().StringSliceP("stage", "s", nil, "Stage to run, repeatable (default: all stages)")
+	processCmd.Flags().Int64P("target-id", "t", 0, "Only pages of this target (0 = all targets)")
+	processCmd.Flags().Bool("failed", false, "Only pages where the given stage failed last time (requires a single --stage)")
+	processCmd.Flags().IntP("workers", "w", 4, "Number of pipeline workers")
+}
+
+func runProcess(cmd *cobra.Command, args []string) error {
+	stages, _ := cmd.Flags().GetStringSlice("stage")
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+	failed, _ := cmd.Flags().GetBool("failed")
+	workers, _ := cmd.Flags().GetInt("workers")
+
+	if workers < 1 || workers > 32 {
+		return fmt.Errorf("workers must be between 1 and 32")
+	}
+	opts := cli.ReprocessOptions{TargetID: targetID, Stages: stages}
+	if failed {
+		if len(stages) != 1 {
+			return fmt.Errorf("--failed requires exactly one --stage")
+		}
+		opts.FailedStage = stages[0]
+	}
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	reprocessor, err := cli.NewReprocessor(cfg, workers)
+	if err != nil {
+		return fmt.Errorf("failed to initialize pipeline: %w", err)
+	}
+	defer func() {
+		if err := reprocessor.Close(); err != nil {
+			fmt.Printf("failed to close pipeline: %v\n", err)
+		}
+	}()
+
+	summary, err := reprocessor.Run(context.Background(), opts)
+	if summary != nil {
+		summary.Print()
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"app/internal/scraper/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Show or set the classifier profile",
+	Long: `Manage the classifier decision tree profile.
+
+The global profile is stored in scraper_config, a target can override any part of it.
+Without a configured profile the built-in default is used.
+
+Examples:
+  scraper-cli profile show
+  scraper-cli profile show --target-id 1
+  scraper-cli profile set --file profile.json
+  scraper-cli profile set --target-id 1 --file short-quotes.json`,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective classifier profile",
+	RunE:  runProfileShow,
+}
+
+var profileSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the global profile or a target override from a JSON file",
+	RunE:  runProfileSet,
+}
+
+func init() {
+	profileShowCmd.Flags().Int64P("target-id", "t", 0, "Show the effective profile for this target")
+
+	profileSetCmd.Flags().Int64P("target-id", "t", 0, "Store the profile as override for this target")
+	profileSetCmd.Flags().StringP("file", "f", "", "Profile JSON file (required)")
+	if err := profileSetCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+
+	profileCmd.AddCommand(profileShowCmd)
+	profileCmd.AddCommand(profileSetCmd)
+}
+
+func runProfileShow(cmd *cobra.Command, args []string) error {
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize target manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+
+	return manager.ShowProfile(targetID)
+}
+
+func runProfileSet(cmd *cobra.Command, args []string) error {
+	targetID, _ := cmd.Flags().GetInt64("target-id")
+	file, _ := cmd.Flags().GetString("file")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read profile file: %w", err)
+	}
+
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize target manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+
+	return manager.SetProfile(targetID, string(data))
+}
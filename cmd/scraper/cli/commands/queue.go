@@ -1,86 +1,171 @@
 package commands
 
 import (
-	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/service/queue"
 
 	"github.com/spf13/cobra"
 )
 
 var queueCmd = &cobra.Command{
 	Use:   "queue",
-	Short: "Queue operations (status, purge)",
+	Short: "Inspect and manage the crawl queue",
+	Long: `Inspect and manage the crawl queue.
+
+Filters select items by target, status (` + strings.Join(queue.Statuses, ", ") + `),
+error message or URL substring and age. Ages are durations like 36h or 7d.
+
+Examples:
+  scraper-cli queue status
+  scraper-cli queue list --status failed --error timeout
+  scraper-cli queue retry -t 1 --error "HTTP 503"
+  scraper-cli queue retry --reset-attempts
+  scraper-cli queue requeue -t 1 https://example.com/a https://example.com/b
+  scraper-cli queue prioritize 10 -t 1 --url /products/
+  scraper-cli queue purge --status completed --older-than 7d`,
 }
 
 var queueStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show queue status summary",
+	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dbConn, err := sql.Open("sqlite3", "data/scraper.db")
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.Status() })
+	},
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queue items matching the filters",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := queueFilter(cmd)
 		if err != nil {
 			return err
 		}
-		defer func() {
-			err := dbConn.Close()
-			if err != nil {
-				fmt.Printf("failed to close dbConn: %v\n", err)
-			}
-		}()
-
-		rows, err := dbConn.Query("SELECT status, COUNT(*) FROM scraper_queue GROUP BY status")
+		limit, _ := cmd.Flags().GetInt("limit")
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.List(filter, limit) })
+	},
+}
+
+var queueRetryCmd = &cobra.Command{
+	Use:   "retry",
+	Short: "Put failed items matching the filters back to pending",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := queueFilter(cmd)
 		if err != nil {
 			return err
 		}
-		defer func() {
-			err := rows.Close()
-			if err != nil {
-				fmt.Printf("failed to close rows: %v\n", err)
-			}
-		}()
-
-		fmt.Println("Status      Count")
-		fmt.Println("----------- -----")
-		for rows.Next() {
-			var status string
-			var count int
-			if err := rows.Scan(&status, &count); err != nil {
-				return err
-			}
-			fmt.Printf("%-11s %5d\n", status, count)
+		reset, _ := cmd.Flags().GetBool("reset-attempts")
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.Retry(filter, reset) })
+	},
+}
+
+var queueRequeueCmd = &cobra.Command{
+	Use:   "requeue <url>...",
+	Short: "Crawl URLs of a target again, whatever their queue status",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetID, _ := cmd.Flags().GetInt64("target-id")
+		if targetID == 0 {
+			return fmt.Errorf("target ID must be specified")
 		}
-		return nil
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.Requeue(targetID, args) })
 	},
 }
 
-var queuePurgeCmd = &cobra.Command{
-	Use:   "purge",
-	Short: "Delete completed/failed queue items",
+var queuePrioritizeCmd = &cobra.Command{
+	Use:   "prioritize <priority>",
+	Short: "Set the priority of pending items matching the filters, higher first",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dbConn, err := sql.Open("sqlite3", "data/scraper.db")
+		priority, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid priority %q", args[0])
+		}
+		filter, err := queueFilter(cmd)
 		if err != nil {
 			return err
 		}
-		defer func() {
-			err := dbConn.Close()
-			if err != nil {
-				fmt.Printf("failed to close dbConn: %v\n", err)
-			}
-		}()
-
-		// res, err := dbConn.Exec("DELETE FROM scraper_queue WHERE status IN ('completed', 'failed')")
-		res, err := dbConn.Exec("DELETE FROM scraper_queue")
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.Prioritize(filter, priority) })
+	},
+}
 
+var queuePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete queue items matching the filters, finished ones by default",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := queueFilter(cmd)
 		if err != nil {
 			return err
 		}
-		n, _ := res.RowsAffected()
-		fmt.Printf("Deleted %d completed/failed queue items.\n", n)
-		return queueStatusCmd.RunE(cmd, args)
+		force, _ := cmd.Flags().GetBool("force")
+		return withQueueManager(cmd, func(qm *cli.QueueManager) error { return qm.Purge(filter, force) })
 	},
 }
 
 func init() {
-	queueCmd.AddCommand(queueStatusCmd)
-	queueCmd.AddCommand(queuePurgeCmd)
+	for _, c := range []*cobra.Command{queueListCmd, queueRetryCmd, queuePrioritizeCmd, queuePurgeCmd} {
+		c.Flags().Int64P("target-id", "t", 0, "Only items of this target (0 = all targets)")
+		c.Flags().String("error", "", "Only items whose error message contains this")
+		c.Flags().String("url", "", "Only items whose URL contains this")
+		c.Flags().String("older-than", "", "Only items queued longer ago than this, like 36h or 7d")
+	}
+	queueListCmd.Flags().StringP("status", "s", "", "Only items with these comma separated statuses")
+	queueListCmd.Flags().Int("limit", 50, "Maximum items to list")
+	queueRetryCmd.Flags().Bool("reset-attempts", false, "Also retry items that used all their attempts, starting their attempts over")
+	queueRequeueCmd.Flags().Int64P("target-id", "t", 0, "Target of the URLs (required)")
+	queuePurgeCmd.Flags().StringP("status", "s", "completed,skipped,failed", "Delete items with these comma separated statuses, all for any")
+	queuePurgeCmd.Flags().BoolP("force", "f", false, "Delete without confirmation")
+
+	queueCmd.AddCommand(queueStatusCmd, queueListCmd, queueRetryCmd, queueRequeueCmd, queuePrioritizeCmd, queuePurgeCmd)
 	rootCmd.AddCommand(queueCmd)
 }
+
+// queueFilter reads the filter flags a queue command has
+func queueFilter(cmd *cobra.Command) (queue.Filter, error) {
+	var filter queue.Filter
+	filter.TargetID, _ = cmd.Flags().GetInt64("target-id")
+	filter.ErrorContains, _ = cmd.Flags().GetString("error")
+	filter.URLContains, _ = cmd.Flags().GetString("url")
+	if cmd.Flags().Lookup("status") != nil {
+		raw, _ := cmd.Flags().GetString("status")
+		statuses, err := queue.ParseStatuses(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Statuses = statuses
+	}
+	if raw, _ := cmd.Flags().GetString("older-than"); raw != "" {
+		age, err := queue.ParseAge(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.OlderThan = time.Now().Add(-age)
+	}
+	return filter, nil
+}
+
+func withQueueManager(cmd *cobra.Command, fn func(*cli.QueueManager) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewQueueManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize queue manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+	return fn(manager)
+}
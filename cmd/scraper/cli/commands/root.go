@@ -1,13 +1,25 @@
 package commands
 
 import (
+	"app/internal/scraper/config"
+	"app/internal/scraper/service/logger"
+
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "scraper-cli",
 	Short: "Web scraper CLI tool",
-	Long:  "Command line interface for managing web scraping targets and operations",
+	Long: `Command line interface for managing web scraping targets and operations
+
+Settings are resolved in this order, the first one set wins: command line
+flags, environment variables (also read from .env), the config file
+(--config, SCRAPER_CONFIG or ./scraper.json) and the scraper_config table.
+Run "scraper-cli config show" to see the values in effect.
+
+Diagnostics are logged to stderr at the --log-level (default $LOG_LEVEL or
+info), which takes per component overrides like "warn,fetcher=debug".`,
+	PersistentPreRunE: setupLogging,
 }
 
 func Execute() error {
@@ -15,6 +27,11 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("config", "", "Config file (default $SCRAPER_CONFIG or ./scraper.json when present)")
+	rootCmd.PersistentFlags().String("db", "", "Database DSN, a SQLite path or a postgres:// URL (default $SCRAPER_DATABASE_URL)")
+	rootCmd.PersistentFlags().String("log-level", "", "Log level with optional per component levels, e.g. warn,fetcher=debug (default $LOG_LEVEL or info)")
+	rootCmd.PersistentFlags().String("log-format", "", "Log format, text or json (default $LOG_FORMAT or text)")
+
 	// Add subcommands
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(listCmd)
@@ -22,4 +39,42 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(classifyCmd)
+	rootCmd.AddCommand(processCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importWARCCmd)
+	rootCmd.AddCommand(usersCmd)
+}
+
+// setupLogging configures the loggers from LOG_LEVEL and LOG_FORMAT, the flags win
+func setupLogging(cmd *cobra.Command, args []string) error {
+	opts := logger.OptionsFromEnv()
+	if f := cmd.Flags().Lookup("log-level"); f != nil && f.Changed {
+		opts.Level = f.Value.String()
+	}
+	if f := cmd.Flags().Lookup("log-format"); f != nil && f.Changed {
+		opts.Format = f.Value.String()
+	}
+	return logger.Setup(opts)
+}
+
+// loadConfig resolves the configuration of a command. settingFlags maps the
+// command's own flags to the settings they override, --db is always mapped.
+func loadConfig(cmd *cobra.Command, settingFlags map[string]string) (*config.Config, error) {
+	file, _ := cmd.Flags().GetString("config")
+	opts := config.Options{File: file, Flags: map[string]string{}}
+
+	flags := map[string]string{"db": config.KeyDatabaseURL}
+	for name, key := range settingFlags {
+		flags[name] = key
+	}
+	for name, key := range flags {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			opts.Flags[key] = f.Value.String()
+		}
+	}
+	return config.Load(opts)
 }
@@ -3,7 +3,9 @@ package commands
 import (
 	"fmt"
 
+	"app/internal/scraper/cassette"
 	"app/internal/scraper/cli"
+	"app/internal/scraper/config"
 
 	"github.com/spf13/cobra"
 )
@@ -17,7 +19,10 @@ Examples:
   scraper-cli run
   scraper-cli run --target-id 1
   scraper-cli run --progress --verbose
-  scraper-cli run --dry-run`,
+  scraper-cli run --dry-run
+  scraper-cli run --warc-dir data/warc
+  scraper-cli run --metrics-addr :9091
+  scraper-cli run --target-id 1 --record internal/scraper/cli/testdata/site.json`,
 	RunE: runScraper,
 }
 
@@ -26,8 +31,11 @@ func init() {
 	runCmd.Flags().BoolP("progress", "p", true, "Show progress bar")
 	runCmd.Flags().BoolP("verbose", "v", false, "Verbose output")
 	runCmd.Flags().BoolP("dry-run", "d", false, "Dry run (no actual crawling)")
-	runCmd.Flags().IntP("workers", "w", 3, "Number of worker threads")
-	runCmd.Flags().IntP("batch-size", "b", 10, "Batch size for URL processing")
+	runCmd.Flags().IntP("workers", "w", 0, "Number of worker threads, 1-20 (default max_concurrent_workers)")
+	runCmd.Flags().IntP("batch-size", "b", 0, "Batch size for URL processing, 1-100 (default queue_batch_size)")
+	runCmd.Flags().String("warc-dir", "", "Archive every fetch as WARC files in this directory (default warc_dir)")
+	runCmd.Flags().String("record", "", "Record every fetch into this cassette file for offline replay in tests")
+	runCmd.Flags().String("metrics-addr", "", "Serve Prometheus metrics on this address's /metrics while crawling, e.g. :9091")
 }
 
 func runScraper(cmd *cobra.Command, args []string) error {
@@ -35,18 +43,19 @@ func runScraper(cmd *cobra.Command, args []string) error {
 	progress, _ := cmd.Flags().GetBool("progress")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	workers, _ := cmd.Flags().GetInt("workers")
-	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	record, _ := cmd.Flags().GetString("record")
+	metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
 
-	if workers < 1 || workers > 20 {
-		return fmt.Errorf("workers must be between 1 and 20")
-	}
-
-	if batchSize < 1 || batchSize > 100 {
-		return fmt.Errorf("batch-size must be between 1 and 100")
+	cfg, err := loadConfig(cmd, map[string]string{
+		"workers":    config.KeyWorkers,
+		"batch-size": config.KeyBatchSize,
+		"warc-dir":   config.KeyWARCDir,
+	})
+	if err != nil {
+		return err
 	}
 
-	runner, err := cli.NewScraperRunner(workers, batchSize)
+	runner, err := cli.NewScraperRunner(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize scraper runner: %w", err)
 	}
@@ -57,5 +66,25 @@ func runScraper(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	return runner.Run(targetID, progress, verbose, dryRun)
+	if metricsAddr != "" {
+		stop, err := runner.ServeMetrics(metricsAddr)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = stop() }()
+		fmt.Printf("📈 Serving metrics on http://%s/metrics\n", metricsAddr)
+	}
+
+	if record == "" {
+		return runner.Run(targetID, progress, verbose, dryRun)
+	}
+
+	recorder := cassette.NewRecorder(nil)
+	runner.SetTransport(recorder)
+	runErr := runner.Run(targetID, progress, verbose, dryRun)
+	if err := recorder.Save(record); err != nil {
+		return err
+	}
+	fmt.Printf("📼 Recorded %d requests to %s\n", len(recorder.Cassette().Interactions), record)
+	return runErr
 }
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"app/internal/scraper/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "Show the history of crawl runs",
+	Long: `Show the history of crawl runs.
+
+Every run of the CLI, the admin UI or a schedule is recorded with its trigger,
+targets, counts and error breakdown. The queue items and logs of a run link to
+it, show counts them.
+
+Examples:
+  scraper-cli runs list
+  scraper-cli runs list --limit 50
+  scraper-cli runs show 12`,
+}
+
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the latest runs, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit <= 0 {
+			return fmt.Errorf("--limit must be positive")
+		}
+		return withRunManager(cmd, func(rm *cli.RunManager) error {
+			return rm.List(limit)
+		})
+	},
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show a run with its error breakdown, queue items and logs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid run ID %q", args[0])
+		}
+		return withRunManager(cmd, func(rm *cli.RunManager) error {
+			return rm.Show(id)
+		})
+	},
+}
+
+func init() {
+	runsListCmd.Flags().Int("limit", 20, "Number of runs to list")
+
+	runsCmd.AddCommand(runsListCmd, runsShowCmd)
+	rootCmd.AddCommand(runsCmd)
+}
+
+func withRunManager(cmd *cobra.Command, fn func(*cli.RunManager) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewRunManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize run manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+	return fn(manager)
+}
@@ -32,7 +32,11 @@ func runShowTarget(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("target ID must be specified")
 	}
 
-	manager, err := cli.NewTargetManager()
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize target manager: %w", err)
 	}
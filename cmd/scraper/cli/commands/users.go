@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"app/internal/scraper/cli"
+	"app/internal/scraper/service/auth"
+
+	"github.com/spf13/cobra"
+)
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage admin UI users and API tokens",
+	Long: `Manage the accounts of the admin UI and their API tokens.
+
+Roles: viewer (read only), operator (also manages targets, the queue and
+crawls), admin (also changes settings). Passwords are read from stdin.
+
+Examples:
+  scraper-cli users add alice --role admin
+  echo "$PASSWORD" | scraper-cli users add ci --role operator
+  scraper-cli users list
+  scraper-cli users set-role bob viewer
+  scraper-cli users disable bob
+  scraper-cli users token create ci --name deploy
+  scraper-cli users token revoke 3`,
+}
+
+var usersAddCmd = &cobra.Command{
+	Use:   "add <username>",
+	Short: "Create a user",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runUsersAdd,
+}
+
+var usersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.ListUsers() })
+	},
+}
+
+var usersSetRoleCmd = &cobra.Command{
+	Use:   "set-role <username> <role>",
+	Short: "Change the role of a user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, err := auth.ParseRole(args[1])
+		if err != nil {
+			return err
+		}
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.SetRole(args[0], role) })
+	},
+}
+
+var usersPasswordCmd = &cobra.Command{
+	Use:   "set-password <username>",
+	Short: "Change the password of a user and end their sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.SetPassword(args[0], password) })
+	},
+}
+
+var usersDisableCmd = &cobra.Command{
+	Use:   "disable <username>",
+	Short: "Disable a user, ending their sessions and API tokens",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.SetActive(args[0], false) })
+	},
+}
+
+var usersEnableCmd = &cobra.Command{
+	Use:   "enable <username>",
+	Short: "Enable a disabled user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.SetActive(args[0], true) })
+	},
+}
+
+var usersTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for /api/v1",
+}
+
+var usersTokenCreateCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "Create an API token acting as the user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.CreateToken(args[0], name) })
+	},
+}
+
+var usersTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.ListTokens() })
+	},
+}
+
+var usersTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <token-id>",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid token ID %q", args[0])
+		}
+		return withUserManager(cmd, func(um *cli.UserManager) error { return um.RevokeToken(id) })
+	},
+}
+
+func init() {
+	usersAddCmd.Flags().StringP("role", "r", string(auth.RoleViewer), "Role: viewer, operator or admin")
+	usersTokenCreateCmd.Flags().StringP("name", "n", "", "What the token is for (required)")
+	if err := usersTokenCreateCmd.MarkFlagRequired("name"); err != nil {
+		panic(err)
+	}
+
+	usersTokenCmd.AddCommand(usersTokenCreateCmd)
+	usersTokenCmd.AddCommand(usersTokenListCmd)
+	usersTokenCmd.AddCommand(usersTokenRevokeCmd)
+
+	usersCmd.AddCommand(usersAddCmd)
+	usersCmd.AddCommand(usersListCmd)
+	usersCmd.AddCommand(usersSetRoleCmd)
+	usersCmd.AddCommand(usersPasswordCmd)
+	usersCmd.AddCommand(usersDisableCmd)
+	usersCmd.AddCommand(usersEnableCmd)
+	usersCmd.AddCommand(usersTokenCmd)
+}
+
+func runUsersAdd(cmd *cobra.Command, args []string) error {
+	roleFlag, _ := cmd.Flags().GetString("role")
+	role, err := auth.ParseRole(roleFlag)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	return withUserManager(cmd, func(um *cli.UserManager) error { return um.AddUser(args[0], password, role) })
+}
+
+// readPassword reads the first line of stdin, prompting when it is a terminal
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func withUserManager(cmd *cobra.Command, fn func(*cli.UserManager) error) error {
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewUserManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize user manager: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			fmt.Printf("failed to close manager: %v\n", err)
+		}
+	}()
+	return fn(manager)
+}
@@ -2,10 +2,8 @@ package commands
 
 import (
 	"fmt"
-	"time"
 
 	"app/internal/scraper/cli"
-	"app/internal/scraper/service/sitemap"
 
 	"github.com/spf13/cobra"
 )
@@ -37,7 +35,11 @@ func runValidateTarget(cmd *cobra.Command, args []string) error {
 	autoDiscover, _ := cmd.Flags().GetBool("auto-discover")
 	limit, _ := cmd.Flags().GetInt("limit")
 
-	manager, err := cli.NewTargetManager()
+	cfg, err := loadConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	manager, err := cli.NewTargetManager(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize target manager: %w", err)
 	}
@@ -47,7 +49,7 @@ func runValidateTarget(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	sitemapService := sitemap.NewSitemapService(30 * time.Second)
+	sitemapService := manager.Sitemaps()
 
 	if targetID > 0 {
 		// Validate existing target by showing its details
@@ -70,11 +72,9 @@ func runValidateTarget(cmd *cobra.Command, args []string) error {
 		sitemapURL = discovered
 	}
 
-	userAgent := "ScraperBot/1.0" // or get from flags if available
-
 	if sitemapURL != "" {
 		fmt.Printf("Validating sitemap: %s\n", sitemapURL)
-		urls, err := sitemapService.ParseSitemapURL(cmd.Context(), sitemapURL, userAgent)
+		urls, err := sitemapService.ParseSitemapURL(cmd.Context(), sitemapURL, "")
 		if err != nil {
 			return fmt.Errorf("failed to parse sitemap: %w", err)
 		}
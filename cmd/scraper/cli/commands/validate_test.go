@@ -13,7 +13,7 @@ import (
 )
 
 func TestParseSitemapURL(t *testing.T) {
-	svc := sitemap.NewSitemapService(2 * time.Second)
+	svc := sitemap.NewSitemapService(2*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 	t.Run("valid sitemap with lastmod", func(t *testing.T) {
 		sitemapXML := `<?xml version="1.0" encoding="UTF-8"?>
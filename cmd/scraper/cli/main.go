@@ -2,11 +2,21 @@ package main
 
 import (
 	"app/cmd/scraper/cli/commands"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Read the same .env as the UI, it is optional for the CLI
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "failed to load .env file: %v\n", err)
+		os.Exit(1)
+	}
+
 	if err := commands.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
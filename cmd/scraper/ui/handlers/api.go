@@ -1,22 +1,26 @@
 package handlers
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"app/cmd/scraper/ui/models"
 	"app/cmd/scraper/ui/templates/components"
 	"app/internal/scraper/db"
+	"app/internal/scraper/service/auth"
+	"app/internal/scraper/service/logger"
 )
 
 type APIHandler struct {
 	queries db.Querier
+	// Logs the actions of users to stderr and scraper_logs
+	activity *slog.Logger
 }
 
 func NewAPIHandler(queries db.Querier) *APIHandler {
-	return &APIHandler{queries: queries}
+	return &APIHandler{queries: queries, activity: logger.New(logger.ComponentUI, queries)}
 }
 
 // Stats returns stats widget for HTMX
@@ -70,7 +74,7 @@ func (h *APIHandler) TargetsList(w http.ResponseWriter, r *http.Request) {
 
 	dbTargets, err := h.queries.ListActiveTargets(ctx)
 	if err != nil {
-		log.Printf("Error getting targets: %v", err)
+		uiLog().ErrorContext(ctx, "Failed to list targets", logger.Err(err))
 		component := components.TargetsList([]models.TargetData{})
 		if renderErr := component.Render(r.Context(), w); renderErr != nil {
 			http.Error(w, renderErr.Error(), http.StatusInternalServerError)
@@ -124,7 +128,7 @@ func (h *APIHandler) Logs(w http.ResponseWriter, r *http.Request) {
 
 	dbLogs, err := h.queries.GetRecentLogs(ctx, int64(limit))
 	if err != nil {
-		log.Printf("Error getting logs: %v", err)
+		uiLog().ErrorContext(ctx, "Failed to load logs", logger.Err(err))
 		logs := []models.LogEntry{}
 		component := components.LogsList(logs)
 		if renderErr := component.Render(r.Context(), w); renderErr != nil {
@@ -156,16 +160,7 @@ func (h *APIHandler) StartCrawling(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-store, must-revalidate")
 	ctx := r.Context()
 
-	err := h.queries.LogMessage(ctx, db.LogMessageParams{
-		LogType:  "info",
-		TargetID: sql.NullInt64{Valid: false},
-		Url:      sql.NullString{Valid: false},
-		Message:  "Crawling started via admin interface",
-		Details:  sql.NullString{String: "Started by user", Valid: true},
-	})
-	if err != nil {
-		log.Printf("Error adding log: %v", err)
-	}
+	h.activity.InfoContext(ctx, "Crawling started via admin interface", "user", username(ctx))
 
 	component := components.StatusMessage("success", "Crawling started successfully")
 	if err := component.Render(r.Context(), w); err != nil {
@@ -179,19 +174,18 @@ func (h *APIHandler) RefreshSitemaps(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-store, must-revalidate")
 	ctx := r.Context()
 
-	err := h.queries.LogMessage(ctx, db.LogMessageParams{
-		LogType:  "info",
-		TargetID: sql.NullInt64{Valid: false},
-		Url:      sql.NullString{Valid: false},
-		Message:  "Sitemap refresh initiated via admin interface",
-		Details:  sql.NullString{String: "Initiated by user", Valid: true},
-	})
-	if err != nil {
-		log.Printf("Error adding log: %v", err)
-	}
+	h.activity.InfoContext(ctx, "Sitemap refresh initiated via admin interface", "user", username(ctx))
 
 	component := components.StatusMessage("info", "Sitemaps refresh initiated")
 	if err := component.Render(r.Context(), w); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// username names the signed in user in activity logs
+func username(ctx context.Context) string {
+	if user, ok := auth.UserFrom(ctx); ok {
+		return user.Username
+	}
+	return "unknown"
+}
@@ -14,6 +14,8 @@ import (
 )
 
 type mockQueries struct {
+	unexpectedQuerier
+
 	GetTargetCountFunc       func(context.Context) (int64, error)
 	GetPendingQueueCountFunc func(context.Context) (int64, error)
 	GetTotalPagesCountFunc   func(context.Context) (int64, error)
@@ -55,72 +57,6 @@ func (m *mockQueries) LogMessage(ctx context.Context, arg db.LogMessageParams) e
 	return nil
 }
 
-// Satisfy db.Querier interface for tests
-func (m *mockQueries) CompleteQueueItem(ctx context.Context, id int64) error {
-	panic("not implemented")
-}
-func (m *mockQueries) CreateTarget(ctx context.Context, arg db.CreateTargetParams) (db.ScraperTarget, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) DeactivateTarget(ctx context.Context, id int64) error { panic("not implemented") }
-func (m *mockQueries) DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) EnqueueURL(ctx context.Context, arg db.EnqueueURLParams) (db.ScraperQueue, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) FailQueueItem(ctx context.Context, arg db.FailQueueItemParams) error {
-	panic("not implemented")
-}
-func (m *mockQueries) GetConfig(ctx context.Context, key string) (string, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetLogsByLevel(ctx context.Context, arg db.GetLogsByLevelParams) ([]db.ScraperLog, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetLogsByTarget(ctx context.Context, arg db.GetLogsByTargetParams) ([]db.ScraperLog, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetPageByPath(ctx context.Context, arg db.GetPageByPathParams) (db.ScraperPage, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetPageContentHash(ctx context.Context, arg db.GetPageContentHashParams) (sql.NullString, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetQueueStats(ctx context.Context) (db.GetQueueStatsRow, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetTarget(ctx context.Context, id int64) (db.ScraperTarget, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetTargetByDomain(ctx context.Context, domainName sql.NullString) (db.ScraperTarget, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) GetTargetByURL(ctx context.Context, websiteUrl string) (db.ScraperTarget, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) ListAllConfig(ctx context.Context) ([]db.ScraperConfig, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) ListAllTargets(ctx context.Context) ([]db.ScraperTarget, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) ListPagesByTarget(ctx context.Context, arg db.ListPagesByTargetParams) ([]db.ScraperPage, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) RetryFailedItem(ctx context.Context, id int64) error { panic("not implemented") }
-func (m *mockQueries) SavePage(ctx context.Context, arg db.SavePageParams) (db.ScraperPage, error) {
-	panic("not implemented")
-}
-func (m *mockQueries) SetConfig(ctx context.Context, arg db.SetConfigParams) error {
-	panic("not implemented")
-}
-func (m *mockQueries) UpdateTargetLastVisited(ctx context.Context, id int64) error {
-	panic("not implemented")
-}
-func (m *mockQueries) UpdateTargetPatterns(ctx context.Context, arg db.UpdateTargetPatternsParams) error {
-	panic("not implemented")
-}
 func (m *mockQueries) GetPageClassifier(ctx context.Context, arg db.GetPageClassifierParams) (db.GetPageClassifierRow, error) {
 	return db.GetPageClassifierRow{}, nil
 }
@@ -132,6 +68,7 @@ func (m *mockQueries) SavePageClassifier(ctx context.Context, arg db.SavePageCla
 
 func TestAPIHandler_Stats(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier:        unexpectedQuerier{t},
 		GetTargetCountFunc:       func(ctx context.Context) (int64, error) { return 2, nil },
 		GetPendingQueueCountFunc: func(ctx context.Context) (int64, error) { return 3, nil },
 		GetTotalPagesCountFunc:   func(ctx context.Context) (int64, error) { return 4, nil },
@@ -153,6 +90,7 @@ func TestAPIHandler_Stats(t *testing.T) {
 
 func TestAPIHandler_Stats_DBError(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier:        unexpectedQuerier{t},
 		GetTargetCountFunc:       func(ctx context.Context) (int64, error) { return 0, errors.New("fail") },
 		GetPendingQueueCountFunc: func(ctx context.Context) (int64, error) { return 0, errors.New("fail") },
 		GetTotalPagesCountFunc:   func(ctx context.Context) (int64, error) { return 0, errors.New("fail") },
@@ -171,6 +109,7 @@ func TestAPIHandler_Stats_DBError(t *testing.T) {
 
 func TestAPIHandler_TargetsList(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		ListActiveTargetsFunc: func(ctx context.Context) ([]db.ScraperTarget, error) {
 			return []db.ScraperTarget{{ID: 1, WebsiteUrl: "https://a.com", SitemapUrl: sql.NullString{String: "https://a.com/sitemap.xml", Valid: true}, IsActive: sql.NullBool{Bool: true, Valid: true}, CreatedAt: sql.NullTime{Time: time.Now(), Valid: true}}}, nil
 		},
@@ -188,6 +127,7 @@ func TestAPIHandler_TargetsList(t *testing.T) {
 
 func TestAPIHandler_TargetsList_DBError(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		ListActiveTargetsFunc: func(ctx context.Context) ([]db.ScraperTarget, error) {
 			return nil, errors.New("fail")
 		},
@@ -205,6 +145,7 @@ func TestAPIHandler_TargetsList_DBError(t *testing.T) {
 
 func TestAPIHandler_Logs(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		GetRecentLogsFunc: func(ctx context.Context, limit int64) ([]db.ScraperLog, error) {
 			return []db.ScraperLog{{LogType: "info", Message: "msg", Url: sql.NullString{String: "u", Valid: true}, Details: sql.NullString{String: "d", Valid: true}, CreatedAt: sql.NullTime{Time: time.Now(), Valid: true}}}, nil
 		},
@@ -222,6 +163,7 @@ func TestAPIHandler_Logs(t *testing.T) {
 
 func TestAPIHandler_Logs_DBError(t *testing.T) {
 	mock := &mockQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		GetRecentLogsFunc: func(ctx context.Context, limit int64) ([]db.ScraperLog, error) {
 			return nil, errors.New("fail")
 		},
@@ -239,7 +181,8 @@ func TestAPIHandler_Logs_DBError(t *testing.T) {
 
 func TestAPIHandler_StartCrawling(t *testing.T) {
 	mock := &mockQueries{
-		LogMessageFunc: func(ctx context.Context, arg db.LogMessageParams) error { return nil },
+		unexpectedQuerier: unexpectedQuerier{t},
+		LogMessageFunc:    func(ctx context.Context, arg db.LogMessageParams) error { return nil },
 	}
 	h := NewAPIHandler(mock)
 	r := httptest.NewRequest("POST", "/api/crawl/start", nil)
@@ -254,7 +197,8 @@ func TestAPIHandler_StartCrawling(t *testing.T) {
 
 func TestAPIHandler_RefreshSitemaps(t *testing.T) {
 	mock := &mockQueries{
-		LogMessageFunc: func(ctx context.Context, arg db.LogMessageParams) error { return nil },
+		unexpectedQuerier: unexpectedQuerier{t},
+		LogMessageFunc:    func(ctx context.Context, arg db.LogMessageParams) error { return nil },
 	}
 	h := NewAPIHandler(mock)
 	r := httptest.NewRequest("POST", "/api/sitemap/refresh-all", nil)
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/auth"
+	"app/internal/scraper/service/logger"
+)
+
+// Authenticator starts and ends login sessions
+type Authenticator interface {
+	Login(ctx context.Context, username, password string) (string, auth.User, error)
+	Logout(ctx context.Context, secret string) error
+}
+
+type AuthHandler struct {
+	queries db.Querier
+	auth    Authenticator
+}
+
+func NewAuthHandler(queries db.Querier, authenticator Authenticator) *AuthHandler {
+	return &AuthHandler{queries: queries, auth: authenticator}
+}
+
+// SecureRequest reports whether the request came over HTTPS, directly or through a proxy,
+// so cookies are only marked Secure where the browser would send them back
+func SecureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// safeNext returns where to go after signing in, only paths on this host are followed
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// LoginPage renders the sign in form
+func (h *AuthHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
+	h.renderLogin(w, r, http.StatusOK, "", safeNext(r.URL.Query().Get("next")))
+}
+
+// Login checks the submitted credentials and sets the session cookie
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderLogin(w, r, http.StatusBadRequest, "Invalid form data", "/")
+		return
+	}
+	next := safeNext(r.FormValue("next"))
+	secret, user, err := h.auth.Login(r.Context(), r.FormValue("username"), r.FormValue("password"))
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		h.renderLogin(w, r, http.StatusUnauthorized, "Invalid username or password", next)
+		return
+	}
+	if err != nil {
+		uiLog().ErrorContext(r.Context(), "Login failed", logger.Err(err))
+		h.renderLogin(w, r, http.StatusInternalServerError, "Sign in failed, try again later", next)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    secret,
+		Path:     "/",
+		Expires:  time.Now().Add(auth.SessionTTL),
+		HttpOnly: true,
+		Secure:   SecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	uiLog().InfoContext(r.Context(), "User signed in", "user", user.Username, "role", user.Role)
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout ends the session and clears its cookie
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+		if err := h.auth.Logout(r.Context(), cookie.Value); err != nil {
+			uiLog().WarnContext(r.Context(), "Failed to delete session", logger.Err(err))
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   SecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (h *AuthHandler) renderLogin(w http.ResponseWriter, r *http.Request, status int, message, next string) {
+	// Point at the CLI while nobody can sign in yet
+	noUsers := false
+	if count, err := h.queries.CountActiveUsers(r.Context()); err == nil {
+		noUsers = count == 0
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	if err := pages.Login(message, next, noUsers).Render(r.Context(), w); err != nil {
+		uiLog().ErrorContext(r.Context(), "Failed to render login page", logger.Err(err))
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/service/auth"
+)
+
+func postForm(h http.HandlerFunc, path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == auth.SessionCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestAuthHandler_LoginPage(t *testing.T) {
+	queries := newTestQueries(t)
+	h := NewAuthHandler(queries, auth.NewService(queries))
+
+	r := httptest.NewRequest("GET", "/login?next=/settings", nil)
+	r = r.WithContext(auth.WithCSRFToken(r.Context(), "t0k"))
+	w := httptest.NewRecorder()
+	h.LoginPage(w, r)
+	body := w.Body.String()
+	for _, want := range []string{`name="csrf_token" value="t0k"`, `name="next" value="/settings"`, "scraper-cli users add"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q on the login page", want)
+		}
+	}
+}
+
+func TestAuthHandler_LoginLogout(t *testing.T) {
+	queries := newTestQueries(t)
+	service := auth.NewService(queries)
+	h := NewAuthHandler(queries, service)
+	if _, err := service.CreateUser(context.Background(), "alice", "correct horse", auth.RoleAdmin); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	w := postForm(h.Login, "/login", url.Values{"username": {"alice"}, "password": {"wrong password"}})
+	if w.Code != http.StatusUnauthorized || sessionCookie(w) != nil || !strings.Contains(w.Body.String(), "Invalid username or password") {
+		t.Errorf("expected the form again with an error, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "scraper-cli users add") {
+		t.Error("expected no setup hint once a user exists")
+	}
+
+	w = postForm(h.Login, "/login", url.Values{"username": {"alice"}, "password": {"correct horse"}, "next": {"/export?dataset=quotes"}})
+	cookie := sessionCookie(w)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/export?dataset=quotes" || cookie == nil {
+		t.Fatalf("expected a session and a redirect back, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected an HttpOnly SameSite cookie, got %+v", cookie)
+	}
+	if user, err := service.SessionUser(context.Background(), cookie.Value); err != nil || user.Username != "alice" {
+		t.Errorf("expected the cookie to hold alice's session, got %+v (%v)", user, err)
+	}
+
+	w = postForm(h.Logout, "/logout", nil, cookie)
+	if w.Code != http.StatusSeeOther || sessionCookie(w) == nil || sessionCookie(w).MaxAge >= 0 {
+		t.Errorf("expected the cookie to be cleared, got %d", w.Code)
+	}
+	if _, err := service.SessionUser(context.Background(), cookie.Value); err == nil {
+		t.Error("expected the session to end")
+	}
+}
+
+func TestSafeNext(t *testing.T) {
+	tests := map[string]string{
+		"":                     "/",
+		"/targets/new":         "/targets/new",
+		"https://evil.example": "/",
+		"//evil.example":       "/",
+		"/\\evil.example":      "/",
+	}
+	for next, want := range tests {
+		if got := safeNext(next); got != want {
+			t.Errorf("safeNext(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 )
 
 type mockDashboardQueries struct {
+	unexpectedQuerier
+
 	ListActiveTargetsFunc func(context.Context) ([]db.ScraperTarget, error)
 	GetRecentLogsFunc     func(context.Context, int64) ([]db.ScraperLog, error)
 }
@@ -35,7 +37,7 @@ func (m *mockDashboardQueries) CreateTarget(ctx context.Context, arg db.CreateTa
 	return db.ScraperTarget{}, nil
 }                                                                                    // unused
 func (m *mockDashboardQueries) DeactivateTarget(ctx context.Context, id int64) error { return nil } // unused
-func (m *mockDashboardQueries) DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error) {
+func (m *mockDashboardQueries) DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error) {
 	return db.ScraperQueue{}, nil
 } // unused
 func (m *mockDashboardQueries) EnqueueURL(ctx context.Context, arg db.EnqueueURLParams) (db.ScraperQueue, error) {
@@ -112,7 +114,7 @@ func (m *mockDashboardQueries) SavePageClassifier(ctx context.Context, arg db.Sa
 }
 
 func TestDashboardHandler_Dashboard(t *testing.T) {
-	h := &DashboardHandler{queries: &mockDashboardQueries{}}
+	h := &DashboardHandler{queries: &mockDashboardQueries{unexpectedQuerier: unexpectedQuerier{t}}}
 	r := httptest.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
 
@@ -124,7 +126,7 @@ func TestDashboardHandler_Dashboard(t *testing.T) {
 }
 
 func TestDashboardHandler_HealthAPI(t *testing.T) {
-	h := &DashboardHandler{queries: &mockDashboardQueries{}}
+	h := &DashboardHandler{queries: &mockDashboardQueries{unexpectedQuerier: unexpectedQuerier{t}}}
 	r := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
@@ -137,6 +139,7 @@ func TestDashboardHandler_HealthAPI(t *testing.T) {
 
 func TestDashboardHandler_TargetsPage(t *testing.T) {
 	h := &DashboardHandler{queries: &mockDashboardQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		ListActiveTargetsFunc: func(ctx context.Context) ([]db.ScraperTarget, error) {
 			return []db.ScraperTarget{{ID: 1, WebsiteUrl: "https://a.com", SitemapUrl: sql.NullString{String: "https://a.com/sitemap.xml", Valid: true}, IsActive: sql.NullBool{Bool: true, Valid: true}, CreatedAt: sql.NullTime{Time: time.Now(), Valid: true}}}, nil
 		},
@@ -153,6 +156,7 @@ func TestDashboardHandler_TargetsPage(t *testing.T) {
 
 func TestDashboardHandler_TargetsPage_DBError(t *testing.T) {
 	h := &DashboardHandler{queries: &mockDashboardQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		ListActiveTargetsFunc: func(ctx context.Context) ([]db.ScraperTarget, error) {
 			return nil, sql.ErrConnDone
 		},
@@ -169,6 +173,7 @@ func TestDashboardHandler_TargetsPage_DBError(t *testing.T) {
 
 func TestDashboardHandler_LogsPage(t *testing.T) {
 	h := &DashboardHandler{queries: &mockDashboardQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		GetRecentLogsFunc: func(ctx context.Context, limit int64) ([]db.ScraperLog, error) {
 			return []db.ScraperLog{{LogType: "info", Message: "msg", Url: sql.NullString{String: "u", Valid: true}, Details: sql.NullString{String: "d", Valid: true}, CreatedAt: sql.NullTime{Time: time.Now(), Valid: true}}}, nil
 		},
@@ -185,6 +190,7 @@ func TestDashboardHandler_LogsPage(t *testing.T) {
 
 func TestDashboardHandler_LogsPage_DBError(t *testing.T) {
 	h := &DashboardHandler{queries: &mockDashboardQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		GetRecentLogsFunc: func(ctx context.Context, limit int64) ([]db.ScraperLog, error) {
 			return nil, sql.ErrConnDone
 		},
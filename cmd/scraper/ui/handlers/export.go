@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/export"
+	"app/internal/scraper/service/logger"
+)
+
+type ExportHandler struct {
+	queries db.Querier
+}
+
+func NewExportHandler(queries db.Querier) *ExportHandler {
+	return &ExportHandler{queries: queries}
+}
+
+// Page renders the export form, submitted as a plain GET to Download
+func (h *ExportHandler) Page(w http.ResponseWriter, r *http.Request) {
+	targets, err := h.queries.ListAllTargets(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load targets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	targetData := make([]models.TargetData, 0, len(targets))
+	for _, t := range targets {
+		targetData = append(targetData, models.TargetData{ID: t.ID, WebsiteURL: t.WebsiteUrl})
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.Export(targetData, export.Datasets, export.Formats).Render(r.Context(), w); err != nil {
+		http.Error(w, "Failed to render export page", http.StatusInternalServerError)
+	}
+}
+
+// Download streams the export as an attachment. Once streaming started an
+// error can only be logged, the response is cut short.
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	opts, err := exportOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, opts.FileName()))
+	n, err := export.NewExporter(h.queries).Export(r.Context(), w, opts)
+	if err != nil {
+		uiLog().ErrorContext(r.Context(), "Export failed", "file", opts.FileName(), "records", n, logger.Err(err))
+	}
+}
+
+// exportOptions reads the export form, dates are YYYY-MM-DD and until is exclusive
+func exportOptions(r *http.Request) (export.Options, error) {
+	q := r.URL.Query()
+	opts := export.Options{
+		Dataset:     q.Get("dataset"),
+		Format:      q.Get("format"),
+		IncludeHTML: q.Get("html") == "true" || q.Get("html") == "on",
+	}
+	if opts.Dataset == "" {
+		opts.Dataset = export.DatasetPages
+	}
+	if opts.Format == "" {
+		opts.Format = export.FormatJSONL
+	}
+	if v := q.Get("target_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid target_id %q", v)
+		}
+		opts.TargetID = id
+	}
+	if v := q.Get("processable"); v != "" {
+		processable, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid processable %q: expected true or false", v)
+		}
+		opts.Processable = &processable
+	}
+	for name, dst := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s %q: expected YYYY-MM-DD", name, v)
+			}
+			*dst = t
+		}
+	}
+	return opts, opts.Validate()
+}
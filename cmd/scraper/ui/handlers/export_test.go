@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+)
+
+type mockExportQueries struct {
+	unexpectedQuerier
+
+	params []db.ListPagesForExportParams
+}
+
+func (m *mockExportQueries) ListAllTargets(ctx context.Context) ([]db.ScraperTarget, error) {
+	return []db.ScraperTarget{{ID: 3, WebsiteUrl: "https://quotes.example"}}, nil
+}
+func (m *mockExportQueries) ListPagesForExport(ctx context.Context, arg db.ListPagesForExportParams) ([]db.ListPagesForExportRow, error) {
+	m.params = append(m.params, arg)
+	return []db.ListPagesForExportRow{{
+		ID:         1,
+		TargetID:   3,
+		FullUrl:    "https://quotes.example/q",
+		QuotesJson: sql.NullString{String: `[{"text":"Be yourself.","author":"Oscar Wilde"}]`, Valid: true},
+	}}, nil
+}
+
+func TestExportHandler_Page(t *testing.T) {
+	h := NewExportHandler(&mockExportQueries{unexpectedQuerier: unexpectedQuerier{t}})
+	w := httptest.NewRecorder()
+	h.Page(w, httptest.NewRequest("GET", "/export", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`action="/api/export"`, `hx-boost="false"`, `<option value="3">https://quotes.example</option>`, `<option value="parquet">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+}
+
+func TestExportHandler_Download(t *testing.T) {
+	q := &mockExportQueries{unexpectedQuerier: unexpectedQuerier{t}}
+	h := NewExportHandler(q)
+	w := httptest.NewRecorder()
+	h.Download(w, httptest.NewRequest("GET", "/api/export?dataset=quotes&format=csv&target_id=3&processable=true&since=2024-06-01", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="quotes.csv"` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if !strings.Contains(w.Body.String(), "1,3,https://quotes.example/q,1,Be yourself.,Oscar Wilde") {
+		t.Errorf("unexpected CSV %q", w.Body.String())
+	}
+	p := q.params[0]
+	if p.TargetID.Int64 != 3 || !p.Processable.Valid || !p.Processable.Bool || !p.Since.Valid || p.Until.Valid {
+		t.Errorf("filters not applied: %+v", p)
+	}
+}
+
+func TestExportHandler_DownloadInvalid(t *testing.T) {
+	for _, query := range []string{"format=xml", "dataset=links", "since=yesterday", "target_id=x", "processable=maybe"} {
+		w := httptest.NewRecorder()
+		NewExportHandler(&mockExportQueries{unexpectedQuerier: unexpectedQuerier{t}}).Download(w, httptest.NewRequest("GET", "/api/export?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package handlers
+
+import "app/internal/scraper/service/logger"
+
+// uiLog logs the handlers' diagnostics to stderr
+var uiLog = logger.Lazy(logger.ComponentUI)
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/components"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/logsearch"
+)
+
+const logPageSize = 100
+
+// dateTimeLocal is the value format of <input type="datetime-local">
+const dateTimeLocal = "2006-01-02T15:04"
+
+// LogSearcher finds logs for the log browser, see logsearch.Searcher
+type LogSearcher interface {
+	Search(ctx context.Context, f logsearch.Filter) (logsearch.Result, error)
+	FullText() bool
+}
+
+// LogsHandler serves the log browser
+type LogsHandler struct {
+	queries db.Querier
+	search  LogSearcher
+}
+
+func NewLogsHandler(queries db.Querier, search LogSearcher) *LogsHandler {
+	return &LogsHandler{queries: queries, search: search}
+}
+
+// Page renders the log browser with the filter form and the newest matching logs
+func (h *LogsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	filter, search, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	targets, err := h.queries.ListAllTargets(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load targets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	options := make([]models.TargetData, len(targets))
+	for i, t := range targets {
+		options[i] = models.TargetData{ID: t.ID, WebsiteURL: t.WebsiteUrl}
+	}
+	results, err := h.results(ctx, filter, search)
+	if err != nil {
+		http.Error(w, "Failed to search logs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.LogBrowser(results, options).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render logs page", http.StatusInternalServerError)
+	}
+}
+
+// Results renders the logs matching the filter form. Without before it
+// replaces the results and the browser URL, with it it renders the older logs
+// that replace the "Load more" button.
+func (h *LogsHandler) Results(w http.ResponseWriter, r *http.Request) {
+	filter, search, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.Before, err = parseCursor(r.URL.Query(), "before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := h.results(r.Context(), filter, search)
+	if err != nil {
+		http.Error(w, "Failed to search logs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	component := components.LogRows(results)
+	if search.Before == 0 {
+		w.Header().Set("HX-Push-Url", "/logs?"+filter.Values())
+		component = components.LogResults(results)
+	}
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Tail renders the matching logs written after the after cursor for live
+// tailing, with the poller continuing from the newest. No new logs answer
+// 204 so HTMX leaves the page alone.
+func (h *LogsHandler) Tail(w http.ResponseWriter, r *http.Request) {
+	filter, search, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.After, err = parseCursor(r.URL.Query(), "after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := h.results(r.Context(), filter, search)
+	if err != nil {
+		http.Error(w, "Failed to search logs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(results.Logs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// A burst bigger than a page shows its newest logs, the rest are a page reload away
+	w.Header().Set("Content-Type", "text/html")
+	if err := components.LogTail(results).Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// results runs the search and converts the logs for the templates
+func (h *LogsHandler) results(ctx context.Context, filter models.LogFilter, search logsearch.Filter) (models.LogResults, error) {
+	search.Limit = logPageSize
+	found, err := h.search.Search(ctx, search)
+	if err != nil {
+		return models.LogResults{}, err
+	}
+	results := models.LogResults{
+		Filter:   filter,
+		Logs:     make([]models.LogEntry, len(found.Logs)),
+		Next:     found.Next,
+		Newest:   search.After,
+		FullText: h.search.FullText(),
+	}
+	for i, l := range found.Logs {
+		results.Logs[i] = models.LogEntry{
+			ID:        l.ID,
+			TargetID:  l.TargetID.Int64,
+			Timestamp: l.CreatedAt.Time,
+			Level:     l.LogType,
+			Message:   l.Message,
+			URL:       l.Url.String,
+			Details:   prettyDetails(l.Details.String),
+		}
+		results.Newest = max(results.Newest, l.ID)
+	}
+	return results, nil
+}
+
+// parseLogFilter reads the filter form. since and until are datetime-local
+// values in UTC, RFC 3339 times are accepted too.
+func parseLogFilter(q url.Values) (models.LogFilter, logsearch.Filter, error) {
+	filter := models.LogFilter{
+		Level: q.Get("level"),
+		URL:   strings.TrimSpace(q.Get("url")),
+		Query: strings.TrimSpace(q.Get("q")),
+		Since: q.Get("since"),
+		Until: q.Get("until"),
+	}
+	search := logsearch.Filter{Level: filter.Level, URL: filter.URL, Query: filter.Query}
+	if raw := q.Get("target_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, search, fmt.Errorf("invalid target_id")
+		}
+		filter.TargetID, search.TargetID = id, id
+	}
+	if raw := q.Get("run_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, search, fmt.Errorf("invalid run_id")
+		}
+		filter.RunID, search.RunID = id, id
+	}
+	var err error
+	if search.Since, err = parseFormTime(filter.Since); err != nil {
+		return filter, search, fmt.Errorf("invalid since: %w", err)
+	}
+	if search.Until, err = parseFormTime(filter.Until); err != nil {
+		return filter, search, fmt.Errorf("invalid until: %w", err)
+	}
+	return filter, search, nil
+}
+
+func parseFormTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(dateTimeLocal, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// parseCursor reads an optional log id query parameter
+func parseCursor(q url.Values, name string) (int64, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return id, nil
+}
+
+// prettyDetails indents JSON details, other details are shown as logged
+func prettyDetails(details string) string {
+	if !json.Valid([]byte(details)) {
+		return details
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(details), "", "  "); err != nil {
+		uiLog().Warn("Failed to indent log details", logger.Err(err))
+		return details
+	}
+	return out.String()
+}
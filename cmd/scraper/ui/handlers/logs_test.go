@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/logsearch"
+)
+
+func TestLogsHandler(t *testing.T) {
+	store := newTestStore(t)
+	queries := db.New(store.DB())
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	logMessage := func(p db.LogMessageParams) {
+		t.Helper()
+		if err := queries.LogMessage(ctx, p); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+	for i := 1; i <= logPageSize; i++ {
+		logMessage(db.LogMessageParams{LogType: "info", Message: fmt.Sprintf("Page %03d saved", i)})
+	}
+	logMessage(db.LogMessageParams{
+		LogType:  "error",
+		TargetID: sql.NullInt64{Int64: target.ID, Valid: true},
+		Url:      sql.NullString{String: "https://quotes.example/page/2", Valid: true},
+		Message:  "Fetch failed",
+		Details:  sql.NullString{String: `{"error":"connection timeout","attempt":3}`, Valid: true},
+	})
+
+	h := NewLogsHandler(queries, logsearch.New(store))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /logs", h.Page)
+	mux.HandleFunc("GET /logs/results", h.Results)
+	mux.HandleFunc("GET /logs/tail", h.Tail)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	expect := func(path string, want ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := get(path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+		for _, s := range want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("GET %s: expected %q in the response", path, s)
+			}
+		}
+		return w
+	}
+
+	// The newest page links to the older logs and tails after the newest
+	w := expect("/logs", "https://quotes.example", "Fetch failed", "Page 100 saved",
+		"&#34;attempt&#34;: 3", "/targets/1", "/logs/results?before=2", "/logs/tail?after=101")
+	if strings.Contains(w.Body.String(), "Page 001 saved") {
+		t.Error("expected the oldest log on the next page")
+	}
+	expect("/logs/results?before=2", "Page 001 saved")
+
+	w = expect("/logs/results?level=error&target_id=1&q=timeout&url=%2Fpage%2F&since=2000-01-01T00%3A00",
+		"Fetch failed", "/logs/tail?level=error")
+	if strings.Contains(w.Body.String(), "saved") {
+		t.Error("expected only the error log")
+	}
+	if got := w.Header().Get("HX-Push-Url"); !strings.HasPrefix(got, "/logs?") || !strings.Contains(got, "q=timeout") {
+		t.Errorf("unexpected pushed URL %q", got)
+	}
+	expect("/logs/results?until=2000-01-01T00:00:00Z", "No logs match these filters")
+
+	if w := get("/logs/tail?after=101"); w.Code != http.StatusNoContent {
+		t.Errorf("expected no new logs, got %d", w.Code)
+	}
+	logMessage(db.LogMessageParams{LogType: "warn", Message: "Robots.txt disallows /private"})
+	logMessage(db.LogMessageParams{LogType: "info", Message: "Crawl finished"})
+	w = expect("/logs/tail?after=101&level=warn", "Robots.txt disallows", `hx-swap-oob="true"`, "/logs/tail?level=warn&amp;after=102")
+	if strings.Contains(w.Body.String(), "Crawl finished") {
+		t.Error("expected the tail filtered by level")
+	}
+
+	for _, path := range []string{"/logs?target_id=x", "/logs/results?run_id=x", "/logs/results?since=yesterday", "/logs/results?before=-1", "/logs/tail?after=x"} {
+		if w := get(path); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: got %d, want 400", path, w.Code)
+		}
+	}
+}
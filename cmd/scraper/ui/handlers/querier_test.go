@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+)
+
+// unexpectedQuerier is the base for the handler test mocks. Every query
+// fails the test, so a mock only overrides the queries its handler is
+// expected to run.
+type unexpectedQuerier struct {
+	t *testing.T
+}
+
+var _ db.Querier = unexpectedQuerier{}
+
+func (q unexpectedQuerier) fail(method string) {
+	q.t.Helper()
+	q.t.Fatalf("unexpected call to %s", method)
+}
+
+func (q unexpectedQuerier) CompleteQueueItem(_ context.Context, _ int64) (_ error) {
+	q.fail("CompleteQueueItem")
+	return
+}
+
+func (q unexpectedQuerier) CountActiveUsers(_ context.Context) (_ int64, _ error) {
+	q.fail("CountActiveUsers")
+	return
+}
+
+func (q unexpectedQuerier) CountClassifierDecisions(_ context.Context, _ int64) (_ []db.CountClassifierDecisionsRow, _ error) {
+	q.fail("CountClassifierDecisions")
+	return
+}
+
+func (q unexpectedQuerier) CountRunLogs(_ context.Context, _ sql.NullInt64) (_ []db.CountRunLogsRow, _ error) {
+	q.fail("CountRunLogs")
+	return
+}
+
+func (q unexpectedQuerier) CountRunQueueItems(_ context.Context, _ sql.NullInt64) (_ []db.CountRunQueueItemsRow, _ error) {
+	q.fail("CountRunQueueItems")
+	return
+}
+
+func (q unexpectedQuerier) CreateAPIToken(_ context.Context, _ db.
+	CreateAPITokenParams) (_ db.
+	ScraperApiToken, _ error) {
+	q.fail("CreateAPIToken")
+	return
+}
+
+func (q unexpectedQuerier) CreateRun(_ context.Context, _ db.
+	CreateRunParams) (_ db.
+	ScraperRun, _ error) {
+	q.fail("CreateRun")
+	return
+}
+
+func (q unexpectedQuerier) CreateSession(_ context.Context, _ db.
+	CreateSessionParams) (_ error) {
+	q.fail("CreateSession")
+	return
+}
+
+func (q unexpectedQuerier) CreateTarget(_ context.Context, _ db.
+	CreateTargetParams) (_ db.
+	ScraperTarget, _ error) {
+	q.fail("CreateTarget")
+	return
+}
+
+func (q unexpectedQuerier) CreateUser(_ context.Context, _ db.
+	CreateUserParams) (_ db.
+	ScraperUser, _ error) {
+	q.fail("CreateUser")
+	return
+}
+
+func (q unexpectedQuerier) DeactivateTarget(_ context.Context, _ int64) (_ error) {
+	q.fail("DeactivateTarget")
+	return
+}
+
+func (q unexpectedQuerier) DeleteAPIToken(_ context.Context, _ int64) (_ int64, _ error) {
+	q.fail("DeleteAPIToken")
+	return
+}
+
+func (q unexpectedQuerier) DeleteExpiredSessions(_ context.Context, _ time.Time) (_ int64, _ error) {
+	q.fail("DeleteExpiredSessions")
+	return
+}
+
+func (q unexpectedQuerier) DeleteOrphanContents(_ context.Context) (_ int64, _ error) {
+	q.fail("DeleteOrphanContents")
+	return
+}
+
+func (q unexpectedQuerier) DeleteQueueItem(_ context.Context, _ int64) (_ int64, _ error) {
+	q.fail("DeleteQueueItem")
+	return
+}
+
+func (q unexpectedQuerier) DeleteSession(_ context.Context, _ string) (_ error) {
+	q.fail("DeleteSession")
+	return
+}
+
+func (q unexpectedQuerier) DeleteUserSessions(_ context.Context, _ int64) (_ error) {
+	q.fail("DeleteUserSessions")
+	return
+}
+
+func (q unexpectedQuerier) DequeuePendingURL(_ context.Context, _ sql.NullInt64) (_ db.
+	ScraperQueue, _ error) {
+	q.fail("DequeuePendingURL")
+	return
+}
+
+func (q unexpectedQuerier) EnqueueDiscoveredURL(_ context.Context, _ db.
+	EnqueueDiscoveredURLParams) (_ int64, _ error) {
+	q.fail("EnqueueDiscoveredURL")
+	return
+}
+
+func (q unexpectedQuerier) EnqueueURL(_ context.Context, _ db.
+	EnqueueURLParams) (_ db.
+	ScraperQueue, _ error) {
+	q.fail("EnqueueURL")
+	return
+}
+
+func (q unexpectedQuerier) FailQueueItem(_ context.Context, _ db.
+	FailQueueItemParams) (_ error) {
+	q.fail("FailQueueItem")
+	return
+}
+
+func (q unexpectedQuerier) FinishRun(_ context.Context, _ db.
+	FinishRunParams) (_ error) {
+	q.fail("FinishRun")
+	return
+}
+
+func (q unexpectedQuerier) GetAPITokenUser(_ context.Context, _ string) (_ db.
+	GetAPITokenUserRow, _ error) {
+	q.fail("GetAPITokenUser")
+	return
+}
+
+func (q unexpectedQuerier) GetConfig(_ context.Context, _ string) (_ string, _ error) {
+	q.fail("GetConfig")
+	return
+}
+
+func (q unexpectedQuerier) GetContent(_ context.Context, _ string) (_ db.
+	GetContentRow, _ error) {
+	q.fail("GetContent")
+	return
+}
+
+func (q unexpectedQuerier) GetContentStats(_ context.Context) (_ db.
+	GetContentStatsRow, _ error) {
+	q.fail("GetContentStats")
+	return
+}
+
+func (q unexpectedQuerier) GetLogsByLevel(_ context.Context, _ db.
+	GetLogsByLevelParams) (_ []db.ScraperLog, _ error) {
+	q.fail("GetLogsByLevel")
+	return
+}
+
+func (q unexpectedQuerier) GetLogsByTarget(_ context.Context, _ db.
+	GetLogsByTargetParams) (_ []db.ScraperLog, _ error) {
+	q.fail("GetLogsByTarget")
+	return
+}
+
+func (q unexpectedQuerier) GetPage(_ context.Context, _ int64) (_ db.
+	ScraperPage, _ error) {
+	q.fail("GetPage")
+	return
+}
+
+func (q unexpectedQuerier) GetPageByPath(_ context.Context, _ db.
+	GetPageByPathParams) (_ db.
+	ScraperPage, _ error) {
+	q.fail("GetPageByPath")
+	return
+}
+
+func (q unexpectedQuerier) GetPageClassifier(_ context.Context, _ db.
+	GetPageClassifierParams) (_ db.
+	GetPageClassifierRow, _ error) {
+	q.fail("GetPageClassifier")
+	return
+}
+
+func (q unexpectedQuerier) GetPageContentHash(_ context.Context, _ db.
+	GetPageContentHashParams) (_ sql.NullString, _ error) {
+	q.fail("GetPageContentHash")
+	return
+}
+
+func (q unexpectedQuerier) GetPendingQueueCount(_ context.Context) (_ int64, _ error) {
+	q.fail("GetPendingQueueCount")
+	return
+}
+
+func (q unexpectedQuerier) GetQueueItem(_ context.Context, _ int64) (_ db.
+	ScraperQueue, _ error) {
+	q.fail("GetQueueItem")
+	return
+}
+
+func (q unexpectedQuerier) GetQueueStats(_ context.Context) (_ db.
+	GetQueueStatsRow, _ error) {
+	q.fail("GetQueueStats")
+	return
+}
+
+func (q unexpectedQuerier) GetRecentErrorsCount(_ context.Context) (_ int64, _ error) {
+	q.fail("GetRecentErrorsCount")
+	return
+}
+
+func (q unexpectedQuerier) GetRecentLogs(_ context.Context, _ int64) (_ []db.ScraperLog, _ error) {
+	q.fail("GetRecentLogs")
+	return
+}
+
+func (q unexpectedQuerier) GetRun(_ context.Context, _ int64) (_ db.
+	ScraperRun, _ error) {
+	q.fail("GetRun")
+	return
+}
+
+func (q unexpectedQuerier) GetRunningRun(_ context.Context) (_ db.
+	ScraperRun, _ error) {
+	q.fail("GetRunningRun")
+	return
+}
+
+func (q unexpectedQuerier) GetSessionUser(_ context.Context, _ string) (_ db.
+	GetSessionUserRow, _ error) {
+	q.fail("GetSessionUser")
+	return
+}
+
+func (q unexpectedQuerier) GetTarget(_ context.Context, _ int64) (_ db.
+	ScraperTarget, _ error) {
+	q.fail("GetTarget")
+	return
+}
+
+func (q unexpectedQuerier) GetTargetByDomain(_ context.Context, _ sql.NullString) (_ db.
+	ScraperTarget, _ error) {
+	q.fail("GetTargetByDomain")
+	return
+}
+
+func (q unexpectedQuerier) GetTargetByURL(_ context.Context, _ string) (_ db.
+	ScraperTarget, _ error) {
+	q.fail("GetTargetByURL")
+	return
+}
+
+func (q unexpectedQuerier) GetTargetCount(_ context.Context) (_ int64, _ error) {
+	q.fail("GetTargetCount")
+	return
+}
+
+func (q unexpectedQuerier) GetTargetQueueStats(_ context.Context, _ int64) (_ db.
+	GetTargetQueueStatsRow, _ error) {
+	q.fail("GetTargetQueueStats")
+	return
+}
+
+func (q unexpectedQuerier) GetTotalPagesCount(_ context.Context) (_ int64, _ error) {
+	q.fail("GetTotalPagesCount")
+	return
+}
+
+func (q unexpectedQuerier) GetUser(_ context.Context, _ int64) (_ db.
+	ScraperUser, _ error) {
+	q.fail("GetUser")
+	return
+}
+
+func (q unexpectedQuerier) GetUserByUsername(_ context.Context, _ string) (_ db.
+	ScraperUser, _ error) {
+	q.fail("GetUserByUsername")
+	return
+}
+
+func (q unexpectedQuerier) ImportPage(_ context.Context, _ db.
+	ImportPageParams) (_ int64, _ error) {
+	q.fail("ImportPage")
+	return
+}
+
+func (q unexpectedQuerier) ListAPITokens(_ context.Context) (_ []db.ListAPITokensRow, _ error) {
+	q.fail("ListAPITokens")
+	return
+}
+
+func (q unexpectedQuerier) ListActiveTargets(_ context.Context) (_ []db.ScraperTarget, _ error) {
+	q.fail("ListActiveTargets")
+	return
+}
+
+func (q unexpectedQuerier) ListAllConfig(_ context.Context) (_ []db.ScraperConfig, _ error) {
+	q.fail("ListAllConfig")
+	return
+}
+
+func (q unexpectedQuerier) ListAllTargets(_ context.Context) (_ []db.ScraperTarget, _ error) {
+	q.fail("ListAllTargets")
+	return
+}
+
+func (q unexpectedQuerier) ListLogs(_ context.Context, _ db.
+	ListLogsParams) (_ []db.ScraperLog, _ error) {
+	q.fail("ListLogs")
+	return
+}
+
+func (q unexpectedQuerier) ListPagePaths(_ context.Context, _ db.
+	ListPagePathsParams) (_ []string, _ error) {
+	q.fail("ListPagePaths")
+	return
+}
+
+func (q unexpectedQuerier) ListPageSummaries(_ context.Context, _ db.
+	ListPageSummariesParams) (_ []db.ListPageSummariesRow, _ error) {
+	q.fail("ListPageSummaries")
+	return
+}
+
+func (q unexpectedQuerier) ListPagesByTarget(_ context.Context, _ db.
+	ListPagesByTargetParams) (_ []db.ScraperPage, _ error) {
+	q.fail("ListPagesByTarget")
+	return
+}
+
+func (q unexpectedQuerier) ListPagesForExport(_ context.Context, _ db.
+	ListPagesForExportParams) (_ []db.ListPagesForExportRow, _ error) {
+	q.fail("ListPagesForExport")
+	return
+}
+
+func (q unexpectedQuerier) ListPagesForPipeline(_ context.Context, _ db.
+	ListPagesForPipelineParams) (_ []db.ListPagesForPipelineRow, _ error) {
+	q.fail("ListPagesForPipeline")
+	return
+}
+
+func (q unexpectedQuerier) ListPagesForReclassify(_ context.Context, _ db.
+	ListPagesForReclassifyParams) (_ []db.ListPagesForReclassifyRow, _ error) {
+	q.fail("ListPagesForReclassify")
+	return
+}
+
+func (q unexpectedQuerier) ListQueueItems(_ context.Context, _ db.
+	ListQueueItemsParams) (_ []db.ScraperQueue, _ error) {
+	q.fail("ListQueueItems")
+	return
+}
+
+func (q unexpectedQuerier) ListRecentQueueFailures(_ context.Context, _ db.
+	ListRecentQueueFailuresParams) (_ []db.ScraperQueue, _ error) {
+	q.fail("ListRecentQueueFailures")
+	return
+}
+
+func (q unexpectedQuerier) ListRuns(_ context.Context, _ int64) (_ []db.ScraperRun, _ error) {
+	q.fail("ListRuns")
+	return
+}
+
+func (q unexpectedQuerier) ListTargetsPage(_ context.Context, _ db.
+	ListTargetsPageParams) (_ []db.ScraperTarget, _ error) {
+	q.fail("ListTargetsPage")
+	return
+}
+
+func (q unexpectedQuerier) ListUsers(_ context.Context) (_ []db.ScraperUser, _ error) {
+	q.fail("ListUsers")
+	return
+}
+
+func (q unexpectedQuerier) LogMessage(_ context.Context, _ db.
+	LogMessageParams) (_ error) {
+	q.fail("LogMessage")
+	return
+}
+
+func (q unexpectedQuerier) MarkPageVisited(_ context.Context, _ db.
+	MarkPageVisitedParams) (_ error) {
+	q.fail("MarkPageVisited")
+	return
+}
+
+func (q unexpectedQuerier) PutContent(_ context.Context, _ db.
+	PutContentParams) (_ error) {
+	q.fail("PutContent")
+	return
+}
+
+func (q unexpectedQuerier) RecordUserLogin(_ context.Context, _ int64) (_ error) {
+	q.fail("RecordUserLogin")
+	return
+}
+
+func (q unexpectedQuerier) RetryFailedItem(_ context.Context, _ int64) (_ error) {
+	q.fail("RetryFailedItem")
+	return
+}
+
+func (q unexpectedQuerier) SavePage(_ context.Context, _ db.
+	SavePageParams) (_ db.
+	ScraperPage, _ error) {
+	q.fail("SavePage")
+	return
+}
+
+func (q unexpectedQuerier) SavePageClassifier(_ context.Context, _ db.
+	SavePageClassifierParams) (_ error) {
+	q.fail("SavePageClassifier")
+	return
+}
+
+func (q unexpectedQuerier) SavePagePipelineStatus(_ context.Context, _ db.
+	SavePagePipelineStatusParams) (_ error) {
+	q.fail("SavePagePipelineStatus")
+	return
+}
+
+func (q unexpectedQuerier) SavePageQuotes(_ context.Context, _ db.
+	SavePageQuotesParams) (_ error) {
+	q.fail("SavePageQuotes")
+	return
+}
+
+func (q unexpectedQuerier) SetConfig(_ context.Context, _ db.
+	SetConfigParams) (_ error) {
+	q.fail("SetConfig")
+	return
+}
+
+func (q unexpectedQuerier) SetUserActive(_ context.Context, _ db.
+	SetUserActiveParams) (_ int64, _ error) {
+	q.fail("SetUserActive")
+	return
+}
+
+func (q unexpectedQuerier) SkipQueueItem(_ context.Context, _ int64) (_ error) {
+	q.fail("SkipQueueItem")
+	return
+}
+
+func (q unexpectedQuerier) TouchAPIToken(_ context.Context, _ int64) (_ error) {
+	q.fail("TouchAPIToken")
+	return
+}
+
+func (q unexpectedQuerier) UpdateTarget(_ context.Context, _ db.
+	UpdateTargetParams) (_ db.
+	ScraperTarget, _ error) {
+	q.fail("UpdateTarget")
+	return
+}
+
+func (q unexpectedQuerier) UpdateTargetClassifierOverrides(_ context.Context, _ db.
+	UpdateTargetClassifierOverridesParams) (_ error) {
+	q.fail("UpdateTargetClassifierOverrides")
+	return
+}
+
+func (q unexpectedQuerier) UpdateTargetLastVisited(_ context.Context, _ int64) (_ error) {
+	q.fail("UpdateTargetLastVisited")
+	return
+}
+
+func (q unexpectedQuerier) UpdateTargetLearnedTemplate(_ context.Context, _ db.
+	UpdateTargetLearnedTemplateParams) (_ error) {
+	q.fail("UpdateTargetLearnedTemplate")
+	return
+}
+
+func (q unexpectedQuerier) UpdateTargetPatterns(_ context.Context, _ db.
+	UpdateTargetPatternsParams) (_ error) {
+	q.fail("UpdateTargetPatterns")
+	return
+}
+
+func (q unexpectedQuerier) UpdateUserPassword(_ context.Context, _ db.
+	UpdateUserPasswordParams) (_ int64, _ error) {
+	q.fail("UpdateUserPassword")
+	return
+}
+
+func (q unexpectedQuerier) UpdateUserRole(_ context.Context, _ db.
+	UpdateUserRoleParams) (_ int64, _ error) {
+	q.fail("UpdateUserRole")
+	return
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/components"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/queue"
+	"app/internal/scraper/storage"
+)
+
+const queuePageSize = 100
+
+// QueueHandler serves the queue page and its bulk and per item actions
+type QueueHandler struct {
+	queries db.Querier
+	queue   *queue.Service
+}
+
+func NewQueueHandler(store storage.Store) *QueueHandler {
+	return &QueueHandler{queries: store.Queries(), queue: queue.NewService(store)}
+}
+
+// Page renders the queue filters over the first matching items and the bulk actions
+func (h *QueueHandler) Page(w http.ResponseWriter, r *http.Request) {
+	filter, search, err := parseQueueFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	targets, err := h.queries.ListAllTargets(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load targets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	options := make([]models.TargetData, len(targets))
+	for i, t := range targets {
+		options[i] = models.TargetData{ID: t.ID, WebsiteURL: t.WebsiteUrl}
+	}
+	results, err := h.results(ctx, filter, search)
+	if err != nil {
+		http.Error(w, "Failed to load queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.Queue(results, options).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render queue page", http.StatusInternalServerError)
+	}
+}
+
+// Items renders the items matching the filter form. Without after it replaces
+// the results and the browser URL, with it it renders the following items
+// that replace the "Load more" row.
+func (h *QueueHandler) Items(w http.ResponseWriter, r *http.Request) {
+	filter, search, err := parseQueueFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := parseCursor(r.URL.Query(), "after")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	if after != 0 {
+		items, next, err := h.queue.List(r.Context(), search, after, queuePageSize)
+		if err != nil {
+			http.Error(w, "Failed to load queue: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := components.QueueRows(filter, toQueueItems(items), next).Render(r.Context(), w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	results, err := h.results(r.Context(), filter, search)
+	if err != nil {
+		http.Error(w, "Failed to load queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("HX-Push-Url", "/queue?"+filter.Values())
+	h.render(w, r, results)
+}
+
+// Retry puts the failed items matching the filters back to pending,
+// reset_attempts also retries those that used all their attempts
+func (h *QueueHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	h.bulk(w, r, func(ctx context.Context, f queue.Filter) (string, error) {
+		reset := r.FormValue("reset_attempts") == "on"
+		n, err := h.queue.Retry(ctx, f, reset)
+		if err != nil {
+			return "", err
+		}
+		if reset {
+			return fmt.Sprintf("Retrying %d failed items with their attempts reset", n), nil
+		}
+		return fmt.Sprintf("Retrying %d failed items, those without attempts left stay failed", n), nil
+	})
+}
+
+// Prioritize sets the priority of the pending items matching the filters
+func (h *QueueHandler) Prioritize(w http.ResponseWriter, r *http.Request) {
+	h.bulk(w, r, func(ctx context.Context, f queue.Filter) (string, error) {
+		priority, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("priority")), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("priority must be a whole number")
+		}
+		n, err := h.queue.Prioritize(ctx, f, priority)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set priority %d on %d pending items", priority, n), nil
+	})
+}
+
+// Purge deletes the items matching the filters, the finished ones when no
+// status is selected
+func (h *QueueHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	h.bulk(w, r, func(ctx context.Context, f queue.Filter) (string, error) {
+		n, err := h.queue.Purge(ctx, purgeFilter(f))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted %d items", n), nil
+	})
+}
+
+// bulk runs an action on the items matching the posted filters and renders
+// the refreshed results with its outcome
+func (h *QueueHandler) bulk(w http.ResponseWriter, r *http.Request, action func(context.Context, queue.Filter) (string, error)) {
+	w.Header().Set("Content-Type", "text/html")
+	if err := r.ParseForm(); err != nil {
+		h.render(w, r, models.QueueResults{Message: "Invalid form data", MessageLevel: "error"})
+		return
+	}
+	filter, search, err := parseQueueFilter(r.Form)
+	if err != nil {
+		h.render(w, r, models.QueueResults{Filter: filter, Message: err.Error(), MessageLevel: "error"})
+		return
+	}
+	ctx := r.Context()
+	message, actionErr := action(ctx, search)
+	results, err := h.results(ctx, filter, search)
+	if err != nil {
+		http.Error(w, "Failed to load queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results.Message, results.MessageLevel = message, "success"
+	if actionErr != nil {
+		results.Message, results.MessageLevel = actionErr.Error(), "error"
+	}
+	h.render(w, r, results)
+}
+
+// Requeue puts one item back to pending with its attempts reset and renders its row
+func (h *QueueHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	item, ok := h.item(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.queue.Requeue(ctx, item.TargetID, []string{item.Url}); err != nil {
+		http.Error(w, "Failed to requeue item: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	item, err := h.queries.GetQueueItem(ctx, item.ID)
+	if err != nil {
+		http.Error(w, "Failed to load queue item: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	if err := components.QueueRow(toQueueItems([]db.ScraperQueue{item})[0]).Render(ctx, w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Delete removes one item, the empty response removes its row
+func (h *QueueHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.item(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.queries.DeleteQueueItem(r.Context(), item.ID); err != nil {
+		http.Error(w, "Failed to delete item: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// item loads the queue item of the {id} path value, answering the request when it can't
+func (h *QueueHandler) item(w http.ResponseWriter, r *http.Request) (db.ScraperQueue, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid queue item ID", http.StatusBadRequest)
+		return db.ScraperQueue{}, false
+	}
+	item, err := h.queries.GetQueueItem(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return item, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to load queue item: "+err.Error(), http.StatusInternalServerError)
+		return item, false
+	}
+	return item, true
+}
+
+// results lists the first matching items and counts what the bulk actions would change
+func (h *QueueHandler) results(ctx context.Context, filter models.QueueFilter, search queue.Filter) (models.QueueResults, error) {
+	items, next, err := h.queue.List(ctx, search, 0, queuePageSize)
+	if err != nil {
+		return models.QueueResults{}, err
+	}
+	results := models.QueueResults{Filter: filter, Items: toQueueItems(items), Next: next}
+	count := func(n *int64, f queue.Filter) {
+		if err == nil {
+			*n, err = h.queue.Count(ctx, f)
+		}
+	}
+	count(&results.Total, search)
+	count(&results.Purgeable, purgeFilter(search))
+	if filter.Status == "" || filter.Status == "failed" {
+		count(&results.Failed, withStatus(search, "failed"))
+	}
+	if filter.Status == "" || filter.Status == "pending" {
+		count(&results.Pending, withStatus(search, "pending"))
+	}
+	return results, err
+}
+
+func (h *QueueHandler) render(w http.ResponseWriter, r *http.Request, results models.QueueResults) {
+	if err := components.QueueResults(results).Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func withStatus(f queue.Filter, status string) queue.Filter {
+	f.Statuses = []string{status}
+	return f
+}
+
+// purgeFilter selects the finished items when the filters name no status
+func purgeFilter(f queue.Filter) queue.Filter {
+	if len(f.Statuses) == 0 {
+		f.Statuses = []string{"completed", "skipped", "failed"}
+	}
+	return f
+}
+
+// parseQueueFilter reads the filter form
+func parseQueueFilter(q url.Values) (models.QueueFilter, queue.Filter, error) {
+	filter := models.QueueFilter{
+		Status:    q.Get("status"),
+		Error:     strings.TrimSpace(q.Get("error")),
+		URL:       strings.TrimSpace(q.Get("url")),
+		OlderThan: strings.TrimSpace(q.Get("older_than")),
+	}
+	search := queue.Filter{ErrorContains: filter.Error, URLContains: filter.URL}
+	if filter.Status != "" {
+		statuses, err := queue.ParseStatuses(filter.Status)
+		if err != nil {
+			return filter, search, err
+		}
+		search.Statuses = statuses
+	}
+	if raw := q.Get("target_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, search, fmt.Errorf("invalid target_id")
+		}
+		filter.TargetID, search.TargetID = id, id
+	}
+	if filter.OlderThan != "" {
+		age, err := queue.ParseAge(filter.OlderThan)
+		if err != nil {
+			return filter, search, err
+		}
+		search.OlderThan = time.Now().Add(-age)
+	}
+	return filter, search, nil
+}
+
+func toQueueItems(items []db.ScraperQueue) []models.QueueItem {
+	out := make([]models.QueueItem, len(items))
+	for i, item := range items {
+		out[i] = models.QueueItem{
+			ID:          item.ID,
+			TargetID:    item.TargetID,
+			URL:         item.Url,
+			Status:      item.Status.String,
+			Priority:    item.Priority.Int64,
+			Attempts:    item.Attempts.Int64,
+			MaxAttempts: item.MaxAttempts.Int64,
+			QueuedAt:    item.CreatedAt.Time,
+			Error:       item.ErrorMessage.String,
+		}
+	}
+	return out
+}
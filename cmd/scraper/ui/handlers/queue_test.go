@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/auth"
+)
+
+func TestQueueHandler(t *testing.T) {
+	store := newTestStore(t)
+	queries := db.New(store.DB())
+	ctx := context.Background()
+	if _, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"}); err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	for i := 1; i <= queuePageSize+2; i++ {
+		if _, err := queries.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: 1, Url: fmt.Sprintf("https://quotes.example/%03d", i)}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	for id, msg := range map[int64]string{1: "HTTP 503: Service Unavailable", 2: "i/o timeout"} {
+		if err := queries.FailQueueItem(ctx, db.FailQueueItemParams{ID: id, ErrorMessage: sql.NullString{String: msg, Valid: true}}); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+
+	h := NewQueueHandler(store)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /queue", h.Page)
+	mux.HandleFunc("GET /queue/items", h.Items)
+	mux.HandleFunc("POST /api/queue/retry", h.Retry)
+	mux.HandleFunc("POST /api/queue/prioritize", h.Prioritize)
+	mux.HandleFunc("POST /api/queue/purge", h.Purge)
+	mux.HandleFunc("POST /api/queue/{id}/requeue", h.Requeue)
+	mux.HandleFunc("DELETE /api/queue/{id}", h.Delete)
+	do := func(role auth.Role, method, path, form string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(form))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(auth.WithUser(r.Context(), auth.User{ID: 1, Username: "op", Role: role}))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	expect := func(method, path, form string, want ...string) string {
+		t.Helper()
+		w := do(auth.RoleOperator, method, path, form)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", method, path, w.Code, w.Body.String())
+		}
+		for _, s := range want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("%s %s: expected %q in the response", method, path, s)
+			}
+		}
+		return w.Body.String()
+	}
+
+	expect("GET", "/queue", "", "https://quotes.example/001", "HTTP 503", "102 items match",
+		"Retry 2 failed", "Prioritize 100 pending", "Purge 2 completed/skipped/failed", "/queue/items?after=100")
+	expect("GET", "/queue/items?after=100", "", "https://quotes.example/102")
+	if w := do(auth.RoleViewer, "GET", "/queue", ""); strings.Contains(w.Body.String(), "/api/queue/") {
+		t.Error("expected no actions for viewers")
+	}
+	expect("GET", "/queue/items?status=failed&error=timeout", "", "1 items match", "Retry 1 failed", "Purge 1 failed")
+
+	expect("POST", "/api/queue/retry", "status=failed&error=503", "Retrying 1 failed items", "Retry 0 failed")
+	expect("POST", "/api/queue/retry", "status=completed", "retry applies to failed items only")
+	expect("POST", "/api/queue/prioritize", "url=%2F10&priority=7", "Set priority 7 on 3 pending items")
+	expect("POST", "/api/queue/prioritize", "priority=high", "priority must be a whole number")
+	if item, _ := queries.GetQueueItem(ctx, 100); item.Priority.Int64 != 7 {
+		t.Errorf("expected item 100 prioritized, got %d", item.Priority.Int64)
+	}
+	expect("POST", "/api/queue/purge", "", "Deleted 1 items", "101 items match")
+
+	if err := queries.CompleteQueueItem(ctx, 3); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	expect("POST", "/api/queue/3/requeue", "", "https://quotes.example/003", "pending")
+	expect("DELETE", "/api/queue/3", "")
+	if _, err := queries.GetQueueItem(ctx, 3); err != sql.ErrNoRows {
+		t.Errorf("expected item 3 deleted, got %v", err)
+	}
+	for path, want := range map[string]int{"/api/queue/3/requeue": 404, "/api/queue/x/requeue": 400} {
+		if w := do(auth.RoleOperator, "POST", path, ""); w.Code != want {
+			t.Errorf("POST %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+	if w := do(auth.RoleOperator, "GET", "/queue?older_than=soon", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid age rejected, got %d", w.Code)
+	}
+}
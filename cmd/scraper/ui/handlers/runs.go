@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+)
+
+// runPageSize is the number of latest runs the runs page compares
+const runPageSize = 100
+
+// RunsHandler serves the history of crawl runs
+type RunsHandler struct {
+	queries db.Querier
+}
+
+func NewRunsHandler(queries db.Querier) *RunsHandler {
+	return &RunsHandler{queries: queries}
+}
+
+// Page renders the latest runs, newest first
+func (h *RunsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.queries.ListRuns(r.Context(), runPageSize)
+	if err != nil {
+		http.Error(w, "Failed to load runs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	summaries := make([]models.RunSummary, len(runs))
+	for i, run := range runs {
+		summaries[i] = runSummary(run)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.Runs(summaries).Render(r.Context(), w); err != nil {
+		http.Error(w, "Failed to render runs page", http.StatusInternalServerError)
+	}
+}
+
+// Detail renders a run with its error breakdown and the queue items and logs
+// linked to it
+func (h *RunsHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid run ID", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	run, err := h.queries.GetRun(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load run: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	detail := models.RunDetail{RunSummary: runSummary(run), Error: run.ErrorMessage.String}
+	var types map[string]int64
+	if json.Unmarshal([]byte(run.ErrorBreakdown.String), &types) == nil {
+		for t, n := range types {
+			detail.ErrorTypes = append(detail.ErrorTypes, models.Count{Label: t, Count: n})
+		}
+		sort.Slice(detail.ErrorTypes, func(i, j int) bool {
+			a, b := detail.ErrorTypes[i], detail.ErrorTypes[j]
+			return a.Count > b.Count || (a.Count == b.Count && a.Label < b.Label)
+		})
+	}
+	runID := sql.NullInt64{Int64: id, Valid: true}
+	items, err := h.queries.CountRunQueueItems(ctx, runID)
+	if err != nil {
+		http.Error(w, "Failed to count queue items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, c := range items {
+		detail.QueueItems = append(detail.QueueItems, models.Count{Label: c.Status, Count: c.Count})
+	}
+	logs, err := h.queries.CountRunLogs(ctx, runID)
+	if err != nil {
+		http.Error(w, "Failed to count logs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, c := range logs {
+		detail.Logs = append(detail.Logs, models.Count{Label: c.LogType, Count: c.Count})
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.RunDetail(detail).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render run page", http.StatusInternalServerError)
+	}
+}
+
+func runSummary(run db.ScraperRun) models.RunSummary {
+	summary := models.RunSummary{
+		ID:        run.ID,
+		Trigger:   run.TriggerSource,
+		Status:    run.Status,
+		DryRun:    run.DryRun,
+		TotalURLs: run.TotalUrls,
+		Processed: run.Processed,
+		Errors:    run.Errors,
+		Skipped:   run.Skipped,
+		StartedAt: run.StartedAt.Time,
+	}
+	_ = json.Unmarshal([]byte(run.TargetIds), &summary.TargetIDs)
+	if run.FinishedAt.Valid {
+		summary.Duration = run.FinishedAt.Time.Sub(run.StartedAt.Time).Round(time.Second).String()
+	}
+	return summary
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+)
+
+func TestRunsHandler(t *testing.T) {
+	store := newTestStore(t)
+	queries := db.New(store.DB())
+	ctx := context.Background()
+
+	h := NewRunsHandler(queries)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /runs", h.Page)
+	mux.HandleFunc("GET /runs/{id}", h.Detail)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	expect := func(path string, want ...string) {
+		t.Helper()
+		w := get(path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+		for _, s := range want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("GET %s: expected %q in the response", path, s)
+			}
+		}
+	}
+
+	expect("/runs", "No runs recorded yet.")
+
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	finished, err := queries.CreateRun(ctx, db.CreateRunParams{TriggerSource: "ui", TargetIds: fmt.Sprintf("[%d]", target.ID)})
+	if err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	if err := queries.FinishRun(ctx, db.FinishRunParams{
+		ID: finished.ID, Status: "completed", TotalUrls: 8, Processed: 6, Errors: 2,
+		ErrorBreakdown: sql.NullString{String: `{"timeout":1,"client_error":1}`, Valid: true},
+	}); err != nil {
+		t.Fatalf("finish run: %v", err)
+	}
+	runID := sql.NullInt64{Int64: finished.ID, Valid: true}
+	if err := queries.LogMessage(ctx, db.LogMessageParams{LogType: "error", Message: "Fetch failed", RunID: runID}); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if _, err := queries.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: target.ID, Url: "https://quotes.example/"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := queries.DequeuePendingURL(ctx, runID); err != nil {
+		t.Fatalf("dequeue: %v", err)
+	}
+	running, err := queries.CreateRun(ctx, db.CreateRunParams{TriggerSource: "cli", DryRun: true, TargetIds: "[]"})
+	if err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+
+	expect("/runs", fmt.Sprintf("/runs/%d", finished.ID), fmt.Sprintf("/runs/%d", running.ID),
+		"completed", "25.0%", "running", "dry run")
+	expect(fmt.Sprintf("/runs/%d", finished.ID), "client_error", "timeout", "processing",
+		fmt.Sprintf("/targets/%d", target.ID), fmt.Sprintf("/logs?run_id=%d", finished.ID))
+	expect(fmt.Sprintf("/runs/%d", running.ID), "No errors.", "No queue items linked to this run.", "No logs linked to this run.")
+
+	if w := get("/runs/999"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", w.Code)
+	}
+	if w := get("/runs/x"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid ID, got %d", w.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/components"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+
+	"github.com/a-h/templ"
+)
+
+type SettingsHandler struct {
+	queries db.Querier
+	cfg     *config.Config
+}
+
+func NewSettingsHandler(queries db.Querier, cfg *config.Config) *SettingsHandler {
+	return &SettingsHandler{queries: queries, cfg: cfg}
+}
+
+// Page lists the settings stored in scraper_config with an edit form each
+func (h *SettingsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListAllConfig(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stored := make(map[string]string, len(rows))
+	for _, row := range rows {
+		stored[row.Key] = row.Value
+	}
+
+	settings := make([]models.SettingData, 0, len(config.Settings))
+	for _, s := range config.Settings {
+		if !s.Database {
+			continue
+		}
+		value, ok := stored[s.Key]
+		if !ok {
+			value = s.Default
+		}
+		data := models.SettingData{
+			Key:         s.Key,
+			Value:       value,
+			Default:     s.Default,
+			Description: s.Description,
+			Numeric:     s.Type == config.TypeInt,
+			Min:         s.Min,
+			Max:         s.Max,
+		}
+		if h.cfg != nil {
+			if v, overridden := h.cfg.Overrides(s.Key); overridden {
+				data.Override = fmt.Sprintf("%s %s = %s", v.Source, v.Origin, v.Value)
+			}
+		}
+		settings = append(settings, data)
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.Settings(settings).Render(r.Context(), w); err != nil {
+		http.Error(w, "Failed to render settings page", http.StatusInternalServerError)
+	}
+}
+
+// Update validates and stores one setting submitted via HTMX. Errors are
+// rendered into the form like the target form does.
+func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	key := r.PathValue("key")
+	setting, ok := config.Lookup(key)
+	if !ok || !setting.Database {
+		h.render(w, r, components.FormError("Unknown setting "+key))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.render(w, r, components.FormError("Invalid form data"))
+		return
+	}
+
+	value := strings.TrimSpace(r.FormValue("value"))
+	if err := setting.Validate(value); err != nil {
+		h.render(w, r, components.FormError(err.Error()))
+		return
+	}
+
+	err := h.queries.SetConfig(r.Context(), db.SetConfigParams{
+		Key:         key,
+		Value:       value,
+		Description: sql.NullString{String: setting.Description, Valid: true},
+	})
+	if err != nil {
+		h.render(w, r, components.FormError("Failed to save setting: "+err.Error()))
+		return
+	}
+	h.render(w, r, components.FormSuccess("Saved, applies to the next scraper run"))
+}
+
+func (h *SettingsHandler) render(w http.ResponseWriter, r *http.Request, component templ.Component) {
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
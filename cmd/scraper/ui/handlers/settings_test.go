@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+)
+
+type mockSettingsQueries struct {
+	unexpectedQuerier
+
+	rows  []db.ScraperConfig
+	saved []db.SetConfigParams
+}
+
+func (m *mockSettingsQueries) ListAllConfig(ctx context.Context) ([]db.ScraperConfig, error) {
+	return m.rows, nil
+}
+func (m *mockSettingsQueries) SetConfig(ctx context.Context, arg db.SetConfigParams) error {
+	m.saved = append(m.saved, arg)
+	return nil
+}
+
+func TestSettingsHandler_Page(t *testing.T) {
+	t.Setenv("SCRAPER_BATCH_SIZE", "25")
+	cfg, err := config.Load(config.Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	q := &mockSettingsQueries{unexpectedQuerier: unexpectedQuerier{t}, rows: []db.ScraperConfig{{Key: config.KeyWorkers, Value: "7"}}}
+	h := NewSettingsHandler(q, cfg)
+	w := httptest.NewRecorder()
+	h.Page(w, httptest.NewRequest("GET", "/settings", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"Maximum concurrent scraper workers",
+		`value="7"`,
+		`hx-post="/api/settings/default_user_agent"`,
+		"Overridden on this host by env SCRAPER_BATCH_SIZE = 25",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, config.KeyDatabaseURL) {
+		t.Error("expected database_url, which can't be stored in the database, to be hidden")
+	}
+}
+
+func TestSettingsHandler_Update(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		wantSaved bool
+		wantBody  string
+	}{
+		{name: "valid", key: config.KeyWorkers, value: "8", wantSaved: true, wantBody: "Saved"},
+		{name: "out of range", key: config.KeyWorkers, value: "99", wantBody: "must be between 1 and 20"},
+		{name: "not a number", key: config.KeyRequestTimeout, value: "soon", wantBody: "must be a whole number"},
+		{name: "empty string", key: config.KeyUserAgent, value: "  ", wantBody: "must not be empty"},
+		{name: "not stored in database", key: config.KeyDatabaseURL, value: "x.db", wantBody: "Unknown setting"},
+		{name: "unknown", key: "bogus", value: "1", wantBody: "Unknown setting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &mockSettingsQueries{unexpectedQuerier: unexpectedQuerier{t}}
+			h := NewSettingsHandler(q, nil)
+			form := url.Values{"value": {tt.value}}
+			r := httptest.NewRequest("POST", "/api/settings/"+tt.key, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.SetPathValue("key", tt.key)
+			w := httptest.NewRecorder()
+			h.Update(w, r)
+
+			if saved := len(q.saved) == 1; saved != tt.wantSaved {
+				t.Fatalf("expected saved=%v, got %+v", tt.wantSaved, q.saved)
+			}
+			if tt.wantSaved && (q.saved[0].Key != tt.key || q.saved[0].Value != tt.value || !q.saved[0].Description.Valid) {
+				t.Errorf("unexpected saved setting: %+v", q.saved[0])
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/cmd/scraper/ui/models"
+	"app/cmd/scraper/ui/templates/components"
+	"app/cmd/scraper/ui/templates/pages"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
+)
+
+const (
+	detailPageSize    = 50
+	detailFailures    = 20
+	detailLogs        = 20
+	treeMaxPaths      = 5000
+	treeMaxDepth      = 3
+	treeMaxChildren   = 25
+	maxHTMLPreviewLen = 200 << 10
+)
+
+// targetIDs parses the {id} and, when the route has one, {pageID} path values
+func targetIDs(r *http.Request) (targetID, pageID int64, err error) {
+	if targetID, err = strconv.ParseInt(r.PathValue("id"), 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid target ID")
+	}
+	if raw := r.PathValue("pageID"); raw != "" {
+		if pageID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid page ID")
+		}
+	}
+	return targetID, pageID, nil
+}
+
+// Detail renders a target's configuration, queue, failures, classifier
+// outcomes, site tree, first crawled pages and recent logs
+func (h *TargetsHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	targetID, _, err := targetIDs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	target, err := h.queries.GetTarget(ctx, targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load target: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	detail := models.TargetDetail{
+		ID:         target.ID,
+		WebsiteURL: target.WebsiteUrl,
+		Active:     !target.IsActive.Valid || target.IsActive.Bool,
+		Config:     targetConfig(target),
+	}
+	if err := h.loadTargetDetail(r, &detail); err != nil {
+		http.Error(w, "Failed to load target: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.TargetDetail(detail).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render target page", http.StatusInternalServerError)
+	}
+}
+
+func (h *TargetsHandler) loadTargetDetail(r *http.Request, detail *models.TargetDetail) error {
+	ctx := r.Context()
+
+	stats, err := h.queries.GetTargetQueueStats(ctx, detail.ID)
+	if err != nil {
+		return fmt.Errorf("queue stats: %w", err)
+	}
+	detail.Queue = models.QueueCounts{
+		Pending:    stats.Pending,
+		Processing: stats.Processing,
+		Completed:  stats.Completed,
+		Skipped:    stats.Skipped,
+		Failed:     stats.Failed,
+	}
+
+	failures, err := h.queries.ListRecentQueueFailures(ctx, db.ListRecentQueueFailuresParams{TargetID: detail.ID, RowLimit: detailFailures})
+	if err != nil {
+		return fmt.Errorf("queue failures: %w", err)
+	}
+	for _, f := range failures {
+		detail.Failures = append(detail.Failures, models.QueueFailure{
+			ID:       f.ID,
+			URL:      f.Url,
+			Attempts: f.Attempts.Int64,
+			Error:    f.ErrorMessage.String,
+			FailedAt: f.ProcessedAt.Time,
+		})
+	}
+
+	decisions, err := h.queries.CountClassifierDecisions(ctx, detail.ID)
+	if err != nil {
+		return fmt.Errorf("classifier decisions: %w", err)
+	}
+	for _, d := range decisions {
+		detail.Decisions = append(detail.Decisions, models.DecisionCount{
+			Reason: d.DecisionReason,
+			// processable defaults to false, the reason tells classified pages apart
+			Classified:  d.DecisionReason != "",
+			Processable: d.Processable.Bool,
+			Pages:       d.Pages,
+		})
+	}
+
+	paths, err := h.queries.ListPagePaths(ctx, db.ListPagePathsParams{TargetID: detail.ID, RowLimit: treeMaxPaths + 1})
+	if err != nil {
+		return fmt.Errorf("page paths: %w", err)
+	}
+	if detail.TreeTruncated = len(paths) > treeMaxPaths; detail.TreeTruncated {
+		paths = paths[:treeMaxPaths]
+	}
+	detail.TreePages = len(paths)
+	detail.Tree = buildPathTree(detail.WebsiteURL, paths)
+
+	if detail.Pages, detail.PagesAfter, err = h.pageRows(r, detail.ID, 0); err != nil {
+		return err
+	}
+
+	logs, err := h.queries.GetLogsByTarget(ctx, db.GetLogsByTargetParams{
+		TargetID: sql.NullInt64{Int64: detail.ID, Valid: true},
+		Limit:    detailLogs,
+	})
+	if err != nil {
+		return fmt.Errorf("logs: %w", err)
+	}
+	for _, l := range logs {
+		detail.Logs = append(detail.Logs, models.LogEntry{
+			Timestamp: l.CreatedAt.Time,
+			Level:     l.LogType,
+			Message:   l.Message,
+			URL:       l.Url.String,
+			Details:   l.Details.String,
+		})
+	}
+	return nil
+}
+
+// Pages returns the next rows of a target's crawled pages for HTMX
+func (h *TargetsHandler) Pages(w http.ResponseWriter, r *http.Request) {
+	targetID, _, err := targetIDs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	if err != nil || after < 0 {
+		http.Error(w, "Invalid after", http.StatusBadRequest)
+		return
+	}
+	rows, next, err := h.pageRows(r, targetID, after)
+	if err != nil {
+		http.Error(w, "Failed to load pages: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	if err := components.TargetPageRows(targetID, rows, next).Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// pageRows returns a target's pages after the cursor and the cursor of the following ones
+func (h *TargetsHandler) pageRows(r *http.Request, targetID, after int64) ([]models.PageRow, int64, error) {
+	summaries, err := h.queries.ListPageSummaries(r.Context(), db.ListPageSummariesParams{
+		AfterID:  after,
+		TargetID: sql.NullInt64{Int64: targetID, Valid: true},
+		PageSize: detailPageSize + 1,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("pages: %w", err)
+	}
+	var next int64
+	if len(summaries) > detailPageSize {
+		summaries = summaries[:detailPageSize]
+		next = summaries[len(summaries)-1].ID
+	}
+	rows := make([]models.PageRow, len(summaries))
+	for i, p := range summaries {
+		rows[i] = models.PageRow{
+			ID:             p.ID,
+			TargetID:       p.TargetID,
+			URL:            p.FullUrl,
+			StatusCode:     p.HttpStatusCode.Int64,
+			ContentLength:  p.ContentLength.Int64,
+			ResponseTimeMs: p.ResponseTimeMs.Int64,
+			LastVisitedAt:  p.LastVisitedAt.Time,
+			Processable:    p.Processable.Bool,
+		}
+	}
+	return rows, next, nil
+}
+
+// PageDetail renders a crawled page with its stored HTML and classifier features
+func (h *TargetsHandler) PageDetail(w http.ResponseWriter, r *http.Request) {
+	targetID, pageID, err := targetIDs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	page, err := h.queries.GetPage(ctx, pageID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && page.TargetID != targetID) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load page: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	target, err := h.queries.GetTarget(ctx, targetID)
+	if err != nil {
+		http.Error(w, "Failed to load target: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	detail := models.PageDetail{
+		ID:        page.ID,
+		TargetID:  targetID,
+		TargetURL: target.WebsiteUrl,
+		URL:       page.FullUrl,
+		Meta: []models.Field{
+			{Label: "HTTP status", Value: formatNullInt(page.HttpStatusCode, "")},
+			{Label: "Size", Value: formatNullInt(page.ContentLength, " bytes")},
+			{Label: "Response time", Value: formatNullInt(page.ResponseTimeMs, " ms")},
+			{Label: "Language", Value: page.Language.String},
+			{Label: "Content hash", Value: page.ContentHash.String},
+			{Label: "Visits", Value: formatNullInt(page.VisitCount, "")},
+			{Label: "First discovered", Value: formatNullTime(page.FirstDiscoveredAt)},
+			{Label: "Last visited", Value: formatNullTime(page.LastVisitedAt)},
+			{Label: "Last changed", Value: formatNullTime(page.LastUpdatedAt)},
+		},
+	}
+
+	if page.QuoteClassifierJson.Valid && page.QuoteClassifierJson.String != "" {
+		var result classifier.QuoteClassifierDecision
+		if err := json.Unmarshal([]byte(page.QuoteClassifierJson.String), &result); err != nil {
+			detail.Decision = []models.Field{{Label: "Error", Value: "unreadable classifier result: " + err.Error()}}
+		} else {
+			detail.Classified = true
+			detail.Decision = decisionFields(result.Decision)
+			detail.Features = featureFields(result.Features)
+		}
+	}
+
+	body, err := content.PageBody(ctx, h.contents, page.HtmlContent, page.ContentKey)
+	switch {
+	case errors.Is(err, content.ErrNotFound):
+		detail.HTMLError = "No HTML stored for this page"
+	case err != nil:
+		uiLog().ErrorContext(ctx, "Failed to load page content", "page_id", page.ID, logger.Err(err))
+		detail.HTMLError = "Failed to load the stored HTML: " + err.Error()
+	default:
+		detail.HTML = body
+		if len(body) > maxHTMLPreviewLen {
+			detail.HTML = strings.ToValidUTF8(body[:maxHTMLPreviewLen], "")
+			detail.HTMLTruncated = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pages.PageDetail(detail).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+// targetConfig lists the crawl settings of a target in the order the page shows them
+func targetConfig(t db.ScraperTarget) []models.Field {
+	followSitemap := "yes"
+	if t.FollowSitemap.Valid && !t.FollowSitemap.Bool {
+		followSitemap = "no"
+	}
+	rate := ""
+	if t.RequestsPerSecond.Valid {
+		rate = strconv.FormatFloat(t.RequestsPerSecond.Float64, 'f', -1, 64) + " requests/s"
+	}
+	return []models.Field{
+		{Label: "Domain", Value: t.DomainName.String},
+		{Label: "Sitemap URL", Value: t.SitemapUrl.String},
+		{Label: "Follow sitemap", Value: followSitemap},
+		{Label: "Sitemap patterns", Value: t.SitemapPatterns.String},
+		{Label: "URL patterns", Value: t.UrlPatterns.String},
+		{Label: "Crawl delay", Value: formatNullInt(t.CrawlDelaySeconds, " s")},
+		{Label: "Rate limit", Value: rate},
+		{Label: "Max concurrent requests", Value: formatNullInt(t.MaxConcurrentRequests, "")},
+		{Label: "User agent", Value: t.UserAgent.String},
+		{Label: "Custom headers", Value: t.CustomHeaders.String},
+		{Label: "Classifier overrides", Value: t.ClassifierOverridesJson.String},
+		{Label: "Notes", Value: t.Notes.String},
+		{Label: "Created", Value: formatNullTime(t.CreatedAt)},
+		{Label: "Last crawled", Value: formatNullTime(t.LastVisitedAt)},
+	}
+}
+
+func decisionFields(d classifier.QuoteDecision) []models.Field {
+	processable := "no"
+	if d.Processable {
+		processable = "yes"
+	}
+	return []models.Field{
+		{Label: "Processable", Value: processable},
+		{Label: "Reason", Value: d.DecisionReason},
+		{Label: "Confidence", Value: strconv.FormatFloat(d.Confidence, 'f', 2, 64)},
+		{Label: "Selectors", Value: strings.Join(d.Selectors, ", ")},
+		{Label: "Profile", Value: d.Profile},
+		{Label: "Classified at", Value: d.ClassifiedAt},
+	}
+}
+
+// featureFields formats classifier features sorted by name
+func featureFields(features map[string]interface{}) []models.Field {
+	fields := make([]models.Field, 0, len(features))
+	for name, value := range features {
+		var formatted string
+		switch v := value.(type) {
+		case float64:
+			formatted = strconv.FormatFloat(v, 'f', -1, 64)
+			if v != float64(int64(v)) {
+				formatted = strconv.FormatFloat(v, 'f', 3, 64)
+			}
+		case string:
+			formatted = v
+		default:
+			data, _ := json.Marshal(v)
+			formatted = string(data)
+		}
+		fields = append(fields, models.Field{Label: name, Value: formatted})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Label < fields[j].Label })
+	return fields
+}
+
+func formatNullInt(v sql.NullInt64, unit string) string {
+	if !v.Valid {
+		return ""
+	}
+	return strconv.FormatInt(v.Int64, 10) + unit
+}
+
+func formatNullTime(v sql.NullTime) string {
+	if !v.Valid {
+		return ""
+	}
+	return v.Time.Format(time.DateTime)
+}
+
+// pathTree is a node of the site tree while it is being built
+type pathTree struct {
+	pages    int
+	children map[string]*pathTree
+}
+
+// buildPathTree groups page URLs by their first path segments into a tree
+// flattened depth first, with the site itself as the root. Sections beyond
+// treeMaxChildren per level are folded into one "more" node.
+func buildPathTree(siteURL string, pageURLs []string) []models.PathNode {
+	root := &pathTree{children: map[string]*pathTree{}}
+	for _, raw := range pageURLs {
+		path := raw
+		if u, err := url.Parse(raw); err == nil {
+			path = u.Path
+		}
+		root.pages++
+		node := root
+		for depth, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+			if segment == "" || depth == treeMaxDepth {
+				break
+			}
+			child, ok := node.children[segment]
+			if !ok {
+				child = &pathTree{children: map[string]*pathTree{}}
+				node.children[segment] = child
+			}
+			child.pages++
+			node = child
+		}
+	}
+
+	nodes := []models.PathNode{{Name: siteURL, Pages: root.pages}}
+	var walk func(node *pathTree, depth int)
+	walk = func(node *pathTree, depth int) {
+		names := make([]string, 0, len(node.children))
+		for name := range node.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			child := node.children[name]
+			if i == treeMaxChildren {
+				folded := 0
+				for _, rest := range names[i:] {
+					folded += node.children[rest].pages
+				}
+				nodes = append(nodes, models.PathNode{
+					Name:  fmt.Sprintf("… %d more sections", len(names)-i),
+					Depth: depth,
+					Pages: folded,
+				})
+				return
+			}
+			nodes = append(nodes, models.PathNode{Name: "/" + name, Depth: depth, Pages: child.pages})
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 1)
+	return nodes
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/cmd/scraper/ui/models"
+	"app/internal/scraper/db"
+)
+
+func TestBuildPathTree(t *testing.T) {
+	got := buildPathTree("https://quotes.example", []string{
+		"https://quotes.example/",
+		"https://quotes.example/author/einstein",
+		"https://quotes.example/author/twain?page=2",
+		"https://quotes.example/tag/life/page/1/",
+		"https://quotes.example/tag-cloud",
+		"/tag/love",
+	})
+	want := []models.PathNode{
+		{Name: "https://quotes.example", Depth: 0, Pages: 6},
+		{Name: "/author", Depth: 1, Pages: 2},
+		{Name: "/einstein", Depth: 2, Pages: 1},
+		{Name: "/twain", Depth: 2, Pages: 1},
+		{Name: "/tag", Depth: 1, Pages: 2},
+		{Name: "/life", Depth: 2, Pages: 1},
+		{Name: "/page", Depth: 3, Pages: 1},
+		{Name: "/love", Depth: 2, Pages: 1},
+		{Name: "/tag-cloud", Depth: 1, Pages: 1},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	var many []string
+	for i := 0; i < treeMaxChildren+5; i++ {
+		many = append(many, fmt.Sprintf("/section-%02d", i))
+	}
+	got = buildPathTree("site", many)
+	if last := got[len(got)-1]; len(got) != treeMaxChildren+2 || last.Name != "… 5 more sections" || last.Pages != 5 {
+		t.Errorf("expected the extra sections folded, got %d nodes ending in %+v", len(got), last)
+	}
+}
+
+type targetDetailEnv struct {
+	t       *testing.T
+	queries *db.Queries
+	mux     *http.ServeMux
+}
+
+func newTargetDetailEnv(t *testing.T) *targetDetailEnv {
+	queries := newTestQueries(t)
+	h := NewTargetsHandler(queries)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /targets/{id}", h.Detail)
+	mux.HandleFunc("GET /targets/{id}/pages", h.Pages)
+	mux.HandleFunc("GET /targets/{id}/pages/{pageID}", h.PageDetail)
+	return &targetDetailEnv{t: t, queries: queries, mux: mux}
+}
+
+func (e *targetDetailEnv) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func (e *targetDetailEnv) expectBody(path string, want ...string) {
+	e.t.Helper()
+	w := e.get(path)
+	if w.Code != http.StatusOK {
+		e.t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+	}
+	for _, s := range want {
+		if !strings.Contains(w.Body.String(), s) {
+			e.t.Errorf("GET %s: expected %q in the page", path, s)
+		}
+	}
+}
+
+func TestTargetsHandler_Detail(t *testing.T) {
+	e := newTargetDetailEnv(t)
+	ctx := context.Background()
+	target, err := e.queries.CreateTarget(ctx, db.CreateTargetParams{
+		WebsiteUrl: "https://quotes.example",
+		SitemapUrl: sql.NullString{String: "https://quotes.example/sitemap.xml", Valid: true},
+		UserAgent:  sql.NullString{String: "quotes-bot/1.0", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	for _, u := range []string{"https://quotes.example/a", "https://quotes.example/b"} {
+		if _, err := e.queries.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: target.ID, Url: u}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	if err := e.queries.FailQueueItem(ctx, db.FailQueueItemParams{ID: 2, ErrorMessage: sql.NullString{String: "HTTP 503: Service Unavailable", Valid: true}}); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+
+	for i := 1; i <= detailPageSize+1; i++ {
+		u := fmt.Sprintf("https://quotes.example/quotes/page-%02d", i)
+		if _, err := e.queries.SavePage(ctx, db.SavePageParams{
+			TargetID:       target.ID,
+			UrlPath:        u,
+			FullUrl:        u,
+			HtmlContent:    sql.NullString{String: "<html><script>alert(1)</script>Be yourself</html>", Valid: true},
+			HttpStatusCode: sql.NullInt64{Int64: 200, Valid: true},
+			ResponseTimeMs: sql.NullInt64{Int64: 42, Valid: true},
+			ContentLength:  sql.NullInt64{Int64: 2048, Valid: true},
+		}); err != nil {
+			t.Fatalf("save page: %v", err)
+		}
+	}
+	if err := e.queries.SavePageClassifier(ctx, db.SavePageClassifierParams{
+		QuoteClassifierJson: sql.NullString{String: `{"features":{"num_blocks":12,"quote_score":0.8125},"decision":{"processable":true,"confidence":0.9,"decision_reason":"structured_quotes","selectors":[".quote"]}}`, Valid: true},
+		Processable:         sql.NullBool{Bool: true, Valid: true},
+		TargetID:            target.ID,
+		UrlPath:             "https://quotes.example/quotes/page-01",
+	}); err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	if err := e.queries.LogMessage(ctx, db.LogMessageParams{
+		LogType:  "warn",
+		TargetID: sql.NullInt64{Int64: target.ID, Valid: true},
+		Message:  "Robots.txt disallows /private",
+	}); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+
+	e.expectBody("/targets/1",
+		"https://quotes.example/sitemap.xml", "quotes-bot/1.0",
+		"HTTP 503: Service Unavailable",
+		"structured_quotes", "not classified",
+		"/quotes", "Robots.txt disallows /private",
+		"/targets/1/pages/1", "2.0 KB", "42 ms",
+		"/targets/1/pages?after=50",
+	)
+	e.expectBody("/targets/1/pages?after=50", "page-51")
+	if w := e.get("/targets/1/pages?after=50"); strings.Contains(w.Body.String(), "Load more") {
+		t.Error("expected no more pages after the last one")
+	}
+
+	e.expectBody("/targets/1/pages/1",
+		"structured_quotes", ".quote", "0.90",
+		"num_blocks", "0.812",
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"/api/v1/pages/1/content",
+	)
+	e.expectBody("/targets/1/pages/2", "This page has not been classified")
+
+	for path, want := range map[string]int{
+		"/targets/9":             http.StatusNotFound,
+		"/targets/x":             http.StatusBadRequest,
+		"/targets/2/pages/1":     http.StatusNotFound,
+		"/targets/1/pages/999":   http.StatusNotFound,
+		"/targets/1/pages?after": http.StatusBadRequest,
+	} {
+		if w := e.get(path); w.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -6,15 +6,17 @@ import (
 
 	"app/cmd/scraper/ui/templates/components"
 	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
 	"database/sql"
 )
 
 type TargetsHandler struct {
-	queries db.Querier
+	queries  db.Querier
+	contents content.Store
 }
 
 func NewTargetsHandler(queries db.Querier) *TargetsHandler {
-	return &TargetsHandler{queries: queries}
+	return &TargetsHandler{queries: queries, contents: content.NewDBStore(queries)}
 }
 
 // NewForm returns the new target form for HTMX modal
@@ -13,6 +13,8 @@ import (
 )
 
 type mockTargetsQueries struct {
+	unexpectedQuerier
+
 	CreateTargetFunc     func(context.Context, db.CreateTargetParams) (db.ScraperTarget, error)
 	DeactivateTargetFunc func(context.Context, int64) error
 }
@@ -38,7 +40,7 @@ func (m *mockTargetsQueries) GetRecentLogs(ctx context.Context, limit int64) ([]
 	return nil, nil
 }
 func (m *mockTargetsQueries) CompleteQueueItem(ctx context.Context, id int64) error { return nil }
-func (m *mockTargetsQueries) DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error) {
+func (m *mockTargetsQueries) DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error) {
 	return db.ScraperQueue{}, nil
 }
 func (m *mockTargetsQueries) EnqueueURL(ctx context.Context, arg db.EnqueueURLParams) (db.ScraperQueue, error) {
@@ -107,7 +109,7 @@ func (m *mockTargetsQueries) SavePageClassifier(ctx context.Context, arg db.Save
 }
 
 func TestTargetsHandler_NewForm(t *testing.T) {
-	h := &TargetsHandler{queries: &mockTargetsQueries{}}
+	h := &TargetsHandler{queries: &mockTargetsQueries{unexpectedQuerier: unexpectedQuerier{t}}}
 	r := httptest.NewRequest("GET", "/targets/new", nil)
 	w := httptest.NewRecorder()
 
@@ -120,6 +122,7 @@ func TestTargetsHandler_NewForm(t *testing.T) {
 
 func TestTargetsHandler_Create_Success(t *testing.T) {
 	h := &TargetsHandler{queries: &mockTargetsQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		CreateTargetFunc: func(ctx context.Context, arg db.CreateTargetParams) (db.ScraperTarget, error) {
 			return db.ScraperTarget{ID: 1, WebsiteUrl: arg.WebsiteUrl}, nil
 		},
@@ -137,7 +140,7 @@ func TestTargetsHandler_Create_Success(t *testing.T) {
 }
 
 func TestTargetsHandler_Create_MissingWebsiteURL(t *testing.T) {
-	h := &TargetsHandler{queries: &mockTargetsQueries{}}
+	h := &TargetsHandler{queries: &mockTargetsQueries{unexpectedQuerier: unexpectedQuerier{t}}}
 	form := "sitemap_url=https://a.com/sitemap.xml"
 	r := httptest.NewRequest("POST", "/targets", strings.NewReader(form))
 	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -152,6 +155,7 @@ func TestTargetsHandler_Create_MissingWebsiteURL(t *testing.T) {
 
 func TestTargetsHandler_Create_DBError(t *testing.T) {
 	h := &TargetsHandler{queries: &mockTargetsQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		CreateTargetFunc: func(ctx context.Context, arg db.CreateTargetParams) (db.ScraperTarget, error) {
 			return db.ScraperTarget{}, errors.New("db error")
 		},
@@ -170,6 +174,7 @@ func TestTargetsHandler_Create_DBError(t *testing.T) {
 
 func TestTargetsHandler_Delete_Success(t *testing.T) {
 	h := &TargetsHandler{queries: &mockTargetsQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		DeactivateTargetFunc: func(ctx context.Context, id int64) error {
 			return nil
 		},
@@ -186,7 +191,7 @@ func TestTargetsHandler_Delete_Success(t *testing.T) {
 }
 
 func TestTargetsHandler_Delete_BadID(t *testing.T) {
-	h := &TargetsHandler{queries: &mockTargetsQueries{}}
+	h := &TargetsHandler{queries: &mockTargetsQueries{unexpectedQuerier: unexpectedQuerier{t}}}
 	r := httptest.NewRequest("DELETE", "/targets/abc", nil)
 	r.SetPathValue("id", "abc")
 	w := httptest.NewRecorder()
@@ -200,6 +205,7 @@ func TestTargetsHandler_Delete_BadID(t *testing.T) {
 
 func TestTargetsHandler_Delete_DBError(t *testing.T) {
 	h := &TargetsHandler{queries: &mockTargetsQueries{
+		unexpectedQuerier: unexpectedQuerier{t},
 		DeactivateTargetFunc: func(ctx context.Context, id int64) error {
 			return errors.New("db error")
 		},
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/jobs"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
+)
+
+// The /api/v1 JSON API for scripts and other services, documented by openapi.json.
+// Lists are paged by opaque cursors: pass next_cursor of a response as cursor
+// to get the following page, the last page has no next_cursor.
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// Error codes of the error envelope
+const (
+	codeBadRequest = "bad_request"
+	codeNotFound   = "not_found"
+	codeConflict   = "conflict"
+	codeInternal   = "internal"
+)
+
+// CrawlFunc runs a crawl for a target, 0 for all active targets, until ctx is cancelled
+type CrawlFunc func(ctx context.Context, targetID int64, dryRun bool) error
+
+type V1Handler struct {
+	queries  db.Querier
+	contents content.Store
+	jobs     *jobs.Manager
+	crawl    CrawlFunc
+}
+
+func NewV1Handler(queries db.Querier, manager *jobs.Manager, crawl CrawlFunc) *V1Handler {
+	return &V1Handler{
+		queries:  queries,
+		contents: content.NewDBStore(queries),
+		jobs:     manager,
+		crawl:    crawl,
+	}
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type listResponse struct {
+	Data       any    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		uiLog().Warn("Failed to write JSON response", logger.Err(err))
+	}
+}
+
+func writeData(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, code, format string, args ...any) {
+	writeJSON(w, status, map[string]apiError{"error": {Code: code, Message: fmt.Sprintf(format, args...)}})
+}
+
+// WriteAPIError answers with the error envelope, for middleware in front of the API
+func WriteAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeError(w, status, code, "%s", message)
+}
+
+// writeQueryError reports a failed query, missing rows as 404 for the named resource
+func writeQueryError(w http.ResponseWriter, r *http.Request, err error, resource string, id int64) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, codeNotFound, "%s %d not found", resource, id)
+		return
+	}
+	writeInternalError(w, r, err, "Failed to load "+resource, "id", id)
+}
+
+// writeInternalError logs an unexpected error with the request and answers
+// with a generic 500, database errors aren't for clients to see
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error, msg string, attrs ...any) {
+	attrs = append([]any{"method", r.Method, "path", r.URL.Path}, attrs...)
+	uiLog().ErrorContext(r.Context(), msg, append(attrs, logger.Err(err))...)
+	writeError(w, http.StatusInternalServerError, codeInternal, "internal error")
+}
+
+// NotFound answers requests to unknown /api/v1 routes
+func (h *V1Handler) NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, codeNotFound, "no route for %s %s", r.Method, r.URL.Path)
+}
+
+// OpenAPI serves the OpenAPI 3 document of the API
+func (h *V1Handler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(openAPISpec); err != nil {
+		uiLog().WarnContext(r.Context(), "Failed to write OpenAPI document", logger.Err(err))
+	}
+}
+
+// page is the cursor and size of a list request
+type page struct {
+	after int64
+	limit int
+}
+
+// encodeCursor hides the keyset id so clients don't build cursors themselves
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func parsePage(r *http.Request) (page, error) {
+	p := page{limit: defaultPageLimit}
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return p, fmt.Errorf("invalid limit %q: expected 1-%d", v, maxPageLimit)
+		}
+		p.limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		if err == nil {
+			p.after, err = strconv.ParseInt(string(raw), 10, 64)
+		}
+		if err != nil || p.after <= 0 {
+			return p, fmt.Errorf("invalid cursor %q", v)
+		}
+	}
+	return p, nil
+}
+
+// writeList answers with up to p.limit items, the query fetched one more to
+// tell whether another page follows
+func writeList[T any](w http.ResponseWriter, p page, items []T, id func(T) int64) {
+	resp := listResponse{Data: items}
+	if len(items) > p.limit {
+		items = items[:p.limit]
+		resp = listResponse{Data: items, NextCursor: encodeCursor(id(items[len(items)-1]))}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+func queryInt(r *http.Request, name string) (sql.NullInt64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return sql.NullInt64{}, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return sql.NullInt64{Int64: n, Valid: true}, nil
+}
+
+func queryBool(r *http.Request, name string) (sql.NullBool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return sql.NullBool{}, fmt.Errorf("invalid %s %q: expected true or false", name, v)
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// queryTime accepts RFC 3339 timestamps or YYYY-MM-DD dates
+func queryTime(r *http.Request, name string) (sql.NullTime, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return sql.NullTime{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+		}
+	}
+	return sql.NullTime{}, fmt.Errorf("invalid %s %q: expected RFC 3339 or YYYY-MM-DD", name, v)
+}
+
+// decodeBody reads a JSON request body, unknown fields are rejected so typos don't go unnoticed
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullInt(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func nullBool(v sql.NullBool) *bool {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Bool
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time.UTC()
+	return &t
+}
+
+// rawJSON passes a stored JSON column through, null when empty or invalid
+func rawJSON(v sql.NullString) json.RawMessage {
+	if !v.Valid || !json.Valid([]byte(v.String)) {
+		return nil
+	}
+	return json.RawMessage(v.String)
+}
+
+type v1Stats struct {
+	ActiveTargets int64                 `json:"active_targets"`
+	Pages         int64                 `json:"pages"`
+	Queue         db.GetQueueStatsRow   `json:"queue"`
+	RecentErrors  int64                 `json:"recent_errors"`
+	Content       db.GetContentStatsRow `json:"content"`
+}
+
+// Stats returns the dashboard counters
+func (h *V1Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var stats v1Stats
+	steps := []func() error{
+		func() (err error) { stats.ActiveTargets, err = h.queries.GetTargetCount(ctx); return },
+		func() (err error) { stats.Pages, err = h.queries.GetTotalPagesCount(ctx); return },
+		func() (err error) { stats.Queue, err = h.queries.GetQueueStats(ctx); return },
+		func() (err error) { stats.RecentErrors, err = h.queries.GetRecentErrorsCount(ctx); return },
+		func() (err error) { stats.Content, err = h.queries.GetContentStats(ctx); return },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			writeInternalError(w, r, err, "Failed to load stats")
+			return
+		}
+	}
+	writeData(w, http.StatusOK, stats)
+}
+
+type v1JobInput struct {
+	Kind     string `json:"kind"`
+	TargetID int64  `json:"target_id"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+// ListJobs returns the jobs started since the server came up, newest first
+func (h *V1Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, listResponse{Data: h.jobs.List()})
+}
+
+// GetJob returns the state of a job
+func (h *V1Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	job, err := h.jobs.Get(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, "job %d not found", id)
+		return
+	}
+	writeData(w, http.StatusOK, job)
+}
+
+// StartJob starts a crawl of one target or all active targets
+func (h *V1Handler) StartJob(w http.ResponseWriter, r *http.Request) {
+	var in v1JobInput
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	if in.Kind == "" {
+		in.Kind = jobs.KindCrawl
+	}
+	if in.Kind != jobs.KindCrawl {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "unknown job kind %q: expected %q", in.Kind, jobs.KindCrawl)
+		return
+	}
+	if in.TargetID != 0 {
+		if _, err := h.queries.GetTarget(r.Context(), in.TargetID); err != nil {
+			writeQueryError(w, r, err, "target", in.TargetID)
+			return
+		}
+	}
+
+	job, err := h.jobs.Start(in.Kind, in.TargetID, in.DryRun, func(ctx context.Context) error {
+		return h.crawl(ctx, in.TargetID, in.DryRun)
+	})
+	if errors.Is(err, jobs.ErrBusy) {
+		writeError(w, http.StatusConflict, codeConflict, "%v", err)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to start job")
+		return
+	}
+	writeData(w, http.StatusAccepted, job)
+}
+
+// CancelJob asks a running job to stop, it reports cancelled once it did
+func (h *V1Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	job, err := h.jobs.Cancel(id)
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "job %d not found", id)
+	case errors.Is(err, jobs.ErrFinished):
+		writeError(w, http.StatusConflict, codeConflict, "job %d already %s", id, job.Status)
+	default:
+		writeData(w, http.StatusAccepted, job)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
+)
+
+type v1Page struct {
+	ID                int64      `json:"id"`
+	TargetID          int64      `json:"target_id"`
+	URLPath           string     `json:"url_path"`
+	FullURL           string     `json:"full_url"`
+	ContentHash       *string    `json:"content_hash"`
+	HTTPStatusCode    *int64     `json:"http_status_code"`
+	ResponseTimeMs    *int64     `json:"response_time_ms"`
+	ContentLength     *int64     `json:"content_length"`
+	FirstDiscoveredAt *time.Time `json:"first_discovered_at"`
+	LastVisitedAt     *time.Time `json:"last_visited_at"`
+	LastUpdatedAt     *time.Time `json:"last_updated_at"`
+	VisitCount        *int64     `json:"visit_count"`
+	Processable       *bool      `json:"processable"`
+	Language          *string    `json:"language"`
+}
+
+// v1PageDetail adds the pipeline results stored with the page
+type v1PageDetail struct {
+	v1Page
+	Quotes         json.RawMessage `json:"quotes"`
+	PipelineStatus json.RawMessage `json:"pipeline_status"`
+}
+
+type v1Classifier struct {
+	PageID      int64           `json:"page_id"`
+	Processable *bool           `json:"processable"`
+	Language    *string         `json:"language"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type v1Log struct {
+	ID        int64           `json:"id"`
+	Level     string          `json:"level"`
+	TargetID  *int64          `json:"target_id"`
+	URL       *string         `json:"url"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt *time.Time      `json:"created_at"`
+}
+
+func toV1PageSummary(p db.ListPageSummariesRow) v1Page {
+	return v1Page{
+		ID:                p.ID,
+		TargetID:          p.TargetID,
+		URLPath:           p.UrlPath,
+		FullURL:           p.FullUrl,
+		ContentHash:       nullString(p.ContentHash),
+		HTTPStatusCode:    nullInt(p.HttpStatusCode),
+		ResponseTimeMs:    nullInt(p.ResponseTimeMs),
+		ContentLength:     nullInt(p.ContentLength),
+		FirstDiscoveredAt: nullTime(p.FirstDiscoveredAt),
+		LastVisitedAt:     nullTime(p.LastVisitedAt),
+		LastUpdatedAt:     nullTime(p.LastUpdatedAt),
+		VisitCount:        nullInt(p.VisitCount),
+		Processable:       nullBool(p.Processable),
+		Language:          nullString(p.Language),
+	}
+}
+
+func toV1PageDetail(p db.ScraperPage) v1PageDetail {
+	return v1PageDetail{
+		v1Page: toV1PageSummary(db.ListPageSummariesRow{
+			ID:                p.ID,
+			TargetID:          p.TargetID,
+			UrlPath:           p.UrlPath,
+			FullUrl:           p.FullUrl,
+			ContentHash:       p.ContentHash,
+			HttpStatusCode:    p.HttpStatusCode,
+			ResponseTimeMs:    p.ResponseTimeMs,
+			ContentLength:     p.ContentLength,
+			FirstDiscoveredAt: p.FirstDiscoveredAt,
+			LastVisitedAt:     p.LastVisitedAt,
+			LastUpdatedAt:     p.LastUpdatedAt,
+			VisitCount:        p.VisitCount,
+			Processable:       p.Processable,
+			Language:          p.Language,
+		}),
+		Quotes:         rawJSON(p.QuotesJson),
+		PipelineStatus: rawJSON(p.PipelineStatusJson),
+	}
+}
+
+// ListPages returns page metadata by id, filtered by target_id, processable and status
+func (h *V1Handler) ListPages(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	params := db.ListPageSummariesParams{AfterID: p.after, PageSize: int64(p.limit + 1)}
+	if params.TargetID, err = queryInt(r, "target_id"); err == nil {
+		if params.Processable, err = queryBool(r, "processable"); err == nil {
+			params.HttpStatusCode, err = queryInt(r, "status")
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	rows, err := h.queries.ListPageSummaries(r.Context(), params)
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to list pages")
+		return
+	}
+	pages := make([]v1Page, len(rows))
+	for i, row := range rows {
+		pages[i] = toV1PageSummary(row)
+	}
+	writeList(w, p, pages, func(p v1Page) int64 { return p.ID })
+}
+
+// GetPage returns a page's metadata with its quotes and pipeline status
+func (h *V1Handler) GetPage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	page, err := h.queries.GetPage(r.Context(), id)
+	if err != nil {
+		writeQueryError(w, r, err, "page", id)
+		return
+	}
+	writeData(w, http.StatusOK, toV1PageDetail(page))
+}
+
+// PageContent returns the stored HTML of a page as text/html
+func (h *V1Handler) PageContent(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	page, err := h.queries.GetPage(ctx, id)
+	if err != nil {
+		writeQueryError(w, r, err, "page", id)
+		return
+	}
+	body, err := content.PageBody(ctx, h.contents, page.HtmlContent, page.ContentKey)
+	if errors.Is(err, content.ErrNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, "page %d has no stored content", id)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to load content")
+		return
+	}
+	// Scraped pages must not run scripts on the admin origin when opened in a browser
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(body)); err != nil {
+		uiLog().WarnContext(r.Context(), "Failed to write page content", "page_id", id, logger.Err(err))
+	}
+}
+
+// PageClassifier returns the stored classifier result of a page
+func (h *V1Handler) PageClassifier(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	page, err := h.queries.GetPage(r.Context(), id)
+	if err != nil {
+		writeQueryError(w, r, err, "page", id)
+		return
+	}
+	result := rawJSON(page.QuoteClassifierJson)
+	if result == nil {
+		writeError(w, http.StatusNotFound, codeNotFound, "page %d has not been classified", id)
+		return
+	}
+	writeData(w, http.StatusOK, v1Classifier{
+		PageID:      page.ID,
+		Processable: nullBool(page.Processable),
+		Language:    nullString(page.Language),
+		Result:      result,
+	})
+}
+
+// logDetails passes JSON details through and quotes plain text ones
+func logDetails(v sql.NullString) json.RawMessage {
+	if raw := rawJSON(v); raw != nil || !v.Valid {
+		return raw
+	}
+	data, _ := json.Marshal(v.String)
+	return data
+}
+
+// ListLogs returns logs newest first, filtered by level, target_id, since and until
+func (h *V1Handler) ListLogs(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	// Logs page backwards, the cursor is the oldest id already returned
+	params := db.ListLogsParams{
+		BeforeID: p.after,
+		LogType:  optionalString(r.URL.Query().Get("level")),
+		PageSize: int64(p.limit + 1),
+	}
+	if params.BeforeID == 0 {
+		params.BeforeID = math.MaxInt64
+	}
+	if params.TargetID, err = queryInt(r, "target_id"); err == nil {
+		if params.Since, err = queryTime(r, "since"); err == nil {
+			params.Until, err = queryTime(r, "until")
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	rows, err := h.queries.ListLogs(r.Context(), params)
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to list logs")
+		return
+	}
+	logs := make([]v1Log, len(rows))
+	for i, l := range rows {
+		logs[i] = v1Log{
+			ID:        l.ID,
+			Level:     l.LogType,
+			TargetID:  nullInt(l.TargetID),
+			URL:       nullString(l.Url),
+			Message:   l.Message,
+			Details:   logDetails(l.Details),
+			CreatedAt: nullTime(l.CreatedAt),
+		}
+	}
+	writeList(w, p, logs, func(l v1Log) int64 { return l.ID })
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"app/internal/scraper/db"
+)
+
+// Queue item states
+var queueStatuses = []string{"pending", "processing", "completed", "skipped", "failed"}
+
+type v1QueueItem struct {
+	ID           int64      `json:"id"`
+	TargetID     int64      `json:"target_id"`
+	URL          string     `json:"url"`
+	Priority     int64      `json:"priority"`
+	Status       string     `json:"status"`
+	Attempts     int64      `json:"attempts"`
+	MaxAttempts  int64      `json:"max_attempts"`
+	ErrorMessage *string    `json:"error_message"`
+	CreatedAt    *time.Time `json:"created_at"`
+	ProcessedAt  *time.Time `json:"processed_at"`
+}
+
+type v1EnqueueInput struct {
+	TargetID int64  `json:"target_id"`
+	URL      string `json:"url"`
+	Priority int64  `json:"priority"`
+}
+
+func toV1QueueItem(q db.ScraperQueue) v1QueueItem {
+	return v1QueueItem{
+		ID:           q.ID,
+		TargetID:     q.TargetID,
+		URL:          q.Url,
+		Priority:     q.Priority.Int64,
+		Status:       q.Status.String,
+		Attempts:     q.Attempts.Int64,
+		MaxAttempts:  q.MaxAttempts.Int64,
+		ErrorMessage: nullString(q.ErrorMessage),
+		CreatedAt:    nullTime(q.CreatedAt),
+		ProcessedAt:  nullTime(q.ProcessedAt),
+	}
+}
+
+// ListQueue returns queue items by id, filtered by status and target_id
+func (h *V1Handler) ListQueue(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	targetID, err := queryInt(r, "target_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(queueStatuses, status) {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "invalid status %q: expected one of %v", status, queueStatuses)
+		return
+	}
+	rows, err := h.queries.ListQueueItems(r.Context(), db.ListQueueItemsParams{
+		AfterID:  p.after,
+		Status:   optionalString(status),
+		TargetID: targetID,
+		PageSize: int64(p.limit + 1),
+	})
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to list queue")
+		return
+	}
+	items := make([]v1QueueItem, len(rows))
+	for i, q := range rows {
+		items[i] = toV1QueueItem(q)
+	}
+	writeList(w, p, items, func(q v1QueueItem) int64 { return q.ID })
+}
+
+// GetQueueItem returns one queue item
+func (h *V1Handler) GetQueueItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	item, err := h.queries.GetQueueItem(r.Context(), id)
+	if err != nil {
+		writeQueryError(w, r, err, "queue item", id)
+		return
+	}
+	writeData(w, http.StatusOK, toV1QueueItem(item))
+}
+
+// Enqueue adds a URL of a target to the queue for the next crawl
+func (h *V1Handler) Enqueue(w http.ResponseWriter, r *http.Request) {
+	var in v1EnqueueInput
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	if err := validateHTTPURL("url", in.URL); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.queries.GetTarget(ctx, in.TargetID); err != nil {
+		writeQueryError(w, r, err, "target", in.TargetID)
+		return
+	}
+	item, err := h.queries.EnqueueURL(ctx, db.EnqueueURLParams{
+		TargetID: in.TargetID,
+		Url:      in.URL,
+		Priority: sql.NullInt64{Int64: in.Priority, Valid: true},
+	})
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to enqueue URL")
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/queue/%d", item.ID))
+	writeData(w, http.StatusCreated, toV1QueueItem(item))
+}
+
+// RetryQueueItem puts a failed item back to pending while it has attempts left
+func (h *V1Handler) RetryQueueItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	item, err := h.queries.GetQueueItem(ctx, id)
+	if err != nil {
+		writeQueryError(w, r, err, "queue item", id)
+		return
+	}
+	if item.Status.String != "failed" {
+		writeError(w, http.StatusConflict, codeConflict, "queue item %d is %s, only failed items are retried", id, item.Status.String)
+		return
+	}
+	if err := h.queries.RetryFailedItem(ctx, id); err != nil {
+		writeInternalError(w, r, err, "Failed to retry queue item")
+		return
+	}
+	if item, err = h.queries.GetQueueItem(ctx, id); err != nil {
+		writeQueryError(w, r, err, "queue item", id)
+		return
+	}
+	if item.Status.String != "pending" {
+		writeError(w, http.StatusConflict, codeConflict, "queue item %d used all %d attempts", id, item.MaxAttempts.Int64)
+		return
+	}
+	writeData(w, http.StatusOK, toV1QueueItem(item))
+}
+
+// DeleteQueueItem removes an item from the queue
+func (h *V1Handler) DeleteQueueItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	n, err := h.queries.DeleteQueueItem(r.Context(), id)
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to delete queue item")
+		return
+	}
+	if n == 0 {
+		writeError(w, http.StatusNotFound, codeNotFound, "queue item %d not found", id)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
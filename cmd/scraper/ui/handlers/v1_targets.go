@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+)
+
+type v1Target struct {
+	ID                    int64             `json:"id"`
+	WebsiteURL            string            `json:"website_url"`
+	SitemapURL            *string           `json:"sitemap_url"`
+	FollowSitemap         *bool             `json:"follow_sitemap"`
+	IsActive              bool              `json:"is_active"`
+	CrawlDelaySeconds     *int64            `json:"crawl_delay_seconds"`
+	MaxConcurrentRequests *int64            `json:"max_concurrent_requests"`
+	RequestsPerSecond     *float64          `json:"requests_per_second"`
+	UserAgent             *string           `json:"user_agent"`
+	CustomHeaders         map[string]string `json:"custom_headers"`
+	Notes                 *string           `json:"notes"`
+	SitemapPatterns       []string          `json:"sitemap_patterns"`
+	URLPatterns           []string          `json:"url_patterns"`
+	DomainName            *string           `json:"domain_name"`
+	LastVisitedAt         *time.Time        `json:"last_visited_at"`
+	CreatedAt             *time.Time        `json:"created_at"`
+	UpdatedAt             *time.Time        `json:"updated_at"`
+}
+
+// v1TargetInput is the body of create and update, fields left out keep their
+// value and an empty string clears an optional text field
+type v1TargetInput struct {
+	WebsiteURL            *string            `json:"website_url"`
+	SitemapURL            *string            `json:"sitemap_url"`
+	FollowSitemap         *bool              `json:"follow_sitemap"`
+	IsActive              *bool              `json:"is_active"`
+	CrawlDelaySeconds     *int64             `json:"crawl_delay_seconds"`
+	MaxConcurrentRequests *int64             `json:"max_concurrent_requests"`
+	RequestsPerSecond     *float64           `json:"requests_per_second"`
+	UserAgent             *string            `json:"user_agent"`
+	CustomHeaders         *map[string]string `json:"custom_headers"`
+	Notes                 *string            `json:"notes"`
+	SitemapPatterns       *[]string          `json:"sitemap_patterns"`
+	URLPatterns           *[]string          `json:"url_patterns"`
+	DomainName            *string            `json:"domain_name"`
+}
+
+func toV1Target(t db.ScraperTarget) v1Target {
+	out := v1Target{
+		ID:                    t.ID,
+		WebsiteURL:            t.WebsiteUrl,
+		SitemapURL:            nullString(t.SitemapUrl),
+		FollowSitemap:         nullBool(t.FollowSitemap),
+		IsActive:              t.IsActive.Valid && t.IsActive.Bool,
+		CrawlDelaySeconds:     nullInt(t.CrawlDelaySeconds),
+		MaxConcurrentRequests: nullInt(t.MaxConcurrentRequests),
+		RequestsPerSecond:     nullFloat(t.RequestsPerSecond),
+		UserAgent:             nullString(t.UserAgent),
+		Notes:                 nullString(t.Notes),
+		DomainName:            nullString(t.DomainName),
+		LastVisitedAt:         nullTime(t.LastVisitedAt),
+		CreatedAt:             nullTime(t.CreatedAt),
+		UpdatedAt:             nullTime(t.UpdatedAt),
+	}
+	// Columns written by other tools may hold invalid JSON, those read as empty
+	if t.CustomHeaders.Valid {
+		_ = json.Unmarshal([]byte(t.CustomHeaders.String), &out.CustomHeaders)
+	}
+	if t.SitemapPatterns.Valid {
+		_ = json.Unmarshal([]byte(t.SitemapPatterns.String), &out.SitemapPatterns)
+	}
+	if t.UrlPatterns.Valid {
+		_ = json.Unmarshal([]byte(t.UrlPatterns.String), &out.URLPatterns)
+	}
+	return out
+}
+
+// apply merges the input into the target's update parameters and validates the result
+func (in v1TargetInput) apply(t db.ScraperTarget) (db.UpdateTargetParams, error) {
+	p := db.UpdateTargetParams{
+		ID:                    t.ID,
+		WebsiteUrl:            t.WebsiteUrl,
+		SitemapUrl:            t.SitemapUrl,
+		FollowSitemap:         t.FollowSitemap,
+		CrawlDelaySeconds:     t.CrawlDelaySeconds,
+		MaxConcurrentRequests: t.MaxConcurrentRequests,
+		RequestsPerSecond:     t.RequestsPerSecond,
+		UserAgent:             t.UserAgent,
+		CustomHeaders:         t.CustomHeaders,
+		Notes:                 t.Notes,
+		SitemapPatterns:       t.SitemapPatterns,
+		UrlPatterns:           t.UrlPatterns,
+		DomainName:            t.DomainName,
+		IsActive:              t.IsActive,
+	}
+	if in.WebsiteURL != nil {
+		if err := validateHTTPURL("website_url", *in.WebsiteURL); err != nil {
+			return p, err
+		}
+		p.WebsiteUrl = *in.WebsiteURL
+	}
+	if in.SitemapURL != nil {
+		if *in.SitemapURL != "" {
+			if err := validateHTTPURL("sitemap_url", *in.SitemapURL); err != nil {
+				return p, err
+			}
+		}
+		p.SitemapUrl = optionalString(*in.SitemapURL)
+	}
+	if in.FollowSitemap != nil {
+		p.FollowSitemap = sql.NullBool{Bool: *in.FollowSitemap, Valid: true}
+	}
+	if in.IsActive != nil {
+		p.IsActive = sql.NullBool{Bool: *in.IsActive, Valid: true}
+	}
+	if in.CrawlDelaySeconds != nil {
+		if *in.CrawlDelaySeconds < 0 {
+			return p, errors.New("crawl_delay_seconds must not be negative")
+		}
+		p.CrawlDelaySeconds = sql.NullInt64{Int64: *in.CrawlDelaySeconds, Valid: true}
+	}
+	if in.MaxConcurrentRequests != nil {
+		if *in.MaxConcurrentRequests < 1 {
+			return p, errors.New("max_concurrent_requests must be at least 1")
+		}
+		p.MaxConcurrentRequests = sql.NullInt64{Int64: *in.MaxConcurrentRequests, Valid: true}
+	}
+	if in.RequestsPerSecond != nil {
+		if *in.RequestsPerSecond <= 0 {
+			return p, errors.New("requests_per_second must be positive")
+		}
+		p.RequestsPerSecond = sql.NullFloat64{Float64: *in.RequestsPerSecond, Valid: true}
+	}
+	if in.UserAgent != nil {
+		p.UserAgent = optionalString(*in.UserAgent)
+	}
+	if in.Notes != nil {
+		p.Notes = optionalString(*in.Notes)
+	}
+	if in.DomainName != nil {
+		p.DomainName = optionalString(*in.DomainName)
+	}
+	if in.CustomHeaders != nil {
+		p.CustomHeaders = optionalJSON(len(*in.CustomHeaders) > 0, *in.CustomHeaders)
+	}
+	for name, patterns := range map[string]*[]string{"sitemap_patterns": in.SitemapPatterns, "url_patterns": in.URLPatterns} {
+		if patterns == nil {
+			continue
+		}
+		if _, err := config.CompilePatterns(*patterns); err != nil {
+			return p, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if in.SitemapPatterns != nil {
+		p.SitemapPatterns = optionalJSON(len(*in.SitemapPatterns) > 0, *in.SitemapPatterns)
+	}
+	if in.URLPatterns != nil {
+		p.UrlPatterns = optionalJSON(len(*in.URLPatterns) > 0, *in.URLPatterns)
+	}
+	return p, nil
+}
+
+func validateHTTPURL(field, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s %q: expected an http or https URL", field, raw)
+	}
+	return nil
+}
+
+func optionalString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// optionalJSON stores v as JSON text, NULL when it is empty
+func optionalJSON(set bool, v any) sql.NullString {
+	if !set {
+		return sql.NullString{}
+	}
+	data, _ := json.Marshal(v)
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// ListTargets returns targets by id, active=true|false filters on is_active
+func (h *V1Handler) ListTargets(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	active, err := queryBool(r, "active")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	rows, err := h.queries.ListTargetsPage(r.Context(), db.ListTargetsPageParams{
+		AfterID:  p.after,
+		IsActive: active,
+		PageSize: int64(p.limit + 1),
+	})
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to list targets")
+		return
+	}
+	targets := make([]v1Target, len(rows))
+	for i, t := range rows {
+		targets[i] = toV1Target(t)
+	}
+	writeList(w, p, targets, func(t v1Target) int64 { return t.ID })
+}
+
+// GetTarget returns one target
+func (h *V1Handler) GetTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	target, err := h.queries.GetTarget(r.Context(), id)
+	if err != nil {
+		writeQueryError(w, r, err, "target", id)
+		return
+	}
+	writeData(w, http.StatusOK, toV1Target(target))
+}
+
+// CreateTarget adds a target, website_url is required and must be unique
+func (h *V1Handler) CreateTarget(w http.ResponseWriter, r *http.Request) {
+	var in v1TargetInput
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	if in.WebsiteURL == nil || *in.WebsiteURL == "" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "website_url is required")
+		return
+	}
+	// Validate everything before the insert so a bad field doesn't leave a half-made target
+	p, err := in.apply(db.ScraperTarget{IsActive: sql.NullBool{Bool: true, Valid: true}})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.queries.GetTargetByURL(ctx, p.WebsiteUrl); err == nil {
+		writeError(w, http.StatusConflict, codeConflict, "a target for %s already exists", p.WebsiteUrl)
+		return
+	}
+
+	created, err := h.queries.CreateTarget(ctx, db.CreateTargetParams{
+		WebsiteUrl:            p.WebsiteUrl,
+		SitemapUrl:            p.SitemapUrl,
+		FollowSitemap:         p.FollowSitemap,
+		CrawlDelaySeconds:     p.CrawlDelaySeconds,
+		MaxConcurrentRequests: p.MaxConcurrentRequests,
+		UserAgent:             p.UserAgent,
+		CustomHeaders:         p.CustomHeaders,
+		Notes:                 p.Notes,
+		SitemapPatterns:       p.SitemapPatterns,
+		UrlPatterns:           p.UrlPatterns,
+		DomainName:            p.DomainName,
+	})
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to create target")
+		return
+	}
+	// Columns CreateTarget leaves at their defaults
+	if in.RequestsPerSecond != nil || in.IsActive != nil {
+		p.ID = created.ID
+		if !p.RequestsPerSecond.Valid {
+			p.RequestsPerSecond = created.RequestsPerSecond
+		}
+		if created, err = h.queries.UpdateTarget(ctx, p); err != nil {
+			writeInternalError(w, r, err, "Failed to update target")
+			return
+		}
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/targets/%d", created.ID))
+	writeData(w, http.StatusCreated, toV1Target(created))
+}
+
+// UpdateTarget changes the fields present in the body
+func (h *V1Handler) UpdateTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	var in v1TargetInput
+	if err := decodeBody(w, r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	target, err := h.queries.GetTarget(ctx, id)
+	if err != nil {
+		writeQueryError(w, r, err, "target", id)
+		return
+	}
+	p, err := in.apply(target)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	if p.WebsiteUrl != target.WebsiteUrl {
+		if other, err := h.queries.GetTargetByURL(ctx, p.WebsiteUrl); err == nil && other.ID != id {
+			writeError(w, http.StatusConflict, codeConflict, "target %d already uses %s", other.ID, p.WebsiteUrl)
+			return
+		}
+	}
+	updated, err := h.queries.UpdateTarget(ctx, p)
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to update target")
+		return
+	}
+	writeData(w, http.StatusOK, toV1Target(updated))
+}
+
+// DeleteTarget deactivates a target like the admin UI, its pages and logs are kept
+func (h *V1Handler) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "%v", err)
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.queries.GetTarget(ctx, id); err != nil {
+		writeQueryError(w, r, err, "target", id)
+		return
+	}
+	if err := h.queries.DeactivateTarget(ctx, id); err != nil {
+		writeInternalError(w, r, err, "Failed to deactivate target")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/jobs"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// v1Env serves the API on a migrated in-memory database
+type v1Env struct {
+	t       *testing.T
+	queries *db.Queries
+	jobs    *jobs.Manager
+	mux     *http.ServeMux
+}
+
+// newTestQueries returns queries on a migrated in-memory database
+func newTestQueries(t *testing.T) *db.Queries {
+	t.Helper()
+	return db.New(newTestStore(t).DB())
+}
+
+// newTestStore returns a migrated in-memory SQLite store
+func newTestStore(t *testing.T) storage.Store {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	store := storage.NewSQLite(dbConn)
+	if err := migrate.EnsureSchema(context.Background(), store, true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+func newV1Env(t *testing.T, crawl CrawlFunc) *v1Env {
+	t.Helper()
+	queries := newTestQueries(t)
+	manager := jobs.NewManager()
+	h := NewV1Handler(queries, manager, crawl)
+
+	// The routes of server.setupRoutes
+	mux := http.NewServeMux()
+	for pattern, fn := range map[string]http.HandlerFunc{
+		"GET /api/v1/":                      h.NotFound,
+		"GET /api/v1/openapi.json":          h.OpenAPI,
+		"GET /api/v1/stats":                 h.Stats,
+		"GET /api/v1/targets":               h.ListTargets,
+		"POST /api/v1/targets":              h.CreateTarget,
+		"GET /api/v1/targets/{id}":          h.GetTarget,
+		"PATCH /api/v1/targets/{id}":        h.UpdateTarget,
+		"DELETE /api/v1/targets/{id}":       h.DeleteTarget,
+		"GET /api/v1/queue":                 h.ListQueue,
+		"POST /api/v1/queue":                h.Enqueue,
+		"GET /api/v1/queue/{id}":            h.GetQueueItem,
+		"DELETE /api/v1/queue/{id}":         h.DeleteQueueItem,
+		"POST /api/v1/queue/{id}/retry":     h.RetryQueueItem,
+		"GET /api/v1/pages":                 h.ListPages,
+		"GET /api/v1/pages/{id}":            h.GetPage,
+		"GET /api/v1/pages/{id}/content":    h.PageContent,
+		"GET /api/v1/pages/{id}/classifier": h.PageClassifier,
+		"GET /api/v1/logs":                  h.ListLogs,
+		"GET /api/v1/jobs":                  h.ListJobs,
+		"POST /api/v1/jobs":                 h.StartJob,
+		"GET /api/v1/jobs/{id}":             h.GetJob,
+		"POST /api/v1/jobs/{id}/cancel":     h.CancelJob,
+	} {
+		mux.HandleFunc(pattern, fn)
+	}
+	return &v1Env{t: t, queries: queries, jobs: manager, mux: mux}
+}
+
+// do sends a request and decodes the JSON response into out when given
+func (e *v1Env) do(method, path, body string, out any) *httptest.ResponseRecorder {
+	e.t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	e.mux.ServeHTTP(w, r)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			e.t.Fatalf("%s %s: invalid JSON %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w
+}
+
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+func (e *v1Env) expectError(method, path, body string, status int, code string) {
+	e.t.Helper()
+	var env errorEnvelope
+	w := e.do(method, path, body, &env)
+	if w.Code != status || env.Error.Code != code || env.Error.Message == "" {
+		e.t.Errorf("%s %s: expected %d %s, got %d %s", method, path, status, code, w.Code, w.Body.String())
+	}
+}
+
+func TestV1_Targets(t *testing.T) {
+	e := newV1Env(t, nil)
+
+	var created struct{ Data v1Target }
+	w := e.do("POST", "/api/v1/targets", `{"website_url":"https://quotes.example","sitemap_url":"https://quotes.example/sitemap.xml",
+		"url_patterns":["/quote/"],"custom_headers":{"X-Token":"a"},"requests_per_second":0.5}`, &created)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/api/v1/targets/1" {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	got := created.Data
+	if !got.IsActive || *got.SitemapURL != "https://quotes.example/sitemap.xml" || got.URLPatterns[0] != "/quote/" ||
+		got.CustomHeaders["X-Token"] != "a" || *got.RequestsPerSecond != 0.5 {
+		t.Errorf("unexpected target %+v", got)
+	}
+
+	e.expectError("POST", "/api/v1/targets", `{"website_url":"https://quotes.example"}`, http.StatusConflict, codeConflict)
+	e.expectError("POST", "/api/v1/targets", `{"sitemap_url":"https://x.example/s.xml"}`, http.StatusBadRequest, codeBadRequest)
+	e.expectError("POST", "/api/v1/targets", `{"website_url":"ftp://x.example"}`, http.StatusBadRequest, codeBadRequest)
+	e.expectError("POST", "/api/v1/targets", `{"website_url":"https://x.example","url_patterns":["("]}`, http.StatusBadRequest, codeBadRequest)
+	e.expectError("POST", "/api/v1/targets", `{"website":"https://x.example"}`, http.StatusBadRequest, codeBadRequest)
+
+	var updated struct{ Data v1Target }
+	w = e.do("PATCH", "/api/v1/targets/1", `{"sitemap_url":"","notes":"weekly","is_active":false}`, &updated)
+	if w.Code != http.StatusOK || updated.Data.SitemapURL != nil || *updated.Data.Notes != "weekly" || updated.Data.IsActive ||
+		updated.Data.URLPatterns[0] != "/quote/" {
+		t.Errorf("expected only the given fields changed, got %d %+v", w.Code, updated.Data)
+	}
+	e.expectError("PATCH", "/api/v1/targets/9", `{}`, http.StatusNotFound, codeNotFound)
+	e.expectError("GET", "/api/v1/targets/abc", "", http.StatusBadRequest, codeBadRequest)
+
+	if w := e.do("DELETE", "/api/v1/targets/1", "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d", w.Code)
+	}
+	e.expectError("DELETE", "/api/v1/targets/9", "", http.StatusNotFound, codeNotFound)
+	e.expectError("GET", "/api/v1/nothing", "", http.StatusNotFound, codeNotFound)
+}
+
+func TestV1_CursorPagination(t *testing.T) {
+	e := newV1Env(t, nil)
+	for _, site := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := e.queries.CreateTarget(context.Background(), db.CreateTargetParams{WebsiteUrl: "https://" + site + ".example"}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	var seen []string
+	path := "/api/v1/targets?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("expected the last page to end the cursor chain")
+		}
+		var resp struct {
+			Data       []v1Target
+			NextCursor string `json:"next_cursor"`
+		}
+		if w := e.do("GET", path, "", &resp); w.Code != http.StatusOK {
+			t.Fatalf("list: %d %s", w.Code, w.Body.String())
+		}
+		for _, target := range resp.Data {
+			seen = append(seen, target.WebsiteURL)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		path = "/api/v1/targets?limit=2&cursor=" + resp.NextCursor
+	}
+	if strings.Join(seen, ",") != "https://a.example,https://b.example,https://c.example,https://d.example,https://e.example" {
+		t.Errorf("expected every target once in order, got %v", seen)
+	}
+
+	e.expectError("GET", "/api/v1/targets?cursor=bogus", "", http.StatusBadRequest, codeBadRequest)
+	e.expectError("GET", "/api/v1/targets?limit=501", "", http.StatusBadRequest, codeBadRequest)
+}
+
+func TestV1_QueuePagesAndLogs(t *testing.T) {
+	e := newV1Env(t, nil)
+	ctx := context.Background()
+	target, err := e.queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var queued struct{ Data v1QueueItem }
+	if w := e.do("POST", "/api/v1/queue", `{"target_id":1,"url":"https://quotes.example/q/1","priority":5}`, &queued); w.Code != http.StatusCreated {
+		t.Fatalf("enqueue: %d %s", w.Code, w.Body.String())
+	}
+	if queued.Data.Status != "pending" || queued.Data.Priority != 5 {
+		t.Errorf("unexpected item %+v", queued.Data)
+	}
+	e.expectError("POST", "/api/v1/queue", `{"target_id":9,"url":"https://quotes.example/q/2"}`, http.StatusNotFound, codeNotFound)
+	e.expectError("POST", "/api/v1/queue/1/retry", "", http.StatusConflict, codeConflict)
+
+	if err := e.queries.FailQueueItem(ctx, db.FailQueueItemParams{ID: 1, ErrorMessage: sql.NullString{String: "timeout", Valid: true}}); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	var failed struct{ Data []v1QueueItem }
+	e.do("GET", "/api/v1/queue?status=failed&target_id=1", "", &failed)
+	if len(failed.Data) != 1 || *failed.Data[0].ErrorMessage != "timeout" {
+		t.Errorf("expected the failed item, got %+v", failed.Data)
+	}
+	var retried struct{ Data v1QueueItem }
+	if w := e.do("POST", "/api/v1/queue/1/retry", "", &retried); w.Code != http.StatusOK || retried.Data.Status != "pending" {
+		t.Errorf("retry: %d %+v", w.Code, retried.Data)
+	}
+	e.expectError("GET", "/api/v1/queue?status=lost", "", http.StatusBadRequest, codeBadRequest)
+	if w := e.do("DELETE", "/api/v1/queue/1", "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d", w.Code)
+	}
+	e.expectError("GET", "/api/v1/queue/1", "", http.StatusNotFound, codeNotFound)
+
+	page, err := e.queries.SavePage(ctx, db.SavePageParams{
+		TargetID:       target.ID,
+		UrlPath:        "https://quotes.example/q/1",
+		FullUrl:        "https://quotes.example/q/1",
+		HtmlContent:    sql.NullString{String: "<html>Be yourself</html>", Valid: true},
+		HttpStatusCode: sql.NullInt64{Int64: 200, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("save page: %v", err)
+	}
+	var pages struct{ Data []v1Page }
+	e.do("GET", "/api/v1/pages?target_id=1&status=200", "", &pages)
+	if len(pages.Data) != 1 || *pages.Data[0].HTTPStatusCode != 200 {
+		t.Errorf("expected the page listed, got %+v", pages.Data)
+	}
+	if w := e.do("GET", "/api/v1/pages/1/content", "", nil); w.Body.String() != "<html>Be yourself</html>" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || w.Header().Get("Content-Security-Policy") != "sandbox" {
+		t.Errorf("unexpected content %q", w.Body.String())
+	}
+	e.expectError("GET", "/api/v1/pages/1/classifier", "", http.StatusNotFound, codeNotFound)
+	if err := e.queries.SavePageClassifier(ctx, db.SavePageClassifierParams{
+		QuoteClassifierJson: sql.NullString{String: `{"decision":{"processable":true}}`, Valid: true},
+		Processable:         sql.NullBool{Bool: true, Valid: true},
+		Language:            sql.NullString{String: "en", Valid: true},
+		TargetID:            target.ID,
+		UrlPath:             page.UrlPath,
+	}); err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	var classifier struct{ Data v1Classifier }
+	e.do("GET", "/api/v1/pages/1/classifier", "", &classifier)
+	if !*classifier.Data.Processable || string(classifier.Data.Result) != `{"decision":{"processable":true}}` {
+		t.Errorf("unexpected classifier %+v", classifier.Data)
+	}
+	e.expectError("GET", "/api/v1/pages/2", "", http.StatusNotFound, codeNotFound)
+
+	for _, l := range []db.LogMessageParams{
+		{LogType: "info", Message: "started", Details: sql.NullString{String: "by user", Valid: true}},
+		{LogType: "error", TargetID: sql.NullInt64{Int64: 1, Valid: true}, Message: "timeout", Details: sql.NullString{String: `{"status":504}`, Valid: true}},
+		{LogType: "error", Message: "dns"},
+	} {
+		if err := e.queries.LogMessage(ctx, l); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+	var logs struct {
+		Data       []v1Log
+		NextCursor string `json:"next_cursor"`
+	}
+	e.do("GET", "/api/v1/logs?level=error&limit=1", "", &logs)
+	if len(logs.Data) != 1 || logs.Data[0].Message != "dns" || logs.NextCursor == "" {
+		t.Fatalf("expected the newest error first, got %+v", logs)
+	}
+	cursor := logs.NextCursor
+	logs.NextCursor = ""
+	e.do("GET", "/api/v1/logs?level=error&limit=1&cursor="+cursor, "", &logs)
+	if len(logs.Data) != 1 || string(logs.Data[0].Details) != `{"status":504}` || logs.NextCursor != "" {
+		t.Errorf("expected the older error on the last page, got %+v", logs)
+	}
+	e.do("GET", "/api/v1/logs?level=info&since="+time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), "", &logs)
+	if len(logs.Data) != 1 || string(logs.Data[0].Details) != `"by user"` {
+		t.Errorf("expected plain text details quoted, got %+v", logs.Data)
+	}
+	e.expectError("GET", "/api/v1/logs?since=yesterday", "", http.StatusBadRequest, codeBadRequest)
+
+	var stats struct{ Data v1Stats }
+	e.do("GET", "/api/v1/stats", "", &stats)
+	if stats.Data.ActiveTargets != 1 || stats.Data.Pages != 1 {
+		t.Errorf("unexpected stats %+v", stats.Data)
+	}
+}
+
+func TestV1_Jobs(t *testing.T) {
+	started := make(chan int64, 1)
+	e := newV1Env(t, func(ctx context.Context, targetID int64, dryRun bool) error {
+		started <- targetID
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if _, err := e.queries.CreateTarget(context.Background(), db.CreateTargetParams{WebsiteUrl: "https://quotes.example"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var job struct{ Data jobs.Job }
+	if w := e.do("POST", "/api/v1/jobs", `{"target_id":1}`, &job); w.Code != http.StatusAccepted || job.Data.Status != jobs.StatusRunning {
+		t.Fatalf("start: %d %s", w.Code, w.Body.String())
+	}
+	if id := <-started; id != 1 {
+		t.Errorf("expected a crawl of target 1, got %d", id)
+	}
+	e.expectError("POST", "/api/v1/jobs", `{}`, http.StatusConflict, codeConflict)
+	e.expectError("POST", "/api/v1/jobs", `{"kind":"reindex"}`, http.StatusBadRequest, codeBadRequest)
+	e.expectError("POST", "/api/v1/jobs", `{"target_id":9}`, http.StatusNotFound, codeNotFound)
+
+	if w := e.do("POST", "/api/v1/jobs/1/cancel", "", nil); w.Code != http.StatusAccepted {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body.String())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if finished, err := e.jobs.Wait(ctx, 1); err != nil || finished.Status != jobs.StatusCancelled {
+		t.Fatalf("expected the job cancelled, got %+v (%v)", finished, err)
+	}
+	e.do("GET", "/api/v1/jobs/1", "", &job)
+	if job.Data.Status != jobs.StatusCancelled || job.Data.FinishedAt == nil {
+		t.Errorf("unexpected job %+v", job.Data)
+	}
+	e.expectError("POST", "/api/v1/jobs/1/cancel", "", http.StatusConflict, codeConflict)
+	e.expectError("GET", "/api/v1/jobs/2", "", http.StatusNotFound, codeNotFound)
+}
+
+func TestV1_OpenAPI(t *testing.T) {
+	e := newV1Env(t, nil)
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if w := e.do("GET", "/api/v1/openapi.json", "", &doc); w.Code != http.StatusOK || doc.OpenAPI == "" {
+		t.Fatalf("openapi: %d", w.Code)
+	}
+	// Every documented operation is routed
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			concrete := "/api/v1" + strings.ReplaceAll(path, "{id}", "1")
+			r := httptest.NewRequest(strings.ToUpper(method), concrete, nil)
+			if _, pattern := e.mux.Handler(r); pattern == "GET /api/v1/" || pattern == "" {
+				t.Errorf("%s %s is documented but not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestV1_InternalErrorsHideDetails(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_ = dbConn.Close()
+	h := NewV1Handler(db.New(dbConn), jobs.NewManager(), nil)
+
+	for path, fn := range map[string]http.HandlerFunc{
+		"/api/v1/targets/1": h.GetTarget,
+		"/api/v1/targets":   h.ListTargets,
+	} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		fn(w, r)
+		var env errorEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", path, w.Body.String(), err)
+		}
+		if w.Code != http.StatusInternalServerError || env.Error.Code != codeInternal || env.Error.Message != "internal error" {
+			t.Errorf("GET %s: expected a generic internal error, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,50 +1,77 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
 
 	"app/cmd/scraper/ui/server"
+	"app/internal/scraper/config"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/storage"
 
 	"github.com/joho/godotenv"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Failed to load .env file: %v", err)
+		fatal("Failed to load .env file", err)
 	}
 
+	// LOG_LEVEL and LOG_FORMAT, e.g. LOG_LEVEL=info,ui=warn
+	if err := logger.Setup(logger.OptionsFromEnv()); err != nil {
+		fatal("Failed to set up logging", err)
+	}
+	log := logger.New(logger.ComponentUI, nil)
+
 	// Get SCRAPER_PORT - no fallback, must be set
 	port := os.Getenv("SCRAPER_PORT")
 	if port == "" {
-		log.Fatalf("SCRAPER_PORT environment variable is required")
+		fatal("SCRAPER_PORT environment variable is required", nil)
 	}
 
-	// Get database path - no fallback, must be set
-	dbPath := os.Getenv("SCRAPER_DB_PATH")
-	if dbPath == "" {
-		log.Fatalf("SCRAPER_DB_PATH environment variable is required")
+	// Resolve settings like the CLI: environment, config file, then scraper_config
+	cfg, err := config.Load(config.Options{})
+	if err != nil {
+		fatal("Failed to load configuration", err)
 	}
 
 	// Initialize database
-	database, err := sql.Open("sqlite3", dbPath)
+	store, err := storage.Open(cfg.DatabaseURL())
 	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
+		fatal("Failed to open database", err)
 	}
 	defer func() {
-		err := database.Close()
+		err := store.Close()
 		if err != nil {
-			log.Printf("failed to close database: %v", err)
+			log.Warn("Failed to close database", logger.Err(err))
 		}
 	}()
 
+	// Apply pending migrations, or refuse to start with SCRAPER_AUTO_MIGRATE=false
+	if err := migrate.EnsureSchema(context.Background(), store, migrate.AutoMigrateFromEnv()); err != nil {
+		fatal("Failed to migrate database", err)
+	}
+	if err := cfg.LoadDatabase(context.Background(), store.Queries()); err != nil {
+		fatal("Failed to load configuration", err)
+	}
+
 	// Create server with all routes and handlers
-	srv := server.New(database)
+	srv := server.New(store, cfg)
+
+	log.Info("Starting scraper server with admin UI", "port", port)
+	fatal("Server stopped", http.ListenAndServe(":"+port, srv.Handler()))
+}
 
-	log.Printf("🕷️  Starting scraper server with admin UI on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, srv.Handler()))
+// fatal logs why the server can't run and exits
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, logger.Err(err))
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
 }
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
 
 type StatsData struct {
 	Targets      int
@@ -18,6 +22,8 @@ type TargetData struct {
 }
 
 type LogEntry struct {
+	ID        int64
+	TargetID  int64
 	Timestamp time.Time
 	Level     string
 	Message   string
@@ -61,3 +67,238 @@ type HealthData struct {
 	Timestamp time.Time
 	Uptime    string
 }
+
+// SettingData is a scraper_config setting on the settings page
+type SettingData struct {
+	Key         string
+	Value       string
+	Default     string
+	Description string
+	// Numeric settings get a number input bounded by Min and Max
+	Numeric  bool
+	Min, Max int
+	// Override describes the env var or config file overriding the stored
+	// value for this host, empty when the stored value is in effect
+	Override string
+}
+
+// Field is a labelled value on a detail page, Value is empty when unset
+type Field struct {
+	Label string
+	Value string
+}
+
+// QueueCounts breaks a target's queue down by status
+type QueueCounts struct {
+	Pending    int64
+	Processing int64
+	Completed  int64
+	Skipped    int64
+	Failed     int64
+}
+
+func (q QueueCounts) Total() int64 {
+	return q.Pending + q.Processing + q.Completed + q.Skipped + q.Failed
+}
+
+// QueueFailure is a queue item that ran out of attempts or failed last time
+type QueueFailure struct {
+	ID       int64
+	URL      string
+	Attempts int64
+	Error    string
+	FailedAt time.Time
+}
+
+// DecisionCount is the number of a target's pages with one classifier outcome
+type DecisionCount struct {
+	Reason      string
+	Classified  bool
+	Processable bool
+	Pages       int64
+}
+
+// PathNode is a section of a target's site, Depth 0 being the site itself
+type PathNode struct {
+	Name  string
+	Depth int
+	Pages int
+}
+
+// PageRow is a crawled page in a target's page list
+type PageRow struct {
+	ID             int64
+	TargetID       int64
+	URL            string
+	StatusCode     int64
+	ContentLength  int64
+	ResponseTimeMs int64
+	LastVisitedAt  time.Time
+	Processable    bool
+}
+
+// TargetDetail is everything the target page shows about one target
+type TargetDetail struct {
+	ID         int64
+	WebsiteURL string
+	Active     bool
+	Config     []Field
+	Queue      QueueCounts
+	Failures   []QueueFailure
+	Decisions  []DecisionCount
+	Tree       []PathNode
+	// TreePages is the number of pages the tree was built from, fewer than
+	// the target has when TreeTruncated
+	TreePages     int
+	TreeTruncated bool
+	Pages         []PageRow
+	// PagesAfter is the cursor of the next page of Pages, 0 on the last one
+	PagesAfter int64
+	Logs       []LogEntry
+}
+
+// PageDetail is one crawled page with its stored HTML and classifier result
+type PageDetail struct {
+	ID         int64
+	TargetID   int64
+	TargetURL  string
+	URL        string
+	Meta       []Field
+	Classified bool
+	Decision   []Field
+	Features   []Field
+	HTML       string
+	// HTMLTruncated is set when HTML holds only the start of the page
+	HTMLTruncated bool
+	HTMLError     string
+}
+
+// LogFilter holds the log browser filters as entered in its form
+type LogFilter struct {
+	Level    string
+	TargetID int64
+	RunID    int64
+	URL      string
+	Query    string
+	// Since and Until are datetime-local values in UTC
+	Since string
+	Until string
+}
+
+// Values encodes the set filters as a query string for follow-up requests
+func (f LogFilter) Values() string {
+	v := url.Values{}
+	for key, value := range map[string]string{"level": f.Level, "url": f.URL, "q": f.Query, "since": f.Since, "until": f.Until} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.TargetID != 0 {
+		v.Set("target_id", strconv.FormatInt(f.TargetID, 10))
+	}
+	if f.RunID != 0 {
+		v.Set("run_id", strconv.FormatInt(f.RunID, 10))
+	}
+	return v.Encode()
+}
+
+// LogResults is a page of the log browser: Next pages back to older logs,
+// 0 on the last page, and Newest is where live tailing continues from
+type LogResults struct {
+	Filter   LogFilter
+	Logs     []LogEntry
+	Next     int64
+	Newest   int64
+	FullText bool
+}
+
+// QueueFilter holds the queue page filters as entered in its form
+type QueueFilter struct {
+	TargetID int64
+	Status   string
+	Error    string
+	URL      string
+	// OlderThan is an age like 24h or 7d
+	OlderThan string
+}
+
+// Values encodes the set filters as a query string for follow-up requests
+func (f QueueFilter) Values() string {
+	v := url.Values{}
+	for key, value := range map[string]string{"status": f.Status, "error": f.Error, "url": f.URL, "older_than": f.OlderThan} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.TargetID != 0 {
+		v.Set("target_id", strconv.FormatInt(f.TargetID, 10))
+	}
+	return v.Encode()
+}
+
+// QueueItem is a row of the queue page
+type QueueItem struct {
+	ID          int64
+	TargetID    int64
+	URL         string
+	Status      string
+	Priority    int64
+	Attempts    int64
+	MaxAttempts int64
+	QueuedAt    time.Time
+	Error       string
+}
+
+// QueueResults is a page of queue items matching the filters. Failed, Pending
+// and Purgeable count the items the bulk retry, prioritize and purge would
+// change, Message reports the outcome of the last action.
+type QueueResults struct {
+	Filter       QueueFilter
+	Items        []QueueItem
+	Next         int64
+	Total        int64
+	Failed       int64
+	Pending      int64
+	Purgeable    int64
+	Message      string
+	MessageLevel string
+}
+
+// RunSummary is a row of the runs page
+type RunSummary struct {
+	ID        int64
+	Trigger   string
+	Status    string
+	DryRun    bool
+	TargetIDs []int64
+	TotalURLs int64
+	Processed int64
+	Errors    int64
+	Skipped   int64
+	StartedAt time.Time
+	// Duration is empty while the run is going
+	Duration string
+}
+
+// ErrorRate is the percentage of the run's URLs that failed
+func (r RunSummary) ErrorRate() float64 {
+	if r.TotalURLs == 0 {
+		return 0
+	}
+	return float64(r.Errors) * 100 / float64(r.TotalURLs)
+}
+
+// Count is a labelled number, a row of a breakdown
+type Count struct {
+	Label string
+	Count int64
+}
+
+// RunDetail is a run with the breakdowns of its errors, queue items and logs
+type RunDetail struct {
+	RunSummary
+	Error      string
+	ErrorTypes []Count
+	QueueItems []Count
+	Logs       []Count
+}
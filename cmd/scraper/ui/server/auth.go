@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"app/cmd/scraper/ui/handlers"
+	"app/internal/scraper/service/auth"
+	"app/internal/scraper/service/logger"
+)
+
+// Authenticator resolves session cookies and API tokens to users
+type Authenticator interface {
+	SessionUser(ctx context.Context, secret string) (auth.User, error)
+	TokenUser(ctx context.Context, secret string) (auth.User, error)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", true
+	}
+	return strings.TrimSpace(token), true
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func isAPIv1(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/v1/")
+}
+
+// withCSRF protects cookie authenticated requests with a double submit token:
+// the token is kept in a cookie and unsafe requests must repeat it in the
+// X-CSRF-Token header, which HTMX sends from the page, or the csrf_token form
+// field. Requests with an Authorization header carry no cookies to abuse and
+// are exempt.
+func withCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(auth.CSRFCookie); err == nil && cookie.Value != "" {
+			token = cookie.Value
+		} else {
+			secret, err := auth.NewSecret()
+			if err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			token = secret
+			http.SetCookie(w, &http.Cookie{
+				Name:     auth.CSRFCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   handlers.SecureRequest(r),
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		if _, hasAuthorization := bearerToken(r); !safeMethod(r.Method) && !hasAuthorization {
+			sent := r.Header.Get(auth.CSRFHeader)
+			if sent == "" {
+				sent = r.PostFormValue(auth.CSRFField)
+			}
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				uiLog().WarnContext(r.Context(), "CSRF check failed", "method", r.Method, "path", r.URL.Path)
+				deny(w, r, http.StatusForbidden, "forbidden", "missing or invalid CSRF token, reload the page")
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithCSRFToken(r.Context(), token)))
+	}
+}
+
+// requireRole lets requests through whose user has at least the role. The
+// user comes from an API token when an Authorization header is sent, the
+// session cookie otherwise.
+func requireRole(authenticator Authenticator, role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var user auth.User
+		err := auth.ErrUnauthenticated
+		if token, ok := bearerToken(r); ok {
+			if token != "" {
+				user, err = authenticator.TokenUser(r.Context(), token)
+			}
+		} else if cookie, cookieErr := r.Cookie(auth.SessionCookie); cookieErr == nil {
+			user, err = authenticator.SessionUser(r.Context(), cookie.Value)
+		}
+
+		if err != nil {
+			if !errors.Is(err, auth.ErrUnauthenticated) {
+				uiLog().ErrorContext(r.Context(), "Authentication failed", logger.Err(err))
+				deny(w, r, http.StatusInternalServerError, "internal", "authentication failed")
+				return
+			}
+			unauthenticated(w, r)
+			return
+		}
+		if !user.Role.Allows(role) {
+			deny(w, r, http.StatusForbidden, "forbidden", "requires the "+string(role)+" role")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	}
+}
+
+// unauthenticated sends browsers to the login page and API clients a 401
+func unauthenticated(w http.ResponseWriter, r *http.Request) {
+	login := "/login?next=" + url.QueryEscape(r.URL.RequestURI())
+	switch {
+	case isAPIv1(r):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scraper"`)
+		handlers.WriteAPIError(w, http.StatusUnauthorized, "unauthorized", "sign in or send an API token")
+	case r.Header.Get("HX-Request") == "true":
+		// HTMX follows this header with a full page load
+		w.Header().Set("HX-Redirect", login)
+		w.WriteHeader(http.StatusUnauthorized)
+	case r.Method == http.MethodGet:
+		http.Redirect(w, r, login, http.StatusSeeOther)
+	default:
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+}
+
+func deny(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if isAPIv1(r) {
+		handlers.WriteAPIError(w, status, code, message)
+		return
+	}
+	http.Error(w, http.StatusText(status)+": "+message, status)
+}
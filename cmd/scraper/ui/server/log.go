@@ -0,0 +1,6 @@
+package server
+
+import "app/internal/scraper/service/logger"
+
+// uiLog logs the server's diagnostics to stderr
+var uiLog = logger.Lazy(logger.ComponentUI)
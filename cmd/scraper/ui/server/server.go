@@ -1,13 +1,21 @@
 package server
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"net/http"
 	"time"
 
 	"app/cmd/scraper/ui/handlers"
+	"app/internal/scraper/cli"
+	"app/internal/scraper/config"
 	"app/internal/scraper/db"
+	"app/internal/scraper/jobs"
+	"app/internal/scraper/metrics"
+	"app/internal/scraper/service/auth"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/logsearch"
+	"app/internal/scraper/storage"
 )
 
 // Server holds the application dependencies and routes
@@ -18,27 +26,48 @@ type Server struct {
 	queries db.Querier
 	db      *sql.DB
 	mux     *http.ServeMux
+	auth    Authenticator
 
 	// Handler instances
 	dashboardHandler DashboardHandlerIface
 	apiHandler       APIHandlerIface
 	targetsHandler   TargetsHandlerIface
+	logsHandler      LogsHandlerIface
+	runsHandler      RunsHandlerIface
+	queueHandler     QueueHandlerIface
+	settingsHandler  SettingsHandlerIface
+	exportHandler    ExportHandlerIface
+	v1Handler        V1HandlerIface
+	authHandler      AuthHandlerIface
 }
 
-// New creates a new server instance
-func New(database *sql.DB) *Server {
-	queries := db.New(database)
+// New creates a new server instance, cfg tells the settings page which
+// stored settings are overridden on this host
+func New(store storage.Store, cfg *config.Config) *Server {
+	queries := store.Queries()
 
+	authService := auth.NewService(queries)
 	s := &Server{
 		queries: queries,
-		db:      database,
+		db:      store.DB(),
 		mux:     http.NewServeMux(),
+		auth:    authService,
 	}
 
 	// Initialize handlers
 	s.dashboardHandler = handlers.NewDashboardHandler(queries)
 	s.apiHandler = handlers.NewAPIHandler(queries)
 	s.targetsHandler = handlers.NewTargetsHandler(queries)
+	s.logsHandler = handlers.NewLogsHandler(queries, logsearch.New(store))
+	s.runsHandler = handlers.NewRunsHandler(queries)
+	s.queueHandler = handlers.NewQueueHandler(store)
+	s.settingsHandler = handlers.NewSettingsHandler(queries, cfg)
+	s.exportHandler = handlers.NewExportHandler(queries)
+	s.v1Handler = handlers.NewV1Handler(queries, jobs.NewManager(), crawler(cfg))
+	s.authHandler = handlers.NewAuthHandler(queries, authService)
+
+	// The queue gauges of /metrics are read from the database on each scrape
+	metrics.CollectQueue(store.DB())
 
 	// Setup routes
 	s.setupRoutes()
@@ -46,6 +75,24 @@ func New(database *sql.DB) *Server {
 	return s
 }
 
+// crawler runs crawl jobs like `scraper-cli run`, each with its own runner
+// and connection, recorded as runs triggered from the UI
+func crawler(cfg *config.Config) handlers.CrawlFunc {
+	return func(ctx context.Context, targetID int64, dryRun bool) error {
+		runner, err := cli.NewScraperRunner(cfg)
+		if err != nil {
+			return err
+		}
+		runner.SetTrigger(cli.TriggerUI)
+		defer func() {
+			if err := runner.Close(); err != nil {
+				uiLog().Warn("Failed to close runner", logger.Err(err))
+			}
+		}()
+		return runner.RunContext(ctx, targetID, false, false, dryRun)
+	}
+}
+
 // Handler interfaces for test injection
 
 type DashboardHandlerIface interface {
@@ -63,56 +110,194 @@ type TargetsHandlerIface interface {
 	NewForm(http.ResponseWriter, *http.Request)
 	Create(http.ResponseWriter, *http.Request)
 	Delete(http.ResponseWriter, *http.Request)
+	Detail(http.ResponseWriter, *http.Request)
+	Pages(http.ResponseWriter, *http.Request)
+	PageDetail(http.ResponseWriter, *http.Request)
+}
+type LogsHandlerIface interface {
+	Page(http.ResponseWriter, *http.Request)
+	Results(http.ResponseWriter, *http.Request)
+	Tail(http.ResponseWriter, *http.Request)
+}
+type RunsHandlerIface interface {
+	Page(http.ResponseWriter, *http.Request)
+	Detail(http.ResponseWriter, *http.Request)
+}
+type QueueHandlerIface interface {
+	Page(http.ResponseWriter, *http.Request)
+	Items(http.ResponseWriter, *http.Request)
+	Retry(http.ResponseWriter, *http.Request)
+	Prioritize(http.ResponseWriter, *http.Request)
+	Purge(http.ResponseWriter, *http.Request)
+	Requeue(http.ResponseWriter, *http.Request)
+	Delete(http.ResponseWriter, *http.Request)
+}
+type SettingsHandlerIface interface {
+	Page(http.ResponseWriter, *http.Request)
+	Update(http.ResponseWriter, *http.Request)
+}
+type ExportHandlerIface interface {
+	Page(http.ResponseWriter, *http.Request)
+	Download(http.ResponseWriter, *http.Request)
+}
+type V1HandlerIface interface {
+	NotFound(http.ResponseWriter, *http.Request)
+	OpenAPI(http.ResponseWriter, *http.Request)
+	Stats(http.ResponseWriter, *http.Request)
+	ListTargets(http.ResponseWriter, *http.Request)
+	GetTarget(http.ResponseWriter, *http.Request)
+	CreateTarget(http.ResponseWriter, *http.Request)
+	UpdateTarget(http.ResponseWriter, *http.Request)
+	DeleteTarget(http.ResponseWriter, *http.Request)
+	ListQueue(http.ResponseWriter, *http.Request)
+	GetQueueItem(http.ResponseWriter, *http.Request)
+	Enqueue(http.ResponseWriter, *http.Request)
+	RetryQueueItem(http.ResponseWriter, *http.Request)
+	DeleteQueueItem(http.ResponseWriter, *http.Request)
+	ListPages(http.ResponseWriter, *http.Request)
+	GetPage(http.ResponseWriter, *http.Request)
+	PageContent(http.ResponseWriter, *http.Request)
+	PageClassifier(http.ResponseWriter, *http.Request)
+	ListLogs(http.ResponseWriter, *http.Request)
+	ListJobs(http.ResponseWriter, *http.Request)
+	GetJob(http.ResponseWriter, *http.Request)
+	StartJob(http.ResponseWriter, *http.Request)
+	CancelJob(http.ResponseWriter, *http.Request)
+}
+type AuthHandlerIface interface {
+	LoginPage(http.ResponseWriter, *http.Request)
+	Login(http.ResponseWriter, *http.Request)
+	Logout(http.ResponseWriter, *http.Request)
 }
 
 // NewWithHandlers for testing
-func NewWithHandlers(queries db.Querier, dashboardHandler DashboardHandlerIface, apiHandler APIHandlerIface, targetsHandler TargetsHandlerIface) *Server {
+func NewWithHandlers(queries db.Querier, dashboardHandler DashboardHandlerIface, apiHandler APIHandlerIface, targetsHandler TargetsHandlerIface, logsHandler LogsHandlerIface, runsHandler RunsHandlerIface, queueHandler QueueHandlerIface, settingsHandler SettingsHandlerIface, exportHandler ExportHandlerIface, v1Handler V1HandlerIface, authHandler AuthHandlerIface, authenticator Authenticator) *Server {
 	s := &Server{
 		queries:          queries,
 		mux:              http.NewServeMux(),
+		auth:             authenticator,
 		dashboardHandler: dashboardHandler,
 		apiHandler:       apiHandler,
 		targetsHandler:   targetsHandler,
+		logsHandler:      logsHandler,
+		runsHandler:      runsHandler,
+		queueHandler:     queueHandler,
+		settingsHandler:  settingsHandler,
+		exportHandler:    exportHandler,
+		v1Handler:        v1Handler,
+		authHandler:      authHandler,
 	}
 	s.setupRoutes()
 	return s
 }
 
-// setupRoutes configures all application routes
+// setupRoutes configures all application routes. Each route names the least
+// role that may use it: viewers read, operators also manage targets, the queue
+// and crawls, admins also change settings.
 func (s *Server) setupRoutes() {
 	// Static files for admin UI
 	s.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static/"))))
 
-	// Main admin routes
-	s.mux.Handle("GET /", withMiddleware(s.dashboardHandler.Dashboard))
+	// Public routes: liveness probe, sign in and the API description
 	s.mux.Handle("GET /health", withMiddleware(s.dashboardHandler.HealthAPI))
+	s.mux.Handle("GET /login", withMiddleware(withCSRF(s.authHandler.LoginPage)))
+	s.mux.Handle("POST /login", withMiddleware(withCSRF(s.authHandler.Login)))
+	s.mux.Handle("POST /logout", withMiddleware(withCSRF(s.authHandler.Logout)))
+	s.mux.Handle("GET /api/v1/openapi.json", withMiddleware(s.v1Handler.OpenAPI))
+
+	// Main admin routes
+	s.handle("GET /", auth.RoleViewer, s.dashboardHandler.Dashboard)
 
 	// HTMX API routes for admin functionality
-	s.mux.Handle("GET /api/stats", withMiddleware(s.apiHandler.Stats))
-	s.mux.Handle("GET /api/targets", withMiddleware(s.apiHandler.TargetsList))
-	s.mux.Handle("GET /api/logs", withMiddleware(s.apiHandler.Logs))
+	s.handle("GET /api/stats", auth.RoleViewer, s.apiHandler.Stats)
+	s.handle("GET /api/targets", auth.RoleViewer, s.apiHandler.TargetsList)
+	s.handle("GET /api/logs", auth.RoleViewer, s.apiHandler.Logs)
 
 	// Target management routes
-	s.mux.Handle("GET /targets/new", withMiddleware(s.targetsHandler.NewForm))
-	s.mux.Handle("POST /api/targets", withMiddleware(s.targetsHandler.Create))
-	s.mux.Handle("DELETE /api/targets/{id}", withMiddleware(s.targetsHandler.Delete))
+	s.handle("GET /targets/new", auth.RoleOperator, s.targetsHandler.NewForm)
+	s.handle("POST /api/targets", auth.RoleOperator, s.targetsHandler.Create)
+	s.handle("DELETE /api/targets/{id}", auth.RoleOperator, s.targetsHandler.Delete)
+	s.handle("GET /targets/{id}", auth.RoleViewer, s.targetsHandler.Detail)
+	s.handle("GET /targets/{id}/pages", auth.RoleViewer, s.targetsHandler.Pages)
+	s.handle("GET /targets/{id}/pages/{pageID}", auth.RoleViewer, s.targetsHandler.PageDetail)
+
+	// Queue management
+	s.handle("GET /queue", auth.RoleViewer, s.queueHandler.Page)
+	s.handle("GET /queue/items", auth.RoleViewer, s.queueHandler.Items)
+	s.handle("POST /api/queue/retry", auth.RoleOperator, s.queueHandler.Retry)
+	s.handle("POST /api/queue/prioritize", auth.RoleOperator, s.queueHandler.Prioritize)
+	s.handle("POST /api/queue/purge", auth.RoleOperator, s.queueHandler.Purge)
+	s.handle("POST /api/queue/{id}/requeue", auth.RoleOperator, s.queueHandler.Requeue)
+	s.handle("DELETE /api/queue/{id}", auth.RoleOperator, s.queueHandler.Delete)
+
+	// Crawl run history
+	s.handle("GET /runs", auth.RoleViewer, s.runsHandler.Page)
+	s.handle("GET /runs/{id}", auth.RoleViewer, s.runsHandler.Detail)
+
+	// Log browser
+	s.handle("GET /logs", auth.RoleViewer, s.logsHandler.Page)
+	s.handle("GET /logs/results", auth.RoleViewer, s.logsHandler.Results)
+	s.handle("GET /logs/tail", auth.RoleViewer, s.logsHandler.Tail)
+
+	// Settings stored in scraper_config
+	s.handle("GET /settings", auth.RoleAdmin, s.settingsHandler.Page)
+	s.handle("POST /api/settings/{key}", auth.RoleAdmin, s.settingsHandler.Update)
+
+	// Data export downloads
+	s.handle("GET /export", auth.RoleViewer, s.exportHandler.Page)
+	s.handle("GET /api/export", auth.RoleViewer, s.exportHandler.Download)
 
 	// Crawling control routes
-	s.mux.Handle("POST /api/crawl/start", withMiddleware(s.apiHandler.StartCrawling))
-	s.mux.Handle("POST /api/sitemap/refresh-all", withMiddleware(s.apiHandler.RefreshSitemaps))
+	s.handle("POST /api/crawl/start", auth.RoleOperator, s.apiHandler.StartCrawling)
+	s.handle("POST /api/sitemap/refresh-all", auth.RoleOperator, s.apiHandler.RefreshSitemaps)
+
+	// Prometheus metrics, scraped with a viewer API token
+	s.handle("GET /metrics", auth.RoleViewer, metrics.Default.Handler().ServeHTTP)
+
+	// Versioned JSON API, see handlers/openapi.json
+	// Unknown API routes get the JSON error envelope, registered per method as "/api/v1/" would conflict with "GET /"
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		s.handle(method+" /api/v1/", auth.RoleViewer, s.v1Handler.NotFound)
+	}
+	s.handle("GET /api/v1/stats", auth.RoleViewer, s.v1Handler.Stats)
+	s.handle("GET /api/v1/targets", auth.RoleViewer, s.v1Handler.ListTargets)
+	s.handle("POST /api/v1/targets", auth.RoleOperator, s.v1Handler.CreateTarget)
+	s.handle("GET /api/v1/targets/{id}", auth.RoleViewer, s.v1Handler.GetTarget)
+	s.handle("PATCH /api/v1/targets/{id}", auth.RoleOperator, s.v1Handler.UpdateTarget)
+	s.handle("DELETE /api/v1/targets/{id}", auth.RoleOperator, s.v1Handler.DeleteTarget)
+	s.handle("GET /api/v1/queue", auth.RoleViewer, s.v1Handler.ListQueue)
+	s.handle("POST /api/v1/queue", auth.RoleOperator, s.v1Handler.Enqueue)
+	s.handle("GET /api/v1/queue/{id}", auth.RoleViewer, s.v1Handler.GetQueueItem)
+	s.handle("DELETE /api/v1/queue/{id}", auth.RoleOperator, s.v1Handler.DeleteQueueItem)
+	s.handle("POST /api/v1/queue/{id}/retry", auth.RoleOperator, s.v1Handler.RetryQueueItem)
+	s.handle("GET /api/v1/pages", auth.RoleViewer, s.v1Handler.ListPages)
+	s.handle("GET /api/v1/pages/{id}", auth.RoleViewer, s.v1Handler.GetPage)
+	s.handle("GET /api/v1/pages/{id}/content", auth.RoleViewer, s.v1Handler.PageContent)
+	s.handle("GET /api/v1/pages/{id}/classifier", auth.RoleViewer, s.v1Handler.PageClassifier)
+	s.handle("GET /api/v1/logs", auth.RoleViewer, s.v1Handler.ListLogs)
+	s.handle("GET /api/v1/jobs", auth.RoleViewer, s.v1Handler.ListJobs)
+	s.handle("POST /api/v1/jobs", auth.RoleOperator, s.v1Handler.StartJob)
+	s.handle("GET /api/v1/jobs/{id}", auth.RoleViewer, s.v1Handler.GetJob)
+	s.handle("POST /api/v1/jobs/{id}/cancel", auth.RoleOperator, s.v1Handler.CancelJob)
+}
+
+// handle registers a route for users with at least the role
+func (s *Server) handle(pattern string, role auth.Role, handler http.HandlerFunc) {
+	s.mux.Handle(pattern, withMiddleware(withCSRF(requireRole(s.auth, role, handler))))
 }
 
-// Handler returns the main HTTP handler
+// Handler returns the main HTTP handler, recording the HTTP metrics of every request
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return metrics.Instrument(s.mux)
 }
 
 // Middleware wrapper for logging
 func withLogging(next http.HandlerFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		log.Printf("%s %s %s %v", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
 		next.ServeHTTP(w, r)
+		uiLog().InfoContext(r.Context(), "Request served", "method", r.Method, "path", r.URL.Path,
+			"remote", r.RemoteAddr, "duration", time.Since(start))
 	})
 }
 
@@ -121,7 +306,7 @@ func withRecovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
+				uiLog().ErrorContext(r.Context(), "Panic recovered", "panic", err, "method", r.Method, "path", r.URL.Path)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			}
 		}()
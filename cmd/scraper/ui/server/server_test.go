@@ -1,9 +1,14 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"app/internal/scraper/service/auth"
 )
 
 type mockDashboardHandler struct{}
@@ -74,13 +79,224 @@ func (m *mockTargetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 }
+func (m *mockTargetsHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockTargetsHandler) Pages(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockTargetsHandler) PageDetail(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+type mockLogsHandler struct{}
+
+func (m *mockLogsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockLogsHandler) Results(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockLogsHandler) Tail(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+type mockRunsHandler struct{}
+
+func (m *mockRunsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockRunsHandler) Detail(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+type mockQueueHandler struct{}
+
+func (m *mockQueueHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Items(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Prioritize(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockQueueHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+type mockSettingsHandler struct{}
+
+func (m *mockSettingsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockSettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+type mockExportHandler struct{}
+
+func (m *mockExportHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+func (m *mockExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		panic(err)
+	}
+}
+
+// mockV1Handler answers every route with its method name, unknown routes with 404
+type mockV1Handler struct{}
+
+func (m *mockV1Handler) write(w http.ResponseWriter, status int, name string) {
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(name)); err != nil {
+		panic(err)
+	}
+}
+func (m *mockV1Handler) NotFound(w http.ResponseWriter, r *http.Request) { m.write(w, 404, "NotFound") }
+func (m *mockV1Handler) OpenAPI(w http.ResponseWriter, r *http.Request)  { m.write(w, 200, "OpenAPI") }
+func (m *mockV1Handler) Stats(w http.ResponseWriter, r *http.Request)    { m.write(w, 200, "Stats") }
+func (m *mockV1Handler) ListTargets(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "ListTargets")
+}
+func (m *mockV1Handler) GetTarget(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "GetTarget")
+}
+func (m *mockV1Handler) CreateTarget(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "CreateTarget")
+}
+func (m *mockV1Handler) UpdateTarget(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "UpdateTarget")
+}
+func (m *mockV1Handler) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "DeleteTarget")
+}
+func (m *mockV1Handler) ListQueue(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "ListQueue")
+}
+func (m *mockV1Handler) GetQueueItem(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "GetQueueItem")
+}
+func (m *mockV1Handler) Enqueue(w http.ResponseWriter, r *http.Request) { m.write(w, 200, "Enqueue") }
+func (m *mockV1Handler) RetryQueueItem(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "RetryQueueItem")
+}
+func (m *mockV1Handler) DeleteQueueItem(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "DeleteQueueItem")
+}
+func (m *mockV1Handler) ListPages(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "ListPages")
+}
+func (m *mockV1Handler) GetPage(w http.ResponseWriter, r *http.Request) { m.write(w, 200, "GetPage") }
+func (m *mockV1Handler) PageContent(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "PageContent")
+}
+func (m *mockV1Handler) PageClassifier(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "PageClassifier")
+}
+func (m *mockV1Handler) ListLogs(w http.ResponseWriter, r *http.Request) { m.write(w, 200, "ListLogs") }
+func (m *mockV1Handler) ListJobs(w http.ResponseWriter, r *http.Request) { m.write(w, 200, "ListJobs") }
+func (m *mockV1Handler) GetJob(w http.ResponseWriter, r *http.Request)   { m.write(w, 200, "GetJob") }
+func (m *mockV1Handler) StartJob(w http.ResponseWriter, r *http.Request) { m.write(w, 200, "StartJob") }
+func (m *mockV1Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	m.write(w, 200, "CancelJob")
+}
+
+type mockAuthHandler struct{}
+
+func (m *mockAuthHandler) LoginPage(w http.ResponseWriter, r *http.Request) { w.WriteHeader(200) }
+func (m *mockAuthHandler) Login(w http.ResponseWriter, r *http.Request)     { w.WriteHeader(200) }
+func (m *mockAuthHandler) Logout(w http.ResponseWriter, r *http.Request)    { w.WriteHeader(200) }
+
+// mockAuthenticator knows one session and API token per role, named after the role
+type mockAuthenticator struct{}
+
+func (m *mockAuthenticator) user(secret string) (auth.User, error) {
+	role, err := auth.ParseRole(secret)
+	if err != nil {
+		return auth.User{}, auth.ErrUnauthenticated
+	}
+	return auth.User{ID: 1, Username: secret, Role: role}, nil
+}
+func (m *mockAuthenticator) SessionUser(ctx context.Context, secret string) (auth.User, error) {
+	return m.user(secret)
+}
+func (m *mockAuthenticator) TokenUser(ctx context.Context, secret string) (auth.User, error) {
+	return m.user(secret)
+}
+
+func newTestServer() *Server {
+	return NewWithHandlers(nil, &mockDashboardHandler{}, &mockAPIHandler{}, &mockTargetsHandler{}, &mockLogsHandler{}, &mockRunsHandler{}, &mockQueueHandler{}, &mockSettingsHandler{},
+		&mockExportHandler{}, &mockV1Handler{}, &mockAuthHandler{}, &mockAuthenticator{})
+}
 
 func TestServerRoutes(t *testing.T) {
-	dh := &mockDashboardHandler{}
-	ah := &mockAPIHandler{}
-	th := &mockTargetsHandler{}
-	s := NewWithHandlers(nil, dh, ah, th)
-	handler := s.Handler()
+	handler := newTestServer().Handler()
 
 	tests := []struct {
 		method string
@@ -94,10 +310,38 @@ func TestServerRoutes(t *testing.T) {
 		{"GET", "/api/logs", 200},
 		{"POST", "/api/crawl/start", 200},
 		{"POST", "/api/sitemap/refresh-all", 200},
+		{"GET", "/queue?status=failed", 200},
+		{"GET", "/queue/items?after=100", 200},
+		{"POST", "/api/queue/retry", 200},
+		{"POST", "/api/queue/prioritize", 200},
+		{"POST", "/api/queue/purge", 200},
+		{"POST", "/api/queue/7/requeue", 200},
+		{"DELETE", "/api/queue/7", 200},
+		{"GET", "/logs?level=error&q=timeout", 200},
+		{"GET", "/logs/results?before=120", 200},
+		{"GET", "/logs/tail?after=140", 200},
+		{"GET", "/runs", 200},
+		{"GET", "/runs/4", 200},
+		{"GET", "/settings", 200},
+		{"POST", "/api/settings/max_concurrent_workers", 200},
+		{"GET", "/export", 200},
+		{"GET", "/api/export", 200},
+		{"GET", "/targets/3", 200},
+		{"GET", "/targets/3/pages?after=50", 200},
+		{"GET", "/targets/3/pages/12", 200},
+		{"GET", "/api/v1/openapi.json", 200},
+		{"GET", "/api/v1/targets/3", 200},
+		{"PATCH", "/api/v1/targets/3", 200},
+		{"POST", "/api/v1/queue/7/retry", 200},
+		{"GET", "/api/v1/pages/5/content", 200},
+		{"POST", "/api/v1/jobs/1/cancel", 200},
+		{"GET", "/api/v1/unknown", 404},
+		{"PUT", "/api/v1/targets/3", 404},
 	}
 
 	for _, tc := range tests {
 		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("Authorization", "Bearer admin")
 		w := httptest.NewRecorder()
 		handler.ServeHTTP(w, r)
 		if w.Code != tc.want {
@@ -105,3 +349,119 @@ func TestServerRoutes(t *testing.T) {
 		}
 	}
 }
+
+func TestServerAuth(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"health is public", "GET", "/health", nil, 200},
+		{"login is public", "GET", "/login", nil, 200},
+		{"API description is public", "GET", "/api/v1/openapi.json", nil, 200},
+		{"pages redirect to login", "GET", "/", nil, 303},
+		{"HTMX requests get 401", "GET", "/api/stats", map[string]string{"HX-Request": "true"}, 401},
+		{"API requests get 401", "GET", "/api/v1/targets", nil, 401},
+		{"unknown token", "GET", "/api/v1/targets", map[string]string{"Authorization": "Bearer nobody"}, 401},
+		{"other schemes", "GET", "/api/v1/targets", map[string]string{"Authorization": "Basic YWRtaW46eA=="}, 401},
+		{"viewer reads", "GET", "/api/v1/targets", map[string]string{"Authorization": "Bearer viewer"}, 200},
+		{"viewer cannot write", "POST", "/api/v1/targets", map[string]string{"Authorization": "Bearer viewer"}, 403},
+		{"viewer cannot crawl", "POST", "/api/crawl/start", map[string]string{"Authorization": "Bearer viewer"}, 403},
+		{"viewer sees the queue", "GET", "/queue", map[string]string{"Authorization": "Bearer viewer"}, 200},
+		{"viewer sees runs", "GET", "/runs/4", map[string]string{"Authorization": "Bearer viewer"}, 200},
+		{"viewer cannot purge the queue", "POST", "/api/queue/purge", map[string]string{"Authorization": "Bearer viewer"}, 403},
+		{"viewer token scrapes metrics", "GET", "/metrics", map[string]string{"Authorization": "Bearer viewer"}, 200},
+		{"operator writes", "POST", "/api/v1/jobs", map[string]string{"Authorization": "Bearer operator"}, 200},
+		{"operator cannot change settings", "POST", "/api/settings/max_concurrent_workers", map[string]string{"Authorization": "Bearer operator"}, 403},
+		{"operator cannot see settings", "GET", "/settings", map[string]string{"Authorization": "Bearer operator"}, 403},
+		{"session reads", "GET", "/", map[string]string{"Cookie": "scraper_session=viewer"}, 200},
+		{"session without CSRF token", "POST", "/api/crawl/start", map[string]string{"Cookie": "scraper_session=operator; scraper_csrf=t0k"}, 403},
+		{"session with wrong CSRF token", "DELETE", "/api/targets/1", map[string]string{"Cookie": "scraper_session=operator; scraper_csrf=t0k", "X-CSRF-Token": "other"}, 403},
+		{"session with CSRF token", "DELETE", "/api/targets/1", map[string]string{"Cookie": "scraper_session=operator; scraper_csrf=t0k", "X-CSRF-Token": "t0k"}, 200},
+		{"login needs CSRF token", "POST", "/login", nil, 403},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%s: %s %s got %d, want %d", tc.name, tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	for _, path := range []string{"/queue/items?after=100", "/metrics"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Authorization", "Bearer viewer")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Fatalf("GET %s: got %d", path, w.Code)
+		}
+		if path != "/metrics" {
+			continue
+		}
+		// Requests are labelled by route pattern, the metrics request itself is recorded after it's served
+		body := w.Body.String()
+		if !strings.Contains(body, `scraper_http_requests_total{method="GET",route="/queue/items",code="200"}`) {
+			t.Errorf("expected the queue request to be counted by route, got:\n%s", body)
+		}
+		if !strings.Contains(body, "# TYPE scraper_fetch_requests_total counter") {
+			t.Errorf("expected the fetcher metrics, got:\n%s", body)
+		}
+	}
+}
+
+func TestServerAuth_Responses(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	r := httptest.NewRequest("GET", "/export?dataset=pages", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get("Location"); got != "/login?next=%2Fexport%3Fdataset%3Dpages" {
+		t.Errorf("expected a redirect back after login, got %q", got)
+	}
+	if !strings.Contains(w.Header().Get("Set-Cookie"), auth.CSRFCookie+"=") {
+		t.Errorf("expected a CSRF cookie, got %q", w.Header().Get("Set-Cookie"))
+	}
+
+	r = httptest.NewRequest("GET", "/api/stats", nil)
+	r.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get("HX-Redirect"); got != "/login?next=%2Fapi%2Fstats" {
+		t.Errorf("expected HTMX to be sent to the login page, got %q", got)
+	}
+
+	r = httptest.NewRequest("POST", "/api/v1/queue", nil)
+	r.Header.Set("Authorization", "Bearer viewer")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var env struct {
+		Error struct{ Code, Message string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil || env.Error.Code != "forbidden" {
+		t.Errorf("expected the API error envelope, got %q", w.Body.String())
+	}
+
+	// A form post repeats the token in a field instead of the header
+	r = httptest.NewRequest("POST", "/api/crawl/start", strings.NewReader("csrf_token=t0k"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Cookie", "scraper_session=operator; scraper_csrf=t0k")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != 200 {
+		t.Errorf("expected the form field to pass the CSRF check, got %d", w.Code)
+	}
+}
@@ -0,0 +1,234 @@
+// Package cassette records HTTP exchanges into fixture files and replays
+// them, so crawls of a site can run in tests without the network.
+//
+// Record a crawl with `scraper-cli run --record <file>` or wrap any client
+// transport in a Recorder. In tests, a Replayer built from the file serves
+// the recorded responses and fails requests that were not recorded:
+//
+//	c, err := cassette.Load("testdata/quotes_site.json")
+//	client := &http.Client{Transport: cassette.NewReplayer(c)}
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded is returned by Replayer for requests missing from the cassette
+var ErrNotRecorded = errors.New("request not recorded in cassette")
+
+// Cassette is the recorded exchanges of a fixture file, in request order
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request identifies a recorded request, replay matches on method and URL
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response is a recorded response. Text bodies are kept readable in Body,
+// other bodies are base64 encoded in BodyBase64.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+func (r Response) body() ([]byte, error) {
+	if r.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(r.BodyBase64)
+	}
+	return []byte(r.Body), nil
+}
+
+// Load reads a cassette file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette as indented JSON so fixture diffs stay reviewable
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+func key(method, url string) string {
+	return method + " " + url
+}
+
+// Recorder is an http.RoundTripper that passes requests on and records the
+// responses. Safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	next     http.RoundTripper
+	cassette Cassette
+}
+
+// NewRecorder records the exchanges of next, http.DefaultTransport when nil
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded := Response{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+	if utf8.Valid(body) {
+		recorded.Body = string(body)
+	} else {
+		recorded.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: req.URL.String()},
+		Response: recorded,
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Cassette returns the exchanges recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes the recorded exchanges to path
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// Replayer is an http.RoundTripper serving recorded responses. Repeated
+// requests for a URL get its recorded responses in order, the last one is
+// served again once they run out. Safe for concurrent use.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	served    map[string]int
+}
+
+func NewReplayer(c *Cassette) *Replayer {
+	p := &Replayer{responses: map[string][]Response{}, served: map[string]int{}}
+	for _, i := range c.Interactions {
+		k := key(i.Request.Method, i.Request.URL)
+		p.responses[k] = append(p.responses[k], i.Response)
+	}
+	return p
+}
+
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	k := key(req.Method, req.URL.String())
+	p.mu.Lock()
+	recorded, ok := p.responses[k]
+	n := p.served[k]
+	p.served[k]++
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotRecorded, k)
+	}
+	if n >= len(recorded) {
+		n = len(recorded) - 1
+	}
+	r := recorded[n]
+	body, err := r.body()
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorded body for %s: %w", k, err)
+	}
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Unplayed lists the recorded requests that were never requested, sorted,
+// a test can assert a crawl still fetches everything it recorded
+func (p *Replayer) Unplayed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var keys []string
+	for k := range p.responses {
+		if p.served[k] == 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Client returns an HTTP client replaying the cassette, redirects are
+// followed through the replayer like live ones
+func (p *Replayer) Client() *http.Client {
+	return &http.Client{Transport: p}
+}
+
+// String summarizes the cassette, e.g. for test failure messages
+func (c *Cassette) String() string {
+	urls := make([]string, 0, len(c.Interactions))
+	for _, i := range c.Interactions {
+		urls = append(urls, key(i.Request.Method, i.Request.URL))
+	}
+	return fmt.Sprintf("%d interactions: %s", len(c.Interactions), strings.Join(urls, ", "))
+}
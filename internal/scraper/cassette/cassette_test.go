@@ -0,0 +1,103 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string, error) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var flakyHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G', 0xff, 0x00})
+		case "/flaky":
+			if flakyHits.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("recovered"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>" + r.URL.Path + "</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	recorder := NewRecorder(srv.Client().Transport)
+	client := &http.Client{Transport: recorder}
+	for _, path := range []string{"/old", "/image", "/flaky", "/flaky"} {
+		if _, _, err := get(t, client, srv.URL+path); err != nil {
+			t.Fatalf("record %s: %v", path, err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "fixtures", "site.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	srv.Close()
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(c.Interactions) != 5 {
+		t.Fatalf("expected the redirect recorded as its own exchange, got %s", c)
+	}
+	if image := c.Interactions[2].Response; image.BodyBase64 == "" || image.Body != "" {
+		t.Errorf("expected the binary body base64 encoded, got %+v", image)
+	}
+
+	replayer := NewReplayer(c)
+	client = replayer.Client()
+	if status, body, err := get(t, client, srv.URL+"/old"); err != nil || status != 200 || body != "<html>/new</html>" {
+		t.Errorf("expected the redirect followed from the cassette, got %d %q (%v)", status, body, err)
+	}
+	if _, body, _ := get(t, client, srv.URL+"/image"); body != "\x89PNG\xff\x00" {
+		t.Errorf("expected the binary body restored, got %q", body)
+	}
+	for _, want := range []int{503, 200, 200} {
+		if status, _, _ := get(t, client, srv.URL+"/flaky"); status != want {
+			t.Errorf("expected repeated requests to replay in order, got %d want %d", status, want)
+		}
+	}
+	if unplayed := replayer.Unplayed(); len(unplayed) != 0 {
+		t.Errorf("expected nothing left unplayed, got %v", unplayed)
+	}
+}
+
+func TestReplayer_NotRecorded(t *testing.T) {
+	replayer := NewReplayer(&Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: "GET", URL: "https://example.com/"},
+		Response: Response{StatusCode: 200},
+	}}})
+	_, _, err := get(t, replayer.Client(), "https://example.com/missing")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+	if unplayed := replayer.Unplayed(); len(unplayed) != 1 || unplayed[0] != "GET https://example.com/" {
+		t.Errorf("expected the unrequested interaction reported, got %v", unplayed)
+	}
+}
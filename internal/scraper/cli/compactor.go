@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+)
+
+// CompactOptions controls the compaction steps
+type CompactOptions struct {
+	SkipVacuum bool // keep the freed pages in the database file
+	Force      bool // compact even though a run is recorded as running, e.g. one that crashed
+}
+
+// CompactSummary reports what compaction changed
+type CompactSummary struct {
+	OrphansRemoved int64 // stored bodies no page refers to anymore
+	Before         db.GetContentStatsRow
+	After          db.GetContentStatsRow
+	Vacuumed       bool
+	Duration       time.Duration
+}
+
+// Compactor removes stored bodies no page refers to and shrinks the database
+type Compactor struct {
+	db      *sql.DB
+	queries db.Querier
+}
+
+func NewCompactor(cfg *config.Config) (*Compactor, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Compactor{db: store.DB(), queries: store.Queries()}, nil
+}
+
+func (c *Compactor) Close() error {
+	return c.db.Close()
+}
+
+// Run removes orphans and vacuums. It refuses while a run is in progress: a
+// body a crawl stored but didn't link from its page yet looks orphaned.
+func (c *Compactor) Run(ctx context.Context, opts CompactOptions) (*CompactSummary, error) {
+	start := time.Now()
+	if !opts.Force {
+		run, err := c.queries.GetRunningRun(ctx)
+		switch {
+		case err == nil:
+			return nil, fmt.Errorf("run %d is in progress since %s, compact once it finished or pass --force if it crashed",
+				run.ID, run.StartedAt.Time.Format(time.DateTime))
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, fmt.Errorf("failed to check for running runs: %w", err)
+		}
+	}
+	summary := &CompactSummary{}
+	before, err := c.queries.GetContentStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content stats: %w", err)
+	}
+	summary.Before = before
+
+	if summary.OrphansRemoved, err = c.queries.DeleteOrphanContents(ctx); err != nil {
+		return summary, fmt.Errorf("failed to remove orphaned contents: %w", err)
+	}
+	if !opts.SkipVacuum {
+		if _, err := c.db.ExecContext(ctx, "VACUUM"); err != nil {
+			return summary, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		summary.Vacuumed = true
+	}
+
+	if summary.After, err = c.queries.GetContentStats(ctx); err != nil {
+		return summary, fmt.Errorf("failed to read content stats: %w", err)
+	}
+	summary.Duration = time.Since(start)
+	return summary, nil
+}
+
+// Print writes the compaction summary
+func (s *CompactSummary) Print() {
+	fmt.Printf("\n📦 Compaction Summary\n")
+	fmt.Printf("Orphaned contents removed: %d\n", s.OrphansRemoved)
+	fmt.Printf("Stored contents: %d -> %d\n", s.Before.Contents, s.After.Contents)
+	fmt.Printf("Stored size: %s -> %s (raw %s)\n", formatBytes(s.Before.StoredBytes), formatBytes(s.After.StoredBytes), formatBytes(s.After.RawBytes))
+	if s.Vacuumed {
+		fmt.Printf("Database vacuumed\n")
+	}
+	fmt.Printf("Duration: %s\n", s.Duration.Round(time.Millisecond))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+)
+
+func TestCompactor_Run(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+
+	// A page in the content store, and a stored body no page refers to
+	store := content.NewDBStore(queries)
+	key, err := store.Put(ctx, []byte("<p>c</p>!"))
+	if err != nil {
+		t.Fatalf("failed to store content: %v", err)
+	}
+	if _, err := dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, content_key) VALUES (?, '/c', 'https://example.com/c', ?)`, target.ID, key); err != nil {
+		t.Fatalf("failed to insert page: %v", err)
+	}
+	if _, err := store.Put(ctx, []byte("<p>orphan</p>")); err != nil {
+		t.Fatalf("failed to store content: %v", err)
+	}
+
+	// A running crawl may not have linked its bodies yet
+	run, err := queries.CreateRun(ctx, db.CreateRunParams{TriggerSource: TriggerCLI, TargetIds: "[]"})
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	c := &Compactor{db: dbConn, queries: queries}
+	if _, err := c.Run(ctx, CompactOptions{}); err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Fatalf("expected compaction refused during a run, got %v", err)
+	}
+	var contents int
+	_ = dbConn.QueryRow(`SELECT COUNT(*) FROM scraper_contents`).Scan(&contents)
+	if contents != 2 {
+		t.Errorf("expected no contents removed during a run, got %d left", contents)
+	}
+
+	if err := queries.FinishRun(ctx, db.FinishRunParams{ID: run.ID, Status: RunCompleted}); err != nil {
+		t.Fatalf("failed to finish run: %v", err)
+	}
+	summary, err := c.Run(ctx, CompactOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.OrphansRemoved != 1 || summary.After.Contents != 1 || !summary.Vacuumed {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	var html, storedKey sql.NullString
+	_ = dbConn.QueryRow(`SELECT html_content, content_key FROM scraper_pages WHERE url_path = '/c'`).Scan(&html, &storedKey)
+	if got, err := content.PageBody(ctx, store, html, storedKey); err != nil || got != "<p>c</p>!" {
+		t.Errorf("expected the page body preserved, got %q %v", got, err)
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/storage"
+)
+
+// LogManager maintains the scraper_logs table
+type LogManager struct {
+	store storage.Store
+	cfg   *config.Config
+}
+
+func NewLogManager(cfg *config.Config) (*LogManager, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &LogManager{store: store, cfg: cfg}, nil
+}
+
+func (lm *LogManager) Close() error {
+	return lm.store.Close()
+}
+
+// Retention is the configured retention automatic pruning applies, from
+// log_retention_days and log_max_rows
+func (lm *LogManager) Retention() logger.Retention {
+	return logger.Retention{MaxAge: lm.cfg.LogRetention(), MaxRows: lm.cfg.LogMaxRows()}
+}
+
+// Prune deletes the log rows outside the retention, after confirmation
+// unless force is set
+func (lm *LogManager) Prune(retention logger.Retention, force bool) error {
+	if !retention.Enabled() {
+		return fmt.Errorf("no retention to prune by, set --older-than or --keep")
+	}
+	ctx := context.Background()
+	if !force {
+		n, err := logger.CountPrunable(ctx, lm.store, retention)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Println("No log rows to prune.")
+			return nil
+		}
+		fmt.Printf("Delete %d log rows? [y/N]: ", n)
+		var response string
+		if n, err := fmt.Scanln(&response); err != nil && n == 0 {
+			fmt.Printf("failed to read input: %v\n", err)
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Prune cancelled.")
+			return nil
+		}
+	}
+	n, err := logger.Prune(ctx, lm.store, retention)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Deleted %d log rows\n", n)
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"app/internal/scraper/metrics"
+	"app/internal/scraper/service/logger"
+)
+
+// ServeMetrics serves the Prometheus metrics on addr's /metrics while the
+// runner crawls, the returned function stops serving them
+func (sr *ScraperRunner) ServeMetrics(addr string) (func() error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+	metrics.CollectQueue(sr.db)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			sr.log.Error("Metrics server stopped", logger.Err(err))
+		}
+	}()
+	return srv.Close, nil
+}
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/pipeline"
+)
+
+// discoveredURLPriority queues links found on pages after the sitemap URLs
+const discoveredURLPriority = -1
+
+var pipelineLog = logger.Lazy(logger.ComponentFetcher)
+
+// pipelineStore implements pipeline.Store on ScraperQueries, caching the
+// per-target template learners and URL patterns for the run
+type pipelineStore struct {
+	queries ScraperQueries
+	// Global classifier profile JSON from scraper_config, empty selects the built-in default
+	profileJSON string
+
+	// mu guards the maps only, the DB round-trips of a target run under its own lock
+	mu       sync.Mutex
+	targets  map[int64]*targetTemplate
+	patterns map[int64][]*regexp.Regexp
+}
+
+// targetTemplate is the template learner of a target and what of it is stored
+type targetTemplate struct {
+	// mu serialises loading and storing the target's template
+	mu      sync.Mutex
+	learner *classifier.TemplateLearner
+	// The learned template as last stored on the target, and whether the
+	// learner moved on since in ways not worth a write per page
+	saved   classifier.LearnedTemplate
+	unsaved bool
+}
+
+func newPipelineStore(queries ScraperQueries, profileJSON string) *pipelineStore {
+	return &pipelineStore{
+		queries:     queries,
+		profileJSON: profileJSON,
+		targets:     make(map[int64]*targetTemplate),
+		patterns:    make(map[int64][]*regexp.Regexp),
+	}
+}
+
+// target returns the template state of a target, created empty on first use
+func (s *pipelineStore) target(targetID int64) *targetTemplate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.targets[targetID]
+	if !ok {
+		t = &targetTemplate{}
+		s.targets[targetID] = t
+	}
+	return t
+}
+
+// readClassifierProfile reads and validates the global classifier profile JSON,
+// empty when the key is missing and the built-in default applies
+func readClassifierProfile(ctx context.Context, queries interface {
+	GetConfig(ctx context.Context, key string) (string, error)
+}) (string, error) {
+	profileJSON, err := queries.GetConfig(ctx, classifier.ProfileConfigKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to load classifier profile: %w", err)
+	}
+	if _, err := classifier.ResolveProfile(profileJSON, ""); err != nil {
+		return "", fmt.Errorf("invalid classifier profile in config key %q: %w", classifier.ProfileConfigKey, err)
+	}
+	return profileJSON, nil
+}
+
+// targetProfile resolves the classifier profile for a target, falling back to the global profile on invalid overrides
+func (s *pipelineStore) targetProfile(ctx context.Context, targetID int64) *classifier.ClassifierProfile {
+	return resolveTargetProfile(ctx, s.queries, s.profileJSON, targetID)
+}
+
+// resolveTargetProfile applies the target's classifier overrides to the global
+// profile JSON, invalid overrides are logged and the global profile is used
+func resolveTargetProfile(ctx context.Context, queries interface {
+	GetTarget(ctx context.Context, id int64) (db.ScraperTarget, error)
+}, profileJSON string, targetID int64) *classifier.ClassifierProfile {
+	global, err := classifier.ResolveProfile(profileJSON, "")
+	if err != nil {
+		// Validated by the callers when loading the profile, only reachable when that was bypassed
+		global = classifier.DefaultProfile()
+	}
+	target, err := queries.GetTarget(ctx, targetID)
+	if err != nil || !target.ClassifierOverridesJson.Valid {
+		return global
+	}
+	profile, err := classifier.ResolveProfile(profileJSON, target.ClassifierOverridesJson.String)
+	if err != nil {
+		pipelineLog().WarnContext(ctx, "Ignoring invalid classifier overrides", logger.Target(targetID), logger.Err(err))
+		return global
+	}
+	return profile
+}
+
+// TemplateLearner returns the target's template learner, seeding it from the stored template on first use
+func (s *pipelineStore) TemplateLearner(ctx context.Context, targetID int64) *classifier.TemplateLearner {
+	t := s.target(targetID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.learner != nil {
+		return t.learner
+	}
+	var stored *classifier.LearnedTemplate
+	if target, err := s.queries.GetTarget(ctx, targetID); err == nil && target.LearnedTemplateJson.Valid {
+		tmpl, err := classifier.ParseLearnedTemplate(target.LearnedTemplateJson.String)
+		if err != nil {
+			pipelineLog().WarnContext(ctx, "Ignoring invalid learned template", logger.Target(targetID), logger.Err(err))
+		} else {
+			stored = tmpl
+		}
+	}
+	t.learner = classifier.NewTemplateLearner(stored, s.targetProfile(ctx, targetID))
+	t.saved = t.learner.Template()
+	return t.learner
+}
+
+// SaveClassification stores the classifier result on the page and the learned template on the target when it changed
+func (s *pipelineStore) SaveClassification(ctx context.Context, page *pipeline.Page, learner *classifier.TemplateLearner) error {
+	decision := page.Classification
+	jsonStr, err := decision.MarshalDecision()
+	if err != nil {
+		return err
+	}
+	if err := s.queries.SavePageClassifier(ctx, jsonStr, decision.Decision.Processable, decision.Language, page.TargetID, page.URLPath); err != nil {
+		return err
+	}
+
+	// Every page updates the learner's counters, the target is only written
+	// when the template changed in a way extraction notices
+	t := s.target(page.TargetID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tmpl := learner.Template()
+	if t.learner != nil && !tmpl.ChangedFrom(&t.saved) {
+		t.unsaved = true
+		return nil
+	}
+	return s.saveTemplate(ctx, page.TargetID, t, tmpl)
+}
+
+// SaveTemplates stores the learner state not stored yet, the counters and
+// votes of pages that left the template unchanged, at the end of a run
+func (s *pipelineStore) SaveTemplates(ctx context.Context) error {
+	s.mu.Lock()
+	targets := make(map[int64]*targetTemplate, len(s.targets))
+	for targetID, t := range s.targets {
+		targets[targetID] = t
+	}
+	s.mu.Unlock()
+
+	for targetID, t := range targets {
+		t.mu.Lock()
+		var err error
+		if t.unsaved {
+			err = s.saveTemplate(ctx, targetID, t, t.learner.Template())
+		}
+		t.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveTemplate writes a learned template to its target, called with t.mu held
+func (s *pipelineStore) saveTemplate(ctx context.Context, targetID int64, t *targetTemplate, tmpl classifier.LearnedTemplate) error {
+	templateJSON, err := tmpl.MarshalTemplate()
+	if err != nil {
+		return fmt.Errorf("failed to marshal learned template: %w", err)
+	}
+	if err := s.queries.UpdateTargetLearnedTemplate(ctx, templateJSON, targetID); err != nil {
+		return fmt.Errorf("failed to save learned template: %w", err)
+	}
+	t.saved = tmpl
+	t.unsaved = false
+	return nil
+}
+
+func (s *pipelineStore) SaveQuotes(ctx context.Context, page *pipeline.Page) error {
+	quotes := page.Quotes
+	if quotes == nil {
+		quotes = []pipeline.Quote{}
+	}
+	data, err := json.Marshal(quotes)
+	if err != nil {
+		return err
+	}
+	return s.queries.SavePageQuotes(ctx, string(data), page.ID)
+}
+
+// URLPatterns returns the target's compiled URL patterns, the sitemap defaults when none are configured
+func (s *pipelineStore) URLPatterns(ctx context.Context, targetID int64) ([]*regexp.Regexp, error) {
+	s.mu.Lock()
+	patterns, ok := s.patterns[targetID]
+	s.mu.Unlock()
+	if ok {
+		return patterns, nil
+	}
+
+	// Loaded outside the lock, workers racing on a target compile the same patterns
+	target, err := s.queries.GetTarget(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target: %w", err)
+	}
+	var raw []string
+	if target.UrlPatterns.Valid && target.UrlPatterns.String != "" {
+		if err := json.Unmarshal([]byte(target.UrlPatterns.String), &raw); err != nil {
+			return nil, fmt.Errorf("invalid URL patterns for target %d: %w", targetID, err)
+		}
+	}
+	if len(raw) == 0 {
+		raw = config.DefaultPatterns().URLPatterns
+	}
+	compiled, err := config.CompilePatterns(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL patterns for target %d: %w", targetID, err)
+	}
+	s.mu.Lock()
+	s.patterns[targetID] = compiled
+	s.mu.Unlock()
+	return compiled, nil
+}
+
+// EnqueueLinks queues the URLs that are neither queued nor stored yet and returns how many were added
+func (s *pipelineStore) EnqueueLinks(ctx context.Context, targetID int64, urls []string) (int, error) {
+	queued := 0
+	for _, url := range urls {
+		added, err := s.queries.EnqueueDiscoveredURL(ctx, targetID, url, discoveredURLPriority)
+		if err != nil {
+			return queued, err
+		}
+		if added {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+func (s *pipelineStore) SaveStatus(ctx context.Context, pageID int64, status pipeline.Status) error {
+	jsonStr, err := status.MarshalStatus()
+	if err != nil {
+		return err
+	}
+	return s.queries.SavePagePipelineStatus(ctx, jsonStr, pageID)
+}
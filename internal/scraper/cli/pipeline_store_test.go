@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/pipeline"
+)
+
+func TestPipelineStore_SavesTemplateOnChange(t *testing.T) {
+	ctx := context.Background()
+	queries := &mockQueries{GetTargetErr: sql.ErrNoRows}
+	store := newPipelineStore(queries, "")
+	learner := store.TemplateLearner(ctx, 1)
+
+	var sb strings.Builder
+	sb.WriteString("<html><body>")
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&sb, `<div class="quote">Quote number %d is a short and wise thought about life, patience and the passing of time.</div>`, i)
+	}
+	sb.WriteString("</body></html>")
+	body := sb.String()
+	classify := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			url := fmt.Sprintf("http://example.com/%d", i)
+			decision, err := learner.ClassifyPage(url, body)
+			if err != nil {
+				t.Fatalf("classify: %v", err)
+			}
+			if err := store.SaveClassification(ctx, &pipeline.Page{TargetID: 1, URLPath: url, Classification: decision}, learner); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+		}
+	}
+
+	// Learning pages only add votes, the template is written once it converges
+	classify(5)
+	if len(queries.TemplateWrites) != 1 || !strings.Contains(queries.TemplateWrites[0], classifier.TemplateStatusStable) {
+		t.Fatalf("expected the converged template written once, got %v", queries.TemplateWrites)
+	}
+	// Template hits only count
+	classify(3)
+	if len(queries.TemplateWrites) != 1 {
+		t.Errorf("expected no writes for template hits, got %d", len(queries.TemplateWrites)-1)
+	}
+
+	// The end of a run stores the counters, once
+	for range 2 {
+		if err := store.SaveTemplates(ctx); err != nil {
+			t.Fatalf("save templates: %v", err)
+		}
+	}
+	if len(queries.TemplateWrites) != 2 || !strings.Contains(queries.TemplateWrites[1], `"hits":3`) {
+		t.Errorf("expected the hits written once at the end, got %v", queries.TemplateWrites[1:])
+	}
+}
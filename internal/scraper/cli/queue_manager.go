@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/service/queue"
+)
+
+// QueueManager inspects and manages the crawl queue
+type QueueManager struct {
+	db    *sql.DB
+	queue *queue.Service
+}
+
+func NewQueueManager(cfg *config.Config) (*QueueManager, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueManager{db: store.DB(), queue: queue.NewService(store)}, nil
+}
+
+func (qm *QueueManager) Close() error {
+	return qm.db.Close()
+}
+
+// Status prints the number of items in each status
+func (qm *QueueManager) Status() error {
+	rows, err := qm.db.Query("SELECT status, COUNT(*) FROM scraper_queue GROUP BY status ORDER BY status")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("failed to close rows: %v\n", err)
+		}
+	}()
+
+	fmt.Println("Status      Count")
+	fmt.Println("----------- -----")
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+		fmt.Printf("%-11s %5d\n", status, count)
+	}
+	return rows.Err()
+}
+
+// List prints up to limit items matching the filter
+func (qm *QueueManager) List(filter queue.Filter, limit int) error {
+	ctx := context.Background()
+	items, _, err := qm.queue.List(ctx, filter, 0, limit)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("No queue items match.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTARGET\tSTATUS\tPRIORITY\tATTEMPTS\tQUEUED\tURL\tERROR")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%d/%d\t%s\t%s\t%s\n", item.ID, item.TargetID, item.Status.String,
+			item.Priority.Int64, item.Attempts.Int64, item.MaxAttempts.Int64,
+			item.CreatedAt.Time.Format("2006-01-02 15:04"), item.Url, truncate(item.ErrorMessage.String, 60))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if total, err := qm.queue.Count(ctx, filter); err == nil && total > int64(len(items)) {
+		fmt.Printf("\nShowing %d of %d items, raise --limit to see more.\n", len(items), total)
+	}
+	return nil
+}
+
+// Retry puts failed items matching the filter back to pending
+func (qm *QueueManager) Retry(filter queue.Filter, resetAttempts bool) error {
+	n, err := qm.queue.Retry(context.Background(), filter, resetAttempts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Retrying %d failed queue items\n", n)
+	if !resetAttempts {
+		fmt.Println("Items that used all their attempts stay failed, retry them with --reset-attempts.")
+	}
+	return nil
+}
+
+// Requeue queues the target's URLs to be crawled again
+func (qm *QueueManager) Requeue(targetID int64, urls []string) error {
+	result, err := qm.queue.Requeue(context.Background(), targetID, urls)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Requeued %d items, enqueued %d new URLs\n", result.Requeued, result.Enqueued)
+	for _, u := range result.Processing {
+		fmt.Printf("⚠️  %s is being crawled, left alone\n", u)
+	}
+	return nil
+}
+
+// Prioritize sets the priority of pending items matching the filter
+func (qm *QueueManager) Prioritize(filter queue.Filter, priority int64) error {
+	n, err := qm.queue.Prioritize(context.Background(), filter, priority)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Set priority %d on %d pending queue items\n", priority, n)
+	return nil
+}
+
+// Purge deletes items matching the filter, asking first unless forced
+func (qm *QueueManager) Purge(filter queue.Filter, force bool) error {
+	ctx := context.Background()
+	if !force {
+		n, err := qm.queue.Count(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Println("No queue items match.")
+			return nil
+		}
+		fmt.Printf("Delete %d %s queue items? [y/N]: ", n, strings.Join(filter.Statuses, "/"))
+		var response string
+		if n, err := fmt.Scanln(&response); err != nil && n == 0 {
+			fmt.Printf("failed to read input: %v\n", err)
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Purge cancelled.")
+			return nil
+		}
+	}
+	n, err := qm.queue.Purge(ctx, filter)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Deleted %d %s queue items\n", n, strings.Join(filter.Statuses, "/"))
+	return nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/storage"
+)
+
+// reclassifyBatchSize is the number of stored pages loaded per query, HTML bodies can be large
+const reclassifyBatchSize = 100
+
+// reasonUnclassified stands for pages that were stored without a classifier
+// result, ListPagesForReclassify matches them by this reason too
+const reasonUnclassified = "UNCLASSIFIED"
+
+// ReclassifyOptions filters the stored pages to re-classify
+type ReclassifyOptions struct {
+	TargetID    int64     // 0 for all targets
+	Reason      string    // previous decision reason, empty for any
+	Since       time.Time // only pages visited at or after this time, zero for any
+	Processable *bool     // previous processable flag, nil for any
+	DryRun      bool      // classify and report without writing results
+}
+
+// ReclassifySummary reports how decisions changed
+type ReclassifySummary struct {
+	Total               int
+	Changed             int
+	Errors              int
+	BecameProcessable   int
+	BecameUnprocessable int
+	Transitions         map[string]int // "OLD_REASON -> NEW_REASON" counts for changed reasons
+	Duration            time.Duration
+}
+
+// Reclassifier re-runs the classifier over stored page HTML without re-crawling
+type Reclassifier struct {
+	store   storage.Store
+	workers int
+}
+
+type reclassifyResult struct {
+	oldReason      string
+	newReason      string
+	oldProcessable bool
+	newProcessable bool
+	err            error
+}
+
+func NewReclassifier(cfg *config.Config, workers int) (*Reclassifier, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Reclassifier{store: store, workers: workers}, nil
+}
+
+func (r *Reclassifier) Close() error {
+	return r.store.Close()
+}
+
+// Run classifies all matching pages with the configured workers and returns the summary
+func (r *Reclassifier) Run(ctx context.Context, opts ReclassifyOptions) (*ReclassifySummary, error) {
+	start := time.Now()
+	profileJSON, err := readClassifierProfile(ctx, r.store.Queries())
+	if err != nil {
+		return nil, err
+	}
+	classifiers := &targetClassifiers{
+		queries:     r.store.Queries(),
+		profileJSON: profileJSON,
+		services:    map[int64]*classifier.QuotePageClassifierService{},
+	}
+	contents := content.NewDBStore(r.store.Queries())
+
+	workers := r.workers
+	if workers < 1 {
+		workers = 1
+	}
+	pages := make(chan db.ListPagesForReclassifyRow, workers*2)
+	results := make(chan reclassifyResult, workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range pages {
+				results <- r.reclassifyPage(ctx, page, contents, classifiers, opts.DryRun)
+			}
+		}()
+	}
+
+	summary := &ReclassifySummary{Transitions: map[string]int{}}
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for res := range results {
+			summary.add(res)
+		}
+	}()
+
+	listErr := r.listPages(ctx, opts, pages)
+	close(pages)
+	wg.Wait()
+	close(results)
+	<-collected
+
+	summary.Duration = time.Since(start)
+	return summary, listErr
+}
+
+// listPages feeds matching pages to the workers batch by batch, keyed by page id
+func (r *Reclassifier) listPages(ctx context.Context, opts ReclassifyOptions, pages chan<- db.ListPagesForReclassifyRow) error {
+	params := db.ListPagesForReclassifyParams{
+		TargetID:  sql.NullInt64{Int64: opts.TargetID, Valid: opts.TargetID > 0},
+		Reason:    sql.NullString{String: opts.Reason, Valid: opts.Reason != ""},
+		Since:     sql.NullTime{Time: opts.Since.UTC(), Valid: !opts.Since.IsZero()},
+		BatchSize: reclassifyBatchSize,
+	}
+	if opts.Processable != nil {
+		params.Processable = sql.NullBool{Bool: *opts.Processable, Valid: true}
+	}
+
+	for {
+		batch, err := r.store.Queries().ListPagesForReclassify(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to list pages: %w", err)
+		}
+		for _, page := range batch {
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if len(batch) < reclassifyBatchSize {
+			return nil
+		}
+		params.AfterID = batch[len(batch)-1].ID
+	}
+}
+
+// reclassifyPage classifies a stored page with its target's profile. Learned
+// templates are left to the crawl: the page is classified on its own and the
+// template's counters aren't touched.
+func (r *Reclassifier) reclassifyPage(ctx context.Context, page db.ListPagesForReclassifyRow, contents content.Store, classifiers *targetClassifiers, dryRun bool) reclassifyResult {
+	res := reclassifyResult{oldReason: reasonUnclassified, oldProcessable: page.Processable.Bool}
+	if page.QuoteClassifierJson.Valid {
+		var old classifier.QuoteClassifierDecision
+		if err := json.Unmarshal([]byte(page.QuoteClassifierJson.String), &old); err == nil && old.Decision.DecisionReason != "" {
+			res.oldReason = old.Decision.DecisionReason
+		}
+	}
+
+	body, err := content.PageBody(ctx, contents, page.HtmlContent, page.ContentKey)
+	if err != nil {
+		res.err = fmt.Errorf("page %d: %w", page.ID, err)
+		return res
+	}
+	decision, err := classifiers.forTarget(ctx, page.TargetID).ClassifyPage(page.FullUrl, body)
+	if err != nil {
+		res.err = fmt.Errorf("page %d: %w", page.ID, err)
+		return res
+	}
+	res.newReason = decision.Decision.DecisionReason
+	res.newProcessable = decision.Decision.Processable
+	if dryRun {
+		return res
+	}
+
+	jsonStr, err := decision.MarshalDecision()
+	if err != nil {
+		res.err = fmt.Errorf("page %d: %w", page.ID, err)
+		return res
+	}
+	err = r.store.Queries().SavePageClassifier(ctx, db.SavePageClassifierParams{
+		QuoteClassifierJson: sql.NullString{String: jsonStr, Valid: true},
+		Processable:         sql.NullBool{Bool: decision.Decision.Processable, Valid: true},
+		Language:            sql.NullString{String: decision.Language, Valid: decision.Language != ""},
+		TargetID:            page.TargetID,
+		UrlPath:             page.UrlPath,
+	})
+	if err != nil {
+		res.err = fmt.Errorf("page %d: failed to save classifier result: %w", page.ID, err)
+	}
+	return res
+}
+
+// targetClassifiers caches a classifier per target with the target's profile
+type targetClassifiers struct {
+	queries     db.Querier
+	profileJSON string
+
+	mu       sync.Mutex
+	services map[int64]*classifier.QuotePageClassifierService
+}
+
+func (c *targetClassifiers) forTarget(ctx context.Context, targetID int64) *classifier.QuotePageClassifierService {
+	c.mu.Lock()
+	service, ok := c.services[targetID]
+	c.mu.Unlock()
+	if ok {
+		return service
+	}
+	// Resolved outside the lock, workers racing on a target build the same classifier
+	service = classifier.NewQuotePageClassifierServiceWithProfile(resolveTargetProfile(ctx, c.queries, c.profileJSON, targetID))
+	c.mu.Lock()
+	c.services[targetID] = service
+	c.mu.Unlock()
+	return service
+}
+
+func (s *ReclassifySummary) add(res reclassifyResult) {
+	s.Total++
+	if res.err != nil {
+		s.Errors++
+		fmt.Printf("❌ %v\n", res.err)
+		return
+	}
+	switch {
+	case !res.oldProcessable && res.newProcessable:
+		s.BecameProcessable++
+	case res.oldProcessable && !res.newProcessable:
+		s.BecameUnprocessable++
+	}
+	if res.oldReason != res.newReason || res.oldProcessable != res.newProcessable {
+		s.Changed++
+	}
+	if res.oldReason != res.newReason {
+		s.Transitions[res.oldReason+" -> "+res.newReason]++
+	}
+}
+
+// Print writes the summary with the reason transitions, most frequent first
+func (s *ReclassifySummary) Print() {
+	fmt.Printf("\n📊 Reclassification Summary\n")
+	fmt.Printf("Pages classified: %d\n", s.Total)
+	fmt.Printf("Decisions changed: %d\n", s.Changed)
+	fmt.Printf("  unprocessable -> processable: %d\n", s.BecameProcessable)
+	fmt.Printf("  processable -> unprocessable: %d\n", s.BecameUnprocessable)
+	fmt.Printf("Errors: %d\n", s.Errors)
+	fmt.Printf("Duration: %s\n", s.Duration.Round(time.Millisecond))
+
+	if len(s.Transitions) == 0 {
+		return
+	}
+	transitions := make([]string, 0, len(s.Transitions))
+	for t := range s.Transitions {
+		transitions = append(transitions, t)
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		if s.Transitions[transitions[i]] != s.Transitions[transitions[j]] {
+			return s.Transitions[transitions[i]] > s.Transitions[transitions[j]]
+		}
+		return transitions[i] < transitions[j]
+	})
+	fmt.Printf("\nReason changes:\n")
+	for _, t := range transitions {
+		fmt.Printf("  %-50s %d\n", t, s.Transitions[t])
+	}
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/storage"
+)
+
+func newReclassifyTestDB(t *testing.T) (*sql.DB, *db.Queries) {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	// A single connection keeps the in-memory database shared by all workers
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	runMigrations(t, dbConn, "../db/migrations")
+	return dbConn, db.New(dbConn)
+}
+
+func insertClassifiedPage(t *testing.T, dbConn *sql.DB, targetID int64, path, htmlContent, reason string, processable bool) {
+	t.Helper()
+	classifierJSON := fmt.Sprintf(`{"url":"%s","features":{},"decision":{"processable":%t,"selectors":[],"confidence":0.1,"decision_reason":"%s"}}`, path, processable, reason)
+	_, err := dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, html_content, quote_classifier_json, processable) VALUES (?, ?, ?, ?, ?, ?)`,
+		targetID, path, "https://example.com"+path, htmlContent, classifierJSON, processable)
+	if err != nil {
+		t.Fatalf("failed to insert page: %v", err)
+	}
+}
+
+func reclassifyQuotesPage() string {
+	var sb strings.Builder
+	sb.WriteString("<html><body><div class=\"quotes\">")
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&sb, `<div class="quote">Quote number %d is a short and wise thought about life, patience and time. — Author %c</div>`, i, 'A'+i)
+	}
+	sb.WriteString("</div></body></html>")
+	return sb.String()
+}
+
+func TestReclassifier_FlipsAndSummary(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+
+	// Stored as unprocessable by an older classifier, the current one accepts it
+	insertClassifiedPage(t, dbConn, target.ID, "/quotes", reclassifyQuotesPage(), classifier.DecisionShortMainText, false)
+	// Stored as processable, the current one rejects it
+	insertClassifiedPage(t, dbConn, target.ID, "/about", "<html><body><p>About us.</p></body></html>", classifier.DecisionQuoteStructure, true)
+
+	r := &Reclassifier{store: storage.NewSQLite(dbConn), workers: 3}
+	summary, err := r.Run(ctx, ReclassifyOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Total != 2 || summary.Changed != 2 || summary.BecameProcessable != 1 || summary.BecameUnprocessable != 1 || summary.Errors != 0 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.Transitions[classifier.DecisionQuoteStructure+" -> "+classifier.DecisionShortMainText] != 1 {
+		t.Errorf("expected QUOTE_STRUCTURE -> SHORT_MAIN_TEXT transition, got %v", summary.Transitions)
+	}
+
+	row, err := queries.GetPageClassifier(ctx, db.GetPageClassifierParams{TargetID: target.ID, UrlPath: "/quotes"})
+	if err != nil {
+		t.Fatalf("failed to read classifier result: %v", err)
+	}
+	if !row.Processable.Bool || !strings.Contains(row.QuoteClassifierJson.String, `"profile":"default"`) {
+		t.Errorf("expected stored result updated, got %+v", row)
+	}
+}
+
+func TestReclassifier_FiltersAndDryRun(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	first, _ := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://a.example.com"})
+	second, _ := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://b.example.com"})
+
+	insertClassifiedPage(t, dbConn, first.ID, "/a1", reclassifyQuotesPage(), classifier.DecisionShortMainText, false)
+	insertClassifiedPage(t, dbConn, first.ID, "/a2", reclassifyQuotesPage(), classifier.DecisionLowQuoteScore, false)
+	insertClassifiedPage(t, dbConn, second.ID, "/b1", reclassifyQuotesPage(), classifier.DecisionShortMainText, false)
+
+	r := &Reclassifier{store: storage.NewSQLite(dbConn), workers: 2}
+	notProcessable := false
+	summary, err := r.Run(ctx, ReclassifyOptions{
+		TargetID:    first.ID,
+		Reason:      classifier.DecisionShortMainText,
+		Processable: &notProcessable,
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Total != 1 || summary.BecameProcessable != 1 {
+		t.Errorf("expected only /a1 to match the filters, got %+v", summary)
+	}
+
+	row, err := queries.GetPageClassifier(ctx, db.GetPageClassifierParams{TargetID: first.ID, UrlPath: "/a1"})
+	if err != nil {
+		t.Fatalf("failed to read classifier result: %v", err)
+	}
+	if row.Processable.Bool {
+		t.Error("dry run must not write results")
+	}
+}
+
+func TestReclassifier_LeavesLearnedTemplate(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	const templateJSON = `{"status":"stable","selectors":["div.missing"],"confidence":0.9,"samples":5,"hits":10}`
+	if _, err := dbConn.Exec(`UPDATE scraper_targets SET learned_template_json = ? WHERE id = ?`, templateJSON, target.ID); err != nil {
+		t.Fatalf("failed to store learned template: %v", err)
+	}
+	insertClassifiedPage(t, dbConn, target.ID, "/quotes", reclassifyQuotesPage(), classifier.DecisionLearnedTemplate, true)
+
+	r := &Reclassifier{store: storage.NewSQLite(dbConn), workers: 1}
+	summary, err := r.Run(ctx, ReclassifyOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// The page is classified by the current classifier, not the stored selectors
+	if summary.Total != 1 || summary.Transitions[classifier.DecisionLearnedTemplate+" -> "+classifier.DecisionStructuredDiverse] != 1 {
+		t.Errorf("expected the page classified on its own, got %+v", summary)
+	}
+
+	var stored string
+	_ = dbConn.QueryRow(`SELECT learned_template_json FROM scraper_targets WHERE id = ?`, target.ID).Scan(&stored)
+	if stored != templateJSON {
+		t.Errorf("expected the learned template left to the crawl, got %s", stored)
+	}
+}
+
+func TestReclassifier_SelectsUnclassified(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	insertClassifiedPage(t, dbConn, target.ID, "/classified", reclassifyQuotesPage(), classifier.DecisionShortMainText, false)
+	if _, err := dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, html_content) VALUES (?, '/new', 'https://example.com/new', ?)`,
+		target.ID, reclassifyQuotesPage()); err != nil {
+		t.Fatalf("failed to insert page: %v", err)
+	}
+
+	r := &Reclassifier{store: storage.NewSQLite(dbConn), workers: 1}
+	summary, err := r.Run(ctx, ReclassifyOptions{Reason: reasonUnclassified, DryRun: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Total != 1 || summary.Transitions[reasonUnclassified+" -> "+classifier.DecisionStructuredDiverse] != 1 {
+		t.Errorf("expected only the page without a result selected, got %+v", summary)
+	}
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/pipeline"
+	"app/internal/scraper/storage"
+)
+
+// ReprocessOptions selects the stored pages and the pipeline stages to re-run
+type ReprocessOptions struct {
+	TargetID    int64    // 0 for all targets
+	Stages      []string // stages to run, empty for the whole pipeline
+	FailedStage string   // only pages where this stage failed last time, empty for any
+}
+
+// ReprocessSummary reports the stage outcomes of a re-run
+type ReprocessSummary struct {
+	Total    int
+	Errors   int                       // pages that could not be loaded or whose status could not be saved
+	Stages   map[string]map[string]int // stage -> status -> pages
+	Duration time.Duration
+}
+
+// Reprocessor re-runs pipeline stages over stored pages without re-crawling
+type Reprocessor struct {
+	store   storage.Store
+	workers int
+}
+
+func NewReprocessor(cfg *config.Config, workers int) (*Reprocessor, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Reprocessor{store: store, workers: workers}, nil
+}
+
+func (r *Reprocessor) Close() error {
+	return r.store.Close()
+}
+
+// Run processes all matching pages through the selected stages with the configured workers
+func (r *Reprocessor) Run(ctx context.Context, opts ReprocessOptions) (*ReprocessSummary, error) {
+	start := time.Now()
+	queries := r.store.Queries()
+	profileJSON, err := readClassifierProfile(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	stageStore := newPipelineStore(newQueriesAdapter(r.store), profileJSON)
+	p := pipeline.NewDefault(stageStore)
+	contents := content.NewDBStore(queries)
+	if err := p.CheckStages(opts.Stages); err != nil {
+		return nil, err
+	}
+	if opts.FailedStage != "" {
+		if err := p.CheckStages([]string{opts.FailedStage}); err != nil {
+			return nil, err
+		}
+	}
+
+	workers := max(r.workers, 1)
+	pages := make(chan db.ListPagesForPipelineRow, workers*2)
+	results := make(chan reprocessResult, workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range pages {
+				results <- reprocessPage(ctx, p, contents, row, opts.Stages)
+			}
+		}()
+	}
+
+	summary := &ReprocessSummary{Stages: map[string]map[string]int{}}
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for res := range results {
+			summary.add(res)
+		}
+	}()
+
+	listErr := r.listPages(ctx, opts, pages)
+	close(pages)
+	wg.Wait()
+	close(results)
+	<-collected
+
+	if err := stageStore.SaveTemplates(context.WithoutCancel(ctx)); err != nil && listErr == nil {
+		listErr = err
+	}
+	summary.Duration = time.Since(start)
+	return summary, listErr
+}
+
+type reprocessResult struct {
+	status pipeline.Status
+	stages []string
+	err    error
+}
+
+// listPages feeds matching pages to the workers batch by batch, keyed by page id
+func (r *Reprocessor) listPages(ctx context.Context, opts ReprocessOptions, pages chan<- db.ListPagesForPipelineRow) error {
+	params := db.ListPagesForPipelineParams{
+		TargetID:    sql.NullInt64{Int64: opts.TargetID, Valid: opts.TargetID > 0},
+		FailedStage: sql.NullString{String: opts.FailedStage, Valid: opts.FailedStage != ""},
+		BatchSize:   reclassifyBatchSize,
+	}
+	for {
+		batch, err := r.store.Queries().ListPagesForPipeline(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to list pages: %w", err)
+		}
+		for _, page := range batch {
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if len(batch) < reclassifyBatchSize {
+			return nil
+		}
+		params.AfterID = batch[len(batch)-1].ID
+	}
+}
+
+// reprocessPage restores the stored results the selected stages may depend on and runs them
+func reprocessPage(ctx context.Context, p *pipeline.Pipeline, contents content.Store, row db.ListPagesForPipelineRow, stages []string) reprocessResult {
+	body, err := content.PageBody(ctx, contents, row.HtmlContent, row.ContentKey)
+	if err != nil {
+		return reprocessResult{err: fmt.Errorf("page %d: %w", row.ID, err)}
+	}
+	page := &pipeline.Page{
+		ID:       row.ID,
+		TargetID: row.TargetID,
+		URLPath:  row.UrlPath,
+		URL:      row.FullUrl,
+		HTML:     body,
+	}
+	status, err := pipeline.ParseStatus(row.PipelineStatusJson.String)
+	if err != nil {
+		fmt.Printf("⚠️  Page %d: %v, starting from an empty status\n", row.ID, err)
+		status = pipeline.Status{}
+	}
+	page.Status = status
+	if row.QuoteClassifierJson.Valid {
+		var decision classifier.QuoteClassifierDecision
+		if err := json.Unmarshal([]byte(row.QuoteClassifierJson.String), &decision); err == nil {
+			page.Classification = &decision
+		}
+	}
+
+	if len(stages) == 0 {
+		stages = p.Stages()
+	}
+	status, err = p.Run(ctx, page, stages...)
+	if err != nil {
+		err = fmt.Errorf("page %d: %w", row.ID, err)
+	}
+	return reprocessResult{status: status, stages: stages, err: err}
+}
+
+func (s *ReprocessSummary) add(res reprocessResult) {
+	s.Total++
+	if res.err != nil {
+		s.Errors++
+		fmt.Printf("❌ %v\n", res.err)
+	}
+	for _, stage := range res.stages {
+		result, ok := res.status[stage]
+		if !ok {
+			continue
+		}
+		if s.Stages[stage] == nil {
+			s.Stages[stage] = map[string]int{}
+		}
+		s.Stages[stage][result.Status]++
+	}
+}
+
+// Print writes the per-stage outcome counts in pipeline order
+func (s *ReprocessSummary) Print() {
+	fmt.Printf("\n📊 Pipeline Summary\n")
+	fmt.Printf("Pages processed: %d\n", s.Total)
+	fmt.Printf("Errors: %d\n", s.Errors)
+	fmt.Printf("Duration: %s\n", s.Duration.Round(time.Millisecond))
+	fmt.Printf("\n%-20s %8s %8s %8s\n", "STAGE", "OK", "SKIPPED", "FAILED")
+	for _, stage := range pipeline.DefaultStages() {
+		counts, ok := s.Stages[stage]
+		if !ok {
+			continue
+		}
+		fmt.Printf("%-20s %8d %8d %8d\n", stage, counts[pipeline.StatusOK], counts[pipeline.StatusSkipped], counts[pipeline.StatusFailed])
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/pipeline"
+	"app/internal/scraper/storage"
+)
+
+func TestReprocessor_RerunStage(t *testing.T) {
+	dbConn, queries := newReclassifyTestDB(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	insertClassifiedPage(t, dbConn, target.ID, "/quotes", reclassifyQuotesPage(), classifier.DecisionShortMainText, false)
+
+	r := &Reprocessor{store: storage.NewSQLite(dbConn), workers: 2}
+	summary, err := r.Run(ctx, ReprocessOptions{Stages: []string{pipeline.StageClassification, pipeline.StageQuoteExtraction}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if summary.Total != 1 || summary.Stages[pipeline.StageQuoteExtraction][pipeline.StatusOK] != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if _, ok := summary.Stages[pipeline.StageLinkDiscovery]; ok {
+		t.Error("unselected stages must not run")
+	}
+
+	var statusJSON, quotesJSON sql.NullString
+	var processable sql.NullBool
+	err = dbConn.QueryRow(`SELECT pipeline_status_json, quotes_json, processable FROM scraper_pages WHERE url_path = '/quotes'`).Scan(&statusJSON, &quotesJSON, &processable)
+	if err != nil {
+		t.Fatalf("failed to read page: %v", err)
+	}
+	status, err := pipeline.ParseStatus(statusJSON.String)
+	if err != nil || status[pipeline.StageClassification].Status != pipeline.StatusOK {
+		t.Errorf("expected classification status stored, got %q", statusJSON.String)
+	}
+	if !processable.Bool || !strings.Contains(quotesJSON.String, `"author":"Author A"`) {
+		t.Errorf("expected page reclassified and quotes stored, got processable=%v quotes=%q", processable.Bool, quotesJSON.String)
+	}
+
+	// Only pages whose stage failed are selected with FailedStage
+	summary, err = r.Run(ctx, ReprocessOptions{Stages: []string{pipeline.StageQuoteExtraction}, FailedStage: pipeline.StageQuoteExtraction})
+	if err != nil || summary.Total != 0 {
+		t.Errorf("expected no failed pages, got %+v %v", summary, err)
+	}
+}
+
+func TestReprocessor_UnknownStage(t *testing.T) {
+	dbConn, _ := newReclassifyTestDB(t)
+	r := &Reprocessor{store: storage.NewSQLite(dbConn), workers: 1}
+	if _, err := r.Run(context.Background(), ReprocessOptions{Stages: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown stage")
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/logger"
+)
+
+// What started a run, stored in scraper_runs.trigger_source
+const (
+	TriggerCLI      = "cli"
+	TriggerUI       = "ui"
+	TriggerSchedule = "schedule"
+)
+
+// Run statuses stored in scraper_runs.status
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// SetTrigger sets what starts the runs, TriggerCLI by default
+func (sr *ScraperRunner) SetTrigger(trigger string) {
+	sr.trigger = trigger
+}
+
+// startRun records the start of a run and returns its ID, 0 when it couldn't
+// be recorded: the crawl doesn't depend on its history
+func (sr *ScraperRunner) startRun(ctx context.Context, targets []db.ScraperTarget, dryRun bool) int64 {
+	ids := make([]int64, len(targets))
+	for i, t := range targets {
+		ids[i] = t.ID
+	}
+	targetIDs, _ := json.Marshal(ids)
+	trigger := sr.trigger
+	if trigger == "" {
+		trigger = TriggerCLI
+	}
+	run, err := sr.queries.CreateRun(ctx, db.CreateRunParams{TriggerSource: trigger, DryRun: dryRun, TargetIds: string(targetIDs)})
+	if err != nil {
+		sr.log.WarnContext(ctx, "Failed to record run", logger.Err(err))
+		return 0
+	}
+	fmt.Printf("🏷️  Run #%d\n", run.ID)
+	return run.ID
+}
+
+// finishRun records the outcome of a run, err is what RunContext returns
+func (sr *ScraperRunner) finishRun(ctx context.Context, stats *RunStats, err error) {
+	params := db.FinishRunParams{
+		ID:        stats.RunID,
+		Status:    RunCompleted,
+		TotalUrls: int64(stats.TotalURLs),
+		Processed: int64(stats.Processed),
+		Errors:    int64(stats.Errors),
+		Skipped:   int64(stats.Skipped),
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		params.Status = RunCancelled
+	case err != nil:
+		params.Status = RunFailed
+		params.ErrorMessage = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if len(stats.ErrorTypes) > 0 {
+		breakdown, _ := json.Marshal(stats.ErrorTypes)
+		params.ErrorBreakdown = sql.NullString{String: string(breakdown), Valid: true}
+	}
+	// Recorded even when the run was cancelled
+	ctx = context.WithoutCancel(ctx)
+	if err := sr.queries.FinishRun(ctx, params); err != nil {
+		sr.log.WarnContext(ctx, "Failed to record the end of the run", logger.Err(err))
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// RunManager shows the history of crawl runs
+type RunManager struct {
+	store   storage.Store
+	queries db.Querier
+}
+
+func NewRunManager(cfg *config.Config) (*RunManager, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RunManager{store: store, queries: store.Queries()}, nil
+}
+
+func (rm *RunManager) Close() error {
+	return rm.store.Close()
+}
+
+// List prints the latest runs, newest first
+func (rm *RunManager) List(limit int) error {
+	runs, err := rm.queries.ListRuns(context.Background(), int64(limit))
+	if err != nil {
+		return fmt.Errorf("failed to list runs: %w", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded yet.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tSTARTED\tDURATION\tTRIGGER\tSTATUS\tTARGETS\tURLS\tPROCESSED\tERRORS\tSKIPPED")
+	for _, run := range runs {
+		status := run.Status
+		if run.DryRun {
+			status += " (dry run)"
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n", run.ID,
+			run.StartedAt.Time.Format("2006-01-02 15:04"), runDuration(run), run.TriggerSource, status,
+			run.TargetIds, run.TotalUrls, run.Processed, run.Errors, run.Skipped)
+	}
+	return w.Flush()
+}
+
+// Show prints a run with its error breakdown and the queue items and logs linked to it
+func (rm *RunManager) Show(id int64) error {
+	ctx := context.Background()
+	run, err := rm.queries.GetRun(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("run %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get run: %w", err)
+	}
+
+	fmt.Printf("Run ID: %d\n", run.ID)
+	fmt.Printf("Status: %s\n", run.Status)
+	fmt.Printf("Trigger: %s\n", run.TriggerSource)
+	fmt.Printf("Dry Run: %t\n", run.DryRun)
+	fmt.Printf("Targets: %s\n", run.TargetIds)
+	fmt.Printf("Started: %s\n", run.StartedAt.Time.Format(time.RFC3339))
+	if run.FinishedAt.Valid {
+		fmt.Printf("Finished: %s\n", run.FinishedAt.Time.Format(time.RFC3339))
+	}
+	fmt.Printf("Duration: %s\n", runDuration(run))
+	fmt.Printf("URLs: %d (%d processed, %d errors, %d skipped)\n", run.TotalUrls, run.Processed, run.Errors, run.Skipped)
+	if run.ErrorMessage.Valid {
+		fmt.Printf("Error: %s\n", run.ErrorMessage.String)
+	}
+
+	if breakdown := errorBreakdown(run); len(breakdown) > 0 {
+		fmt.Println("\nErrors by type:")
+		for _, e := range breakdown {
+			fmt.Printf("  %-20s %d\n", e.Type, e.Count)
+		}
+	}
+
+	items, err := rm.queries.CountRunQueueItems(ctx, sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to count queue items: %w", err)
+	}
+	if len(items) > 0 {
+		fmt.Println("\nQueue items by status:")
+		for _, c := range items {
+			fmt.Printf("  %-20s %d\n", c.Status, c.Count)
+		}
+	}
+	logs, err := rm.queries.CountRunLogs(ctx, sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to count logs: %w", err)
+	}
+	if len(logs) > 0 {
+		fmt.Println("\nLogs by level:")
+		for _, c := range logs {
+			fmt.Printf("  %-20s %d\n", c.LogType, c.Count)
+		}
+	}
+	return nil
+}
+
+// runDuration formats how long a run took, "running" until it finishes
+func runDuration(run db.ScraperRun) string {
+	if !run.FinishedAt.Valid {
+		return RunRunning
+	}
+	return run.FinishedAt.Time.Sub(run.StartedAt.Time).Round(time.Second).String()
+}
+
+// errorCount is the number of failed URLs of an error type
+type errorCount struct {
+	Type  string
+	Count int
+}
+
+// errorBreakdown returns the error types of a run, most frequent first
+func errorBreakdown(run db.ScraperRun) []errorCount {
+	var types map[string]int
+	if err := json.Unmarshal([]byte(run.ErrorBreakdown.String), &types); err != nil {
+		return nil
+	}
+	breakdown := make([]errorCount, 0, len(types))
+	for t, n := range types {
+		breakdown = append(breakdown, errorCount{Type: t, Count: n})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Count != breakdown[j].Count {
+			return breakdown[i].Count > breakdown[j].Count
+		}
+		return breakdown[i].Type < breakdown[j].Type
+	})
+	return breakdown
+}
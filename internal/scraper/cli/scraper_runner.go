@@ -3,20 +3,26 @@ package cli
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"app/internal/scraper/config"
 	"app/internal/scraper/db"
+	"app/internal/scraper/metrics"
 	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/pipeline"
 	"app/internal/scraper/service/sitemap"
-
-	"github.com/cespare/xxhash/v2"
-	_ "github.com/mattn/go-sqlite3"
+	"app/internal/scraper/service/warc"
+	"app/internal/scraper/storage"
 )
 
 // ScraperQueries defines the subset of db.Queries methods used by ScraperRunner
@@ -26,13 +32,24 @@ type ScraperQueries interface {
 	ListActiveTargets(ctx context.Context) ([]db.ScraperTarget, error)
 	GetQueueStats(ctx context.Context) (db.GetQueueStatsRow, error)
 	WithTx(tx *sql.Tx) ScraperQueries // match db.Queries signature for compatibility
-	DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error)
+	DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error)
 	FailQueueItem(ctx context.Context, params db.FailQueueItemParams) error
 	CompleteQueueItem(ctx context.Context, id int64) error
+	SkipQueueItem(ctx context.Context, id int64) error
 	GetPageByPath(ctx context.Context, params db.GetPageByPathParams) (db.ScraperPage, error)
 	SavePage(ctx context.Context, params db.SavePageParams) (db.ScraperPage, error)
+	MarkPageVisited(ctx context.Context, params db.MarkPageVisitedParams) error
 	EnqueueURL(ctx context.Context, params db.EnqueueURLParams) (db.ScraperQueue, error)
-	SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, targetID int64, url string) error // <-- Added missing method
+	SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, language string, targetID int64, url string) error // <-- Added missing method
+	UpdateTargetLearnedTemplate(ctx context.Context, templateJSON string, targetID int64) error
+	GetConfig(ctx context.Context, key string) (string, error)
+	SavePageQuotes(ctx context.Context, quotesJSON string, pageID int64) error
+	SavePagePipelineStatus(ctx context.Context, statusJSON string, pageID int64) error
+	EnqueueDiscoveredURL(ctx context.Context, targetID int64, url string, priority int64) (bool, error)
+	PutContent(ctx context.Context, arg db.PutContentParams) error
+	GetContent(ctx context.Context, contentHash string) (db.GetContentRow, error)
+	CreateRun(ctx context.Context, arg db.CreateRunParams) (db.ScraperRun, error)
+	FinishRun(ctx context.Context, arg db.FinishRunParams) error
 }
 
 // SitemapParser defines the interface for sitemap parsing
@@ -53,6 +70,28 @@ type ScraperRunner struct {
 	rateLimiter *RateLimiter
 	// For testability: allows injection of batch enqueuer
 	enqueueBatchFunc func(ctx context.Context, targetID int64, urls []string) (int, error)
+	// Global classifier profile JSON from scraper_config, empty selects the built-in default
+	profileJSON string
+	// Post-processing of saved pages, created on first use
+	pipeline *pipeline.Pipeline
+	// The store of the pipeline, holding the learned templates not saved yet
+	stageStore *pipelineStore
+	// Compressed, deduplicated page bodies keyed by their SHA-256
+	contents content.Store
+	// Sent for targets without their own user agent
+	userAgent string
+	// Largest response body read, 0 reads any size
+	maxPageSize int64
+	// Delay between requests to targets without requests_per_second
+	crawlDelay time.Duration
+	// Archives every fetch when warc_dir is set, nil otherwise
+	warc *warc.Writer
+	// Fetcher diagnostics, warnings also go to scraper_logs
+	log *slog.Logger
+	// Buffers the scraper_logs rows of the runner, nil when they're written directly
+	logs *logger.BatchWriter
+	// What started the runs, recorded in scraper_runs: TriggerCLI by default
+	trigger string
 }
 
 type RunStats struct {
@@ -61,14 +100,37 @@ type RunStats struct {
 	Errors    int
 	Skipped   int
 	StartTime time.Time
+	// RunID is the scraper_runs row of the run, 0 when it couldn't be recorded
+	RunID int64
+	// ErrorTypes counts the failed fetches by classifyError type
+	ErrorTypes map[string]int
 }
 
+// Outcome is how the crawl of a queue item ended
+type Outcome string
+
+const (
+	// OutcomeFetched pages were saved and go through the pipeline
+	OutcomeFetched Outcome = "fetched"
+	// OutcomeUnchanged pages were fetched with the content already stored,
+	// only the visit is recorded
+	OutcomeUnchanged Outcome = "unchanged"
+	// OutcomeSkipped pages have a sitemap lastmod not after their last visit
+	// and were not fetched
+	OutcomeSkipped Outcome = "skipped"
+	// OutcomeFailed pages have the Error
+	OutcomeFailed Outcome = "failed"
+)
+
 type ScrapedPage struct {
+	PageID       int64 // scraper_pages id, set once the page is saved
+	TargetID     int64
 	URL          string
 	Content      string
 	ContentHash  string
 	StatusCode   int
 	ResponseTime time.Duration
+	Outcome      Outcome
 	Error        error
 }
 
@@ -86,42 +148,82 @@ type PageToProcess struct {
 	LastMod  *time.Time
 }
 
-func NewScraperRunner(workers, batchSize int) (*ScraperRunner, error) {
-	// Initialize database connection
-	database, err := sql.Open("sqlite3", "data/scraper.db?_journal_mode=WAL")
+func NewScraperRunner(cfg *config.Config) (*ScraperRunner, error) {
+	// Initialize the configured storage backend, migrated to the current schema
+	store, err := OpenStore(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	queries := &dbQueriesAdapter{q: db.New(database)}
+	// Log rows of the parser and fetcher are written in batches, pruned by the retention settings
+	logs := logger.NewBatchWriter(store, logger.BatchOptions{
+		Retention: logger.Retention{MaxAge: cfg.LogRetention(), MaxRows: cfg.LogMaxRows()},
+	})
+	queries := newQueriesAdapter(store)
+	queries.logs = logs
 
 	// Create sitemap parser with database access
-	parser := sitemap.NewParser(queries, 30*time.Second)
+	parser := sitemap.NewParser(queries, cfg.RequestTimeout(), cfg.UserAgent())
 
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout: cfg.RequestTimeout(),
+	}
+
+	var archive *warc.Writer
+	if dir := cfg.WARCDir(); dir != "" {
+		if archive, err = warc.NewWriter(dir, "scraper", cfg.WARCMaxSize()); err != nil {
+			_ = logs.Close()
+			_ = store.Close()
+			return nil, err
+		}
 	}
 
 	return &ScraperRunner{
-		db:          database,
-		queries:     queries, // Wrap db.Queries with dbQueriesAdapter
+		db:          store.DB(),
+		queries:     queries, // Wrap the store queries with dbQueriesAdapter
 		parser:      parser,
-		workers:     workers,
-		batchSize:   batchSize,
+		workers:     cfg.Workers(),
+		batchSize:   cfg.BatchSize(),
+		userAgent:   cfg.UserAgent(),
+		maxPageSize: cfg.MaxPageSize(),
+		crawlDelay:  cfg.CrawlDelay(),
 		httpClient:  httpClient,
 		maxRetries:  3,               // Default to 3 retries
 		retryDelay:  2 * time.Second, // Default to 2 second delay between retries
 		rateLimiter: NewRateLimiter(),
+		contents:    content.NewDBStore(queries),
+		warc:        archive,
+		log:         logger.New(logger.ComponentFetcher, queries),
+		logs:        logs,
+		trigger:     TriggerCLI,
 	}, nil
 }
 
 func (sr *ScraperRunner) Close() error {
+	if sr.warc != nil {
+		if err := sr.warc.Close(); err != nil {
+			sr.log.Warn("Failed to close WARC archive", logger.Err(err))
+		}
+	}
+	if sr.logs != nil {
+		_ = sr.logs.Close()
+		if n := sr.logs.Dropped(); n > 0 {
+			// Not to the closed writer
+			logger.New(logger.ComponentFetcher, nil).Warn("Log rows were dropped, the database couldn't keep up", "rows", n)
+		}
+	}
 	return sr.db.Close()
 }
 
 func (sr *ScraperRunner) Run(targetID int64, showProgress, verbose, dryRun bool) error {
-	ctx := context.Background()
+	return sr.RunContext(context.Background(), targetID, showProgress, verbose, dryRun)
+}
+
+// RunContext is Run stopping early when ctx is cancelled: workers finish the
+// page in flight, record its queue status and leave the rest pending. The run
+// is recorded in scraper_runs once its targets are known.
+func (sr *ScraperRunner) RunContext(ctx context.Context, targetID int64, showProgress, verbose, dryRun bool) (err error) {
 	stats := &RunStats{
 		StartTime: time.Now(),
 	}
@@ -131,6 +233,10 @@ func (sr *ScraperRunner) Run(targetID int64, showProgress, verbose, dryRun bool)
 		fmt.Printf("🧪 DRY RUN MODE - No actual crawling will be performed\n")
 	}
 
+	if err := sr.loadClassifierProfile(ctx); err != nil {
+		return err
+	}
+
 	// Get targets to process
 	var targets []db.ScraperTarget
 
@@ -157,6 +263,11 @@ func (sr *ScraperRunner) Run(targetID int64, showProgress, verbose, dryRun bool)
 		return nil
 	}
 
+	if stats.RunID = sr.startRun(ctx, targets, dryRun); stats.RunID != 0 {
+		ctx = logger.WithRun(ctx, stats.RunID)
+		defer func() { sr.finishRun(ctx, stats, err) }()
+	}
+
 	// Phase 1: Parse sitemaps and populate queue (if targets have sitemaps)
 	newURLs := 0
 	for i, target := range targets {
@@ -191,6 +302,7 @@ func (sr *ScraperRunner) Run(targetID int64, showProgress, verbose, dryRun bool)
 	fmt.Printf("  - Processing: %d\n", queueStats.Processing)
 	fmt.Printf("  - Completed: %d\n", queueStats.Completed)
 	fmt.Printf("  - Failed: %d\n", queueStats.Failed)
+	fmt.Printf("  - Skipped: %d\n", queueStats.Skipped)
 
 	if totalPending == 0 {
 		fmt.Printf("\nℹ️  No pending URLs found in queue.\n")
@@ -215,7 +327,7 @@ func (sr *ScraperRunner) Run(targetID int64, showProgress, verbose, dryRun bool)
 
 	// Print final summary
 	sr.printSummary(stats)
-	return nil
+	return ctx.Err()
 }
 
 // parseAndQueueURLs parses sitemap and adds URLs to the queue
@@ -313,7 +425,7 @@ func (sr *ScraperRunner) enqueueBatch(ctx context.Context, targetID int64, urls
 		})
 		if err != nil {
 			// Log error but continue with other URLs
-			fmt.Printf("⚠️  Failed to queue URL %s: %v\n", url, err)
+			sr.log.WarnContext(ctx, "Failed to queue URL", logger.Target(targetID), logger.URL(url), logger.Err(err))
 			continue
 		}
 		queued++
@@ -337,7 +449,7 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 	}
 	for _, target := range targets {
 		if target.SitemapUrl.Valid && target.SitemapUrl.String != "" {
-			ctx2 := context.Background()
+			ctx2 := context.WithoutCancel(ctx) // Keeps the run of the parser logs
 			result, err := sr.parser.ParseSitemapForTarget(ctx2, target.ID)
 			if err == nil {
 				for _, url := range result.URLs {
@@ -353,6 +465,11 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 		reporter = NewProgressReporter(stats.TotalURLs, verbose)
 	}
 
+	pagePipeline := sr.pagePipeline()
+	if sr.contents == nil {
+		sr.contents = content.NewDBStore(sr.queries)
+	}
+
 	// Create channels for worker communication
 	resultChan := make(chan ScrapedPage, sr.batchSize)
 
@@ -368,14 +485,17 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 					return
 				default:
 				}
-				queueItem, err := sr.queries.DequeuePendingURL(ctx)
+				queueItem, err := sr.queries.DequeuePendingURL(ctx, sql.NullInt64{Int64: stats.RunID, Valid: stats.RunID != 0})
 				if err != nil {
-					if err == sql.ErrNoRows {
+					if err == sql.ErrNoRows || ctx.Err() != nil {
 						break
 					}
-					resultChan <- ScrapedPage{Error: fmt.Errorf("failed to dequeue URL: %w", err)}
+					resultChan <- ScrapedPage{Outcome: OutcomeFailed, Error: fmt.Errorf("failed to dequeue URL: %w", err)}
 					continue
 				}
+				if queueItem.Attempts.Int64 > 0 {
+					metrics.FetchRetries.Inc(metrics.Target(queueItem.TargetID))
+				}
 				lastMod := lastModMap[queueItem.Url]
 				pageToProcess := PageToProcess{
 					TargetID: queueItem.TargetID,
@@ -385,25 +505,28 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 				page := sr.scrapeURLAttempt(ctx, pageToProcess, lastMod)
 				resultChan <- page
 
-				// --- Quote Page Classifier Integration ---
-				classifier := classifier.NewQuotePageClassifierService()
-				classifierResult, err := classifier.ClassifyPage(pageToProcess.URL, page.Content) // <-- Use page.Content instead of page.HtmlContent
-				if err == nil && classifierResult != nil {
-					jsonStr, _ := json.Marshal(classifierResult)
-					_ = sr.queries.SavePageClassifier(ctx, string(jsonStr), classifierResult.Decision.Processable, queueItem.TargetID, queueItem.Url)
+				// Only pages saved by this attempt are post-processed, failed and skipped fetches keep their previous results
+				if page.Outcome == OutcomeFetched {
+					sr.processPage(ctx, pagePipeline, page)
 				}
-				// --- End Integration ---
 
-				if page.Error != nil {
-					if err := sr.queries.FailQueueItem(ctx, db.FailQueueItemParams{
+				// The queue status is recorded even when the run was cancelled mid-fetch
+				statusCtx := context.WithoutCancel(ctx)
+				switch page.Outcome {
+				case OutcomeFetched:
+					if err := sr.queries.CompleteQueueItem(statusCtx, queueItem.ID); err != nil {
+						sr.log.ErrorContext(ctx, "Failed to mark queue item as complete", logger.Target(queueItem.TargetID), logger.URL(queueItem.Url), logger.Err(err))
+					}
+				case OutcomeUnchanged, OutcomeSkipped:
+					if err := sr.queries.SkipQueueItem(statusCtx, queueItem.ID); err != nil {
+						sr.log.ErrorContext(ctx, "Failed to mark queue item as skipped", logger.Target(queueItem.TargetID), logger.URL(queueItem.Url), logger.Err(err))
+					}
+				default:
+					if err := sr.queries.FailQueueItem(statusCtx, db.FailQueueItemParams{
 						ID:           queueItem.ID,
 						ErrorMessage: sql.NullString{String: page.Error.Error(), Valid: true},
 					}); err != nil {
-						fmt.Printf("failed to mark queue item as failed: %v\n", err)
-					}
-				} else {
-					if err := sr.queries.CompleteQueueItem(ctx, queueItem.ID); err != nil {
-						fmt.Printf("failed to mark queue item as complete: %v\n", err)
+						sr.log.ErrorContext(ctx, "Failed to mark queue item as failed", logger.Target(queueItem.TargetID), logger.URL(queueItem.Url), logger.Err(err))
 					}
 				}
 			}
@@ -421,6 +544,11 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 	// Wait for result collector to finish
 	<-done
 
+	// Templates are saved as they change, this keeps the progress of the others
+	if err := sr.stageStore.SaveTemplates(context.WithoutCancel(ctx)); err != nil {
+		sr.log.ErrorContext(ctx, "Failed to save learned templates", logger.Err(err))
+	}
+
 	if reporter != nil {
 		reporter.Finish()
 	}
@@ -428,12 +556,61 @@ func (sr *ScraperRunner) processQueueWithWorkers(ctx context.Context, stats *Run
 	return nil
 }
 
-// scrapeURLAttempt performs a single scraping attempt
-func (sr *ScraperRunner) scrapeURLAttempt(ctx context.Context, pageToProcess PageToProcess, lastMod *time.Time) ScrapedPage {
+// loadClassifierProfile reads and validates the global classifier profile, a missing key selects the built-in default
+func (sr *ScraperRunner) loadClassifierProfile(ctx context.Context) error {
+	profileJSON, err := sr.queries.GetConfig(ctx, classifier.ProfileConfigKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to load classifier profile: %w", err)
+	}
+	profile, err := classifier.ResolveProfile(profileJSON, "")
+	if err != nil {
+		return fmt.Errorf("invalid classifier profile in config key %q: %w", classifier.ProfileConfigKey, err)
+	}
+	sr.profileJSON = profileJSON
+	fmt.Printf("🧭 Classifier profile: %s\n", profile.Name)
+	return nil
+}
+
+// pagePipeline returns the post-processing pipeline, persisting through the runner's queries
+func (sr *ScraperRunner) pagePipeline() *pipeline.Pipeline {
+	if sr.pipeline == nil {
+		sr.stageStore = newPipelineStore(sr.queries, sr.profileJSON)
+		sr.pipeline = pipeline.NewDefault(sr.stageStore)
+	}
+	return sr.pipeline
+}
+
+// processPage runs the pipeline on a freshly saved page and reports failed stages,
+// their errors are recorded on the page
+func (sr *ScraperRunner) processPage(ctx context.Context, p *pipeline.Pipeline, page ScrapedPage) {
+	status, err := p.Run(ctx, &pipeline.Page{
+		ID:       page.PageID,
+		TargetID: page.TargetID,
+		URLPath:  page.URL,
+		URL:      page.URL,
+		HTML:     page.Content,
+	})
+	if err != nil {
+		sr.log.WarnContext(ctx, "Failed to record pipeline status", logger.Target(page.TargetID), logger.URL(page.URL), logger.Err(err))
+	}
+	for _, stage := range status.Failed() {
+		sr.log.WarnContext(ctx, "Pipeline stage failed", logger.Target(page.TargetID), logger.URL(page.URL), "stage", stage, "error", status[stage].Error)
+	}
+}
+
+// scrapeURLAttempt performs a single scraping attempt, pages with an Error
+// end OutcomeFailed
+func (sr *ScraperRunner) scrapeURLAttempt(ctx context.Context, pageToProcess PageToProcess, lastMod *time.Time) (page ScrapedPage) {
 	startTime := time.Now()
-	page := ScrapedPage{
-		URL: pageToProcess.URL,
+	page = ScrapedPage{
+		TargetID: pageToProcess.TargetID,
+		URL:      pageToProcess.URL,
 	}
+	defer func() {
+		if page.Error != nil {
+			page.Outcome = OutcomeFailed
+		}
+	}()
 
 	// Get target details for user agent
 	target, err := sr.queries.GetTarget(ctx, pageToProcess.TargetID)
@@ -442,10 +619,36 @@ func (sr *ScraperRunner) scrapeURLAttempt(ctx context.Context, pageToProcess Pag
 		return page
 	}
 
+	// Fetch page record from DB
+	log := sr.log.With(logger.Target(pageToProcess.TargetID), logger.URL(pageToProcess.URL))
+	pageRecord, err := sr.queries.GetPageByPath(ctx, db.GetPageByPathParams{
+		TargetID: pageToProcess.TargetID,
+		UrlPath:  pageToProcess.URL,
+	})
+	var lastVisitedAt, lastUpdatedAt time.Time
+	var storedHash string
+	if err == nil {
+		lastVisitedAt = pageRecord.LastVisitedAt.Time
+		lastUpdatedAt = pageRecord.LastUpdatedAt.Time
+		storedHash = pageRecord.ContentHash.String
+		log.DebugContext(ctx, "Found page record", "page_id", pageRecord.ID,
+			"last_visited_at", lastVisitedAt, "last_updated_at", lastUpdatedAt, "stored_hash", storedHash)
+	} else {
+		log.DebugContext(ctx, "No page record", logger.Err(err))
+	}
+	// Only a sitemap lastmod tells a page unchanged before fetching, a page not
+	// modified since the last visit isn't fetched again. Without one the
+	// content hash decides after the fetch
+	if lastMod != nil && !lastVisitedAt.IsZero() && !lastMod.After(lastVisitedAt) {
+		log.DebugContext(ctx, "Skipping page not modified since its last visit", "lastmod", *lastMod)
+		page.Outcome = OutcomeSkipped
+		return page
+	}
+
 	// Set user agent
 	userAgent := target.UserAgent.String
 	if userAgent == "" {
-		userAgent = "ScraperBot/1.0"
+		userAgent = sr.userAgent
 	}
 
 	// Create HTTP request
@@ -457,93 +660,102 @@ func (sr *ScraperRunner) scrapeURLAttempt(ctx context.Context, pageToProcess Pag
 	req.Header.Set("User-Agent", userAgent)
 
 	// Rate limiting
-	rate := 1.0
+	targetLabel := metrics.Target(pageToProcess.TargetID)
+	waitStart := time.Now()
 	if target.RequestsPerSecond.Valid && target.RequestsPerSecond.Float64 > 0 {
-		rate = target.RequestsPerSecond.Float64
+		sr.rateLimiter.WaitN(ctx, pageToProcess.TargetID, target.RequestsPerSecond.Float64)
+	} else {
+		sr.rateLimiter.Wait(pageToProcess.TargetID, sr.crawlDelay)
 	}
-	sr.rateLimiter.WaitN(ctx, pageToProcess.TargetID, rate)
+	metrics.RateLimitWait.Add(metrics.Since(waitStart), targetLabel)
 
 	// Make HTTP request
+	fetchStart := time.Now()
 	resp, err := sr.httpClient.Do(req)
 	if err != nil {
+		metrics.FetchRequests.Inc(targetLabel, "error")
 		page.Error = fmt.Errorf("HTTP request failed: %w", err)
 		return page
 	}
+	metrics.FetchRequests.Inc(targetLabel, strconv.Itoa(resp.StatusCode))
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("failed to close response body: %v\n", err)
+			sr.log.WarnContext(ctx, "Failed to close response body", logger.Target(pageToProcess.TargetID), logger.URL(pageToProcess.URL), logger.Err(err))
 		}
 	}()
 
 	page.StatusCode = resp.StatusCode
 	page.ResponseTime = time.Since(startTime)
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Read response body, one byte past the limit tells an oversized page apart
+	var reader io.Reader = resp.Body
+	if sr.maxPageSize > 0 {
+		reader = io.LimitReader(resp.Body, sr.maxPageSize+1)
+	}
+	body, err := io.ReadAll(reader)
+	metrics.FetchDuration.Observe(metrics.Since(fetchStart), targetLabel)
+	metrics.FetchBytes.Add(float64(len(body)), targetLabel)
 	if err != nil {
 		page.Error = fmt.Errorf("failed to read response body: %w", err)
 		return page
 	}
-
-	page.Content = string(body)
-
-	// Use xxhash for content hash
-	hash := xxhash.Sum64(body)
-	page.ContentHash = fmt.Sprintf("%x", hash)
-
-	// Fetch page record from DB
-	fmt.Printf("[DEBUG] Checking for existing page record: TargetID=%d, UrlPath=%s\n", pageToProcess.TargetID, pageToProcess.URL)
-	pageRecord, err := sr.queries.GetPageByPath(ctx, db.GetPageByPathParams{
-		TargetID: pageToProcess.TargetID,
-		UrlPath:  pageToProcess.URL,
-	})
-	fmt.Printf("[DEBUG] GetPageByPath err: %v, pageRecord: %+v\n", err, pageRecord)
-	var lastVisitedAt, lastUpdatedAt time.Time
-	var storedHash string
-	if err == nil {
-		lastVisitedAt = pageRecord.LastVisitedAt.Time
-		lastUpdatedAt = pageRecord.LastUpdatedAt.Time
-		storedHash = pageRecord.ContentHash.String
-		fmt.Printf("[DEBUG] lastVisitedAt: %v, lastUpdatedAt: %v, storedHash: %s\n", lastVisitedAt, lastUpdatedAt, storedHash)
-	}
-
-	// If lastVisitedAt > lastUpdatedAt, skip
-	if !lastVisitedAt.IsZero() && !lastUpdatedAt.IsZero() && lastVisitedAt.After(lastUpdatedAt) {
-		fmt.Printf("[DEBUG] Skipping: lastVisitedAt > lastUpdatedAt\n")
-		page.Error = fmt.Errorf("skipped: already processed after last update")
+	if sr.maxPageSize > 0 && int64(len(body)) > sr.maxPageSize {
+		page.Error = fmt.Errorf("page larger than %s of %d MB", config.KeyMaxPageSize, sr.maxPageSize>>20)
 		return page
 	}
+	if sr.warc != nil {
+		if err := sr.warc.WriteExchange(req, resp, body, startTime); err != nil {
+			sr.log.WarnContext(ctx, "Failed to archive page", logger.Target(pageToProcess.TargetID), logger.URL(pageToProcess.URL), logger.Err(err))
+		}
+	}
+
+	page.Content = string(body)
 
-	// If lastVisitedAt < lastUpdatedAt, check hash
-	if !lastVisitedAt.IsZero() && !lastUpdatedAt.IsZero() && lastVisitedAt.Before(lastUpdatedAt) {
-		fmt.Printf("[DEBUG] lastVisitedAt < lastUpdatedAt, storedHash: %s, newHash: %s\n", storedHash, page.ContentHash)
-		if storedHash == page.ContentHash {
-			fmt.Printf("[DEBUG] Skipping: hash matches, no update needed\n")
-			page.Error = fmt.Errorf("skipped: hash matches, no update needed")
+	page.ContentHash = content.Hash(body)
+
+	// Content identical to the stored page only records the visit
+	if storedHash != "" && storedHash == page.ContentHash {
+		log.DebugContext(ctx, "Page content unchanged", "hash", storedHash)
+		if err := sr.queries.MarkPageVisited(ctx, db.MarkPageVisitedParams{
+			ID:             pageRecord.ID,
+			HttpStatusCode: sql.NullInt64{Int64: int64(page.StatusCode), Valid: true},
+			ResponseTimeMs: sql.NullInt64{Int64: page.ResponseTime.Milliseconds(), Valid: true},
+		}); err != nil {
+			page.Error = fmt.Errorf("failed to record visit: %w", err)
 			return page
 		}
+		page.PageID = pageRecord.ID
+		page.Outcome = OutcomeUnchanged
+		return page
 	}
 
-	fmt.Printf("[DEBUG] Saving page: TargetID=%d, UrlPath=%s\n", pageToProcess.TargetID, pageToProcess.URL)
+	// The body goes to the content store, identical bodies of other URLs are stored once
+	key, err := sr.contents.Put(ctx, body)
+	if err != nil {
+		page.Error = err
+		return page
+	}
 	// Save page with last_updated_at from sitemap if available
-	_, err = sr.queries.SavePage(ctx, db.SavePageParams{
+	saved, err := sr.queries.SavePage(ctx, db.SavePageParams{
 		TargetID:       pageToProcess.TargetID,
 		UrlPath:        pageToProcess.URL, // adjust if needed
 		FullUrl:        pageToProcess.URL,
-		HtmlContent:    sql.NullString{String: page.Content, Valid: true},
+		HtmlContent:    sql.NullString{},
 		ContentHash:    sql.NullString{String: page.ContentHash, Valid: true},
 		HttpStatusCode: sql.NullInt64{Int64: int64(page.StatusCode), Valid: true},
 		ResponseTimeMs: sql.NullInt64{Int64: page.ResponseTime.Milliseconds(), Valid: true},
 		ContentLength:  sql.NullInt64{Int64: int64(len(page.Content)), Valid: true},
 		LastUpdatedAt:  sql.NullTime{Time: lastModOrNow(lastMod), Valid: true},
+		ContentKey:     sql.NullString{String: key, Valid: true},
 	})
 	if err != nil {
-		fmt.Printf("[DEBUG] Failed to save page: %v\n", err)
 		page.Error = fmt.Errorf("failed to save page: %w", err)
 		return page
 	}
 
-	fmt.Printf("[DEBUG] Page saved successfully: %s\n", pageToProcess.URL)
+	log.DebugContext(ctx, "Saved page", "page_id", saved.ID, "status", page.StatusCode, "bytes", len(body))
+	page.PageID = saved.ID
+	page.Outcome = OutcomeFetched
 	return page
 }
 
@@ -560,18 +772,28 @@ func (sr *ScraperRunner) resultCollector(resultChan <-chan ScrapedPage, stats *R
 	defer func() { done <- true }()
 
 	for page := range resultChan {
-		if page.Error != nil {
+		switch page.Outcome {
+		case OutcomeUnchanged, OutcomeSkipped:
+			stats.Skipped++
+			if reporter != nil && verbose {
+				reporter.LogInfo(fmt.Sprintf("Skipped %s (%s)", page.URL, page.Outcome))
+			}
+		case OutcomeFailed:
 			stats.Errors++
+			// Classify error type
+			errorType := sr.classifyError(page.Error)
+			if stats.ErrorTypes == nil {
+				stats.ErrorTypes = make(map[string]int)
+			}
+			stats.ErrorTypes[errorType]++
 			if reporter != nil {
-				// Classify error type
-				errorType := sr.classifyError(page.Error)
 				reporter.RecordError(errorType)
 
 				if verbose {
 					reporter.LogError(fmt.Sprintf("Error processing %s: %v", page.URL, page.Error))
 				}
 			}
-		} else {
+		default:
 			stats.Processed++
 			if reporter != nil && verbose {
 				reporter.LogSuccess(fmt.Sprintf("✅ Scraped %s (%d bytes, %v)",
@@ -591,6 +813,9 @@ func (sr *ScraperRunner) printSummary(stats *RunStats) {
 	fmt.Printf("\n%s\n", separator)
 	fmt.Printf("📊 SCRAPING SUMMARY\n")
 	fmt.Printf("%s\n", separator)
+	if stats.RunID != 0 {
+		fmt.Printf("🏷️  Run: #%d\n", stats.RunID)
+	}
 	fmt.Printf("⏱️  Total time: %s\n", elapsed.Round(time.Second))
 	fmt.Printf("🔢 Total URLs: %d\n", stats.TotalURLs)
 	fmt.Printf("✅ Processed: %d\n", stats.Processed)
@@ -602,8 +827,11 @@ func (sr *ScraperRunner) printSummary(stats *RunStats) {
 		fmt.Printf("⚡ Average rate: %.2f URLs/second\n", rate)
 	}
 
-	successRate := float64(stats.Processed) / float64(stats.TotalURLs) * 100
-	fmt.Printf("📈 Success rate: %.1f%%\n", successRate)
+	// Skipped pages were up to date, only errors count against the rate
+	if stats.TotalURLs > 0 {
+		successRate := float64(stats.Processed+stats.Skipped) / float64(stats.TotalURLs) * 100
+		fmt.Printf("📈 Success rate: %.1f%%\n", successRate)
+	}
 	fmt.Printf("%s\n", separator)
 }
 
@@ -613,6 +841,15 @@ func (sr *ScraperRunner) SetRetryConfig(maxRetries int, retryDelay time.Duration
 	sr.retryDelay = retryDelay
 }
 
+// SetTransport routes page and sitemap fetches through rt, a cassette
+// recorder to capture a crawl or a replayer to run one offline
+func (sr *ScraperRunner) SetTransport(rt http.RoundTripper) {
+	sr.httpClient.Transport = rt
+	if p, ok := sr.parser.(interface{ SetTransport(http.RoundTripper) }); ok {
+		p.SetTransport(rt)
+	}
+}
+
 // GetRetryConfig returns current retry configuration
 func (sr *ScraperRunner) GetRetryConfig() (int, time.Duration) {
 	return sr.maxRetries, sr.retryDelay
@@ -782,11 +1019,17 @@ func (rl *RateLimiter) WaitN(ctx context.Context, targetID int64, rate float64)
 // 	// Retryable error logic here
 // }
 
-// dbQueriesAdapter wraps *db.Queries to implement ScraperQueries with the correct WithTx signature
+// dbQueriesAdapter wraps the storage queries to implement ScraperQueries with the correct WithTx signature
 // This allows production code to use the interface, and tests to use mocks
 
 type dbQueriesAdapter struct {
-	q *db.Queries
+	store storage.Store
+	q     db.Querier
+	logs  logger.LoggerQueries // Buffered log writer, nil logs through q
+}
+
+func newQueriesAdapter(store storage.Store) *dbQueriesAdapter {
+	return &dbQueriesAdapter{store: store, q: store.Queries()}
 }
 
 func (a *dbQueriesAdapter) GetTarget(ctx context.Context, id int64) (db.ScraperTarget, error) {
@@ -799,15 +1042,18 @@ func (a *dbQueriesAdapter) GetQueueStats(ctx context.Context) (db.GetQueueStatsR
 	return a.q.GetQueueStats(ctx)
 }
 func (a *dbQueriesAdapter) WithTx(tx *sql.Tx) ScraperQueries {
-	return &dbQueriesAdapter{q: a.q.WithTx(tx)}
+	return &dbQueriesAdapter{store: a.store, q: a.store.WithTx(tx), logs: a.logs}
 }
 
 // Add missing LogMessage method to satisfy logger.LoggerQueries
 func (a *dbQueriesAdapter) LogMessage(ctx context.Context, params db.LogMessageParams) error {
+	if a.logs != nil {
+		return a.logs.LogMessage(ctx, params)
+	}
 	return a.q.LogMessage(ctx, params)
 }
-func (a *dbQueriesAdapter) DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error) {
-	return a.q.DequeuePendingURL(ctx)
+func (a *dbQueriesAdapter) DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error) {
+	return a.q.DequeuePendingURL(ctx, runID)
 }
 func (a *dbQueriesAdapter) FailQueueItem(ctx context.Context, params db.FailQueueItemParams) error {
 	return a.q.FailQueueItem(ctx, params)
@@ -815,21 +1061,69 @@ func (a *dbQueriesAdapter) FailQueueItem(ctx context.Context, params db.FailQueu
 func (a *dbQueriesAdapter) CompleteQueueItem(ctx context.Context, id int64) error {
 	return a.q.CompleteQueueItem(ctx, id)
 }
+func (a *dbQueriesAdapter) SkipQueueItem(ctx context.Context, id int64) error {
+	return a.q.SkipQueueItem(ctx, id)
+}
 func (a *dbQueriesAdapter) GetPageByPath(ctx context.Context, params db.GetPageByPathParams) (db.ScraperPage, error) {
 	return a.q.GetPageByPath(ctx, params)
 }
 func (a *dbQueriesAdapter) SavePage(ctx context.Context, params db.SavePageParams) (db.ScraperPage, error) {
 	return a.q.SavePage(ctx, params)
 }
+func (a *dbQueriesAdapter) MarkPageVisited(ctx context.Context, params db.MarkPageVisitedParams) error {
+	return a.q.MarkPageVisited(ctx, params)
+}
 func (a *dbQueriesAdapter) EnqueueURL(ctx context.Context, params db.EnqueueURLParams) (db.ScraperQueue, error) {
 	return a.q.EnqueueURL(ctx, params)
 }
-func (a *dbQueriesAdapter) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, targetID int64, url string) error {
+func (a *dbQueriesAdapter) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, language string, targetID int64, url string) error {
 	params := db.SavePageClassifierParams{
 		QuoteClassifierJson: sql.NullString{String: classifierJSON, Valid: true},
 		Processable:         sql.NullBool{Bool: processable, Valid: true},
+		Language:            sql.NullString{String: language, Valid: language != ""},
 		TargetID:            targetID,
 		UrlPath:             url,
 	}
 	return a.q.SavePageClassifier(ctx, params)
 }
+func (a *dbQueriesAdapter) GetConfig(ctx context.Context, key string) (string, error) {
+	return a.q.GetConfig(ctx, key)
+}
+func (a *dbQueriesAdapter) SavePageQuotes(ctx context.Context, quotesJSON string, pageID int64) error {
+	return a.q.SavePageQuotes(ctx, db.SavePageQuotesParams{
+		QuotesJson: sql.NullString{String: quotesJSON, Valid: true},
+		ID:         pageID,
+	})
+}
+func (a *dbQueriesAdapter) SavePagePipelineStatus(ctx context.Context, statusJSON string, pageID int64) error {
+	return a.q.SavePagePipelineStatus(ctx, db.SavePagePipelineStatusParams{
+		PipelineStatusJson: sql.NullString{String: statusJSON, Valid: true},
+		ID:                 pageID,
+	})
+}
+func (a *dbQueriesAdapter) EnqueueDiscoveredURL(ctx context.Context, targetID int64, url string, priority int64) (bool, error) {
+	queued, err := a.q.EnqueueDiscoveredURL(ctx, db.EnqueueDiscoveredURLParams{
+		TargetID: targetID,
+		Url:      url,
+		Priority: sql.NullInt64{Int64: priority, Valid: true},
+	})
+	return queued > 0, err
+}
+func (a *dbQueriesAdapter) PutContent(ctx context.Context, arg db.PutContentParams) error {
+	return a.q.PutContent(ctx, arg)
+}
+func (a *dbQueriesAdapter) GetContent(ctx context.Context, contentHash string) (db.GetContentRow, error) {
+	return a.q.GetContent(ctx, contentHash)
+}
+func (a *dbQueriesAdapter) UpdateTargetLearnedTemplate(ctx context.Context, templateJSON string, targetID int64) error {
+	return a.q.UpdateTargetLearnedTemplate(ctx, db.UpdateTargetLearnedTemplateParams{
+		LearnedTemplateJson: sql.NullString{String: templateJSON, Valid: true},
+		ID:                  targetID,
+	})
+}
+func (a *dbQueriesAdapter) CreateRun(ctx context.Context, arg db.CreateRunParams) (db.ScraperRun, error) {
+	return a.q.CreateRun(ctx, arg)
+}
+func (a *dbQueriesAdapter) FinishRun(ctx context.Context, arg db.FinishRunParams) error {
+	return a.q.FinishRun(ctx, arg)
+}
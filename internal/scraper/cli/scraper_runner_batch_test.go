@@ -1,7 +1,8 @@
 package cli
 
 import (
-	"app/internal/scraper/db"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/storage"
 	"context"
 	"database/sql"
 	"testing"
@@ -10,12 +11,13 @@ import (
 func TestBatchEnqueueURLs(t *testing.T) {
 	dbConn, _ := sql.Open("sqlite3", ":memory:")
 	runMigrations(t, dbConn, "../../scraper/db/migrations")
-	queries := &dbQueriesAdapter{q: db.New(dbConn)}
+	queries := newQueriesAdapter(storage.NewSQLite(dbConn))
 	sr := &ScraperRunner{
 		db:        dbConn,
 		queries:   queries,
 		workers:   1,
 		batchSize: 3,
+		log:       logger.New(logger.ComponentFetcher, nil),
 	}
 
 	urls := []string{"a", "b", "c", "d", "e", "f", "g"}
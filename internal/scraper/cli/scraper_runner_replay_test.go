@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/cassette"
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/metrics"
+)
+
+// newReplayRunner builds a runner on a fresh database whose fetches are served
+// from the cassette, re-record it with `scraper-cli run --record <file>`
+func newReplayRunner(t *testing.T, fixture string) (*ScraperRunner, *cassette.Replayer) {
+	t.Helper()
+	c, err := cassette.Load(filepath.Join("testdata", "cassettes", fixture))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg, err := config.Load(config.Options{Flags: map[string]string{
+		config.KeyDatabaseURL: filepath.Join(t.TempDir(), "scraper.db"),
+		config.KeyWorkers:     "2",
+		config.KeyCrawlDelay:  "0",
+	}})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	sr, err := NewScraperRunner(cfg)
+	if err != nil {
+		t.Fatalf("NewScraperRunner: %v", err)
+	}
+	t.Cleanup(func() { _ = sr.Close() })
+	sr.SetRetryConfig(0, 0)
+	replayer := cassette.NewReplayer(c)
+	sr.SetTransport(replayer)
+	return sr, replayer
+}
+
+// createReplayTarget adds the site recorded in quotes_site.json
+func createReplayTarget(t *testing.T, queries *db.Queries) db.ScraperTarget {
+	t.Helper()
+	target, err := queries.CreateTarget(context.Background(), db.CreateTargetParams{
+		WebsiteUrl:        "https://quotes.example.com",
+		SitemapUrl:        sql.NullString{String: "https://quotes.example.com/sitemap_index.xml", Valid: true},
+		CrawlDelaySeconds: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	return target
+}
+
+func TestScraperRunner_ReplayCrawl(t *testing.T) {
+	sr, replayer := newReplayRunner(t, "quotes_site.json")
+	queries := db.New(sr.db)
+	ctx := context.Background()
+	target := createReplayTarget(t, queries)
+
+	if err := sr.Run(target.ID, false, false, false); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if unplayed := replayer.Unplayed(); len(unplayed) != 0 {
+		t.Errorf("expected every recorded request replayed, never requested: %v", unplayed)
+	}
+
+	body := storedPageBody(t, sr.db, "https://quotes.example.com/be-yourself/")
+	if want := "Be yourself; everyone else is already taken."; !strings.Contains(body, want) {
+		t.Errorf("expected the recorded page stored, got %q", body)
+	}
+	page, err := queries.GetPageByPath(ctx, db.GetPageByPathParams{TargetID: target.ID, UrlPath: "https://quotes.example.com/removed-quote/"})
+	if err != nil || page.HttpStatusCode.Int64 != 404 {
+		t.Errorf("expected the recorded 404 stored, got %+v (%v)", page, err)
+	}
+	var queued int
+	if err := sr.db.QueryRow(`SELECT COUNT(*) FROM scraper_queue WHERE target_id = ?`, target.ID).Scan(&queued); err != nil {
+		t.Fatalf("count queue: %v", err)
+	}
+	if queued != 3 {
+		t.Errorf("expected the 3 sitemap pages queued, feed.xml filtered out, got %d", queued)
+	}
+}
+
+func TestScraperRunner_ReplayMetrics(t *testing.T) {
+	sr, _ := newReplayRunner(t, "quotes_site.json")
+	target := createReplayTarget(t, db.New(sr.db))
+	if err := sr.Run(target.ID, false, false, false); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var out strings.Builder
+	metrics.Default.Write(context.Background(), &out)
+	label := metrics.Target(target.ID)
+	for _, series := range []string{
+		`scraper_fetch_requests_total{target="` + label + `",status="404"}`,
+		`scraper_fetch_bytes_total{target="` + label + `"}`,
+		`scraper_fetch_duration_seconds_count{target="` + label + `"}`,
+		`scraper_rate_limit_wait_seconds_total{target="` + label + `"}`,
+		`scraper_classifier_duration_seconds_count`,
+	} {
+		if !strings.Contains(out.String(), series+" ") {
+			t.Errorf("expected %s after a crawl, got:\n%s", series, out.String())
+		}
+	}
+}
+
+func TestScraperRunner_ReplayRunHistory(t *testing.T) {
+	sr, _ := newReplayRunner(t, "quotes_site.json")
+	queries := db.New(sr.db)
+	target := createReplayTarget(t, queries)
+	if err := sr.Run(target.ID, false, false, false); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	sr.logs.Flush()
+
+	ctx := context.Background()
+	runs, err := queries.ListRuns(ctx, 10)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one run recorded, got %d (%v)", len(runs), err)
+	}
+	run := runs[0]
+	if run.Status != RunCompleted || run.TriggerSource != TriggerCLI || run.DryRun || !run.FinishedAt.Valid {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if want := fmt.Sprintf("[%d]", target.ID); run.TargetIds != want {
+		t.Errorf("expected targets %s, got %s", want, run.TargetIds)
+	}
+	if run.TotalUrls == 0 || run.Processed+run.Errors+run.Skipped != run.TotalUrls {
+		t.Errorf("expected every queued URL counted, got %+v", run)
+	}
+	if run.Errors > 0 && !run.ErrorBreakdown.Valid {
+		t.Errorf("expected the error breakdown of the %d errors, got %q", run.Errors, run.ErrorBreakdown.String)
+	}
+
+	// The queue items and logs of the crawl link to it
+	runID := sql.NullInt64{Int64: run.ID, Valid: true}
+	items, err := queries.CountRunQueueItems(ctx, runID)
+	if err != nil {
+		t.Fatalf("CountRunQueueItems: %v", err)
+	}
+	var crawled int64
+	for _, c := range items {
+		crawled += c.Count
+	}
+	if crawled != run.TotalUrls {
+		t.Errorf("expected the run's %d items linked to it, got %+v", run.TotalUrls, items)
+	}
+	if logs, err := queries.CountRunLogs(ctx, runID); err != nil || len(logs) == 0 {
+		t.Errorf("expected the run's logs linked to it, got %+v (%v)", logs, err)
+	}
+}
+
+func TestScraperRunner_ReplayUnrecordedURL(t *testing.T) {
+	sr, _ := newReplayRunner(t, "quotes_site.json")
+	target := createReplayTarget(t, db.New(sr.db))
+	page := sr.scrapeURLAttempt(context.Background(), PageToProcess{TargetID: target.ID, URL: "https://quotes.example.com/not-recorded/"}, nil)
+	if !errors.Is(page.Error, cassette.ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded for a request missing from the cassette, got %v", page.Error)
+	}
+}
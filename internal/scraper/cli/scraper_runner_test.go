@@ -10,11 +10,15 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
 	"app/internal/scraper/service/sitemap"
+	"app/internal/scraper/storage"
 
 	"github.com/cespare/xxhash/v2"
 )
@@ -38,6 +42,7 @@ type mockQueries struct {
 	ListActiveErr     error
 	GetQueueStatsResp db.GetQueueStatsRow
 	GetQueueStatsErr  error
+	TemplateWrites    []string
 }
 
 func (m *mockQueries) EnqueueURL(ctx context.Context, params db.EnqueueURLParams) (db.ScraperQueue, error) {
@@ -57,19 +62,23 @@ func (m *mockQueries) ListActiveTargets(ctx context.Context) ([]db.ScraperTarget
 func (m *mockQueries) GetQueueStats(ctx context.Context) (db.GetQueueStatsRow, error) {
 	return m.GetQueueStatsResp, m.GetQueueStatsErr
 }
-func (m *mockQueries) DequeuePendingURL(ctx context.Context) (db.ScraperQueue, error) {
+func (m *mockQueries) DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error) {
 	return db.ScraperQueue{}, nil
 }
 func (m *mockQueries) FailQueueItem(ctx context.Context, params db.FailQueueItemParams) error {
 	return nil
 }
 func (m *mockQueries) CompleteQueueItem(ctx context.Context, id int64) error { return nil }
+func (m *mockQueries) SkipQueueItem(ctx context.Context, id int64) error     { return nil }
 func (m *mockQueries) GetPageByPath(ctx context.Context, params db.GetPageByPathParams) (db.ScraperPage, error) {
 	return db.ScraperPage{}, nil
 }
 func (m *mockQueries) SavePage(ctx context.Context, params db.SavePageParams) (db.ScraperPage, error) {
 	return db.ScraperPage{}, nil
 }
+func (m *mockQueries) MarkPageVisited(ctx context.Context, params db.MarkPageVisitedParams) error {
+	return nil
+}
 
 // Add LogMessage to mockQueries for tests
 func (m *mockQueries) LogMessage(ctx context.Context, params db.LogMessageParams) error {
@@ -77,7 +86,44 @@ func (m *mockQueries) LogMessage(ctx context.Context, params db.LogMessageParams
 }
 
 // Add SavePageClassifier stub to mockQueries
-func (m *mockQueries) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, targetID int64, url string) error {
+func (m *mockQueries) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, language string, targetID int64, url string) error {
+	return nil
+}
+
+func (m *mockQueries) UpdateTargetLearnedTemplate(ctx context.Context, templateJSON string, targetID int64) error {
+	m.TemplateWrites = append(m.TemplateWrites, templateJSON)
+	return nil
+}
+
+func (m *mockQueries) GetConfig(ctx context.Context, key string) (string, error) {
+	return "", sql.ErrNoRows
+}
+
+func (m *mockQueries) SavePageQuotes(ctx context.Context, quotesJSON string, pageID int64) error {
+	return nil
+}
+
+func (m *mockQueries) SavePagePipelineStatus(ctx context.Context, statusJSON string, pageID int64) error {
+	return nil
+}
+
+func (m *mockQueries) PutContent(ctx context.Context, arg db.PutContentParams) error {
+	return nil
+}
+
+func (m *mockQueries) GetContent(ctx context.Context, contentHash string) (db.GetContentRow, error) {
+	return db.GetContentRow{}, sql.ErrNoRows
+}
+
+func (m *mockQueries) EnqueueDiscoveredURL(ctx context.Context, targetID int64, url string, priority int64) (bool, error) {
+	return false, nil
+}
+
+func (m *mockQueries) CreateRun(ctx context.Context, arg db.CreateRunParams) (db.ScraperRun, error) {
+	return db.ScraperRun{ID: 1, TriggerSource: arg.TriggerSource, Status: "running"}, nil
+}
+
+func (m *mockQueries) FinishRun(ctx context.Context, arg db.FinishRunParams) error {
 	return nil
 }
 
@@ -133,9 +179,9 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 		t.Fatal(err)
 	}
 	runMigrations(t, dbConn, "../../scraper/db/migrations")
-	queries := &dbQueriesAdapter{q: db.New(dbConn)}
+	queries := newQueriesAdapter(storage.NewSQLite(dbConn))
 	// Insert a target
-	_, _ = dbConn.Exec(`INSERT INTO scraper_targets (id, website_url, sitemap_url, user_agent, requests_per_second) VALUES (1, 'http://test', '', 'TestAgent', 1.0)`)
+	_, _ = dbConn.Exec(`INSERT INTO scraper_targets (id, website_url, sitemap_url, user_agent, requests_per_second) VALUES (1, 'http://test', 'http://test/sitemap.xml', 'TestAgent', 1.0)`)
 	// Mock HTTP server to serve content
 	mux := http.NewServeMux()
 	mux.HandleFunc("/page1", func(w http.ResponseWriter, r *http.Request) {
@@ -144,7 +190,14 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 		}
 	})
 	content := "hello world"
+	var page2Fetches atomic.Int32
 	mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		page2Fetches.Add(1)
+		if _, err := fmt.Fprint(w, content); err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+	})
+	mux.HandleFunc("/page4", func(w http.ResponseWriter, r *http.Request) {
 		if _, err := fmt.Fprint(w, content); err != nil {
 			t.Fatalf("failed to write content: %v", err)
 		}
@@ -161,6 +214,7 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 	_, _ = dbConn.Exec(`INSERT INTO scraper_queue (id, url, target_id, priority) VALUES (1, ?, 1, 0)`, base+"/page1")
 	_, _ = dbConn.Exec(`INSERT INTO scraper_queue (id, url, target_id, priority) VALUES (2, ?, 1, 0)`, base+"/page2")
 	_, _ = dbConn.Exec(`INSERT INTO scraper_queue (id, url, target_id, priority) VALUES (3, ?, 1, 0)`, base+"/page3")
+	_, _ = dbConn.Exec(`INSERT INTO scraper_queue (id, url, target_id, priority) VALUES (4, ?, 1, 0)`, base+"/page4")
 	// Insert a page record for page2 with same hash and up-to-date
 	lastVisited := time.Now().Add(-1 * time.Hour)
 	lastUpdated := time.Now().Add(-2 * time.Hour)
@@ -173,48 +227,75 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 	lastVisited3 := time.Now().Add(-3 * time.Hour)
 	lastUpdated3 := time.Now().Add(-2 * time.Hour)
 	_, _ = dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, html_content, content_hash, http_status_code, response_time_ms, content_length, last_visited_at, last_updated_at) VALUES (1, ?, ?, ?, ?, 200, 100, 11, ?, ?)`, base+"/page3", base+"/page3", oldContent, oldHash, lastVisited3, lastUpdated3)
+	// page4 was updated since the last visit but still has the stored content
+	_, _ = dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, html_content, content_hash, http_status_code, response_time_ms, content_length, last_visited_at, last_updated_at, visit_count) VALUES (1, ?, ?, ?, ?, 200, 100, 11, ?, ?, 1)`, base+"/page4", base+"/page4", content, hash, lastVisited3, lastUpdated3)
+	// The sitemap lastmod of page2 predates its last visit
+	parser := &mockParser{URLs: []mockURL{{Loc: base + "/page2", LastModTime: &lastUpdated}}}
 	// Use the test server's base URL for queue items
 	sr := &ScraperRunner{
 		db:          dbConn,
 		queries:     queries,
+		parser:      parser,
 		workers:     1,
 		batchSize:   2,
 		httpClient:  server.Client(),
 		rateLimiter: NewRateLimiter(),
+		log:         logger.New(logger.ComponentFetcher, nil),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	stats := &RunStats{TotalURLs: 3, StartTime: time.Now()}
+	stats := &RunStats{TotalURLs: 4, StartTime: time.Now()}
 	err = sr.processQueueWithWorkers(ctx, stats, false, false)
 	if err != nil {
 		t.Fatalf("processQueueWithWorkers failed: %v", err)
 	}
 
+	// page2 isn't fetched and page4 only counts a visit, both skipped rather than failed
+	if stats.Processed != 2 || stats.Skipped != 2 || stats.Errors != 0 {
+		t.Errorf("expected 2 processed and 2 skipped, got %+v", stats)
+	}
+	if n := page2Fetches.Load(); n != 0 {
+		t.Errorf("expected page2 not fetched, got %d requests", n)
+	}
+	for id, want := range map[int]string{1: "completed", 2: "skipped", 3: "completed", 4: "skipped"} {
+		var status string
+		var errMsg sql.NullString
+		_ = dbConn.QueryRow(`SELECT status, error_message FROM scraper_queue WHERE id = ?`, id).Scan(&status, &errMsg)
+		if status != want || errMsg.Valid {
+			t.Errorf("queue item %d: got %s (%q), want %s", id, status, errMsg.String, want)
+		}
+	}
+	var visits4 int64
+	_ = dbConn.QueryRow(`SELECT visit_count FROM scraper_pages WHERE url_path = ?`, base+"/page4").Scan(&visits4)
+	if visits4 != 2 {
+		t.Errorf("expected page4's visit recorded, got visit_count %d", visits4)
+	}
+
 	// Check results: page1 should be new, page2 skipped, page3 updated
-	row := dbConn.QueryRow(`SELECT html_content FROM scraper_pages WHERE url_path = ?`, base+"/page1")
-	var got1 string
-	_ = row.Scan(&got1)
+	got1 := storedPageBody(t, dbConn, base+"/page1")
 	if !strings.Contains(got1, "new page1 content") {
 		t.Errorf("page1 not scraped correctly: got %q", got1)
 	}
-	row = dbConn.QueryRow(`SELECT html_content FROM scraper_pages WHERE url_path = ?`, base+"/page2")
-	var got2 string
-	_ = row.Scan(&got2)
+	// page2 keeps the inline body it was inserted with
+	got2 := storedPageBody(t, dbConn, base+"/page2")
 	if got2 != content {
 		t.Errorf("page2 should be skipped, got %q", got2)
 	}
-	row = dbConn.QueryRow(`SELECT html_content FROM scraper_pages WHERE url_path = ?`, base+"/page3")
-	var got3 string
-	_ = row.Scan(&got3)
+	got3 := storedPageBody(t, dbConn, base+"/page3")
 	if !strings.Contains(got3, "updated content") {
 		t.Errorf("page3 not updated: got %q", got3)
 	}
+	var inline sql.NullString
+	_ = dbConn.QueryRow(`SELECT html_content FROM scraper_pages WHERE url_path = ?`, base+"/page3").Scan(&inline)
+	if inline.Valid {
+		t.Errorf("expected page3 body moved to the content store, got inline %q", inline.String)
+	}
 
 	// Check that the correct full_url values are set
 	var fullURL1, fullURL2, fullURL3 string
-	row = dbConn.QueryRow(`SELECT full_url FROM scraper_pages WHERE url_path = ?`, base+"/page1")
+	row := dbConn.QueryRow(`SELECT full_url FROM scraper_pages WHERE url_path = ?`, base+"/page1")
 	_ = row.Scan(&fullURL1)
 	row = dbConn.QueryRow(`SELECT full_url FROM scraper_pages WHERE url_path = ?`, base+"/page2")
 	_ = row.Scan(&fullURL2)
@@ -224,8 +305,16 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 		t.Errorf("full_url values are incorrect: %q, %q, %q", fullURL1, fullURL2, fullURL3)
 	}
 
+	// Only pages saved by this run are post-processed
+	var status1, status2 sql.NullString
+	_ = dbConn.QueryRow(`SELECT pipeline_status_json FROM scraper_pages WHERE url_path = ?`, base+"/page1").Scan(&status1)
+	_ = dbConn.QueryRow(`SELECT pipeline_status_json FROM scraper_pages WHERE url_path = ?`, base+"/page2").Scan(&status2)
+	if !strings.Contains(status1.String, `"classification"`) || status2.Valid {
+		t.Errorf("expected pipeline status on page1 only, got %q and %q", status1.String, status2.String)
+	}
+
 	// Debug output: print all rows in the pages table
-	rows, _ := dbConn.Query(`SELECT url_path, full_url, html_content FROM scraper_pages`)
+	rows, _ := dbConn.Query(`SELECT url_path, full_url, COALESCE(html_content, '') FROM scraper_pages`)
 	for rows.Next() {
 		var urlPath, fullUrl, html string
 		_ = rows.Scan(&urlPath, &fullUrl, &html)
@@ -236,6 +325,109 @@ func TestScraperRunner_SkipUpdateLogic(t *testing.T) {
 	}
 }
 
+func TestScraperRunner_RecrawlsUnchangedPageWithoutLastmod(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runMigrations(t, dbConn, "../../scraper/db/migrations")
+	queries := newQueriesAdapter(storage.NewSQLite(dbConn))
+	_, _ = dbConn.Exec(`INSERT INTO scraper_targets (id, website_url, sitemap_url, requests_per_second) VALUES (1, 'http://test', '', NULL)`)
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = fmt.Fprint(w, "same content")
+	}))
+	defer server.Close()
+
+	sr := &ScraperRunner{
+		db:          dbConn,
+		queries:     queries,
+		httpClient:  server.Client(),
+		rateLimiter: NewRateLimiter(),
+		contents:    content.NewDBStore(queries),
+		log:         logger.New(logger.ComponentFetcher, nil),
+	}
+	ctx := context.Background()
+
+	// Without a sitemap lastmod every visit fetches, the content hash tells the page unchanged
+	for run, want := range []Outcome{OutcomeFetched, OutcomeUnchanged, OutcomeUnchanged} {
+		page := sr.scrapeURLAttempt(ctx, PageToProcess{TargetID: 1, URL: server.URL + "/page"}, nil)
+		if page.Error != nil {
+			t.Fatalf("run %d failed: %v", run+1, page.Error)
+		}
+		if page.Outcome != want {
+			t.Errorf("run %d: got outcome %s, want %s", run+1, page.Outcome, want)
+		}
+		if n := fetches.Load(); n != int32(run+1) {
+			t.Errorf("run %d: expected the page fetched, got %d requests", run+1, n)
+		}
+	}
+}
+
+func TestScraperRunner_ScrapeUsesConfiguredSettings(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runMigrations(t, dbConn, "../../scraper/db/migrations")
+	queries := newQueriesAdapter(storage.NewSQLite(dbConn))
+	// No user agent and no requests_per_second: the runner defaults apply
+	_, _ = dbConn.Exec(`INSERT INTO scraper_targets (id, website_url, sitemap_url, requests_per_second) VALUES (1, 'http://test', '', NULL)`)
+
+	var gotUserAgent string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		_, _ = fmt.Fprint(w, "small page")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, strings.Repeat("x", 2<<20))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sr := &ScraperRunner{
+		db:          dbConn,
+		queries:     queries,
+		httpClient:  server.Client(),
+		rateLimiter: NewRateLimiter(),
+		contents:    content.NewDBStore(queries),
+		userAgent:   "ConfiguredBot/2.0",
+		maxPageSize: 1 << 20,
+		log:         logger.New(logger.ComponentFetcher, nil),
+	}
+	ctx := context.Background()
+
+	page := sr.scrapeURLAttempt(ctx, PageToProcess{TargetID: 1, URL: server.URL + "/small"}, nil)
+	if page.Error != nil {
+		t.Fatalf("small page failed: %v", page.Error)
+	}
+	if gotUserAgent != "ConfiguredBot/2.0" {
+		t.Errorf("expected the default user agent, got %q", gotUserAgent)
+	}
+
+	page = sr.scrapeURLAttempt(ctx, PageToProcess{TargetID: 1, URL: server.URL + "/large"}, nil)
+	if page.Error == nil || !strings.Contains(page.Error.Error(), "max_page_size_mb") {
+		t.Errorf("expected the 2 MB page to exceed the 1 MB limit, got %v", page.Error)
+	}
+}
+
+// storedPageBody reads a page body, inline or from the content store
+func storedPageBody(t *testing.T, dbConn *sql.DB, urlPath string) string {
+	t.Helper()
+	var inline, key sql.NullString
+	if err := dbConn.QueryRow(`SELECT html_content, content_key FROM scraper_pages WHERE url_path = ?`, urlPath).Scan(&inline, &key); err != nil {
+		t.Fatalf("failed to read page %s: %v", urlPath, err)
+	}
+	body, err := content.PageBody(context.Background(), content.NewDBStore(db.New(dbConn)), inline, key)
+	if err != nil {
+		t.Fatalf("failed to read body of %s: %v", urlPath, err)
+	}
+	return body
+}
+
 func TestScraperRunner_ParseAndQueueURLs_HTTP(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -252,7 +444,7 @@ func TestScraperRunner_ParseAndQueueURLs_HTTP(t *testing.T) {
 	_, _ = dbConn.Exec(`CREATE TABLE queue (id INTEGER PRIMARY KEY, url TEXT, target_id INTEGER, priority INTEGER)`)
 	target := db.ScraperTarget{ID: 1, SitemapUrl: sql.NullString{String: server.URL, Valid: true}}
 	parser := &mockParser{URLs: []mockURL{{Loc: "http://a"}}}
-	sr := &ScraperRunner{queries: q, parser: parser, db: dbConn}
+	sr := &ScraperRunner{queries: q, parser: parser, db: dbConn, log: logger.New(logger.ComponentFetcher, nil)}
 	pages, err := sr.parseAndQueueURLs(context.Background(), target, false)
 	if err != nil || len(pages) != 1 {
 		t.Errorf("parseAndQueueURLs HTTP failed: %v %v", pages, err)
@@ -271,7 +463,7 @@ func (m *enqueueMockQueries) EnqueueURL(ctx context.Context, params db.EnqueueUR
 }
 
 // Add SavePageClassifier stub to enqueueMockQueries
-func (m *enqueueMockQueries) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, targetID int64, url string) error {
+func (m *enqueueMockQueries) SavePageClassifier(ctx context.Context, classifierJSON string, processable bool, language string, targetID int64, url string) error {
 	return nil
 }
 
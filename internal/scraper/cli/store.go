@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"context"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+)
+
+// OpenStore opens the storage backend configured in cfg and applies pending
+// migrations, unless SCRAPER_AUTO_MIGRATE=false refuses them. The settings
+// stored in the database are then added to cfg.
+func OpenStore(cfg *config.Config) (storage.Store, error) {
+	ctx := context.Background()
+	store, err := storage.Open(cfg.DatabaseURL())
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate.EnsureSchema(ctx, store, migrate.AutoMigrateFromEnv()); err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	if err := cfg.LoadDatabase(ctx, store.Queries()); err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	return store, nil
+}
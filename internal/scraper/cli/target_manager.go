@@ -4,22 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"app/internal/scraper/config"
 	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
 	"app/internal/scraper/service/sitemap"
 	"app/internal/scraper/service/target"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type TargetManager struct {
 	db            *sql.DB
-	queries       *db.Queries
+	queries       db.Querier
 	targetService *target.TargetService
 	parser        *sitemap.Parser
+	sitemaps      *sitemap.SitemapService
 }
 
 type TargetInfo struct {
@@ -31,34 +33,40 @@ type TargetInfo struct {
 	LastVisited *time.Time `json:"last_visited,omitempty"`
 }
 
-func NewTargetManager() (*TargetManager, error) {
-	// Initialize database connection
-	database, err := sql.Open("sqlite3", "data/scraper.db?_journal_mode=WAL")
+func NewTargetManager(cfg *config.Config) (*TargetManager, error) {
+	// Initialize the configured storage backend, migrated to the current schema
+	store, err := OpenStore(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	queries := db.New(database)
+	queries := store.Queries()
 	targetService := target.NewTargetService(queries)
 
 	// Create sitemap parser with database access
-	parser := sitemap.NewParser(queries, 30*time.Second)
+	parser := sitemap.NewParser(queries, cfg.RequestTimeout(), cfg.UserAgent())
 
 	return &TargetManager{
-		db:            database,
+		db:            store.DB(),
 		queries:       queries,
 		targetService: targetService,
 		parser:        parser,
+		sitemaps:      sitemap.NewSitemapService(cfg.RequestTimeout(), cfg.UserAgent()),
 	}, nil
 }
 
+// Sitemaps returns the sitemap service configured with the request timeout and default user agent
+func (tm *TargetManager) Sitemaps() *sitemap.SitemapService {
+	return tm.sitemaps
+}
+
 func (tm *TargetManager) Close() error {
 	return tm.db.Close()
 }
 
 func (tm *TargetManager) AddTarget(websiteURL, sitemapURL, userAgent string, autoDiscover, validate bool) error {
 	ctx := context.Background()
-	ss := sitemap.NewSitemapService(30 * time.Second)
+	ss := tm.sitemaps
 
 	fmt.Printf("Adding target: %s\n", websiteURL)
 
@@ -164,9 +172,112 @@ func (tm *TargetManager) ShowTarget(targetID int64) error {
 		fmt.Printf("User Agent: %s\n", target.UserAgent.String)
 	}
 
+	if target.ClassifierOverridesJson.Valid {
+		fmt.Printf("Classifier Overrides: %s\n", target.ClassifierOverridesJson.String)
+	}
+
+	if target.LearnedTemplateJson.Valid {
+		tmpl, err := classifier.ParseLearnedTemplate(target.LearnedTemplateJson.String)
+		if err != nil {
+			fmt.Printf("Learned Template: invalid (%v)\n", err)
+		} else {
+			fmt.Printf("Learned Template: %s (%d samples, %d hits, %d drifts)\n", tmpl.Status, tmpl.Samples, tmpl.Hits, tmpl.Drifts)
+			if tmpl.IsStable() {
+				fmt.Printf("Learned Selectors: %s (confidence %.2f)\n", strings.Join(tmpl.Selectors, ", "), tmpl.Confidence)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ShowProfile prints the effective classifier profile, globally or for a single target
+func (tm *TargetManager) ShowProfile(targetID int64) error {
+	ctx := context.Background()
+
+	globalJSON, err := tm.globalProfileJSON(ctx)
+	if err != nil {
+		return err
+	}
+	overrideJSON := ""
+	if targetID > 0 {
+		target, err := tm.queries.GetTarget(ctx, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to get target: %w", err)
+		}
+		overrideJSON = target.ClassifierOverridesJson.String
+	}
+
+	profile, err := classifier.ResolveProfile(globalJSON, overrideJSON)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
 	return nil
 }
 
+// SetProfile validates and stores the global classifier profile, or a target override when targetID is set
+func (tm *TargetManager) SetProfile(targetID int64, profileJSON string) error {
+	ctx := context.Background()
+
+	globalJSON, err := tm.globalProfileJSON(ctx)
+	if err != nil {
+		return err
+	}
+
+	if targetID == 0 {
+		profile, err := classifier.ParseProfile(profileJSON)
+		if err != nil {
+			return err
+		}
+		stored, err := profile.MarshalProfile()
+		if err != nil {
+			return fmt.Errorf("failed to marshal profile: %w", err)
+		}
+		err = tm.queries.SetConfig(ctx, db.SetConfigParams{
+			Key:         classifier.ProfileConfigKey,
+			Value:       stored,
+			Description: sql.NullString{String: "Classifier decision tree profile (JSON)", Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save profile: %w", err)
+		}
+		fmt.Printf("✅ Global classifier profile set to %q\n", profile.Name)
+		return nil
+	}
+
+	if _, err := tm.queries.GetTarget(ctx, targetID); err != nil {
+		return fmt.Errorf("failed to get target: %w", err)
+	}
+	profile, err := classifier.ResolveProfile(globalJSON, profileJSON)
+	if err != nil {
+		return err
+	}
+	// Store the override as given so later global changes still apply to fields it does not set
+	err = tm.queries.UpdateTargetClassifierOverrides(ctx, db.UpdateTargetClassifierOverridesParams{
+		ClassifierOverridesJson: sql.NullString{String: profileJSON, Valid: strings.TrimSpace(profileJSON) != ""},
+		ID:                      targetID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save target overrides: %w", err)
+	}
+	fmt.Printf("✅ Target %d classifier profile set to %q\n", targetID, profile.Name)
+	return nil
+}
+
+// globalProfileJSON returns the stored global profile, empty when not configured
+func (tm *TargetManager) globalProfileJSON(ctx context.Context) (string, error) {
+	value, err := tm.queries.GetConfig(ctx, classifier.ProfileConfigKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to load classifier profile: %w", err)
+	}
+	return value, nil
+}
+
 func (tm *TargetManager) RemoveTarget(targetID int64, force bool) error {
 	ctx := context.Background()
 
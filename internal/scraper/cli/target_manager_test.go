@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
 )
 
 // Add minimal tests for TargetManager methods
@@ -59,21 +60,7 @@ func TestTargetManager_AddAndShowTarget_InMemory(t *testing.T) {
 	println("[DEBUG] Attempting to read schema at ../db/migrations/001_initial_schema.sql")
 
 	// Run all migrations needed for test DB
-	migrationFiles := []string{
-		"../db/migrations/001_initial_schema.up.sql",
-		"../db/migrations/002_rate_limit_per_target.up.sql",
-	}
-	for _, mf := range migrationFiles {
-		println("[DEBUG] Applying migration:", mf)
-		schema, err := os.ReadFile(mf)
-		if err != nil {
-			t.Fatalf("failed to read migration %s: %v", mf, err)
-		}
-		_, err = dbConn.Exec(string(schema))
-		if err != nil {
-			t.Fatalf("failed to exec migration %s: %v", mf, err)
-		}
-	}
+	runMigrations(t, dbConn, "../db/migrations")
 
 	queries := db.New(dbConn)
 	tm := &TargetManager{db: dbConn, queries: queries}
@@ -97,3 +84,53 @@ func TestTargetManager_AddAndShowTarget_InMemory(t *testing.T) {
 		t.Errorf("ShowTarget failed: %v", err)
 	}
 }
+
+func TestTargetManager_SetProfile_InMemory(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer func() {
+		if cerr := dbConn.Close(); cerr != nil {
+			t.Errorf("failed to close db: %v", cerr)
+		}
+	}()
+	runMigrations(t, dbConn, "../db/migrations")
+
+	queries := db.New(dbConn)
+	tm := &TargetManager{db: dbConn, queries: queries}
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://test.com"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+
+	if err := tm.SetProfile(0, `{"rules":["bogus"]}`); err == nil {
+		t.Error("expected invalid global profile to be rejected")
+	}
+	if err := tm.SetProfile(0, `{"name":"strict","thresholds":{"min_text_char_count":800,"min_long_paragraph_len":400,"min_num_blocks":4,"min_dominant_selector":0.7,"high_quote_score":0.7,"structured_quote_score":0.5,"max_page_selectors":2}}`); err != nil {
+		t.Fatalf("SetProfile global failed: %v", err)
+	}
+	if err := tm.SetProfile(target.ID, `{"thresholds":{"min_text_char_count":100}}`); err != nil {
+		t.Fatalf("SetProfile target failed: %v", err)
+	}
+
+	stored, err := queries.GetTarget(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("failed to get target: %v", err)
+	}
+	globalJSON, err := queries.GetConfig(ctx, classifier.ProfileConfigKey)
+	if err != nil {
+		t.Fatalf("failed to get profile config: %v", err)
+	}
+	profile, err := classifier.ResolveProfile(globalJSON, stored.ClassifierOverridesJson.String)
+	if err != nil {
+		t.Fatalf("ResolveProfile failed: %v", err)
+	}
+	if profile.Name != "strict+override" || profile.Thresholds.MinTextCharCount != 100 || profile.Thresholds.MinNumBlocks != 4 {
+		t.Errorf("unexpected effective profile: %+v", profile)
+	}
+	if err := tm.ShowProfile(target.ID); err != nil {
+		t.Errorf("ShowProfile failed: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/auth"
+)
+
+// UserManager manages the admin UI accounts and their API tokens
+type UserManager struct {
+	db      *sql.DB
+	queries db.Querier
+	auth    *auth.Service
+}
+
+func NewUserManager(cfg *config.Config) (*UserManager, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	queries := store.Queries()
+	return &UserManager{db: store.DB(), queries: queries, auth: auth.NewService(queries)}, nil
+}
+
+func (um *UserManager) Close() error {
+	return um.db.Close()
+}
+
+func (um *UserManager) user(ctx context.Context, username string) (db.ScraperUser, error) {
+	user, err := um.queries.GetUserByUsername(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, fmt.Errorf("%w: %s", auth.ErrUserNotFound, username)
+	}
+	if err != nil {
+		return user, fmt.Errorf("failed to load user %s: %w", username, err)
+	}
+	return user, nil
+}
+
+func (um *UserManager) AddUser(username, password string, role auth.Role) error {
+	user, err := um.auth.CreateUser(context.Background(), username, password, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Created %s user %s (ID: %d)\n", user.Role, user.Username, user.ID)
+	return nil
+}
+
+func (um *UserManager) ListUsers() error {
+	users, err := um.queries.ListUsers(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	if len(users) == 0 {
+		fmt.Println("No users. Create one with: scraper-cli users add <username> --role admin")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tACTIVE\tLAST LOGIN")
+	for _, u := range users {
+		lastLogin := "never"
+		if u.LastLoginAt.Valid {
+			lastLogin = u.LastLoginAt.Time.Format("2006-01-02 15:04")
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\n", u.ID, u.Username, u.Role, u.IsActive, lastLogin)
+	}
+	return w.Flush()
+}
+
+func (um *UserManager) SetRole(username string, role auth.Role) error {
+	ctx := context.Background()
+	user, err := um.user(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := um.auth.SetRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s is now %s\n", username, role)
+	return nil
+}
+
+// SetPassword changes the password and ends the user's sessions
+func (um *UserManager) SetPassword(username, password string) error {
+	ctx := context.Background()
+	user, err := um.user(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := um.auth.SetPassword(ctx, user.ID, password); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Changed password of %s\n", username)
+	return nil
+}
+
+// SetActive enables or disables a user, disabled users cannot sign in or use their API tokens
+func (um *UserManager) SetActive(username string, active bool) error {
+	ctx := context.Background()
+	user, err := um.user(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := um.auth.SetActive(ctx, user.ID, active); err != nil {
+		return err
+	}
+	if active {
+		fmt.Printf("✅ Enabled %s\n", username)
+	} else {
+		fmt.Printf("🚫 Disabled %s\n", username)
+	}
+	return nil
+}
+
+// CreateToken issues an API token acting as the user, the secret is printed once
+func (um *UserManager) CreateToken(username, name string) error {
+	ctx := context.Background()
+	user, err := um.user(ctx, username)
+	if err != nil {
+		return err
+	}
+	secret, token, err := um.auth.CreateToken(ctx, user.ID, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 Created API token %d (%s) for %s, it is not shown again:\n\n%s\n\n", token.ID, token.Name, username, secret)
+	fmt.Println("Send it as: Authorization: Bearer <token>")
+	return nil
+}
+
+func (um *UserManager) ListTokens() error {
+	tokens, err := um.queries.ListAPITokens(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		fmt.Println("No API tokens")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tUSER\tCREATED\tLAST USED")
+	for _, t := range tokens {
+		lastUsed := "never"
+		if t.LastUsedAt.Valid {
+			lastUsed = t.LastUsedAt.Time.Format("2006-01-02 15:04")
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Username, t.CreatedAt.Time.Format("2006-01-02 15:04"), lastUsed)
+	}
+	return w.Flush()
+}
+
+func (um *UserManager) RevokeToken(id int64) error {
+	if err := um.auth.RevokeToken(context.Background(), id); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Revoked API token %d\n", id)
+	return nil
+}
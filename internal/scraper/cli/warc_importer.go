@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/scraper/config"
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/warc"
+)
+
+// WARCImportQueries defines the db.Queries methods used by WARCImporter
+type WARCImportQueries interface {
+	ListAllTargets(ctx context.Context) ([]db.ScraperTarget, error)
+	GetPageByPath(ctx context.Context, arg db.GetPageByPathParams) (db.ScraperPage, error)
+	ImportPage(ctx context.Context, arg db.ImportPageParams) (int64, error)
+	content.Queries
+}
+
+// WARCImportOptions controls how archived responses are mapped to pages
+type WARCImportOptions struct {
+	TargetID int64 // import every response into this target, 0 matches targets by host
+	DryRun   bool  // read and match records without writing pages
+}
+
+// WARCImportSummary reports what an import did
+type WARCImportSummary struct {
+	Records   int            // records read, of any type
+	Responses int            // HTTP response records
+	Imported  int            // pages inserted or updated
+	Older     int            // responses older than the stored visit of the page, kept as is
+	Unmatched map[string]int // responses per host without a matching target
+	Errors    int
+	Duration  time.Duration
+}
+
+// WARCImporter rebuilds scraper_pages from archived fetches, the pages can then
+// be reprocessed with `process` or `classify` like freshly crawled ones
+type WARCImporter struct {
+	db       *sql.DB
+	queries  WARCImportQueries
+	contents content.Store
+}
+
+func NewWARCImporter(cfg *config.Config) (*WARCImporter, error) {
+	store, err := OpenStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	queries := store.Queries()
+	return &WARCImporter{db: store.DB(), queries: queries, contents: content.NewDBStore(queries)}, nil
+}
+
+func (i *WARCImporter) Close() error {
+	return i.db.Close()
+}
+
+// Run imports the response records of the given WARC files in order, later
+// captures of a URL replace earlier ones
+func (i *WARCImporter) Run(ctx context.Context, paths []string, opts WARCImportOptions) (*WARCImportSummary, error) {
+	start := time.Now()
+	summary := &WARCImportSummary{Unmatched: map[string]int{}}
+	targets, err := i.targetsByHost(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := i.importFile(ctx, path, targets, opts, summary); err != nil {
+			summary.Duration = time.Since(start)
+			return summary, err
+		}
+	}
+	summary.Duration = time.Since(start)
+	return summary, nil
+}
+
+func (i *WARCImporter) importFile(ctx context.Context, path string, targets map[string]int64, opts WARCImportOptions, summary *WARCImportSummary) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open WARC file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	r, err := warc.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		summary.Records++
+		if rec.Type() != warc.TypeResponse {
+			continue
+		}
+		summary.Responses++
+
+		targetID := opts.TargetID
+		if targetID == 0 {
+			host := hostOf(rec.TargetURI())
+			if targetID = targets[host]; targetID == 0 {
+				summary.Unmatched[host]++
+				continue
+			}
+		}
+		imported, err := i.importResponse(ctx, rec, targetID, opts.DryRun)
+		switch {
+		case err != nil:
+			summary.Errors++
+			fmt.Printf("❌ %s: %v\n", rec.TargetURI(), err)
+		case imported:
+			summary.Imported++
+		default:
+			summary.Older++
+		}
+	}
+}
+
+// importResponse stores the payload like the runner does and reports whether the page was written
+func (i *WARCImporter) importResponse(ctx context.Context, rec *warc.Record, targetID int64, dryRun bool) (bool, error) {
+	fetched, err := rec.Date()
+	if err != nil {
+		return false, fmt.Errorf("invalid WARC-Date: %w", err)
+	}
+	resp, body, err := rec.HTTPResponse()
+	if err != nil {
+		return false, err
+	}
+	// Bodies of captures older than the stored visit aren't stored, no page would refer to them
+	uri := rec.TargetURI()
+	stored, err := i.queries.GetPageByPath(ctx, db.GetPageByPathParams{TargetID: targetID, UrlPath: uri})
+	switch {
+	case err == nil:
+		if stored.LastVisitedAt.Valid && stored.LastVisitedAt.Time.After(fetched) {
+			return false, nil
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return false, fmt.Errorf("failed to get page: %w", err)
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	key, err := i.contents.Put(ctx, body)
+	if err != nil {
+		return false, err
+	}
+	updated := fetched
+	if lastMod, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		updated = lastMod
+	}
+	n, err := i.queries.ImportPage(ctx, db.ImportPageParams{
+		TargetID:       targetID,
+		UrlPath:        uri,
+		FullUrl:        uri,
+		ContentHash:    sql.NullString{String: content.Hash(body), Valid: true},
+		HttpStatusCode: sql.NullInt64{Int64: int64(resp.StatusCode), Valid: true},
+		ContentLength:  sql.NullInt64{Int64: int64(len(body)), Valid: true},
+		LastVisitedAt:  sql.NullTime{Time: fetched.UTC(), Valid: true},
+		LastUpdatedAt:  sql.NullTime{Time: updated.UTC(), Valid: true},
+		ContentKey:     sql.NullString{String: key, Valid: true},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to save page: %w", err)
+	}
+	return n > 0, nil
+}
+
+// targetsByHost maps the host of every target's website URL, with and without www., to the target
+func (i *WARCImporter) targetsByHost(ctx context.Context) (map[string]int64, error) {
+	targets, err := i.queries.ListAllTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	hosts := map[string]int64{}
+	for _, t := range targets {
+		host := hostOf(t.WebsiteUrl)
+		if host == "" {
+			continue
+		}
+		hosts[host] = t.ID
+		if bare, ok := strings.CutPrefix(host, "www."); ok {
+			if _, taken := hosts[bare]; !taken {
+				hosts[bare] = t.ID
+			}
+		} else if _, taken := hosts["www."+host]; !taken {
+			hosts["www."+host] = t.ID
+		}
+	}
+	return hosts, nil
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// Print writes the summary, unmatched hosts most frequent first
+func (s *WARCImportSummary) Print() {
+	fmt.Printf("\n📦 WARC Import Summary\n")
+	fmt.Printf("Records read: %d\n", s.Records)
+	fmt.Printf("Responses: %d\n", s.Responses)
+	fmt.Printf("Pages imported: %d\n", s.Imported)
+	fmt.Printf("Older than stored page: %d\n", s.Older)
+	fmt.Printf("Errors: %d\n", s.Errors)
+	fmt.Printf("Duration: %s\n", s.Duration.Round(time.Millisecond))
+
+	if len(s.Unmatched) == 0 {
+		return
+	}
+	hosts := make([]string, 0, len(s.Unmatched))
+	for h := range s.Unmatched {
+		hosts = append(hosts, h)
+	}
+	sort.Slice(hosts, func(a, b int) bool {
+		if s.Unmatched[hosts[a]] != s.Unmatched[hosts[b]] {
+			return s.Unmatched[hosts[a]] > s.Unmatched[hosts[b]]
+		}
+		return hosts[a] < hosts[b]
+	})
+	fmt.Printf("\nResponses without a target (add the target or pass --target-id):\n")
+	for _, h := range hosts {
+		fmt.Printf("  %-50s %d\n", h, s.Unmatched[h])
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/service/warc"
+	"app/internal/scraper/storage"
+)
+
+// crawlToWARC lets the runner fetch the paths with archiving on and returns the WARC files
+func crawlToWARC(t *testing.T, server *httptest.Server, paths ...string) []string {
+	t.Helper()
+	dbConn, _ := newReclassifyTestDB(t)
+	queries := newQueriesAdapter(storage.NewSQLite(dbConn))
+	if _, err := dbConn.Exec(`INSERT INTO scraper_targets (id, website_url) VALUES (1, ?)`, server.URL); err != nil {
+		t.Fatalf("failed to insert target: %v", err)
+	}
+	archive, err := warc.NewWriter(t.TempDir(), "test", 1<<20)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	sr := &ScraperRunner{
+		db:          dbConn,
+		queries:     queries,
+		httpClient:  server.Client(),
+		rateLimiter: NewRateLimiter(),
+		contents:    content.NewDBStore(queries),
+		warc:        archive,
+		log:         logger.New(logger.ComponentFetcher, nil),
+	}
+	for _, path := range paths {
+		if page := sr.scrapeURLAttempt(context.Background(), PageToProcess{TargetID: 1, URL: server.URL + path}, nil); page.Error != nil {
+			t.Fatalf("fetch %s: %v", path, page.Error)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return archive.Files()
+}
+
+func TestWARCImporter_RebuildsPages(t *testing.T) {
+	var label atomic.Value
+	label.Store("page")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "<html>%s %s</html>", label.Load(), r.URL.Path)
+	}))
+	defer server.Close()
+	files := crawlToWARC(t, server, "/quotes", "/about", "/missing")
+
+	// A fresh database with the target, as when reprocessing an old crawl
+	dbConn, queries := newReclassifyTestDB(t)
+	target, err := queries.CreateTarget(context.Background(), db.CreateTargetParams{WebsiteUrl: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	importer := &WARCImporter{db: dbConn, queries: queries, contents: content.NewDBStore(queries)}
+	summary, err := importer.Run(context.Background(), files, WARCImportOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.Responses != 3 || summary.Imported != 3 || summary.Errors != 0 || len(summary.Unmatched) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if body := storedPageBody(t, dbConn, server.URL+"/quotes"); body != "<html>page /quotes</html>" {
+		t.Errorf("unexpected body %q", body)
+	}
+	page, err := queries.GetPageByPath(context.Background(), db.GetPageByPathParams{TargetID: target.ID, UrlPath: server.URL + "/missing"})
+	if err != nil || page.HttpStatusCode.Int64 != 404 {
+		t.Errorf("expected the 404 kept, got %+v (%v)", page, err)
+	}
+	if time.Since(page.LastVisitedAt.Time) > time.Minute {
+		t.Errorf("expected the capture time as last visit, got %v", page.LastVisitedAt.Time)
+	}
+
+	// Pages visited after the capture keep their newer content, the older
+	// bodies aren't stored
+	if _, err := dbConn.Exec(`UPDATE scraper_pages SET last_visited_at = ?`, time.Now().Add(time.Hour).UTC()); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	label.Store("changed page")
+	files = crawlToWARC(t, server, "/quotes", "/about")
+	summary, err = importer.Run(context.Background(), files, WARCImportOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.Imported != 0 || summary.Older != 2 {
+		t.Errorf("expected all responses older than the stored pages, got %+v", summary)
+	}
+	var contents int
+	_ = dbConn.QueryRow(`SELECT COUNT(*) FROM scraper_contents`).Scan(&contents)
+	if contents != 3 {
+		t.Errorf("expected only the bodies of the imported pages stored, got %d", contents)
+	}
+	if body := storedPageBody(t, dbConn, server.URL+"/quotes"); body != "<html>page /quotes</html>" {
+		t.Errorf("expected the newer body kept, got %q", body)
+	}
+}
+
+func TestWARCImporter_UnmatchedHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "<html>hello</html>")
+	}))
+	defer server.Close()
+	files := crawlToWARC(t, server, "/hello")
+
+	dbConn, queries := newReclassifyTestDB(t)
+	importer := &WARCImporter{db: dbConn, queries: queries, contents: content.NewDBStore(queries)}
+	summary, err := importer.Run(context.Background(), files, WARCImportOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.Imported != 0 || summary.Unmatched["127.0.0.1"] != 1 {
+		t.Errorf("expected the response reported as unmatched, got %+v", summary)
+	}
+
+	target, err := queries.CreateTarget(context.Background(), db.CreateTargetParams{WebsiteUrl: "https://elsewhere.example"})
+	if err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	summary, err = importer.Run(context.Background(), files, WARCImportOptions{TargetID: target.ID})
+	if err != nil || summary.Imported != 1 {
+		t.Errorf("expected --target-id to import the response, got %+v (%v)", summary, err)
+	}
+}
@@ -0,0 +1,439 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// Source is where the value of a setting comes from
+type Source string
+
+const (
+	SourceDefault  Source = "default"
+	SourceDatabase Source = "database"
+	SourceFile     Source = "file"
+	SourceEnv      Source = "env"
+	SourceFlag     Source = "flag"
+)
+
+// precedence lists the sources from lowest to highest priority: the
+// scraper_config table holds shared defaults for every host, a config file,
+// the environment and command line flags override them locally
+var precedence = []Source{SourceDefault, SourceDatabase, SourceFile, SourceEnv, SourceFlag}
+
+// FileEnv is the environment variable naming the config file
+const FileEnv = "SCRAPER_CONFIG"
+
+// DefaultFile is the config file read when present and no other file is named
+const DefaultFile = "scraper.json"
+
+// Type is the value type of a setting
+type Type string
+
+const (
+	TypeString Type = "string"
+	TypeInt    Type = "int"
+)
+
+// Setting is a configuration key and where it can be set
+type Setting struct {
+	// Key names the setting in the config file, on `config show` and, for
+	// settings stored in the database, in the scraper_config table
+	Key string
+	// Env lists the environment variables of the setting, the first one set wins
+	Env         []string
+	Default     string
+	Description string
+	Type        Type
+	// Min and Max bound TypeInt settings
+	Min, Max int
+	// Database reports whether the setting may be stored in scraper_config
+	Database bool
+	validate func(string) error
+}
+
+// Validate checks that value is valid for the setting
+func (s Setting) Validate(value string) error {
+	if err := s.check(value); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", s.Key, value, err)
+	}
+	return nil
+}
+
+func (s Setting) check(value string) error {
+	switch s.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		if n < s.Min || n > s.Max {
+			return fmt.Errorf("must be between %d and %d", s.Min, s.Max)
+		}
+	default:
+		if strings.TrimSpace(value) == "" {
+			return errors.New("must not be empty")
+		}
+	}
+	if s.validate != nil {
+		return s.validate(value)
+	}
+	return nil
+}
+
+const (
+	KeyDatabaseURL    = "database_url"
+	KeyWorkers        = "max_concurrent_workers"
+	KeyBatchSize      = "queue_batch_size"
+	KeyRequestTimeout = "connection_timeout_seconds"
+	KeyUserAgent      = "default_user_agent"
+	KeyMaxPageSize    = "max_page_size_mb"
+	KeyCrawlDelay     = "default_crawl_delay"
+	KeyWARCDir        = "warc_dir"
+	KeyWARCMaxSize    = "warc_max_size_mb"
+	KeyLogRetention   = "log_retention_days"
+	KeyLogMaxRows     = "log_max_rows"
+)
+
+// Settings lists every configuration key
+var Settings = []Setting{
+	{
+		Key:         KeyDatabaseURL,
+		Env:         []string{storage.DSNEnv, "SCRAPER_DB_PATH"},
+		Default:     storage.DefaultDSN,
+		Description: "Database DSN, a SQLite path or a postgres:// URL",
+		Type:        TypeString,
+		validate: func(v string) error {
+			_, _, err := storage.ParseDSN(v)
+			return err
+		},
+	},
+	{
+		Key:         KeyWorkers,
+		Env:         []string{"SCRAPER_CONCURRENT_WORKERS"},
+		Default:     "3",
+		Description: "Maximum concurrent scraper workers",
+		Type:        TypeInt,
+		Min:         1,
+		Max:         20,
+		Database:    true,
+	},
+	{
+		Key:         KeyBatchSize,
+		Env:         []string{"SCRAPER_BATCH_SIZE"},
+		Default:     "10",
+		Description: "Number of URLs to process in each batch",
+		Type:        TypeInt,
+		Min:         1,
+		Max:         100,
+		Database:    true,
+	},
+	{
+		Key:         KeyRequestTimeout,
+		Env:         []string{"SCRAPER_REQUEST_TIMEOUT"},
+		Default:     "30",
+		Description: "HTTP connection timeout",
+		Type:        TypeInt,
+		Min:         1,
+		Max:         600,
+		Database:    true,
+	},
+	{
+		Key:         KeyUserAgent,
+		Env:         []string{"SCRAPER_USER_AGENT"},
+		Default:     "ScraperBot/1.0",
+		Description: "Default user agent string",
+		Type:        TypeString,
+		Database:    true,
+	},
+	{
+		Key:         KeyMaxPageSize,
+		Env:         []string{"SCRAPER_MAX_PAGE_SIZE_MB"},
+		Default:     "10",
+		Description: "Maximum page size to download in MB",
+		Type:        TypeInt,
+		Min:         1,
+		Max:         1024,
+		Database:    true,
+	},
+	{
+		Key:         KeyCrawlDelay,
+		Env:         []string{"SCRAPER_CRAWL_DELAY"},
+		Default:     "1",
+		Description: "Default delay between requests in seconds",
+		Type:        TypeInt,
+		Min:         0,
+		Max:         3600,
+		Database:    true,
+	},
+	{
+		Key:         KeyWARCDir,
+		Env:         []string{"SCRAPER_WARC_DIR"},
+		Description: "Directory the runner archives fetches to as WARC files, empty to disable",
+		Type:        TypeString,
+	},
+	{
+		Key:         KeyWARCMaxSize,
+		Env:         []string{"SCRAPER_WARC_MAX_SIZE_MB"},
+		Default:     "1024",
+		Description: "Size in MB after which a new WARC file is started",
+		Type:        TypeInt,
+		Min:         1,
+		Max:         102400,
+		Database:    true,
+	},
+	{
+		Key:         KeyLogRetention,
+		Env:         []string{"SCRAPER_LOG_RETENTION_DAYS"},
+		Default:     "30",
+		Description: "Days scraper_logs rows are kept before automatic pruning, 0 keeps them",
+		Type:        TypeInt,
+		Min:         0,
+		Max:         3650,
+		Database:    true,
+	},
+	{
+		Key:         KeyLogMaxRows,
+		Env:         []string{"SCRAPER_LOG_MAX_ROWS"},
+		Default:     "1000000",
+		Description: "Newest scraper_logs rows kept by automatic pruning, 0 for no limit",
+		Type:        TypeInt,
+		Min:         0,
+		Max:         1000000000,
+		Database:    true,
+	},
+}
+
+// Lookup returns the setting with the given key
+func Lookup(key string) (Setting, bool) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Value is the resolved value of a setting
+type Value struct {
+	Setting
+	Value  string
+	Source Source
+	// Origin names the environment variable, file or flag the value was read from
+	Origin string
+}
+
+// Options are the inputs of Load besides the environment
+type Options struct {
+	// File is the config file, empty for SCRAPER_CONFIG or ./scraper.json when present
+	File string
+	// Flags holds values set on the command line by setting key
+	Flags map[string]string
+}
+
+type layerValue struct {
+	value  string
+	origin string
+}
+
+// Config is the resolved configuration, see precedence for the order of its sources
+type Config struct {
+	layers map[Source]map[string]layerValue
+}
+
+// Load resolves the configuration from defaults, the config file, the
+// environment and flags. Settings stored in the database are added by
+// LoadDatabase once the store is open.
+func Load(opts Options) (*Config, error) {
+	c := &Config{layers: map[Source]map[string]layerValue{}}
+	for _, src := range precedence {
+		c.layers[src] = map[string]layerValue{}
+	}
+	for _, s := range Settings {
+		c.layers[SourceDefault][s.Key] = layerValue{value: s.Default}
+	}
+
+	if err := c.loadFile(opts.File); err != nil {
+		return nil, err
+	}
+	for _, s := range Settings {
+		for _, env := range s.Env {
+			if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+				if err := c.set(SourceEnv, s, v, env); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	for key, v := range opts.Flags {
+		s, ok := Lookup(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+		if err := c.set(SourceFlag, s, v, "command line"); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// loadFile reads a JSON object of setting keys, numbers may be unquoted
+func (c *Config) loadFile(path string) error {
+	required := true
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	for key, raw := range values {
+		s, ok := Lookup(key)
+		if !ok {
+			return fmt.Errorf("config file %s: unknown setting %q", path, key)
+		}
+		var v string
+		switch raw := raw.(type) {
+		case string:
+			v = raw
+		case json.Number:
+			v = raw.String()
+		default:
+			return fmt.Errorf("config file %s: %s must be a string or number", path, key)
+		}
+		if err := c.set(SourceFile, s, v, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) set(src Source, s Setting, value, origin string) error {
+	if err := s.Validate(value); err != nil {
+		return fmt.Errorf("%s: %w", origin, err)
+	}
+	c.layers[src][s.Key] = layerValue{value: value, origin: origin}
+	return nil
+}
+
+// ConfigQueries reads the scraper_config table
+type ConfigQueries interface {
+	ListAllConfig(ctx context.Context) ([]db.ScraperConfig, error)
+}
+
+// LoadDatabase adds the settings stored in scraper_config. Rows of unknown
+// keys are ignored, they may belong to a newer version.
+func (c *Config) LoadDatabase(ctx context.Context, q ConfigQueries) error {
+	rows, err := q.ListAllConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load settings from database: %w", err)
+	}
+	for _, row := range rows {
+		s, ok := Lookup(row.Key)
+		if !ok || !s.Database {
+			continue
+		}
+		if err := c.set(SourceDatabase, s, row.Value, "scraper_config"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the resolved value of a setting
+func (c *Config) Get(key string) Value {
+	s, _ := Lookup(key)
+	for i := len(precedence) - 1; i >= 0; i-- {
+		if lv, ok := c.layers[precedence[i]][key]; ok {
+			return Value{Setting: s, Value: lv.value, Source: precedence[i], Origin: lv.origin}
+		}
+	}
+	return Value{Setting: s, Source: SourceDefault}
+}
+
+// Values returns the resolved value of every setting, sorted by key
+func (c *Config) Values() []Value {
+	values := make([]Value, 0, len(Settings))
+	for _, s := range Settings {
+		values = append(values, c.Get(s.Key))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// Overrides returns the value that takes precedence over a setting stored in
+// the database, false if the database value is the one in effect
+func (c *Config) Overrides(key string) (Value, bool) {
+	v := c.Get(key)
+	return v, v.Source == SourceFile || v.Source == SourceEnv || v.Source == SourceFlag
+}
+
+func (c *Config) int(key string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(c.Get(key).Value))
+	return n
+}
+
+// DatabaseURL is the DSN of the storage backend
+func (c *Config) DatabaseURL() string { return c.Get(KeyDatabaseURL).Value }
+
+// Workers is the number of concurrent scraper workers
+func (c *Config) Workers() int { return c.int(KeyWorkers) }
+
+// BatchSize is the number of queued URLs dequeued per batch
+func (c *Config) BatchSize() int { return c.int(KeyBatchSize) }
+
+// RequestTimeout is the timeout of HTTP requests
+func (c *Config) RequestTimeout() time.Duration {
+	return time.Duration(c.int(KeyRequestTimeout)) * time.Second
+}
+
+// UserAgent is sent for targets without their own user agent
+func (c *Config) UserAgent() string { return c.Get(KeyUserAgent).Value }
+
+// MaxPageSize is the largest response body downloaded, in bytes
+func (c *Config) MaxPageSize() int64 { return int64(c.int(KeyMaxPageSize)) << 20 }
+
+// CrawlDelay is the delay between requests to targets without their own rate
+func (c *Config) CrawlDelay() time.Duration {
+	return time.Duration(c.int(KeyCrawlDelay)) * time.Second
+}
+
+// WARCDir is where fetches are archived, empty when archiving is off
+func (c *Config) WARCDir() string { return c.Get(KeyWARCDir).Value }
+
+// WARCMaxSize is the size of a WARC file before the next is started, in bytes
+func (c *Config) WARCMaxSize() int64 { return int64(c.int(KeyWARCMaxSize)) << 20 }
+
+// LogRetention is the age after which log rows are pruned, 0 keeps them
+func (c *Config) LogRetention() time.Duration {
+	return time.Duration(c.int(KeyLogRetention)) * 24 * time.Hour
+}
+
+// LogMaxRows is the number of newest log rows pruning keeps, 0 for no limit
+func (c *Config) LogMaxRows() int64 { return int64(c.int(KeyLogMaxRows)) }
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// clearEnv unsets every settings variable so the host environment doesn't leak into tests
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(FileEnv, "")
+	for _, s := range Settings {
+		for _, env := range s.Env {
+			t.Setenv(env, "")
+		}
+	}
+	t.Chdir(t.TempDir())
+}
+
+type fakeConfigQueries []db.ScraperConfig
+
+func (f fakeConfigQueries) ListAllConfig(ctx context.Context) ([]db.ScraperConfig, error) {
+	return f, nil
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scraper.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DatabaseURL() != storage.DefaultDSN {
+		t.Errorf("expected default DSN, got %q", cfg.DatabaseURL())
+	}
+	if cfg.Workers() != 3 || cfg.BatchSize() != 10 {
+		t.Errorf("expected 3 workers and batch size 10, got %d and %d", cfg.Workers(), cfg.BatchSize())
+	}
+	if cfg.RequestTimeout() != 30*time.Second {
+		t.Errorf("expected 30s timeout, got %v", cfg.RequestTimeout())
+	}
+	for _, v := range cfg.Values() {
+		if v.Source != SourceDefault {
+			t.Errorf("%s: expected default source, got %s", v.Key, v.Source)
+		}
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	file := writeFile(t, `{"max_concurrent_workers": 4, "queue_batch_size": 20, "connection_timeout_seconds": "15"}`)
+	t.Setenv("SCRAPER_CONCURRENT_WORKERS", "6")
+	t.Setenv("SCRAPER_BATCH_SIZE", "30")
+
+	cfg, err := Load(Options{File: file, Flags: map[string]string{KeyWorkers: "8"}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rows := fakeConfigQueries{
+		{Key: KeyWorkers, Value: "5"},
+		{Key: KeyBatchSize, Value: "40"},
+		{Key: KeyRequestTimeout, Value: "45"},
+		{Key: KeyUserAgent, Value: "SharedBot/1.0"},
+		{Key: "max_page_size_mb", Value: "10"},
+	}
+	if err := cfg.LoadDatabase(context.Background(), rows); err != nil {
+		t.Fatalf("LoadDatabase: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source Source
+	}{
+		{KeyWorkers, "8", SourceFlag},
+		{KeyBatchSize, "30", SourceEnv},
+		{KeyRequestTimeout, "15", SourceFile},
+		{KeyUserAgent, "SharedBot/1.0", SourceDatabase},
+		{KeyDatabaseURL, storage.DefaultDSN, SourceDefault},
+	}
+	for _, tt := range tests {
+		v := cfg.Get(tt.key)
+		if v.Value != tt.value || v.Source != tt.source {
+			t.Errorf("%s: expected %q from %s, got %q from %s", tt.key, tt.value, tt.source, v.Value, v.Source)
+		}
+	}
+	if _, overridden := cfg.Overrides(KeyUserAgent); overridden {
+		t.Error("expected the database user agent to be in effect")
+	}
+	if v, overridden := cfg.Overrides(KeyBatchSize); !overridden || v.Origin != "SCRAPER_BATCH_SIZE" {
+		t.Errorf("expected batch size overridden by SCRAPER_BATCH_SIZE, got %+v", v)
+	}
+}
+
+func TestLoad_DatabaseURLEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SCRAPER_DB_PATH", "data/scraper/scraper.db")
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DatabaseURL() != "data/scraper/scraper.db" {
+		t.Errorf("expected SCRAPER_DB_PATH, got %q", cfg.DatabaseURL())
+	}
+
+	// SCRAPER_DATABASE_URL takes precedence over the legacy variable
+	t.Setenv(storage.DSNEnv, "postgres://db/scraper")
+	cfg, err = Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DatabaseURL() != "postgres://db/scraper" {
+		t.Errorf("expected SCRAPER_DATABASE_URL, got %q", cfg.DatabaseURL())
+	}
+}
+
+func TestLoad_DefaultFile(t *testing.T) {
+	clearEnv(t)
+	if err := os.WriteFile(DefaultFile, []byte(`{"database_url": "sqlite3://local.db"}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if v := cfg.Get(KeyDatabaseURL); v.Value != "sqlite3://local.db" || v.Source != SourceFile {
+		t.Errorf("expected DSN from ./scraper.json, got %+v", v)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(t *testing.T) Options
+		want string
+	}{
+		{
+			name: "missing named file",
+			opts: func(t *testing.T) Options { return Options{File: filepath.Join(t.TempDir(), "missing.json")} },
+			want: "failed to read config file",
+		},
+		{
+			name: "unknown file key",
+			opts: func(t *testing.T) Options { return Options{File: writeFile(t, `{"workers": 4}`)} },
+			want: `unknown setting "workers"`,
+		},
+		{
+			name: "out of range env",
+			opts: func(t *testing.T) Options {
+				t.Setenv("SCRAPER_CONCURRENT_WORKERS", "50")
+				return Options{}
+			},
+			want: "SCRAPER_CONCURRENT_WORKERS",
+		},
+		{
+			name: "non-numeric flag",
+			opts: func(t *testing.T) Options { return Options{Flags: map[string]string{KeyBatchSize: "many"}} },
+			want: "must be a whole number",
+		},
+		{
+			name: "unsupported DSN",
+			opts: func(t *testing.T) Options { return Options{Flags: map[string]string{KeyDatabaseURL: "mysql://db"}} },
+			want: "unsupported database DSN scheme",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, err := Load(tt.opts(t))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadDatabase_Invalid(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rows := fakeConfigQueries{{Key: KeyRequestTimeout, Value: "soon", Description: sql.NullString{}}}
+	if err := cfg.LoadDatabase(context.Background(), rows); err == nil {
+		t.Error("expected an invalid database value to be rejected")
+	}
+}
@@ -0,0 +1,9 @@
+package db
+
+import "embed"
+
+// Migrations holds the schema migrations of both dialects: migrations/ for
+// SQLite and postgres/migrations/ for PostgreSQL
+//
+//go:embed migrations/*.sql postgres/migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,2 @@
+-- Remove per-target classifier profile overrides
+ALTER TABLE scraper_targets DROP COLUMN classifier_overrides_json;
@@ -0,0 +1,2 @@
+-- Remove detected page language
+ALTER TABLE scraper_pages DROP COLUMN language;
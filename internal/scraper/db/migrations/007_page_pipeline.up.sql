@@ -0,0 +1,3 @@
+-- Add post-processing pipeline results: per-stage status/errors and the extracted quotes
+ALTER TABLE scraper_pages ADD COLUMN pipeline_status_json TEXT;
+ALTER TABLE scraper_pages ADD COLUMN quotes_json TEXT;
@@ -0,0 +1,4 @@
+-- The Go step of this migration moved the stored bodies back inline first
+DROP INDEX IF EXISTS idx_scraper_pages_content_key;
+ALTER TABLE scraper_pages DROP COLUMN content_key;
+DROP TABLE scraper_contents;
@@ -0,0 +1,16 @@
+-- Content-addressed page bodies: identical HTML is stored once, keyed by the
+-- SHA-256 of the body (content_key), and compressed ('gzip'). The page
+-- content_hash stays the cheap change-detection hash. The Go step of this
+-- migration moves the existing bodies out of scraper_pages, since SQLite
+-- cannot compress them in SQL.
+CREATE TABLE scraper_contents (
+    content_key TEXT PRIMARY KEY,
+    encoding TEXT NOT NULL DEFAULT 'identity', -- 'identity' or 'gzip'
+    body BLOB NOT NULL,
+    raw_size INTEGER NOT NULL,
+    stored_size INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE scraper_pages ADD COLUMN content_key TEXT;
+CREATE INDEX idx_scraper_pages_content_key ON scraper_pages(content_key);
@@ -0,0 +1,3 @@
+DROP TABLE scraper_api_tokens;
+DROP TABLE scraper_sessions;
+DROP TABLE scraper_users;
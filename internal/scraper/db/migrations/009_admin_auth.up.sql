@@ -0,0 +1,35 @@
+-- Admin UI accounts. role is 'viewer', 'operator' or 'admin'; passwords are
+-- bcrypt hashes.
+CREATE TABLE scraper_users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'viewer',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_login_at DATETIME
+);
+
+-- Login sessions, keyed by the SHA-256 of the session cookie
+CREATE TABLE scraper_sessions (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+
+    FOREIGN KEY (user_id) REFERENCES scraper_users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_scraper_sessions_user ON scraper_sessions(user_id);
+
+-- Bearer tokens for /api/v1, keyed by the SHA-256 of the token
+CREATE TABLE scraper_api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME,
+
+    FOREIGN KEY (user_id) REFERENCES scraper_users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scraper_logs_fts;
+DROP INDEX IF EXISTS idx_scraper_logs_target;
@@ -0,0 +1,3 @@
+-- Log browser filters: by target and newest first. SQLite keeps its full text
+-- index in scraper_logs_fts, created by the log search when FTS5 is available.
+CREATE INDEX idx_scraper_logs_target ON scraper_logs(target_id, id);
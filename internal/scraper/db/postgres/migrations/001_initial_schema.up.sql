@@ -0,0 +1,96 @@
+-- Initial scraper database schema (PostgreSQL)
+
+-- Target websites configuration
+CREATE TABLE scraper_targets (
+    id BIGSERIAL PRIMARY KEY,
+    website_url TEXT NOT NULL UNIQUE,
+    sitemap_url TEXT,
+    follow_sitemap BOOLEAN DEFAULT true,
+    last_visited_at TIMESTAMPTZ,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+
+    -- Rate limiting settings
+    crawl_delay_seconds BIGINT DEFAULT 1,
+    max_concurrent_requests BIGINT DEFAULT 5,
+
+    -- Additional metadata
+    user_agent TEXT,
+    custom_headers TEXT, -- JSON string for custom headers
+    notes TEXT,
+
+    sitemap_patterns TEXT, -- JSON array of regex patterns for filtering sub-sitemaps
+    url_patterns TEXT,     -- JSON array of regex patterns for filtering URLs
+    domain_name TEXT       -- Extract from website_url for easier pattern lookup
+);
+
+-- Crawled pages storage
+CREATE TABLE scraper_pages (
+    id BIGSERIAL PRIMARY KEY,
+    target_id BIGINT NOT NULL REFERENCES scraper_targets(id) ON DELETE CASCADE,
+    url_path TEXT NOT NULL, -- relative path from domain
+    full_url TEXT NOT NULL,
+    html_content TEXT,
+    content_hash TEXT, -- hash of content for change detection
+    http_status_code BIGINT,
+    response_time_ms BIGINT,
+    content_length BIGINT,
+    last_visited_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_updated_at TIMESTAMPTZ,
+    first_discovered_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    visit_count BIGINT DEFAULT 1,
+
+    UNIQUE(target_id, url_path)
+);
+
+-- Queue shared by all crawler hosts, dequeued with FOR UPDATE SKIP LOCKED
+CREATE TABLE scraper_queue (
+    id BIGSERIAL PRIMARY KEY,
+    target_id BIGINT NOT NULL REFERENCES scraper_targets(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    priority BIGINT DEFAULT 0,
+    attempts BIGINT DEFAULT 0,
+    max_attempts BIGINT DEFAULT 3,
+    status TEXT DEFAULT 'pending', -- 'pending', 'processing', 'completed', 'failed'
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMPTZ,
+    error_message TEXT
+);
+
+-- Logging system
+CREATE TABLE scraper_logs (
+    id BIGSERIAL PRIMARY KEY,
+    log_type TEXT NOT NULL, -- 'manager', 'worker', 'error'
+    target_id BIGINT REFERENCES scraper_targets(id) ON DELETE SET NULL,
+    url TEXT,
+    message TEXT NOT NULL,
+    details TEXT, -- JSON string for additional data
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Configuration management
+CREATE TABLE scraper_config (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    description TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes for performance
+CREATE INDEX idx_scraper_pages_target_id ON scraper_pages(target_id);
+CREATE INDEX idx_scraper_pages_content_hash ON scraper_pages(content_hash);
+CREATE INDEX idx_scraper_queue_status ON scraper_queue(status);
+CREATE INDEX idx_scraper_queue_target_id ON scraper_queue(target_id);
+CREATE INDEX idx_scraper_logs_type ON scraper_logs(log_type);
+CREATE INDEX idx_scraper_logs_created_at ON scraper_logs(created_at);
+CREATE INDEX idx_scraper_targets_domain ON scraper_targets(domain_name);
+
+-- Default configuration values
+INSERT INTO scraper_config (key, value, description) VALUES
+('default_user_agent', 'Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36', 'Default user agent string'),
+('max_page_size_mb', '10', 'Maximum page size to download in MB'),
+('connection_timeout_seconds', '30', 'HTTP connection timeout'),
+('default_crawl_delay', '1', 'Default delay between requests in seconds'),
+('max_concurrent_workers', '5', 'Maximum concurrent scraper workers'),
+('queue_batch_size', '10', 'Number of URLs to process in each batch');
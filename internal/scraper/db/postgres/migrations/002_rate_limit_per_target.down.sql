@@ -0,0 +1,2 @@
+ALTER TABLE scraper_targets DROP COLUMN requests_per_second;
+
@@ -0,0 +1 @@
+ALTER TABLE scraper_targets ADD COLUMN requests_per_second DOUBLE PRECISION DEFAULT 1.0;
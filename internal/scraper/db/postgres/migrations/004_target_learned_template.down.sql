@@ -0,0 +1,2 @@
+-- Remove per-target learned selector template
+ALTER TABLE scraper_targets DROP COLUMN learned_template_json;
@@ -0,0 +1,2 @@
+-- Add per-target learned selector template (JSON aggregated from classifier outcomes)
+ALTER TABLE scraper_targets ADD COLUMN learned_template_json TEXT;
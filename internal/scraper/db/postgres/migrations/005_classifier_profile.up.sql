@@ -0,0 +1,2 @@
+-- Add per-target classifier profile overrides (partial profile JSON merged onto the global profile)
+ALTER TABLE scraper_targets ADD COLUMN classifier_overrides_json TEXT;
@@ -0,0 +1,2 @@
+-- Add detected page language (ISO 639-1) stored alongside the classifier result
+ALTER TABLE scraper_pages ADD COLUMN language TEXT;
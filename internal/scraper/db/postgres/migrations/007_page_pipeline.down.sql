@@ -0,0 +1,3 @@
+-- Remove post-processing pipeline results
+ALTER TABLE scraper_pages DROP COLUMN quotes_json;
+ALTER TABLE scraper_pages DROP COLUMN pipeline_status_json;
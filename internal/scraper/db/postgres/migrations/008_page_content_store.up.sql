@@ -0,0 +1,15 @@
+-- Content-addressed page bodies: identical HTML is stored once, keyed by the
+-- SHA-256 of the body (content_key), and compressed ('gzip'). The page
+-- content_hash stays the cheap change-detection hash. The Go step of this
+-- migration moves the existing bodies out of scraper_pages compressed.
+CREATE TABLE scraper_contents (
+    content_key TEXT PRIMARY KEY,
+    encoding TEXT NOT NULL DEFAULT 'identity', -- 'identity' or 'gzip'
+    body BYTEA NOT NULL,
+    raw_size BIGINT NOT NULL,
+    stored_size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE scraper_pages ADD COLUMN content_key TEXT;
+CREATE INDEX idx_scraper_pages_content_key ON scraper_pages(content_key);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_scraper_logs_search;
+DROP INDEX IF EXISTS idx_scraper_logs_target;
@@ -0,0 +1,5 @@
+-- Log browser filters: by target and newest first, and full text search over
+-- message and details matching the expression of the log search
+CREATE INDEX idx_scraper_logs_target ON scraper_logs(target_id, id);
+CREATE INDEX idx_scraper_logs_search ON scraper_logs
+    USING GIN (to_tsvector('simple', message || ' ' || COALESCE(details, '')));
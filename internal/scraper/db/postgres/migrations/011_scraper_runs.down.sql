@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_scraper_logs_run;
+DROP INDEX IF EXISTS idx_scraper_queue_run;
+ALTER TABLE scraper_logs DROP COLUMN run_id;
+ALTER TABLE scraper_queue DROP COLUMN run_id;
+DROP TABLE scraper_runs;
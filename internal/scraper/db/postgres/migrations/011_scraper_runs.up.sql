@@ -0,0 +1,27 @@
+-- One row per crawl run. trigger_source is 'cli', 'ui' or 'schedule';
+-- target_ids is a JSON array of the targets crawled and error_breakdown a
+-- JSON object counting failed fetches by error type.
+CREATE TABLE scraper_runs (
+    id BIGSERIAL PRIMARY KEY,
+    trigger_source TEXT NOT NULL DEFAULT 'cli',
+    status TEXT NOT NULL DEFAULT 'running', -- 'running', 'completed', 'failed', 'cancelled'
+    dry_run BOOLEAN NOT NULL DEFAULT false,
+    target_ids TEXT NOT NULL DEFAULT '[]',
+    total_urls BIGINT NOT NULL DEFAULT 0,
+    processed BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    skipped BIGINT NOT NULL DEFAULT 0,
+    error_breakdown TEXT,
+    error_message TEXT,
+    started_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_scraper_runs_started_at ON scraper_runs(started_at);
+
+-- The run that last crawled a queue item and the run a log was written by
+ALTER TABLE scraper_queue ADD COLUMN run_id BIGINT REFERENCES scraper_runs(id) ON DELETE SET NULL;
+ALTER TABLE scraper_logs ADD COLUMN run_id BIGINT REFERENCES scraper_runs(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_scraper_queue_run ON scraper_queue(run_id);
+CREATE INDEX idx_scraper_logs_run ON scraper_logs(run_id);
@@ -0,0 +1,12 @@
+-- name: GetConfig :one
+SELECT value FROM scraper_config WHERE key = $1;
+
+-- name: SetConfig :exec
+INSERT INTO scraper_config (key, value, description)
+VALUES ($1, $2, $3)
+ON CONFLICT(key) DO UPDATE SET
+    value = excluded.value,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListAllConfig :many
+SELECT * FROM scraper_config ORDER BY key;
@@ -0,0 +1,17 @@
+-- name: PutContent :exec
+INSERT INTO scraper_contents (content_key, encoding, body, raw_size, stored_size)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT(content_key) DO NOTHING;
+
+-- name: GetContent :one
+SELECT encoding, body FROM scraper_contents WHERE content_key = $1;
+
+-- name: DeleteOrphanContents :execrows
+DELETE FROM scraper_contents
+WHERE content_key NOT IN (SELECT content_key FROM scraper_pages WHERE content_key IS NOT NULL);
+
+-- name: GetContentStats :one
+SELECT COUNT(*)::bigint AS contents,
+       COALESCE(SUM(raw_size), 0)::bigint AS raw_bytes,
+       COALESCE(SUM(stored_size), 0)::bigint AS stored_bytes
+FROM scraper_contents;
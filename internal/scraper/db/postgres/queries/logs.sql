@@ -0,0 +1,30 @@
+-- name: LogMessage :exec
+INSERT INTO scraper_logs (log_type, target_id, url, message, details, run_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetRecentLogs :many
+SELECT * FROM scraper_logs
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: GetLogsByTarget :many
+SELECT * FROM scraper_logs
+WHERE target_id = sqlc.arg(target_id)
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: GetLogsByLevel :many
+SELECT * FROM scraper_logs
+WHERE log_type = sqlc.arg(log_type)
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: ListLogs :many
+SELECT * FROM scraper_logs
+WHERE id < sqlc.arg(before_id)
+  AND (sqlc.narg(log_type)::text IS NULL OR log_type = sqlc.narg(log_type))
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size)::bigint;
@@ -0,0 +1,122 @@
+-- name: SavePage :one
+INSERT INTO scraper_pages (
+    target_id, url_path, full_url, html_content, content_hash,
+    http_status_code, response_time_ms, content_length, last_updated_at, content_key
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT(target_id, url_path) DO UPDATE SET
+    html_content = excluded.html_content,
+    content_hash = excluded.content_hash,
+    content_key = excluded.content_key,
+    http_status_code = excluded.http_status_code,
+    response_time_ms = excluded.response_time_ms,
+    content_length = excluded.content_length,
+    last_visited_at = CURRENT_TIMESTAMP,
+    last_updated_at = excluded.last_updated_at,
+    visit_count = scraper_pages.visit_count + 1
+RETURNING *;
+
+-- name: MarkPageVisited :exec
+UPDATE scraper_pages
+SET last_visited_at = CURRENT_TIMESTAMP, visit_count = visit_count + 1,
+    http_status_code = $1, response_time_ms = $2
+WHERE id = $3;
+
+-- name: GetPageByPath :one
+SELECT * FROM scraper_pages WHERE target_id = $1 AND url_path = $2;
+
+-- name: ListPagesByTarget :many
+SELECT * FROM scraper_pages WHERE target_id = sqlc.arg(target_id) ORDER BY last_visited_at DESC LIMIT sqlc.arg('limit')::bigint;
+
+-- name: GetPageContentHash :one
+SELECT content_hash FROM scraper_pages WHERE target_id = $1 AND url_path = $2 LIMIT 1;
+
+-- name: SavePageClassifier :exec
+UPDATE scraper_pages
+SET quote_classifier_json = $1, processable = $2, language = $3
+WHERE target_id = $4 AND url_path = $5;
+
+-- name: GetPageClassifier :one
+SELECT quote_classifier_json, processable, language FROM scraper_pages WHERE target_id = $1 AND url_path = $2;
+
+-- name: ListPagesForReclassify :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, quote_classifier_json, processable
+FROM scraper_pages
+WHERE (html_content IS NOT NULL OR content_key IS NOT NULL)
+  AND id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable)::boolean IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(reason)::text IS NULL OR COALESCE(NULLIF(quote_classifier_json::jsonb #>> '{decision,decision_reason}', ''), 'UNCLASSIFIED') = sqlc.narg(reason))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR last_visited_at >= sqlc.narg(since))
+ORDER BY id
+LIMIT sqlc.arg(batch_size)::bigint;
+
+-- name: SavePageQuotes :exec
+UPDATE scraper_pages SET quotes_json = $1 WHERE id = $2;
+
+-- name: SavePagePipelineStatus :exec
+UPDATE scraper_pages SET pipeline_status_json = $1 WHERE id = $2;
+
+-- name: ListPagesForPipeline :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, quote_classifier_json, pipeline_status_json
+FROM scraper_pages
+WHERE (html_content IS NOT NULL OR content_key IS NOT NULL)
+  AND id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(failed_stage)::text IS NULL OR pipeline_status_json::jsonb -> sqlc.narg(failed_stage)::text ->> 'status' = 'failed')
+ORDER BY id
+LIMIT sqlc.arg(batch_size)::bigint;
+
+-- name: ListPagesForExport :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, content_hash, http_status_code, response_time_ms,
+       content_length, first_discovered_at, last_visited_at, last_updated_at, visit_count, processable,
+       language, quote_classifier_json, quotes_json
+FROM scraper_pages
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable)::boolean IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR last_visited_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR last_visited_at < sqlc.narg(until))
+ORDER BY id
+LIMIT sqlc.arg(batch_size)::bigint;
+
+-- name: ImportPage :execrows
+INSERT INTO scraper_pages (
+    target_id, url_path, full_url, content_hash, http_status_code, content_length, last_visited_at, last_updated_at, content_key
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT(target_id, url_path) DO UPDATE SET
+    html_content = NULL,
+    content_hash = excluded.content_hash,
+    content_key = excluded.content_key,
+    http_status_code = excluded.http_status_code,
+    content_length = excluded.content_length,
+    last_visited_at = excluded.last_visited_at,
+    last_updated_at = excluded.last_updated_at
+WHERE scraper_pages.last_visited_at IS NULL OR scraper_pages.last_visited_at <= excluded.last_visited_at;
+
+-- name: GetPage :one
+SELECT * FROM scraper_pages WHERE id = sqlc.arg(id);
+
+-- name: ListPageSummaries :many
+SELECT id, target_id, url_path, full_url, content_hash, http_status_code, response_time_ms, content_length,
+       first_discovered_at, last_visited_at, last_updated_at, visit_count, processable, language
+FROM scraper_pages
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable)::boolean IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(http_status_code)::bigint IS NULL OR http_status_code = sqlc.narg(http_status_code))
+ORDER BY id
+LIMIT sqlc.arg(page_size)::bigint;
+
+-- name: ListPagePaths :many
+SELECT url_path FROM scraper_pages
+WHERE target_id = sqlc.arg(target_id)
+ORDER BY url_path
+LIMIT sqlc.arg(row_limit)::bigint;
+
+-- name: CountClassifierDecisions :many
+SELECT COALESCE(quote_classifier_json::jsonb #>> '{decision,decision_reason}', '')::text AS decision_reason,
+       processable, COUNT(*) AS pages
+FROM scraper_pages
+WHERE target_id = $1
+GROUP BY 1, 2
+ORDER BY pages DESC, decision_reason;
@@ -0,0 +1,83 @@
+-- name: EnqueueURL :one
+INSERT INTO scraper_queue (target_id, url, priority)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DequeuePendingURL :one
+-- SKIP LOCKED lets crawlers on several hosts dequeue concurrently: rows
+-- claimed by another transaction are passed over instead of waited for.
+UPDATE scraper_queue
+SET status = 'processing', processed_at = CURRENT_TIMESTAMP, run_id = $1
+WHERE id = (
+    SELECT id FROM scraper_queue
+    WHERE status = 'pending'
+    ORDER BY priority DESC, created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteQueueItem :exec
+UPDATE scraper_queue
+SET status = 'completed', processed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: SkipQueueItem :exec
+UPDATE scraper_queue
+SET status = 'skipped', processed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FailQueueItem :exec
+UPDATE scraper_queue
+SET status = 'failed', attempts = attempts + 1, error_message = $1, processed_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: RetryFailedItem :exec
+UPDATE scraper_queue
+SET status = 'pending', processed_at = NULL, error_message = NULL
+WHERE id = $1 AND attempts < max_attempts;
+
+-- name: GetQueueStats :one
+SELECT
+    COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending,
+    COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing,
+    COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
+    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed,
+    COUNT(CASE WHEN status = 'skipped' THEN 1 END) as skipped
+FROM scraper_queue;
+
+-- name: EnqueueDiscoveredURL :execrows
+INSERT INTO scraper_queue (target_id, url, priority)
+SELECT sqlc.arg(target_id)::bigint, sqlc.arg(url)::text, sqlc.narg(priority)::bigint
+WHERE NOT EXISTS (SELECT 1 FROM scraper_queue WHERE target_id = sqlc.arg(target_id) AND url = sqlc.arg(url))
+  AND NOT EXISTS (SELECT 1 FROM scraper_pages WHERE target_id = sqlc.arg(target_id) AND url_path = sqlc.arg(url));
+
+-- name: GetQueueItem :one
+SELECT * FROM scraper_queue WHERE id = sqlc.arg(id);
+
+-- name: DeleteQueueItem :execrows
+DELETE FROM scraper_queue WHERE id = sqlc.arg(id);
+
+-- name: ListQueueItems :many
+SELECT * FROM scraper_queue
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(target_id)::bigint IS NULL OR target_id = sqlc.narg(target_id))
+ORDER BY id
+LIMIT sqlc.arg(page_size)::bigint;
+
+-- name: GetTargetQueueStats :one
+SELECT
+    COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending,
+    COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing,
+    COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
+    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed,
+    COUNT(CASE WHEN status = 'skipped' THEN 1 END) as skipped
+FROM scraper_queue
+WHERE target_id = $1;
+
+-- name: ListRecentQueueFailures :many
+SELECT * FROM scraper_queue
+WHERE target_id = sqlc.arg(target_id) AND status = 'failed'
+ORDER BY processed_at DESC, id DESC
+LIMIT sqlc.arg(row_limit)::bigint;
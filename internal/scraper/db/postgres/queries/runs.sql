@@ -0,0 +1,39 @@
+-- name: CreateRun :one
+INSERT INTO scraper_runs (trigger_source, dry_run, target_ids)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: FinishRun :exec
+UPDATE scraper_runs
+SET status = $1, total_urls = $2, processed = $3, errors = $4, skipped = $5,
+    error_breakdown = $6, error_message = $7, finished_at = CURRENT_TIMESTAMP
+WHERE id = $8;
+
+-- name: GetRun :one
+SELECT * FROM scraper_runs
+WHERE id = $1;
+
+-- name: GetRunningRun :one
+SELECT * FROM scraper_runs
+WHERE status = 'running'
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListRuns :many
+SELECT * FROM scraper_runs
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: CountRunQueueItems :many
+SELECT COALESCE(status, '') AS status, COUNT(*) AS count
+FROM scraper_queue
+WHERE run_id = $1
+GROUP BY status
+ORDER BY status;
+
+-- name: CountRunLogs :many
+SELECT log_type, COUNT(*) AS count
+FROM scraper_logs
+WHERE run_id = $1
+GROUP BY log_type
+ORDER BY log_type;
@@ -0,0 +1,70 @@
+-- name: CreateTarget :one
+INSERT INTO scraper_targets (
+    website_url, sitemap_url, follow_sitemap, crawl_delay_seconds,
+    max_concurrent_requests, user_agent, custom_headers, notes,
+    sitemap_patterns, url_patterns, domain_name
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: GetTarget :one
+SELECT * FROM scraper_targets WHERE id = $1;
+
+-- name: GetTargetByURL :one
+SELECT * FROM scraper_targets WHERE website_url = $1;
+
+-- name: GetTargetByDomain :one
+SELECT * FROM scraper_targets WHERE domain_name = $1;
+
+-- name: ListActiveTargets :many
+SELECT * FROM scraper_targets WHERE is_active = true ORDER BY created_at;
+
+-- name: ListAllTargets :many
+SELECT * FROM scraper_targets ORDER BY created_at;
+
+-- name: UpdateTargetLastVisited :exec
+UPDATE scraper_targets SET last_visited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateTargetPatterns :exec
+UPDATE scraper_targets
+SET sitemap_patterns = $1, url_patterns = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $3;
+
+-- name: DeactivateTarget :exec
+UPDATE scraper_targets SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: UpdateTargetLearnedTemplate :exec
+UPDATE scraper_targets
+SET learned_template_json = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: UpdateTargetClassifierOverrides :exec
+UPDATE scraper_targets
+SET classifier_overrides_json = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: ListTargetsPage :many
+SELECT * FROM scraper_targets
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active))
+ORDER BY id
+LIMIT sqlc.arg(page_size)::bigint;
+
+-- name: UpdateTarget :one
+UPDATE scraper_targets
+SET website_url = sqlc.arg(website_url),
+    sitemap_url = sqlc.arg(sitemap_url),
+    follow_sitemap = sqlc.arg(follow_sitemap),
+    crawl_delay_seconds = sqlc.arg(crawl_delay_seconds),
+    max_concurrent_requests = sqlc.arg(max_concurrent_requests),
+    requests_per_second = sqlc.arg(requests_per_second),
+    user_agent = sqlc.arg(user_agent),
+    custom_headers = sqlc.arg(custom_headers),
+    notes = sqlc.arg(notes),
+    sitemap_patterns = sqlc.arg(sitemap_patterns),
+    url_patterns = sqlc.arg(url_patterns),
+    domain_name = sqlc.arg(domain_name),
+    is_active = sqlc.arg(is_active),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+RETURNING *;
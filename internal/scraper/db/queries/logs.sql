@@ -1,6 +1,6 @@
 -- name: LogMessage :exec
-INSERT INTO scraper_logs (log_type, target_id, url, message, details)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO scraper_logs (log_type, target_id, url, message, details, run_id)
+VALUES (?, ?, ?, ?, ?, ?);
 
 -- name: GetRecentLogs :many
 SELECT * FROM scraper_logs 
@@ -18,3 +18,13 @@ SELECT * FROM scraper_logs
 WHERE log_type = ? 
 ORDER BY created_at DESC 
 LIMIT ?;
+
+-- name: ListLogs :many
+SELECT * FROM scraper_logs
+WHERE id < sqlc.arg(before_id)
+  AND (sqlc.narg(log_type) IS NULL OR log_type = sqlc.narg(log_type))
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(since) IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until) IS NULL OR created_at < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
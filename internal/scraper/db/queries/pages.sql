@@ -1,11 +1,12 @@
 -- name: SavePage :one
 INSERT INTO scraper_pages (
     target_id, url_path, full_url, html_content, content_hash, 
-    http_status_code, response_time_ms, content_length, last_updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    http_status_code, response_time_ms, content_length, last_updated_at, content_key
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(target_id, url_path) DO UPDATE SET
     html_content = excluded.html_content,
     content_hash = excluded.content_hash,
+    content_key = excluded.content_key,
     http_status_code = excluded.http_status_code,
     response_time_ms = excluded.response_time_ms,
     content_length = excluded.content_length,
@@ -14,6 +15,12 @@ ON CONFLICT(target_id, url_path) DO UPDATE SET
     visit_count = visit_count + 1
 RETURNING *;
 
+-- name: MarkPageVisited :exec
+UPDATE scraper_pages
+SET last_visited_at = CURRENT_TIMESTAMP, visit_count = visit_count + 1,
+    http_status_code = ?, response_time_ms = ?
+WHERE id = ?;
+
 -- name: GetPageByPath :one
 SELECT * FROM scraper_pages WHERE target_id = ? AND url_path = ?;
 
@@ -25,8 +32,91 @@ SELECT content_hash FROM scraper_pages WHERE target_id = ? AND url_path = ? LIMI
 
 -- name: SavePageClassifier :exec
 UPDATE scraper_pages
-SET quote_classifier_json = ?, processable = ?
+SET quote_classifier_json = ?, processable = ?, language = ?
 WHERE target_id = ? AND url_path = ?;
 
 -- name: GetPageClassifier :one
-SELECT quote_classifier_json, processable FROM scraper_pages WHERE target_id = ? AND url_path = ?;
\ No newline at end of file
+SELECT quote_classifier_json, processable, language FROM scraper_pages WHERE target_id = ? AND url_path = ?;
+
+-- name: ListPagesForReclassify :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, quote_classifier_json, processable
+FROM scraper_pages
+WHERE (html_content IS NOT NULL OR content_key IS NOT NULL)
+  AND id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable) IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(reason) IS NULL OR COALESCE(NULLIF(json_extract(quote_classifier_json, '$.decision.decision_reason'), ''), 'UNCLASSIFIED') = sqlc.narg(reason))
+  AND (sqlc.narg(since) IS NULL OR last_visited_at >= sqlc.narg(since))
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: SavePageQuotes :exec
+UPDATE scraper_pages SET quotes_json = ? WHERE id = ?;
+
+-- name: SavePagePipelineStatus :exec
+UPDATE scraper_pages SET pipeline_status_json = ? WHERE id = ?;
+
+-- name: ListPagesForPipeline :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, quote_classifier_json, pipeline_status_json
+FROM scraper_pages
+WHERE (html_content IS NOT NULL OR content_key IS NOT NULL)
+  AND id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(failed_stage) IS NULL OR json_extract(pipeline_status_json, '$.' || sqlc.narg(failed_stage) || '.status') = 'failed')
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: ListPagesForExport :many
+SELECT id, target_id, url_path, full_url, html_content, content_key, content_hash, http_status_code, response_time_ms,
+       content_length, first_discovered_at, last_visited_at, last_updated_at, visit_count, processable,
+       language, quote_classifier_json, quotes_json
+FROM scraper_pages
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable) IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(since) IS NULL OR last_visited_at >= sqlc.narg(since))
+  AND (sqlc.narg(until) IS NULL OR last_visited_at < sqlc.narg(until))
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: ImportPage :execrows
+INSERT INTO scraper_pages (
+    target_id, url_path, full_url, content_hash, http_status_code, content_length, last_visited_at, last_updated_at, content_key
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(target_id, url_path) DO UPDATE SET
+    html_content = NULL,
+    content_hash = excluded.content_hash,
+    content_key = excluded.content_key,
+    http_status_code = excluded.http_status_code,
+    content_length = excluded.content_length,
+    last_visited_at = excluded.last_visited_at,
+    last_updated_at = excluded.last_updated_at
+WHERE scraper_pages.last_visited_at IS NULL OR scraper_pages.last_visited_at <= excluded.last_visited_at;
+
+-- name: GetPage :one
+SELECT * FROM scraper_pages WHERE id = sqlc.arg(id);
+
+-- name: ListPageSummaries :many
+SELECT id, target_id, url_path, full_url, content_hash, http_status_code, response_time_ms, content_length,
+       first_discovered_at, last_visited_at, last_updated_at, visit_count, processable, language
+FROM scraper_pages
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(processable) IS NULL OR processable = sqlc.narg(processable))
+  AND (sqlc.narg(http_status_code) IS NULL OR http_status_code = sqlc.narg(http_status_code))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListPagePaths :many
+SELECT url_path FROM scraper_pages
+WHERE target_id = sqlc.arg(target_id)
+ORDER BY url_path
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountClassifierDecisions :many
+SELECT CAST(COALESCE(json_extract(quote_classifier_json, '$.decision.decision_reason'), '') AS TEXT) AS decision_reason,
+       processable, COUNT(*) AS pages
+FROM scraper_pages
+WHERE target_id = ?
+GROUP BY 1, 2
+ORDER BY pages DESC, decision_reason;
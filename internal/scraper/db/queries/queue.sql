@@ -5,7 +5,7 @@ RETURNING *;
 
 -- name: DequeuePendingURL :one
 UPDATE scraper_queue 
-SET status = 'processing', processed_at = CURRENT_TIMESTAMP
+SET status = 'processing', processed_at = CURRENT_TIMESTAMP, run_id = ?
 WHERE id = (
     SELECT id FROM scraper_queue 
     WHERE status = 'pending' 
@@ -19,6 +19,11 @@ UPDATE scraper_queue
 SET status = 'completed', processed_at = CURRENT_TIMESTAMP 
 WHERE id = ?;
 
+-- name: SkipQueueItem :exec
+UPDATE scraper_queue
+SET status = 'skipped', processed_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: FailQueueItem :exec
 UPDATE scraper_queue 
 SET status = 'failed', attempts = attempts + 1, error_message = ?, processed_at = CURRENT_TIMESTAMP 
@@ -34,5 +39,41 @@ SELECT
     COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending,
     COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing,
     COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
-    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed
-FROM scraper_queue;
\ No newline at end of file
+    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed,
+    COUNT(CASE WHEN status = 'skipped' THEN 1 END) as skipped
+FROM scraper_queue;
+-- name: EnqueueDiscoveredURL :execrows
+INSERT INTO scraper_queue (target_id, url, priority)
+SELECT sqlc.arg(target_id), sqlc.arg(url), sqlc.arg(priority)
+WHERE NOT EXISTS (SELECT 1 FROM scraper_queue WHERE target_id = sqlc.arg(target_id) AND url = sqlc.arg(url))
+  AND NOT EXISTS (SELECT 1 FROM scraper_pages WHERE target_id = sqlc.arg(target_id) AND url_path = sqlc.arg(url));
+
+-- name: GetQueueItem :one
+SELECT * FROM scraper_queue WHERE id = sqlc.arg(id);
+
+-- name: DeleteQueueItem :execrows
+DELETE FROM scraper_queue WHERE id = sqlc.arg(id);
+
+-- name: ListQueueItems :many
+SELECT * FROM scraper_queue
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(status) IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetTargetQueueStats :one
+SELECT
+    COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending,
+    COUNT(CASE WHEN status = 'processing' THEN 1 END) as processing,
+    COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
+    COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed,
+    COUNT(CASE WHEN status = 'skipped' THEN 1 END) as skipped
+FROM scraper_queue
+WHERE target_id = ?;
+
+-- name: ListRecentQueueFailures :many
+SELECT * FROM scraper_queue
+WHERE target_id = sqlc.arg(target_id) AND status = 'failed'
+ORDER BY processed_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
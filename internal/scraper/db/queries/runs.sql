@@ -0,0 +1,39 @@
+-- name: CreateRun :one
+INSERT INTO scraper_runs (trigger_source, dry_run, target_ids)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: FinishRun :exec
+UPDATE scraper_runs
+SET status = ?, total_urls = ?, processed = ?, errors = ?, skipped = ?,
+    error_breakdown = ?, error_message = ?, finished_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetRun :one
+SELECT * FROM scraper_runs
+WHERE id = ?;
+
+-- name: GetRunningRun :one
+SELECT * FROM scraper_runs
+WHERE status = 'running'
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListRuns :many
+SELECT * FROM scraper_runs
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: CountRunQueueItems :many
+SELECT COALESCE(status, '') AS status, COUNT(*) AS count
+FROM scraper_queue
+WHERE run_id = ?
+GROUP BY status
+ORDER BY status;
+
+-- name: CountRunLogs :many
+SELECT log_type, COUNT(*) AS count
+FROM scraper_logs
+WHERE run_id = ?
+GROUP BY log_type
+ORDER BY log_type;
@@ -31,4 +31,40 @@ SET sitemap_patterns = ?, url_patterns = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
 -- name: DeactivateTarget :exec
-UPDATE scraper_targets SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
\ No newline at end of file
+UPDATE scraper_targets SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: UpdateTargetLearnedTemplate :exec
+UPDATE scraper_targets
+SET learned_template_json = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateTargetClassifierOverrides :exec
+UPDATE scraper_targets
+SET classifier_overrides_json = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListTargetsPage :many
+SELECT * FROM scraper_targets
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(is_active) IS NULL OR is_active = sqlc.narg(is_active))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: UpdateTarget :one
+UPDATE scraper_targets
+SET website_url = sqlc.arg(website_url),
+    sitemap_url = sqlc.arg(sitemap_url),
+    follow_sitemap = sqlc.arg(follow_sitemap),
+    crawl_delay_seconds = sqlc.arg(crawl_delay_seconds),
+    max_concurrent_requests = sqlc.arg(max_concurrent_requests),
+    requests_per_second = sqlc.arg(requests_per_second),
+    user_agent = sqlc.arg(user_agent),
+    custom_headers = sqlc.arg(custom_headers),
+    notes = sqlc.arg(notes),
+    sitemap_patterns = sqlc.arg(sitemap_patterns),
+    url_patterns = sqlc.arg(url_patterns),
+    domain_name = sqlc.arg(domain_name),
+    is_active = sqlc.arg(is_active),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,70 @@
+-- name: CreateUser :one
+INSERT INTO scraper_users (username, password_hash, role)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM scraper_users WHERE id = ?;
+
+-- name: GetUserByUsername :one
+SELECT * FROM scraper_users WHERE username = ?;
+
+-- name: ListUsers :many
+SELECT * FROM scraper_users ORDER BY username;
+
+-- name: CountActiveUsers :one
+SELECT COUNT(*) FROM scraper_users WHERE is_active = true;
+
+-- name: UpdateUserRole :execrows
+UPDATE scraper_users SET role = ? WHERE id = ?;
+
+-- name: UpdateUserPassword :execrows
+UPDATE scraper_users SET password_hash = ? WHERE id = ?;
+
+-- name: SetUserActive :execrows
+UPDATE scraper_users SET is_active = ? WHERE id = ?;
+
+-- name: RecordUserLogin :exec
+UPDATE scraper_users SET last_login_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: CreateSession :exec
+INSERT INTO scraper_sessions (id, user_id, expires_at)
+VALUES (?, ?, ?);
+
+-- name: GetSessionUser :one
+SELECT u.id, u.username, u.role, u.is_active, s.expires_at
+FROM scraper_sessions s
+JOIN scraper_users u ON u.id = s.user_id
+WHERE s.id = ?;
+
+-- name: DeleteSession :exec
+DELETE FROM scraper_sessions WHERE id = ?;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM scraper_sessions WHERE user_id = ?;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM scraper_sessions WHERE expires_at < ?;
+
+-- name: CreateAPIToken :one
+INSERT INTO scraper_api_tokens (user_id, name, token_hash)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetAPITokenUser :one
+SELECT t.id AS token_id, u.id, u.username, u.role, u.is_active
+FROM scraper_api_tokens t
+JOIN scraper_users u ON u.id = t.user_id
+WHERE t.token_hash = ?;
+
+-- name: TouchAPIToken :exec
+UPDATE scraper_api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ListAPITokens :many
+SELECT t.id, t.name, u.username, t.created_at, t.last_used_at
+FROM scraper_api_tokens t
+JOIN scraper_users u ON u.id = t.user_id
+ORDER BY t.id;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM scraper_api_tokens WHERE id = ?;
@@ -0,0 +1,169 @@
+// Package jobs runs long operations such as crawls in the background of the
+// admin server so they can be started, watched and cancelled over the API.
+// Jobs live in memory and are forgotten when the server restarts.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of jobs the server can start
+const (
+	KindCrawl = "crawl"
+)
+
+// Job states
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
+	ErrFinished = errors.New("job already finished")
+	// ErrBusy is returned when a job of the same kind is still running
+	ErrBusy = errors.New("a job of this kind is already running")
+)
+
+// Job is a snapshot of a background job
+type Job struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	TargetID   int64      `json:"target_id,omitempty"`
+	DryRun     bool       `json:"dry_run"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Func does the work of a job and should return once ctx is cancelled
+type Func func(ctx context.Context) error
+
+type entry struct {
+	job    Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager tracks the jobs started since the server came up. Safe for
+// concurrent use.
+type Manager struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   map[int64]*entry
+}
+
+func NewManager() *Manager {
+	return &Manager{jobs: map[int64]*entry{}}
+}
+
+// Start runs fn in the background, only one job of a kind runs at a time
+// since two crawls would compete for the same queue
+func (m *Manager) Start(kind string, targetID int64, dryRun bool, fn Func) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.jobs {
+		if e.job.Kind == kind && e.job.Status == StatusRunning {
+			return Job{}, ErrBusy
+		}
+	}
+
+	m.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		job: Job{
+			ID:        m.nextID,
+			Kind:      kind,
+			TargetID:  targetID,
+			DryRun:    dryRun,
+			Status:    StatusRunning,
+			StartedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.jobs[e.job.ID] = e
+	go m.run(ctx, e, fn)
+	return e.job, nil
+}
+
+func (m *Manager) run(ctx context.Context, e *entry, fn Func) {
+	err := fn(ctx)
+	finished := time.Now().UTC()
+
+	m.mu.Lock()
+	switch {
+	case ctx.Err() != nil:
+		e.job.Status = StatusCancelled
+	case err != nil:
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = StatusSucceeded
+	}
+	e.job.FinishedAt = &finished
+	m.mu.Unlock()
+	e.cancel()
+	close(e.done)
+}
+
+// Cancel asks a running job to stop, its status changes once fn returns
+func (m *Manager) Cancel(id int64) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if e.job.Status != StatusRunning {
+		return e.job, ErrFinished
+	}
+	e.cancel()
+	return e.job, nil
+}
+
+// Get returns the current state of a job
+func (m *Manager) Get(id int64) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return e.job, nil
+}
+
+// List returns all jobs, newest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		list = append(list, e.job)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].ID > list[b].ID })
+	return list
+}
+
+// Wait blocks until the job finished or ctx is done and returns its state
+func (m *Manager) Wait(ctx context.Context, id int64) (Job, error) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+	return m.Get(id)
+}
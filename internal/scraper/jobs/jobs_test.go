@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func wait(t *testing.T, m *Manager, id int64) Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	return job
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+	m := NewManager()
+	ok, err := m.Start(KindCrawl, 3, false, func(ctx context.Context) error { return nil })
+	if err != nil || ok.Status != StatusRunning || ok.TargetID != 3 {
+		t.Fatalf("unexpected job %+v (%v)", ok, err)
+	}
+	if job := wait(t, m, ok.ID); job.Status != StatusSucceeded || job.FinishedAt == nil {
+		t.Errorf("expected success, got %+v", job)
+	}
+
+	failed, _ := m.Start(KindCrawl, 0, false, func(ctx context.Context) error { return errors.New("boom") })
+	if job := wait(t, m, failed.ID); job.Status != StatusFailed || job.Error != "boom" {
+		t.Errorf("expected the error recorded, got %+v", job)
+	}
+
+	if list := m.List(); len(list) != 2 || list[0].ID != failed.ID {
+		t.Errorf("expected newest first, got %+v", list)
+	}
+	if _, err := m.Get(99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManager_CancelAndBusy(t *testing.T) {
+	m := NewManager()
+	running, err := m.Start(KindCrawl, 0, true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := m.Start(KindCrawl, 0, false, func(ctx context.Context) error { return nil }); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected a second crawl refused, got %v", err)
+	}
+
+	if _, err := m.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if job := wait(t, m, running.ID); job.Status != StatusCancelled || job.Error != "" {
+		t.Errorf("expected the job cancelled, got %+v", job)
+	}
+	if _, err := m.Cancel(running.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("expected ErrFinished, got %v", err)
+	}
+	if _, err := m.Start(KindCrawl, 0, false, func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("expected a new crawl once the first stopped, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text exposition format on /metrics of the admin UI and of
+// `scraper-cli run --metrics-addr`.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 30s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry holds the metrics of a process and the collectors that refresh
+// gauges when the metrics are scraped
+type Registry struct {
+	mu         sync.Mutex
+	metrics    []metric
+	names      map[string]bool
+	collectors []func(context.Context)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Default is the registry of the package level metrics
+var Default = NewRegistry()
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic("metrics: duplicate metric " + m.name())
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// OnScrape registers a collector run before every scrape, typically to set
+// gauges read from the database
+func (r *Registry) OnScrape(collect func(ctx context.Context)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collect)
+}
+
+// Write runs the collectors and writes every metric in the text format
+func (r *Registry) Write(ctx context.Context, w io.Writer) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	for _, collect := range collectors {
+		collect(ctx)
+	}
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Handler serves the metrics for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(req.Context(), w)
+	})
+}
+
+// vec holds the series of a metric by label values
+type vec[T any] struct {
+	metricName string
+	help       string
+	labels     []string
+	mu         sync.Mutex
+	series     map[string]*series[T]
+}
+
+type series[T any] struct {
+	values []string
+	value  T
+}
+
+func newVec[T any](name, help string, labels []string) vec[T] {
+	return vec[T]{metricName: name, help: help, labels: labels, series: make(map[string]*series[T])}
+}
+
+func (v *vec[T]) name() string { return v.metricName }
+
+// with calls update with the series of the label values under the lock
+func (v *vec[T]) with(values []string, update func(*T)) {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.metricName, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &series[T]{values: slices.Clone(values)}
+		v.series[key] = s
+	}
+	update(&s.value)
+}
+
+// each calls fn with every series sorted by label values, under the lock
+func (v *vec[T]) each(fn func(labels string, value *T)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		s := v.series[k]
+		fn(formatLabels(v.labels, s.values), &s.value)
+	}
+}
+
+func (v *vec[T]) header(w io.Writer, kind string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metricName, escapeHelp(v.help), v.metricName, kind)
+}
+
+// CounterVec counts events by label values
+type CounterVec struct{ vec[float64] }
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec[float64](name, help, labels)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of the label values
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+// Add adds delta, which must not be negative, to the series of the label values
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.metricName + " can't decrease")
+	}
+	c.with(values, func(v *float64) { *v += delta })
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.header(w, "counter")
+	c.each(func(labels string, v *float64) {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", c.metricName, labels, formatFloat(*v))
+	})
+}
+
+// CounterFunc is a counter without labels kept outside the registry, read on
+// every scrape
+type CounterFunc struct {
+	metricName string
+	help       string
+	read       func() float64
+}
+
+func (r *Registry) NewCounterFunc(name, help string, read func() float64) *CounterFunc {
+	c := &CounterFunc{metricName: name, help: help, read: read}
+	r.register(c)
+	return c
+}
+
+func (c *CounterFunc) name() string { return c.metricName }
+
+func (c *CounterFunc) write(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n",
+		c.metricName, escapeHelp(c.help), c.metricName, c.metricName, formatFloat(c.read()))
+}
+
+// GaugeVec holds values that go up and down by label values
+type GaugeVec struct{ vec[float64] }
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec[float64](name, help, labels)}
+	r.register(g)
+	return g
+}
+
+// Set sets the series of the label values
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.with(values, func(v *float64) { *v = value })
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.header(w, "gauge")
+	g.each(func(labels string, v *float64) {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", g.metricName, labels, formatFloat(*v))
+	})
+}
+
+// HistogramVec counts observations in cumulative buckets by label values
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{vec: newVec[histogram](name, help, labels), buckets: slices.Clone(buckets)}
+	slices.Sort(h.buckets)
+	r.register(h)
+	return h
+}
+
+// Observe records a value in the series of the label values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.with(values, func(s *histogram) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.buckets))
+		}
+		if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+			s.counts[i]++
+		}
+		s.count++
+		s.sum += value
+	})
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.header(w, "histogram")
+	h.each(func(labels string, s *histogram) {
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, withLE(labels, formatFloat(le)), cumulative)
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, withLE(labels, "+Inf"), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, labels, formatFloat(s.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, labels, s.count)
+	})
+}
+
+// withLE adds the le label of a bucket to formatted labels
+func withLE(labels, le string) string {
+	if labels == "" {
+		return `{le="` + le + `"}`
+	}
+	return labels[:len(labels)-1] + `,le="` + le + `"}`
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	return w.Body.String()
+}
+
+func TestRegistry_Exposition(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.", "target", "status")
+	depth := r.NewGaugeVec("test_depth", "Queue depth.")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.1}, "target")
+	dropped := 4
+	r.NewCounterFunc("test_dropped_total", "Dropped.", func() float64 { return float64(dropped) })
+
+	requests.Inc("2", "200")
+	requests.Add(2, "1", "200")
+	requests.Inc("1", "error")
+	depth.Set(7)
+	latency.Observe(0.05, "1")
+	latency.Observe(0.5, "1")
+	latency.Observe(3, "1")
+
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{target="1",status="200"} 2
+test_requests_total{target="1",status="error"} 1
+test_requests_total{target="2",status="200"} 1
+# HELP test_depth Queue depth.
+# TYPE test_depth gauge
+test_depth 7
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{target="1",le="0.1"} 1
+test_latency_seconds_bucket{target="1",le="1"} 2
+test_latency_seconds_bucket{target="1",le="+Inf"} 3
+test_latency_seconds_sum{target="1"} 3.55
+test_latency_seconds_count{target="1"} 3
+# HELP test_dropped_total Dropped.
+# TYPE test_dropped_total counter
+test_dropped_total 4
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_EscapesLabels(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Help with \\ and\nnewline.", "route")
+	c.Inc(`/a"b\c` + "\n")
+
+	got := scrape(t, r)
+	if !strings.Contains(got, `# HELP test_total Help with \\ and\nnewline.`) {
+		t.Errorf("help not escaped:\n%s", got)
+	}
+	if !strings.Contains(got, `test_total{route="/a\"b\\c\n"} 1`) {
+		t.Errorf("label not escaped:\n%s", got)
+	}
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Help.", "target")
+	for name, fn := range map[string]func(){
+		"wrong label count": func() { c.Inc("1", "2") },
+		"negative add":      func() { c.Add(-1, "1") },
+		"duplicate name":    func() { r.NewGaugeVec("test_total", "Help.") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestCollectQueue(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE scraper_queue (id INTEGER PRIMARY KEY, status TEXT, processed_at DATETIME);
+		INSERT INTO scraper_queue (status, processed_at) VALUES
+			('pending', NULL), ('pending', NULL), ('failed', datetime('now', '-1 hour')),
+			('processing', datetime('now', '-90 seconds')), ('processing', datetime('now', '-10 seconds'))`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := collectQueue(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	QueueItems.write(&b)
+	for _, line := range []string{
+		`scraper_queue_items{status="pending"} 2`,
+		`scraper_queue_items{status="processing"} 2`,
+		`scraper_queue_items{status="completed"} 0`,
+		`scraper_queue_items{status="failed"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, b.String())
+		}
+	}
+	QueueOldestLease.each(func(_ string, age *float64) {
+		if *age < 89 || *age > 120 {
+			t.Errorf("expected the oldest lease to be about 90s old, got %v", *age)
+		}
+	})
+}
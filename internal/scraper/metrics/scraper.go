@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/service/logger"
+)
+
+// Fetcher metrics, labelled by target ID
+var (
+	FetchRequests = Default.NewCounterVec("scraper_fetch_requests_total",
+		"Page fetches by target and HTTP status, status is \"error\" when no response arrived.", "target", "status")
+	FetchDuration = Default.NewHistogramVec("scraper_fetch_duration_seconds",
+		"Time from sending a page request to reading its body.", DefaultBuckets, "target")
+	FetchBytes = Default.NewCounterVec("scraper_fetch_bytes_total",
+		"Response body bytes downloaded.", "target")
+	FetchRetries = Default.NewCounterVec("scraper_fetch_retries_total",
+		"Fetches of queue items that failed before.", "target")
+	RateLimitWait = Default.NewCounterVec("scraper_rate_limit_wait_seconds_total",
+		"Time fetches waited for the target's rate limit.", "target")
+)
+
+// Queue metrics, read from the database on every scrape once CollectQueue is called
+var (
+	QueueItems = Default.NewGaugeVec("scraper_queue_items",
+		"Queue items by status.", "status")
+	QueueOldestLease = Default.NewGaugeVec("scraper_queue_oldest_lease_seconds",
+		"Age of the oldest item being crawled, 0 when none is.")
+)
+
+// Classifier metrics
+var (
+	ClassifierDecisions = Default.NewCounterVec("scraper_classifier_decisions_total",
+		"Classified pages by decision reason and whether they are processable.", "reason", "processable")
+	ClassifierDuration = Default.NewHistogramVec("scraper_classifier_duration_seconds",
+		"Time to classify the main content of a page.", DefaultBuckets)
+)
+
+// HTTP server metrics, labelled by route pattern so path parameters don't add series
+var (
+	HTTPRequests = Default.NewCounterVec("scraper_http_requests_total",
+		"HTTP requests served by method, route and status code.", "method", "route", "code")
+	HTTPDuration = Default.NewHistogramVec("scraper_http_request_duration_seconds",
+		"Time to serve HTTP requests by method and route.", DefaultBuckets, "method", "route")
+)
+
+// LogRowsDropped counts the scraper_logs rows lost to a full buffer or a failed batch
+var LogRowsDropped = Default.NewCounterFunc("scraper_log_rows_dropped_total",
+	"Log rows not written to scraper_logs because the buffer was full or the batch failed.",
+	func() float64 { return float64(logger.Dropped()) })
+
+var metricsLog = logger.Lazy(logger.ComponentMetrics)
+
+// queueStatuses are reported even when no item has them, so a drained queue reads 0
+var queueStatuses = []string{"pending", "processing", "completed", "skipped", "failed"}
+
+// Target formats a target ID as a label value
+func Target(id int64) string { return strconv.FormatInt(id, 10) }
+
+// Since returns the seconds elapsed since start
+func Since(start time.Time) float64 { return time.Since(start).Seconds() }
+
+// CollectQueue refreshes the queue gauges from the database on every scrape
+// of the default registry. Call it once per process.
+func CollectQueue(db *sql.DB) {
+	Default.OnScrape(func(ctx context.Context) {
+		if err := collectQueue(ctx, db); err != nil {
+			metricsLog().ErrorContext(ctx, "Failed to read queue metrics", logger.Err(err))
+		}
+	})
+}
+
+func collectQueue(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, "SELECT status, COUNT(*) FROM scraper_queue GROUP BY status")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status sql.NullString
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return err
+		}
+		counts[status.String] += n
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, status := range queueStatuses {
+		QueueItems.Set(float64(counts[status]), status)
+	}
+
+	// Dequeueing stamps processed_at, so it's when the worker took the item
+	var leased sql.NullTime
+	err = db.QueryRowContext(ctx, "SELECT processed_at FROM scraper_queue WHERE status = 'processing' AND processed_at IS NOT NULL ORDER BY processed_at LIMIT 1").Scan(&leased)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	age := 0.0
+	if leased.Valid {
+		age = max(Since(leased.Time), 0)
+	}
+	QueueOldestLease.Set(age)
+	return nil
+}
+
+// Instrument records the HTTP metrics of the requests next serves. next is
+// expected to be a ServeMux, whose matched pattern names the route.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if r.Pattern != "" {
+			route = r.Pattern
+			if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+				route = path
+			}
+		}
+		HTTPRequests.Inc(r.Method, route, strconv.Itoa(rec.code))
+		HTTPDuration.Observe(Since(start), r.Method, route)
+	})
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.code, s.wroteHeader = code, true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
@@ -0,0 +1,440 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// versionTable records the applied migrations
+const versionTable = "scraper_schema_migrations"
+
+// AutoMigrateEnv disables applying pending migrations on startup when set to false
+const AutoMigrateEnv = "SCRAPER_AUTO_MIGRATE"
+
+// ErrPending is returned on startup when migrations are pending and auto migration is disabled
+var ErrPending = errors.New("database schema is not up to date")
+
+// Migration is one numbered schema change with its up and down SQL
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// UpStep runs after Up and DownStep before Down, in the same transaction
+	UpStep   Step
+	DownStep Step
+}
+
+// Step is the part of a migration written in Go, for data changes SQL can't
+// express. It runs in the migration's transaction with its own SQL, written
+// against the schema of its version rather than the current queries.
+type Step func(ctx context.Context, tx *Tx) error
+
+// Tx is the transaction of a migration as seen by its Go step, ? placeholders
+// are rewritten for the dialect
+type Tx struct {
+	tx *sql.Tx
+	m  *Migrator
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, t.m.bind(query), args...)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, t.m.bind(query), args...)
+}
+
+// Status is a known migration and when it was applied, zero if pending
+type Status struct {
+	Migration
+	AppliedAt time.Time
+}
+
+func (s Status) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads the NNN_name.up.sql / NNN_name.down.sql pairs of a migrations directory
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		m := migrationFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies the embedded migrations of a store's dialect
+type Migrator struct {
+	db         *sql.DB
+	dialect    storage.Dialect
+	migrations []Migration
+}
+
+// New returns a migrator for the embedded migrations of the store's dialect
+func New(store storage.Store) (*Migrator, error) {
+	dir := "migrations"
+	if store.Dialect() == storage.DialectPostgres {
+		dir = "postgres/migrations"
+	}
+	migrations, err := Load(db.Migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, mig := range migrations {
+		if s, ok := steps[mig.Version]; ok {
+			migrations[i].UpStep, migrations[i].DownStep = s.up, s.down
+		}
+	}
+	return NewWithMigrations(store.DB(), store.Dialect(), migrations), nil
+}
+
+func NewWithMigrations(database *sql.DB, dialect storage.Dialect, migrations []Migration) *Migrator {
+	return &Migrator{db: database, dialect: dialect, migrations: migrations}
+}
+
+// Latest returns the version of the newest known migration
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists the known migrations with their applied time
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Migration: mig, AppliedAt: applied[mig.Version]}
+	}
+	return statuses, nil
+}
+
+// Current returns the newest applied version, 0 for an empty database
+func (m *Migrator) Current(ctx context.Context) (int64, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err := m.db.QueryRowContext(ctx, "SELECT MAX(version) FROM "+versionTable).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version.Int64, nil
+}
+
+// Pending returns the migrations not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if !s.Applied() {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in order, each in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.migrations {
+		applied, err := m.apply(ctx, mig, true)
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// Down reverts the newest applied migrations, at most steps of them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		reverted, err := m.apply(ctx, mig, false)
+		if err != nil {
+			return done, err
+		}
+		if reverted {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// apply runs one migration in a transaction together with its version record.
+// It reports false when another process applied (or reverted) it first.
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %d: %w", mig.Version, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if m.dialect == storage.DialectPostgres {
+		// Crawler hosts starting together migrate one after the other
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('"+versionTable+"'))"); err != nil {
+			return false, fmt.Errorf("failed to lock schema migrations: %w", err)
+		}
+	}
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := applied[mig.Version]; ok == up {
+		return false, nil
+	}
+
+	if !up && mig.Down == "" {
+		return false, fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+	}
+
+	if up {
+		if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+			return false, fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		if err := m.runStep(ctx, tx, mig.UpStep); err != nil {
+			return false, fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, m.bind("INSERT INTO "+versionTable+" (version, name) VALUES (?, ?)"), mig.Version, mig.Name)
+	} else {
+		if err := m.runStep(ctx, tx, mig.DownStep); err != nil {
+			return false, fmt.Errorf("reverting migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			return false, fmt.Errorf("reverting migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, m.bind("DELETE FROM "+versionTable+" WHERE version = ?"), mig.Version)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %w", mig.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", mig.Version, err)
+	}
+	return true, nil
+}
+
+func (m *Migrator) runStep(ctx context.Context, tx *sql.Tx, step Step) error {
+	if step == nil {
+		return nil
+	}
+	return step(ctx, &Tx{tx: tx, m: m})
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied returns the applied versions with their applied time
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int64]time.Time, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM "+versionTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// ensureVersionTable creates the version table. A database migrated by
+// golang-migrate before has its schema_migrations version adopted.
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	exists, err := m.tableExists(ctx, versionTable)
+	if err != nil || exists {
+		return err
+	}
+	baseline, err := m.externalVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+	for _, mig := range m.migrations {
+		if mig.Version > baseline {
+			break
+		}
+		_, err := tx.ExecContext(ctx, m.bind("INSERT INTO "+versionTable+" (version, name) VALUES (?, ?) ON CONFLICT (version) DO NOTHING"), mig.Version, mig.Name)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", mig.Version, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// externalVersion returns the version recorded by golang-migrate, 0 for a
+// new database. A scraper schema without any version record is refused, the
+// migrations already applied to it are unknown.
+func (m *Migrator) externalVersion(ctx context.Context) (int64, error) {
+	exists, err := m.tableExists(ctx, "schema_migrations")
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		var version int64
+		var dirty bool
+		err := m.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("failed to read golang-migrate version: %w", err)
+		}
+		if dirty {
+			return 0, fmt.Errorf("golang-migrate left migration %d dirty, fix the schema and its schema_migrations row first", version)
+		}
+		return version, nil
+	}
+	if exists, err := m.tableExists(ctx, "scraper_targets"); err != nil || !exists {
+		return 0, err
+	}
+	return 0, fmt.Errorf("database has scraper tables but no migration history (%s or schema_migrations)", versionTable)
+}
+
+func (m *Migrator) tableExists(ctx context.Context, name string) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if m.dialect == storage.DialectPostgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	}
+	var n int
+	if err := m.db.QueryRowContext(ctx, query, name).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// bind rewrites ? placeholders to the $n placeholders of PostgreSQL
+func (m *Migrator) bind(query string) string {
+	if m.dialect != storage.DialectPostgres {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&sb, "$%d", n)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// AutoMigrateFromEnv reports whether pending migrations are applied on
+// startup, true unless SCRAPER_AUTO_MIGRATE is false
+func AutoMigrateFromEnv() bool {
+	auto, err := strconv.ParseBool(os.Getenv(AutoMigrateEnv))
+	return err != nil || auto
+}
+
+// EnsureSchema brings the store's schema up to date on startup. With auto
+// migration disabled pending migrations are refused with ErrPending.
+func EnsureSchema(ctx context.Context, store storage.Store, auto bool) error {
+	m, err := New(store)
+	if err != nil {
+		return err
+	}
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d), upgrade scraper", current, m.Latest())
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	if !auto {
+		return fmt.Errorf("%w: %d pending migration(s) from version %d, run `scraper-cli db migrate up` or unset %s",
+			ErrPending, len(pending), pending[0].Version, AutoMigrateEnv)
+	}
+	_, err = m.Up(ctx)
+	return err
+}
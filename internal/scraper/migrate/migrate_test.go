@@ -0,0 +1,303 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newSQLiteStore(t *testing.T) storage.Store {
+	t.Helper()
+	database, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+	return storage.NewSQLite(database)
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);")},
+		"m/002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"m/001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"m/README.md":           {Data: []byte("ignored")},
+	}
+	migrations, err := Load(fsys, "m")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "first" || migrations[0].Down != "" {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[1].Version != 2 || migrations[1].Name != "second" || migrations[1].Down != "DROP TABLE b;" {
+		t.Errorf("unexpected second migration: %+v", migrations[1])
+	}
+}
+
+func TestLoad_MissingUp(t *testing.T) {
+	fsys := fstest.MapFS{"m/001_first.down.sql": {Data: []byte("DROP TABLE a;")}}
+	if _, err := Load(fsys, "m"); err == nil {
+		t.Fatal("expected an error for a migration without up file")
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	sqlite, err := Load(db.Migrations, "migrations")
+	if err != nil {
+		t.Fatalf("Load sqlite: %v", err)
+	}
+	postgres, err := Load(db.Migrations, "postgres/migrations")
+	if err != nil {
+		t.Fatalf("Load postgres: %v", err)
+	}
+	if len(sqlite) == 0 || len(sqlite) != len(postgres) {
+		t.Fatalf("expected the same number of migrations per dialect, got %d and %d", len(sqlite), len(postgres))
+	}
+}
+
+func TestMigrator_UpStatusDown(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+	m, err := New(store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if int64(len(applied)) != m.Latest() {
+		t.Fatalf("expected %d applied migrations, got %d", m.Latest(), len(applied))
+	}
+	if _, err := store.Queries().ListAllTargets(ctx); err != nil {
+		t.Fatalf("schema not usable after Up: %v", err)
+	}
+
+	// A second Up is a no-op
+	applied, err = m.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected no migrations on second Up, got %d (%v)", len(applied), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied() {
+			t.Errorf("migration %d not applied", s.Version)
+		}
+	}
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != 2 || reverted[0].Version != m.Latest() {
+		t.Fatalf("expected the 2 newest migrations reverted, got %+v", reverted)
+	}
+	current, err := m.Current(ctx)
+	if err != nil {
+		t.Fatalf("Current: %v", err)
+	}
+	if current != m.Latest()-2 {
+		t.Errorf("expected version %d after Down, got %d", m.Latest()-2, current)
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("expected 2 pending migrations, got %d (%v)", len(pending), err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+	m := NewWithMigrations(store.DB(), storage.DialectSQLite, []Migration{
+		{Version: 1, Name: "ok", Up: "CREATE TABLE a (id INTEGER);"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE b (id INTEGER); SELECT * FROM missing;"},
+	})
+
+	applied, err := m.Up(ctx)
+	if err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	if len(applied) != 1 {
+		t.Fatalf("expected 1 applied migration, got %d", len(applied))
+	}
+	current, err := m.Current(ctx)
+	if err != nil || current != 1 {
+		t.Fatalf("expected version 1, got %d (%v)", current, err)
+	}
+	var n int
+	if err := store.DB().QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'b'").Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected table b rolled back, found %d (%v)", n, err)
+	}
+}
+
+func TestEnsureSchema(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+
+	err := EnsureSchema(ctx, store, false)
+	if !errors.Is(err, ErrPending) {
+		t.Fatalf("expected ErrPending without auto migration, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "db migrate up") {
+		t.Errorf("expected the error to name the migrate command, got %q", err)
+	}
+
+	if err := EnsureSchema(ctx, store, true); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	// Up to date, no migration needed even without auto migration
+	if err := EnsureSchema(ctx, store, false); err != nil {
+		t.Fatalf("EnsureSchema on migrated database: %v", err)
+	}
+
+	if _, err := store.DB().Exec("INSERT INTO " + versionTable + " (version, name) VALUES (999, 'future')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := EnsureSchema(ctx, store, true); err == nil {
+		t.Error("expected a database newer than the binary to be refused")
+	}
+}
+
+func TestMigrator_AdoptsGolangMigrateVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+	migrations, err := Load(db.Migrations, "migrations")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// A database migrated to version 3 by golang-migrate
+	for _, mig := range migrations[:3] {
+		if _, err := store.DB().Exec(mig.Up); err != nil {
+			t.Fatalf("apply %d: %v", mig.Version, err)
+		}
+	}
+	if _, err := store.DB().Exec(`CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);
+INSERT INTO schema_migrations (version, dirty) VALUES (3, 0);`); err != nil {
+		t.Fatalf("create schema_migrations: %v", err)
+	}
+
+	m := NewWithMigrations(store.DB(), storage.DialectSQLite, migrations)
+	current, err := m.Current(ctx)
+	if err != nil || current != 3 {
+		t.Fatalf("expected adopted version 3, got %d (%v)", current, err)
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(migrations)-3 || applied[0].Version != 4 {
+		t.Fatalf("expected migrations from 4 applied, got %+v", applied)
+	}
+}
+
+func TestMigrator_RefusesUntrackedSchema(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+	if _, err := store.DB().Exec("CREATE TABLE scraper_targets (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	m, err := New(store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatal("expected a schema without migration history to be refused")
+	}
+}
+
+func TestAutoMigrateFromEnv(t *testing.T) {
+	tests := map[string]bool{"": true, "true": true, "1": true, "false": false, "0": false, "bogus": true}
+	for value, want := range tests {
+		t.Setenv(AutoMigrateEnv, value)
+		if got := AutoMigrateFromEnv(); got != want {
+			t.Errorf("%s=%q: expected %v, got %v", AutoMigrateEnv, value, want, got)
+		}
+	}
+}
+
+func TestMigrator_ContentStoreSteps(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLiteStore(t)
+	m, err := New(store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// Back to the inline bodies before 008_page_content_store
+	if _, err := m.Down(ctx, int(m.Latest()-7)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	bodies := map[string]string{"/a": "<p>same</p>", "/b": "<p>same</p>", "/c": "<p>other</p>"}
+	if _, err := store.DB().Exec("INSERT INTO scraper_targets (website_url) VALUES ('https://example.com')"); err != nil {
+		t.Fatalf("insert target: %v", err)
+	}
+	for path, body := range bodies {
+		if _, err := store.DB().Exec("INSERT INTO scraper_pages (target_id, url_path, full_url, html_content) VALUES (1, ?, ?, ?)", path, "https://example.com"+path, body); err != nil {
+			t.Fatalf("insert page: %v", err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	var inline, contents, identity int
+	_ = store.DB().QueryRow("SELECT COUNT(*) FROM scraper_pages WHERE html_content IS NOT NULL").Scan(&inline)
+	_ = store.DB().QueryRow("SELECT COUNT(*), COUNT(*) FILTER (WHERE encoding <> 'gzip') FROM scraper_contents").Scan(&contents, &identity)
+	if inline != 0 || contents != 2 || identity != 0 {
+		t.Errorf("expected the bodies moved compressed and deduplicated, got %d inline and %d stored, %d uncompressed", inline, contents, identity)
+	}
+	for path, want := range bodies {
+		var html, key, hash sql.NullString
+		if err := store.DB().QueryRow("SELECT html_content, content_key, content_hash FROM scraper_pages WHERE url_path = ?", path).Scan(&html, &key, &hash); err != nil {
+			t.Fatalf("query page: %v", err)
+		}
+		if key.String != content.Key([]byte(want)) || hash.String != content.Hash([]byte(want)) {
+			t.Errorf("%s: expected the SHA-256 key and the change-detection hash, got %q and %q", path, key.String, hash.String)
+		}
+		got, err := content.PageBody(ctx, content.NewDBStore(store.Queries()), html, key)
+		if err != nil || got != want {
+			t.Errorf("%s: expected body %q, got %q (%v)", path, want, got, err)
+		}
+	}
+
+	// Reverting decompresses the bodies back inline
+	if _, err := m.Down(ctx, int(m.Latest()-7)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	for path, want := range bodies {
+		var html sql.NullString
+		if err := store.DB().QueryRow("SELECT html_content FROM scraper_pages WHERE url_path = ?", path).Scan(&html); err != nil {
+			t.Fatalf("query page: %v", err)
+		}
+		if html.String != want {
+			t.Errorf("%s: expected body %q restored, got %q", path, want, html.String)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"app/internal/scraper/service/content"
+)
+
+// steps are the Go steps of the embedded migrations by version, the same on
+// every dialect
+var steps = map[int64]struct{ up, down Step }{
+	8: {up: moveBodiesToContentStore, down: restoreBodiesFromContentStore},
+}
+
+// stepBatchSize is the number of pages a step loads per query
+const stepBatchSize = 100
+
+// moveBodiesToContentStore moves the bodies inline in scraper_pages to the
+// compressed content store, SQL can't gzip them
+func moveBodiesToContentStore(ctx context.Context, tx *Tx) error {
+	var afterID int64
+	for {
+		type inlinePage struct {
+			id   int64
+			body string
+		}
+		var batch []inlinePage
+		err := queryRows(ctx, tx, func(rows *sql.Rows) error {
+			var p inlinePage
+			if err := rows.Scan(&p.id, &p.body); err != nil {
+				return err
+			}
+			batch = append(batch, p)
+			return nil
+		}, fmt.Sprintf(`SELECT id, html_content FROM scraper_pages
+WHERE html_content IS NOT NULL AND id > ?
+ORDER BY id LIMIT %d`, stepBatchSize), afterID)
+		if err != nil {
+			return fmt.Errorf("failed to list inline pages: %w", err)
+		}
+
+		for _, p := range batch {
+			body := []byte(p.body)
+			compressed, err := content.Compress(body)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", p.id, err)
+			}
+			key := content.Key(body)
+			_, err = tx.ExecContext(ctx, `INSERT INTO scraper_contents (content_key, encoding, body, raw_size, stored_size)
+VALUES (?, 'gzip', ?, ?, ?)
+ON CONFLICT (content_key) DO NOTHING`, key, compressed, len(body), len(compressed))
+			if err != nil {
+				return fmt.Errorf("page %d: failed to store content: %w", p.id, err)
+			}
+			// The hash is recomputed from the body, pages inserted without one get it now
+			_, err = tx.ExecContext(ctx, `UPDATE scraper_pages SET content_key = ?, content_hash = ?, html_content = NULL WHERE id = ?`,
+				key, content.Hash(body), p.id)
+			if err != nil {
+				return fmt.Errorf("page %d: failed to move content: %w", p.id, err)
+			}
+		}
+		if len(batch) < stepBatchSize {
+			return nil
+		}
+		afterID = batch[len(batch)-1].id
+	}
+}
+
+// restoreBodiesFromContentStore moves the stored bodies back inline before
+// the content store is dropped, SQL can't decompress them. Pages whose body
+// isn't stored are left as they are.
+func restoreBodiesFromContentStore(ctx context.Context, tx *Tx) error {
+	var afterID int64
+	for {
+		type storedPage struct {
+			id       int64
+			encoding string
+			body     []byte
+		}
+		var batch []storedPage
+		err := queryRows(ctx, tx, func(rows *sql.Rows) error {
+			var p storedPage
+			if err := rows.Scan(&p.id, &p.encoding, &p.body); err != nil {
+				return err
+			}
+			batch = append(batch, p)
+			return nil
+		}, fmt.Sprintf(`SELECT p.id, c.encoding, c.body FROM scraper_pages p
+JOIN scraper_contents c ON c.content_key = p.content_key
+WHERE p.html_content IS NULL AND p.id > ?
+ORDER BY p.id LIMIT %d`, stepBatchSize), afterID)
+		if err != nil {
+			return fmt.Errorf("failed to list stored pages: %w", err)
+		}
+
+		for _, p := range batch {
+			body, err := content.Decode(p.encoding, p.body)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", p.id, err)
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE scraper_pages SET html_content = ? WHERE id = ?`, string(body), p.id); err != nil {
+				return fmt.Errorf("page %d: failed to restore content: %w", p.id, err)
+			}
+		}
+		if len(batch) < stepBatchSize {
+			return nil
+		}
+		afterID = batch[len(batch)-1].id
+	}
+}
+
+// queryRows runs a query and scans every row, the rows are closed before the
+// step writes in the same transaction
+func queryRows(ctx context.Context, tx *Tx, scan func(*sql.Rows) error, query string, args ...any) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
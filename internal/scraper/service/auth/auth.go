@@ -0,0 +1,334 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"app/internal/scraper/db"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role grants access to the admin UI and API, each role includes the ones before it
+type Role string
+
+const (
+	// RoleViewer reads dashboards, pages, queue and logs
+	RoleViewer Role = "viewer"
+	// RoleOperator also manages targets, the queue and crawls
+	RoleOperator Role = "operator"
+	// RoleAdmin also changes settings
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists the roles from least to most privileged
+var Roles = []Role{RoleViewer, RoleOperator, RoleAdmin}
+
+// SessionTTL is how long a login lasts
+const SessionTTL = 12 * time.Hour
+
+// MinPasswordLength is the shortest accepted password
+const MinPasswordLength = 8
+
+// Names of the session and CSRF cookies, and where requests repeat the CSRF token
+const (
+	SessionCookie = "scraper_session"
+	CSRFCookie    = "scraper_csrf"
+	CSRFHeader    = "X-CSRF-Token"
+	CSRFField     = "csrf_token"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUnauthenticated    = errors.New("not signed in")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrTokenNotFound      = errors.New("API token not found")
+)
+
+func ParseRole(s string) (Role, error) {
+	for _, r := range Roles {
+		if string(r) == s {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("invalid role %q: expected one of viewer, operator, admin", s)
+}
+
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if role == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Allows reports whether r has at least the required role
+func (r Role) Allows(required Role) bool {
+	return r.rank() >= 0 && r.rank() >= required.rank()
+}
+
+// User is an authenticated account
+type User struct {
+	ID       int64
+	Username string
+	Role     Role
+}
+
+// Queries defines the db.Queries methods used by Service
+type Queries interface {
+	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.ScraperUser, error)
+	GetUserByUsername(ctx context.Context, username string) (db.ScraperUser, error)
+	UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (int64, error)
+	UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (int64, error)
+	SetUserActive(ctx context.Context, arg db.SetUserActiveParams) (int64, error)
+	RecordUserLogin(ctx context.Context, id int64) error
+	CreateSession(ctx context.Context, arg db.CreateSessionParams) error
+	GetSessionUser(ctx context.Context, id string) (db.GetSessionUserRow, error)
+	DeleteSession(ctx context.Context, id string) error
+	DeleteUserSessions(ctx context.Context, userID int64) error
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error)
+	CreateAPIToken(ctx context.Context, arg db.CreateAPITokenParams) (db.ScraperApiToken, error)
+	GetAPITokenUser(ctx context.Context, tokenHash string) (db.GetAPITokenUserRow, error)
+	TouchAPIToken(ctx context.Context, id int64) error
+	DeleteAPIToken(ctx context.Context, id int64) (int64, error)
+}
+
+// Service manages users, login sessions and API tokens stored in the scraper database.
+// Session cookies and API tokens are random secrets, only their SHA-256 is stored.
+type Service struct {
+	queries Queries
+	now     func() time.Time
+	// dummyHash is compared against for unknown users so logins take as long either way
+	dummyHash []byte
+}
+
+func NewService(queries Queries) *Service {
+	dummy, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return &Service{queries: queries, now: time.Now, dummyHash: dummy}
+}
+
+// HashPassword returns the bcrypt hash of a password of at least MinPasswordLength characters
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// NewSecret returns a random URL-safe secret for session cookies, API and CSRF tokens
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) CreateUser(ctx context.Context, username, password string, role Role) (db.ScraperUser, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return db.ScraperUser{}, errors.New("username is required")
+	}
+	if role.rank() < 0 {
+		return db.ScraperUser{}, fmt.Errorf("invalid role %q", role)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return db.ScraperUser{}, err
+	}
+	user, err := s.queries.CreateUser(ctx, db.CreateUserParams{Username: username, PasswordHash: hash, Role: string(role)})
+	if err != nil {
+		return db.ScraperUser{}, fmt.Errorf("failed to create user %s: %w", username, err)
+	}
+	return user, nil
+}
+
+// SetPassword changes a password and signs the user out everywhere
+func (s *Service) SetPassword(ctx context.Context, userID int64, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	n, err := s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{PasswordHash: hash, ID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return s.queries.DeleteUserSessions(ctx, userID)
+}
+
+func (s *Service) SetRole(ctx context.Context, userID int64, role Role) error {
+	if role.rank() < 0 {
+		return fmt.Errorf("invalid role %q", role)
+	}
+	n, err := s.queries.UpdateUserRole(ctx, db.UpdateUserRoleParams{Role: string(role), ID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetActive enables or disables an account, disabling also ends its sessions
+func (s *Service) SetActive(ctx context.Context, userID int64, active bool) error {
+	n, err := s.queries.SetUserActive(ctx, db.SetUserActiveParams{IsActive: active, ID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	if active {
+		return nil
+	}
+	return s.queries.DeleteUserSessions(ctx, userID)
+}
+
+// Login checks a password and starts a session, it returns the session secret for the cookie
+func (s *Service) Login(ctx context.Context, username, password string) (string, User, error) {
+	row, err := s.queries.GetUserByUsername(ctx, strings.TrimSpace(username))
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return "", User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", User{}, fmt.Errorf("failed to load user: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(row.PasswordHash), []byte(password)) != nil || !row.IsActive {
+		return "", User{}, ErrInvalidCredentials
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return "", User{}, err
+	}
+	now := s.now().UTC()
+	// Logins are rare enough to clean up expired sessions on the way
+	if _, err := s.queries.DeleteExpiredSessions(ctx, now); err != nil {
+		return "", User{}, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	err = s.queries.CreateSession(ctx, db.CreateSessionParams{
+		ID:        hashSecret(secret),
+		UserID:    row.ID,
+		ExpiresAt: now.Add(SessionTTL),
+	})
+	if err != nil {
+		return "", User{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	if err := s.queries.RecordUserLogin(ctx, row.ID); err != nil {
+		return "", User{}, fmt.Errorf("failed to record login: %w", err)
+	}
+	return secret, User{ID: row.ID, Username: row.Username, Role: Role(row.Role)}, nil
+}
+
+func (s *Service) Logout(ctx context.Context, secret string) error {
+	return s.queries.DeleteSession(ctx, hashSecret(secret))
+}
+
+// SessionUser returns the user of a session cookie, ErrUnauthenticated if it is unknown,
+// expired or the account was disabled
+func (s *Service) SessionUser(ctx context.Context, secret string) (User, error) {
+	row, err := s.queries.GetSessionUser(ctx, hashSecret(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("failed to load session: %w", err)
+	}
+	if !row.IsActive || !s.now().Before(row.ExpiresAt) {
+		return User{}, ErrUnauthenticated
+	}
+	return User{ID: row.ID, Username: row.Username, Role: Role(row.Role)}, nil
+}
+
+// CreateToken issues an API token for a user, the secret is only returned here
+func (s *Service) CreateToken(ctx context.Context, userID int64, name string) (string, db.ScraperApiToken, error) {
+	secret, err := NewSecret()
+	if err != nil {
+		return "", db.ScraperApiToken{}, err
+	}
+	token, err := s.queries.CreateAPIToken(ctx, db.CreateAPITokenParams{UserID: userID, Name: name, TokenHash: hashSecret(secret)})
+	if err != nil {
+		return "", db.ScraperApiToken{}, fmt.Errorf("failed to create API token: %w", err)
+	}
+	return secret, token, nil
+}
+
+func (s *Service) RevokeToken(ctx context.Context, id int64) error {
+	n, err := s.queries.DeleteAPIToken(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	if n == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// TokenUser returns the user of an API token and records its use
+func (s *Service) TokenUser(ctx context.Context, secret string) (User, error) {
+	row, err := s.queries.GetAPITokenUser(ctx, hashSecret(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("failed to load API token: %w", err)
+	}
+	if !row.IsActive {
+		return User{}, ErrUnauthenticated
+	}
+	if err := s.queries.TouchAPIToken(ctx, row.TokenID); err != nil {
+		return User{}, fmt.Errorf("failed to record API token use: %w", err)
+	}
+	return User{ID: row.ID, Username: row.Username, Role: Role(row.Role)}, nil
+}
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	csrfKey
+)
+
+// WithUser returns a context carrying the signed in user
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userKey, u)
+}
+
+// UserFrom returns the signed in user of a request context
+func UserFrom(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(userKey).(User)
+	return u, ok
+}
+
+// WithCSRFToken returns a context carrying the CSRF token pages embed in forms and HTMX requests
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfKey, token)
+}
+
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfKey).(string)
+	return token
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestService(t *testing.T) (*Service, *db.Queries) {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	if err := migrate.EnsureSchema(context.Background(), storage.NewSQLite(dbConn), true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	queries := db.New(dbConn)
+	return NewService(queries), queries
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role, required Role
+		want           bool
+	}{
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleViewer, RoleOperator, false},
+		{Role("root"), RoleViewer, false},
+	}
+	for _, tc := range tests {
+		if got := tc.role.Allows(tc.required); got != tc.want {
+			t.Errorf("%s allows %s: got %t, want %t", tc.role, tc.required, got, tc.want)
+		}
+	}
+	if _, err := ParseRole("superuser"); err == nil {
+		t.Error("expected unknown roles to be rejected")
+	}
+}
+
+func TestService_LoginAndSessions(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateUser(ctx, "alice", "short", RoleAdmin); err == nil {
+		t.Fatal("expected short passwords to be rejected")
+	}
+	user, err := s.CreateUser(ctx, " alice ", "correct horse", RoleOperator)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if user.Username != "alice" || user.PasswordHash == "correct horse" {
+		t.Errorf("expected a trimmed name and hashed password, got %+v", user)
+	}
+	if _, err := s.CreateUser(ctx, "alice", "another password", RoleViewer); err == nil {
+		t.Error("expected duplicate usernames to be rejected")
+	}
+
+	for _, creds := range [][2]string{{"alice", "wrong password"}, {"bob", "correct horse"}} {
+		if _, _, err := s.Login(ctx, creds[0], creds[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("login %s: expected invalid credentials, got %v", creds[0], err)
+		}
+	}
+
+	secret, signedIn, err := s.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if signedIn.Username != "alice" || signedIn.Role != RoleOperator {
+		t.Errorf("unexpected user %+v", signedIn)
+	}
+	got, err := s.SessionUser(ctx, secret)
+	if err != nil || got != signedIn {
+		t.Fatalf("expected the session to resolve to %+v, got %+v (%v)", signedIn, got, err)
+	}
+	// Signing in again only cleans up expired sessions
+	if _, _, err := s.Login(ctx, "alice", "correct horse"); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if _, err := s.SessionUser(ctx, secret); err != nil {
+		t.Errorf("expected the first session to survive a second login, got %v", err)
+	}
+	if _, err := s.SessionUser(ctx, "forged"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected unknown sessions to be rejected, got %v", err)
+	}
+
+	// Role changes apply to running sessions
+	if err := s.SetRole(ctx, user.ID, RoleViewer); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	if got, _ := s.SessionUser(ctx, secret); got.Role != RoleViewer {
+		t.Errorf("expected the new role, got %s", got.Role)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(SessionTTL + time.Minute) }
+	if _, err := s.SessionUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected the session to expire, got %v", err)
+	}
+	s.now = time.Now
+
+	if err := s.Logout(ctx, secret); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if _, err := s.SessionUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected the session to end on logout, got %v", err)
+	}
+}
+
+func TestService_PasswordChangeAndDisableEndSessions(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	user, err := s.CreateUser(ctx, "bob", "first password", RoleViewer)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	secret, _, err := s.Login(ctx, "bob", "first password")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if err := s.SetPassword(ctx, user.ID, "second password"); err != nil {
+		t.Fatalf("set password: %v", err)
+	}
+	if _, err := s.SessionUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected a password change to end sessions, got %v", err)
+	}
+	if _, _, err := s.Login(ctx, "bob", "first password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected the old password to fail, got %v", err)
+	}
+
+	if secret, _, err = s.Login(ctx, "bob", "second password"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if err := s.SetActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if _, err := s.SessionUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected disabling to end sessions, got %v", err)
+	}
+	if _, _, err := s.Login(ctx, "bob", "second password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected disabled users to be refused, got %v", err)
+	}
+	if err := s.SetRole(ctx, 99, RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected unknown users to be reported, got %v", err)
+	}
+}
+
+func TestService_APITokens(t *testing.T) {
+	s, queries := newTestService(t)
+	ctx := context.Background()
+	user, err := s.CreateUser(ctx, "ci", "ci password", RoleOperator)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	secret, token, err := s.CreateToken(ctx, user.ID, "deploy")
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if token.TokenHash == secret {
+		t.Error("expected only the token hash to be stored")
+	}
+	got, err := s.TokenUser(ctx, secret)
+	if err != nil || got.Username != "ci" || got.Role != RoleOperator {
+		t.Fatalf("expected the token to act as ci, got %+v (%v)", got, err)
+	}
+	tokens, err := queries.ListAPITokens(ctx)
+	if err != nil || len(tokens) != 1 || !tokens[0].LastUsedAt.Valid {
+		t.Errorf("expected the use to be recorded, got %+v (%v)", tokens, err)
+	}
+
+	if err := s.SetActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if _, err := s.TokenUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected tokens of disabled users to be refused, got %v", err)
+	}
+	if err := s.SetActive(ctx, user.ID, true); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+
+	if err := s.RevokeToken(ctx, token.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := s.TokenUser(ctx, secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected revoked tokens to be refused, got %v", err)
+	}
+	if err := s.RevokeToken(ctx, token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected a second revoke to report the missing token, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package classifier
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Main content extraction configuration constants
+const (
+	minParagraphLength   = 25   // characters a paragraph needs to contribute to its ancestors' score
+	maxParagraphBonus    = 3.0  // cap of the length bonus, one point per 100 characters
+	classWeight          = 25.0 // bonus or penalty for content-like or boilerplate-like class/id names
+	maxMenuLinkDensity   = 0.5  // blocks with more link text than this may be navigation
+	maxMenuLinkTextLen   = 30   // navigation links are short, quote links are not
+	grandparentScoreRate = 0.5  // share of a paragraph's score propagated to its grandparent
+	siblingScoreRate     = 0.5  // siblings scoring this share of the best candidate are content too
+)
+
+// boilerplateTags never contain main content and are removed with their subtree
+var boilerplateTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true, "canvas": true,
+	"iframe": true, "nav": true, "aside": true, "form": true, "button": true, "select": true,
+	"input": true, "textarea": true, "head": true, "link": true, "meta": true,
+}
+
+// tagWeights are the initial scores of candidate containers by tag
+var tagWeights = map[string]float64{
+	"article": 10, "main": 10, "div": 5, "section": 3, "blockquote": 3, "td": 3, "pre": 3,
+	"ol": -3, "ul": -3, "dl": -3, "dd": -3, "dt": -3, "li": -3, "address": -3,
+	"h1": -5, "h2": -5, "h3": -5, "h4": -5, "h5": -5, "h6": -5, "th": -5,
+}
+
+// paragraphTags are scored as paragraphs, divs qualify when they have no block children
+var paragraphTags = map[string]bool{"p": true, "pre": true, "td": true, "blockquote": true, "li": true}
+
+var (
+	positiveClassPattern = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story|quote|citat`)
+	negativeClassPattern = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|sponsor|\bads?\b|advert|promo|related|share|social|nav|menu|header|widget|banner|cookie|breadcrumb|pagination|popup|modal|subscribe|newsletter`)
+)
+
+// ContentExtractor finds the main content of a page, readability style:
+// boilerplate is removed, paragraphs score their ancestors by text density,
+// class/id names and tags adjust the scores and link density discounts them
+type ContentExtractor struct{}
+
+func NewContentExtractor() *ContentExtractor {
+	return &ContentExtractor{}
+}
+
+// MainContent returns a cleaned copy of the highest scoring content subtree of the document.
+// The returned tree is detached from doc, which is left unmodified.
+func (e *ContentExtractor) MainContent(doc *html.Node) *html.Node {
+	body := findElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+	clean := cleanTree(body, false)
+	if clean == nil {
+		return &html.Node{Type: html.ElementNode, Data: "body"}
+	}
+
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node // in document order, so ties resolve deterministically
+	addScore := func(n *html.Node, score float64) {
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, n)
+			scores[n] = initialScore(n)
+		}
+		scores[n] += score
+	}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && isParagraphNode(n) {
+			text := strings.TrimSpace(extractNodeText(n))
+			if length := textLength(text); length >= minParagraphLength {
+				score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, maxParagraphBonus)
+				if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+					addScore(parent, score)
+					if grandparent := parent.Parent; grandparent != nil && grandparent.Type == html.ElementNode {
+						addScore(grandparent, score*grandparentScoreRate)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(clean)
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil || bestScore <= 0 {
+		return clean
+	}
+	// Content split across sibling containers (e.g. two columns of quotes) is kept together
+	if parent := best.Parent; parent != nil {
+		for sibling := parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if sibling == best {
+				continue
+			}
+			if score, ok := scores[sibling]; ok && score*(1-linkDensity(sibling)) >= siblingScoreRate*bestScore {
+				return parent
+			}
+		}
+	}
+	return best
+}
+
+// initialScore weighs a candidate container by its tag and class/id names
+func initialScore(n *html.Node) float64 {
+	score := tagWeights[strings.ToLower(n.Data)] + classScore(n)
+	for _, sel := range mainContentSelectors {
+		if nodeMatchesSelector(n, sel) {
+			score += classWeight
+			break
+		}
+	}
+	return score
+}
+
+// classScore weighs the class and id names of an element
+func classScore(n *html.Node) float64 {
+	names := attrValue(n, "class") + " " + attrValue(n, "id")
+	if strings.TrimSpace(names) == "" {
+		return 0
+	}
+	score := 0.0
+	if negativeClassPattern.MatchString(names) {
+		score -= classWeight
+	}
+	if positiveClassPattern.MatchString(names) {
+		score += classWeight
+	}
+	return score
+}
+
+// cleanTree copies n without boilerplate: scripts, navigation, hidden elements,
+// page headers/footers, boilerplate-named blocks and link-heavy menus
+func cleanTree(n *html.Node, inBlockquote bool) *html.Node {
+	if n.Type == html.CommentNode {
+		return nil
+	}
+	if n.Type == html.ElementNode {
+		if isBoilerplate(n, inBlockquote) {
+			return nil
+		}
+		inBlockquote = inBlockquote || strings.EqualFold(n.Data, "blockquote")
+	}
+
+	clone := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if child := cleanTree(c, inBlockquote); child != nil {
+			clone.AppendChild(child)
+		}
+	}
+	return clone
+}
+
+func isBoilerplate(n *html.Node, inBlockquote bool) bool {
+	tag := strings.ToLower(n.Data)
+	if boilerplateTags[tag] || isHidden(n) {
+		return true
+	}
+	// <footer> inside a blockquote holds the attribution, elsewhere it is page chrome
+	if (tag == "header" || tag == "footer") && !inBlockquote {
+		return true
+	}
+	if tag == "body" || tag == "main" || tag == "article" || inBlockquote {
+		return false
+	}
+	if classScore(n) < 0 {
+		return true
+	}
+	return isMenu(n)
+}
+
+func isHidden(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if attr.Val == "true" {
+				return true
+			}
+		case "style":
+			style := strings.ReplaceAll(strings.ToLower(attr.Val), " ", "")
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMenu reports link lists: mostly link text made of short links
+func isMenu(n *html.Node) bool {
+	if !isBlockElement(n.Data) {
+		return false
+	}
+	links, linkText := linkStats(n)
+	if links < 2 || linkDensity(n) <= maxMenuLinkDensity {
+		return false
+	}
+	return linkText/links < maxMenuLinkTextLen
+}
+
+// linkDensity is the share of the node's text inside links
+func linkDensity(n *html.Node) float64 {
+	total := textLength(strings.TrimSpace(extractNodeText(n)))
+	if total == 0 {
+		return 0
+	}
+	_, linkText := linkStats(n)
+	return float64(linkText) / float64(total)
+}
+
+// linkStats counts the links of a node and the characters of their text
+func linkStats(n *html.Node) (links int, linkText int) {
+	var visit func(*html.Node)
+	visit = func(c *html.Node) {
+		if c.Type == html.ElementNode && strings.EqualFold(c.Data, "a") {
+			links++
+			linkText += textLength(strings.TrimSpace(extractNodeText(c)))
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return links, linkText
+}
+
+// isParagraphNode reports nodes scored as paragraphs: p, li, blockquote... and divs without block children
+func isParagraphNode(n *html.Node) bool {
+	tag := strings.ToLower(n.Data)
+	if paragraphTags[tag] {
+		return true
+	}
+	return tag == "div" && !hasBlockDescendant(n)
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && strings.EqualFold(n.Data, tag) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parseHTML(t *testing.T, htmlStr string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+	return doc
+}
+
+func TestContentExtractor_MainContent_SkipsBoilerplate(t *testing.T) {
+	root := NewContentExtractor().MainContent(parseHTML(t, loadFixture(t, "boilerplate_quotes.html")))
+
+	if got := buildSelector(root); got != "div.quotes" {
+		t.Errorf("expected div.quotes as main content, got %s", got)
+	}
+	text := extractNodeText(root)
+	for _, boilerplate := range []string{"analytics", "cookies", "Popular authors", "fridge", "All rights reserved", "Home"} {
+		if strings.Contains(text, boilerplate) {
+			t.Errorf("main content contains boilerplate %q", boilerplate)
+		}
+	}
+	if !strings.Contains(text, "Winston Churchill") {
+		t.Error("main content lost a quote")
+	}
+}
+
+func TestContentExtractor_CleansScriptsAndHiddenElements(t *testing.T) {
+	page := `<body><div class="post">
+		<p>First paragraph of the article, long enough to count as real content here.</p>
+		<script>var tracking = "should never be extracted";</script>
+		<p style="display: none">Hidden paragraph that screen readers and users never see at all.</p>
+		<p>Second paragraph of the article, with commas, clauses, and more words.</p>
+	</div></body>`
+	root := NewContentExtractor().MainContent(parseHTML(t, page))
+	text := extractNodeText(root)
+	if strings.Contains(text, "tracking") || strings.Contains(text, "Hidden paragraph") {
+		t.Errorf("expected script and hidden content removed, got %q", text)
+	}
+	if !strings.Contains(text, "Second paragraph") {
+		t.Errorf("expected article paragraphs kept, got %q", text)
+	}
+}
+
+func TestContentExtractor_KeepsBlockquoteFooter(t *testing.T) {
+	page := `<body><main><blockquote>Simplicity is the ultimate sophistication in all things. <footer>— Leonardo da Vinci</footer></blockquote></main><footer>Site footer</footer></body>`
+	text := extractNodeText(NewContentExtractor().MainContent(parseHTML(t, page)))
+	if !strings.Contains(text, "Leonardo da Vinci") || strings.Contains(text, "Site footer") {
+		t.Errorf("expected attribution footer kept and page footer removed, got %q", text)
+	}
+}
+
+func TestContentExtractor_SiblingColumns(t *testing.T) {
+	column := func(class string) string {
+		return `<div class="` + class + `"><p>A long enough quote about patience, time and the river of life.</p><p>Another long quote about courage, fear and the strength of a heart.</p></div>`
+	}
+	root := NewContentExtractor().MainContent(parseHTML(t, `<body><div id="columns">`+column("left")+column("right")+`</div></body>`))
+	if got := buildSelector(root); got != "div#columns" {
+		t.Errorf("expected both columns kept under div#columns, got %s", got)
+	}
+}
+
+func TestClassifyPage_BoilerplatePage(t *testing.T) {
+	result, err := NewQuotePageClassifierService().ClassifyPage("http://example.com/courage", loadFixture(t, "boilerplate_quotes.html"))
+	if err != nil {
+		t.Fatalf("ClassifyPage error: %v", err)
+	}
+	if !result.Decision.Processable || len(result.Decision.Selectors) == 0 || result.Decision.Selectors[0] != "div.quote" {
+		t.Errorf("expected processable page with div.quote selector, got %+v, features %v", result.Decision, result.Features)
+	}
+}
@@ -1,6 +1,7 @@
 package classifier
 
 // Decision tree configuration constants
+// These are the built-in defaults, profiles (see profile.go) can override them
 const (
 	minTextCharCount     = 500
 	minLongParagraphLen  = 400
@@ -10,6 +11,9 @@ const (
 	structuredQuoteScore = 0.5
 	colorizedSelector    = "[style*=color]"
 	maxPageSelectors     = 2 // Maximum selectors to use per page
+	singleAuthorRatio    = 0.8
+	narrativeRatio       = 0.5
+	greetingRatio        = 0.5
 )
 
 // DecisionStats struct definition
@@ -21,6 +25,7 @@ type DecisionStats struct {
 }
 
 type DecisionContext struct {
+	Thresholds            Thresholds
 	Stats                 PatternStats
 	NumTextBlocks         int
 	DominantSelector      string
@@ -31,32 +36,55 @@ type DecisionContext struct {
 
 type DecisionRule func(ctx DecisionContext) *DecisionStats
 
-var decisionRules = []DecisionRule{
-	ruleShortMainText,
-	ruleOneLongParagraph,
-	ruleTooFewBlocks,
-	ruleLowDominantSelector,
-	ruleHighQuoteScore,
-	ruleStructuredDiverseContent,
-	ruleSingleAuthorBias,
-	ruleDialogPattern,
-	ruleLowQuoteScore,
+// decisionRules maps rule names used in profiles to their implementations
+var decisionRules = map[string]DecisionRule{
+	RuleShortMainText:       ruleShortMainText,
+	RuleOneLongParagraph:    ruleOneLongParagraph,
+	RuleTooFewBlocks:        ruleTooFewBlocks,
+	RuleLowDominantSelector: ruleLowDominantSelector,
+	RuleHighQuoteScore:      ruleHighQuoteScore,
+	RuleStructuredDiverse:   ruleStructuredDiverseContent,
+	RuleSingleAuthorBias:    ruleSingleAuthorBias,
+	RuleDialogPattern:       ruleDialogPattern,
+	RuleLowQuoteScore:       ruleLowQuoteScore,
+	RuleLongNarrative:       ruleLongNarrative,
+	RuleGreetingMessages:    ruleGreetingMessages,
+}
+
+// defaultRuleOrder is the rule evaluation order of the built-in profile
+var defaultRuleOrder = []string{
+	RuleShortMainText,
+	RuleOneLongParagraph,
+	RuleTooFewBlocks,
+	RuleLongNarrative,
+	RuleGreetingMessages,
+	RuleLowDominantSelector,
+	RuleHighQuoteScore,
+	RuleStructuredDiverse,
+	RuleSingleAuthorBias,
+	RuleDialogPattern,
+	RuleLowQuoteScore,
 }
 
 // Selector helper
-func getSelectors(stats PatternStats, dominantSelector string) []string {
-	selectors := []string{dominantSelector}
-	if stats.ColorizedBlocks {
+func getSelectors(ctx DecisionContext) []string {
+	selectors := []string{ctx.DominantSelector}
+	if ctx.Stats.ColorizedBlocks {
 		selectors = append(selectors, colorizedSelector)
 	}
-	if len(selectors) > maxPageSelectors {
-		selectors = selectors[:maxPageSelectors]
+	if len(selectors) > ctx.Thresholds.MaxPageSelectors {
+		selectors = selectors[:ctx.Thresholds.MaxPageSelectors]
 	}
 	return selectors
 }
 
-// Decision tree logic, returns DecisionStats
+// Decision tree logic with the built-in profile, returns DecisionStats
 func processableDecision(stats PatternStats, numTextBlocks int, dominantSelectorRatio float64, avgQuoteScore float64, singleAuthorBias bool) DecisionStats {
+	return profileDecision(DefaultProfile(), stats, numTextBlocks, dominantSelectorRatio, avgQuoteScore, singleAuthorBias)
+}
+
+// profileDecision evaluates the profile's rules in order, the first matching rule decides
+func profileDecision(profile *ClassifierProfile, stats PatternStats, numTextBlocks int, dominantSelectorRatio float64, avgQuoteScore float64, singleAuthorBias bool) DecisionStats {
 	dominantSelector := ""
 	dominantSelectorCount := 0
 	for sel, cnt := range stats.SelectorCount {
@@ -66,6 +94,7 @@ func processableDecision(stats PatternStats, numTextBlocks int, dominantSelector
 		}
 	}
 	ctx := DecisionContext{
+		Thresholds:            profile.Thresholds,
 		Stats:                 stats,
 		NumTextBlocks:         numTextBlocks,
 		DominantSelector:      dominantSelector,
@@ -73,8 +102,8 @@ func processableDecision(stats PatternStats, numTextBlocks int, dominantSelector
 		AvgQuoteScore:         avgQuoteScore,
 		SingleAuthorBias:      singleAuthorBias,
 	}
-	for _, rule := range decisionRules {
-		if result := rule(ctx); result != nil {
+	for _, name := range profile.Rules {
+		if result := decisionRules[name](ctx); result != nil {
 			return *result
 		}
 	}
@@ -83,28 +112,28 @@ func processableDecision(stats PatternStats, numTextBlocks int, dominantSelector
 
 // Rule implementations
 func ruleShortMainText(ctx DecisionContext) *DecisionStats {
-	if ctx.Stats.TextCharCount < minTextCharCount {
+	if ctx.Stats.TextCharCount < ctx.Thresholds.MinTextCharCount {
 		return &DecisionStats{Reason: DecisionShortMainText, Processable: false, Selectors: nil, Confidence: 0.1}
 	}
 	return nil
 }
 
 func ruleOneLongParagraph(ctx DecisionContext) *DecisionStats {
-	if len(ctx.Stats.BlockLens) == 1 && ctx.Stats.LongestBlockLen > minLongParagraphLen {
+	if len(ctx.Stats.BlockLens) == 1 && ctx.Stats.LongestBlockLen > ctx.Thresholds.MinLongParagraphLen {
 		return &DecisionStats{Reason: DecisionOneLongParagraph, Processable: false, Selectors: nil, Confidence: 0.2}
 	}
 	return nil
 }
 
 func ruleTooFewBlocks(ctx DecisionContext) *DecisionStats {
-	if len(ctx.Stats.BlockLens) < minNumBlocks {
+	if len(ctx.Stats.BlockLens) < ctx.Thresholds.MinNumBlocks {
 		return &DecisionStats{Reason: DecisionTooFewBlocks, Processable: false, Selectors: nil, Confidence: 0.2}
 	}
 	return nil
 }
 
 func ruleLowDominantSelector(ctx DecisionContext) *DecisionStats {
-	if ctx.DominantSelectorRatio < minDominantSelector {
+	if ctx.DominantSelectorRatio < ctx.Thresholds.MinDominantSelector {
 		return &DecisionStats{Reason: DecisionDominantSelectorLow, Processable: false, Selectors: nil, Confidence: 0.3}
 	}
 	return nil
@@ -115,16 +144,16 @@ func ruleHighQuoteScore(ctx DecisionContext) *DecisionStats {
 	if ctx.SingleAuthorBias {
 		return &DecisionStats{Reason: DecisionSingleAuthorBias, Processable: false, Selectors: nil, Confidence: ctx.AvgQuoteScore}
 	}
-	if ctx.AvgQuoteScore >= highQuoteScore {
-		selectors := getSelectors(ctx.Stats, ctx.DominantSelector)
+	if ctx.AvgQuoteScore >= ctx.Thresholds.HighQuoteScore {
+		selectors := getSelectors(ctx)
 		return &DecisionStats{Reason: DecisionQuoteStructure, Processable: true, Selectors: selectors, Confidence: ctx.AvgQuoteScore}
 	}
 	return nil
 }
 
 func ruleStructuredDiverseContent(ctx DecisionContext) *DecisionStats {
-	if ctx.AvgQuoteScore >= structuredQuoteScore && !ctx.Stats.DialogPattern {
-		selectors := getSelectors(ctx.Stats, ctx.DominantSelector)
+	if ctx.AvgQuoteScore >= ctx.Thresholds.StructuredQuoteScore && !ctx.Stats.DialogPattern {
+		selectors := getSelectors(ctx)
 		return &DecisionStats{Reason: DecisionStructuredDiverse, Processable: true, Selectors: selectors, Confidence: ctx.AvgQuoteScore}
 	}
 	return nil
@@ -145,8 +174,25 @@ func ruleDialogPattern(ctx DecisionContext) *DecisionStats {
 }
 
 func ruleLowQuoteScore(ctx DecisionContext) *DecisionStats {
-	if ctx.AvgQuoteScore < structuredQuoteScore {
+	if ctx.AvgQuoteScore < ctx.Thresholds.StructuredQuoteScore {
 		return &DecisionStats{Reason: DecisionLowQuoteScore, Processable: false, Selectors: nil, Confidence: ctx.AvgQuoteScore}
 	}
 	return nil
 }
+
+func ruleLongNarrative(ctx DecisionContext) *DecisionStats {
+	if ctx.NumTextBlocks > 0 && blockRatio(ctx.Stats.NarrativeBlocks, ctx.NumTextBlocks) >= ctx.Thresholds.NarrativeRatio {
+		return &DecisionStats{Reason: DecisionLongNarrative, Processable: false, Selectors: nil, Confidence: 0.3}
+	}
+	return nil
+}
+
+func ruleGreetingMessages(ctx DecisionContext) *DecisionStats {
+	if ctx.Stats.GreetingBlocks < ctx.Thresholds.MinNumBlocks {
+		return nil
+	}
+	if ratio := blockRatio(ctx.Stats.GreetingBlocks, ctx.NumTextBlocks); ratio >= ctx.Thresholds.GreetingRatio {
+		return &DecisionStats{Reason: DecisionBirthdayMessages, Processable: false, Selectors: nil, Confidence: ratio}
+	}
+	return nil
+}
@@ -13,7 +13,7 @@ var blockTags = map[string]bool{
 	"div": true, "p": true, "blockquote": true, "section": true, "article": true, "main": true, "li": true, "ul": true, "ol": true,
 }
 
-// Main content selectors for root node detection
+// Main content selectors, containers matching them get a bonus in main content scoring
 var mainContentSelectors = []string{"article", "main", "#content", ".post-content", "#main", ".entry-content"}
 
 // Define textBlock struct used for block extraction
@@ -23,6 +23,7 @@ type textBlock struct {
 	Path      string
 	Depth     int
 	Colorized bool
+	Author    string // attribution found in markup or as a dash suffix, empty if none
 }
 
 // PageFeatureExtractor is responsible for HTML traversal and feature extraction
@@ -40,41 +41,14 @@ func (e *PageFeatureExtractor) ExtractFeatures(htmlStr string) ([]textBlock, Pat
 	if err != nil {
 		return nil, PatternStats{}, err
 	}
-	root := findMainContentNode(doc)
-	if root == nil {
-		root = doc // fallback
-	}
-	blocks := extractTextBlocks(root)
-	stats := buildPatternStats(blocks)
+	blocks, stats := e.ExtractContentFeatures(NewContentExtractor().MainContent(doc))
 	return blocks, stats, nil
 }
 
-// findMainContentNode tries common selectors, else picks node with most text
-func findMainContentNode(n *html.Node) *html.Node {
-	candidates := findNodesBySelectors(n, mainContentSelectors)
-	if len(candidates) > 0 {
-		return candidates[0]
-	}
-	return findNodeWithMostText(n)
-}
-
-func findNodesBySelectors(n *html.Node, selectors []string) []*html.Node {
-	var nodes []*html.Node
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		if n.Type == html.ElementNode {
-			for _, sel := range selectors {
-				if nodeMatchesSelector(n, sel) {
-					nodes = append(nodes, n)
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(n)
-	return nodes
+// ExtractContentFeatures returns text blocks and pattern stats of an already extracted main content tree
+func (e *PageFeatureExtractor) ExtractContentFeatures(root *html.Node) ([]textBlock, PatternStats) {
+	blocks := extractTextBlocks(root)
+	return blocks, buildPatternStats(blocks)
 }
 
 // extractTextBlocks traverses the DOM and extracts candidate text blocks for quote mining
@@ -87,14 +61,15 @@ func extractTextBlocks(root *html.Node) []textBlock {
 			path := buildPath(n)
 			colorized := hasColorStyle(n)
 			if isBlockElement(n.Data) {
-				text := extractNodeText(n)
-				if len(strings.TrimSpace(text)) > 0 {
+				text := strings.TrimSpace(extractNodeText(n))
+				if len(text) > 0 {
 					blocks = append(blocks, textBlock{
-						Text:      strings.TrimSpace(text),
+						Text:      text,
 						Selector:  selector,
 						Path:      path,
 						Depth:     depth,
 						Colorized: colorized,
+						Author:    blockAuthor(n, text),
 					})
 				}
 			}
@@ -107,6 +82,59 @@ func extractTextBlocks(root *html.Node) []textBlock {
 	return blocks
 }
 
+// blockAuthor finds the attribution of a block: <cite>, <footer> inside a blockquote,
+// an element with an author class, or an em-dash suffix such as "— Author"
+func blockAuthor(n *html.Node, text string) string {
+	if author := markupAuthor(n); author != "" {
+		return author
+	}
+	// A container's text ends with its last child's attribution, only leaf blocks own a suffix
+	if hasBlockDescendant(n) {
+		return ""
+	}
+	return suffixAuthor(text)
+}
+
+func hasBlockDescendant(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (isBlockElement(c.Data) || hasBlockDescendant(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// markupAuthor searches the block for attribution elements without descending into nested blocks,
+// so a container of many quotes is not attributed to the author of its first quote
+func markupAuthor(block *html.Node) string {
+	inBlockquote := strings.EqualFold(block.Data, "blockquote")
+	var found string
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if found != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n != block {
+			tag := strings.ToLower(n.Data)
+			if tag == "blockquote" {
+				return
+			}
+			if tag == "cite" || (tag == "footer" && inBlockquote) || nodeMatchesSelector(n, ".author") {
+				found = normalizeAuthor(extractNodeText(n))
+				return
+			}
+			if isBlockElement(tag) {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil && found == ""; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(block)
+	return found
+}
+
 func buildSelector(n *html.Node) string {
 	if n.Type != html.ElementNode {
 		return ""
@@ -166,30 +194,6 @@ func extractNodeText(n *html.Node) string {
 	return sb.String()
 }
 
-func findNodeWithMostText(n *html.Node) *html.Node {
-	var maxNode *html.Node
-	maxLen := 0
-	var f func(*html.Node)
-	f = func(node *html.Node) {
-		if node.Type == html.ElementNode {
-			text := extractNodeText(node)
-			l := len(strings.TrimSpace(text))
-			if l > maxLen {
-				maxLen = l
-				maxNode = node
-			}
-		}
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(n)
-	if maxNode != nil {
-		return maxNode
-	}
-	return n
-}
-
 func nodeMatchesSelector(n *html.Node, sel string) bool {
 	sel = strings.TrimSpace(sel)
 	if sel == "" || n.Type != html.ElementNode {
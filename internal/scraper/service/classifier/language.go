@@ -0,0 +1,160 @@
+package classifier
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LanguageUnknown is reported when no language could be detected (ISO 639-2 "undetermined")
+const LanguageUnknown = "und"
+
+// Language detection configuration constants
+const (
+	maxLanguageSampleRunes = 5000 // leading text analysed per page
+	minLanguageLetters     = 20   // letters required before a language is reported
+	minStopwordHits        = 3    // stopword matches required to tell Latin-script languages apart
+	kanaShareForJapanese   = 0.1  // kana share of Han letters that marks Japanese text
+)
+
+// scriptLanguages maps scripts used by a single major language to its ISO 639-1 code
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Armenian, "hy"},
+	{unicode.Georgian, "ka"},
+}
+
+// cyrillicMarkers are letters unique to one Cyrillic language, Russian is the fallback
+var cyrillicMarkers = []struct {
+	letters string
+	lang    string
+}{
+	{"іїєґ", "uk"},
+	{"ў", "be"},
+	{"ђћџљњј", "sr"},
+}
+
+// latinStopwords are frequent short words of Latin-script languages
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "you", "that", "it", "in", "are", "not", "with"},
+	"de": {"der", "die", "und", "ist", "nicht", "das", "ich", "zu", "den", "mit", "sie", "ein"},
+	"fr": {"le", "la", "les", "et", "est", "un", "une", "que", "pas", "des", "vous", "dans"},
+	"es": {"el", "la", "los", "que", "es", "y", "en", "no", "una", "por", "las", "con"},
+	"it": {"il", "che", "non", "di", "è", "la", "per", "una", "sono", "gli", "della", "si"},
+	"pt": {"o", "que", "não", "de", "uma", "é", "os", "em", "com", "para", "você", "as"},
+	"nl": {"de", "het", "een", "en", "niet", "is", "van", "dat", "ik", "je", "zijn", "op"},
+	"pl": {"nie", "się", "i", "jest", "to", "że", "na", "w", "z", "jak", "co", "ale"},
+}
+
+// DetectLanguage returns the ISO 639-1 code of the dominant language of the text,
+// or LanguageUnknown when there is too little text or no clear winner
+func DetectLanguage(text string) string {
+	runes := []rune(text)
+	if len(runes) > maxLanguageSampleRunes {
+		runes = runes[:maxLanguageSampleRunes]
+	}
+	sample := string(runes)
+
+	var letters, latin, cyrillic, han, kana int
+	scriptCounts := make([]int, len(scriptLanguages))
+	for _, r := range runes {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for i, sl := range scriptLanguages {
+				if unicode.Is(sl.script, r) {
+					scriptCounts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters < minLanguageLetters {
+		return LanguageUnknown
+	}
+
+	best, bestCount := LanguageUnknown, 0
+	consider := func(lang string, count int) {
+		if count > bestCount {
+			best, bestCount = lang, count
+		}
+	}
+	consider("latin", latin)
+	consider("cyrillic", cyrillic)
+	if kana > 0 && float64(kana) >= kanaShareForJapanese*float64(han) {
+		consider("ja", han+kana)
+	} else {
+		consider("zh", han+kana)
+	}
+	for i, sl := range scriptLanguages {
+		consider(sl.lang, scriptCounts[i])
+	}
+
+	switch best {
+	case "latin":
+		return detectLatinLanguage(sample)
+	case "cyrillic":
+		return detectCyrillicLanguage(sample)
+	}
+	return best
+}
+
+func detectCyrillicLanguage(text string) string {
+	lower := strings.ToLower(text)
+	for _, m := range cyrillicMarkers {
+		if strings.ContainsAny(lower, m.letters) {
+			return m.lang
+		}
+	}
+	// Bulgarian has no ы or э, Russian text of any length uses them
+	if !strings.ContainsAny(lower, "ыэ") && strings.Contains(lower, "ъ") {
+		return "bg"
+	}
+	return "ru"
+}
+
+func detectLatinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	counts := make(map[string]int, len(words))
+	for _, w := range words {
+		counts[w]++
+	}
+
+	best, bestHits, tie := LanguageUnknown, 0, false
+	for lang, stopwords := range latinStopwords {
+		hits := 0
+		for _, w := range stopwords {
+			hits += counts[w]
+		}
+		switch {
+		case hits > bestHits:
+			best, bestHits, tie = lang, hits, false
+		case hits == bestHits:
+			tie = true
+		}
+	}
+	if bestHits < minStopwordHits || tie {
+		return LanguageUnknown
+	}
+	return best
+}
@@ -0,0 +1,30 @@
+package classifier
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The only way to do great work is to love what you do. If you have not found it yet, keep looking and do not settle.", "en"},
+		{"german", "Das Leben ist nicht das, was man gelebt hat, sondern das, woran man sich erinnert und wie man sich erinnert, um davon zu erzählen. Ich bin nicht sicher, ob die Zeit heilt.", "de"},
+		{"french", "La vie est un sommeil, l'amour en est le rêve, et vous aurez vécu si vous avez aimé. Ce n'est pas le temps qui passe, c'est nous qui passons dans la vie.", "fr"},
+		{"spanish", "La vida no es la que uno vivió, sino la que uno recuerda y cómo la recuerda para contarla. No hay camino, se hace camino al andar por los caminos de la vida.", "es"},
+		{"russian", "Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива по-своему. Мы в ответе за тех, кого приручили.", "ru"},
+		{"ukrainian", "Борітеся — поборете, вам Бог помагає! За вас правда, за вас слава і воля святая. Їжак ходить у лісі.", "uk"},
+		{"japanese", "七転び八起き。猿も木から落ちる。石の上にも三年という言葉があります。", "ja"},
+		{"chinese", "学而时习之，不亦说乎？有朋自远方来，不亦乐乎？人不知而不愠，不亦君子乎？", "zh"},
+		{"greek", "Ένα πράγμα ξέρω, ότι δεν ξέρω τίποτα. Ο ανεξέταστος βίος δεν αξίζει να τον ζει κανείς.", "el"},
+		{"too short", "Hi there", LanguageUnknown},
+		{"no stopwords", "Lorem ipsum dolor sit amet consectetur adipiscing elit sed", LanguageUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
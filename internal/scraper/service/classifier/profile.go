@@ -0,0 +1,192 @@
+package classifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProfileConfigKey is the scraper_config key holding the global profile JSON
+const ProfileConfigKey = "classifier_profile"
+
+// DefaultProfileName is the name of the built-in profile
+const DefaultProfileName = "default"
+
+// Rule names used in profiles, see decisionRules for the implementations
+const (
+	RuleShortMainText       = "short_main_text"
+	RuleOneLongParagraph    = "one_long_paragraph"
+	RuleTooFewBlocks        = "too_few_blocks"
+	RuleLowDominantSelector = "low_dominant_selector"
+	RuleHighQuoteScore      = "high_quote_score"
+	RuleStructuredDiverse   = "structured_diverse"
+	RuleSingleAuthorBias    = "single_author_bias"
+	RuleDialogPattern       = "dialog_pattern"
+	RuleLowQuoteScore       = "low_quote_score"
+	RuleLongNarrative       = "long_narrative"
+	RuleGreetingMessages    = "greeting_messages"
+)
+
+// Thresholds used by the decision rules
+type Thresholds struct {
+	MinTextCharCount     int     `json:"min_text_char_count"`
+	MinLongParagraphLen  int     `json:"min_long_paragraph_len"`
+	MinNumBlocks         int     `json:"min_num_blocks"`
+	MinDominantSelector  float64 `json:"min_dominant_selector"`
+	HighQuoteScore       float64 `json:"high_quote_score"`
+	StructuredQuoteScore float64 `json:"structured_quote_score"`
+	MaxPageSelectors     int     `json:"max_page_selectors"`
+	SingleAuthorRatio    float64 `json:"single_author_ratio"`
+	NarrativeRatio       float64 `json:"narrative_ratio"`
+	GreetingRatio        float64 `json:"greeting_ratio"`
+}
+
+// ClassifierProfile is a named, data-driven decision tree configuration
+// The global profile is stored as JSON in scraper_config (key classifier_profile),
+// targets may override parts of it in scraper_targets.classifier_overrides_json
+// name: recorded in every QuoteDecision for traceability
+// rules: rule names evaluated in order, the first matching rule decides
+// thresholds: values the rules compare page features against
+type ClassifierProfile struct {
+	Name       string     `json:"name"`
+	Rules      []string   `json:"rules"`
+	Thresholds Thresholds `json:"thresholds"`
+}
+
+// DefaultProfile returns the built-in profile matching the compiled-in constants
+func DefaultProfile() *ClassifierProfile {
+	return &ClassifierProfile{
+		Name:  DefaultProfileName,
+		Rules: append([]string(nil), defaultRuleOrder...),
+		Thresholds: Thresholds{
+			MinTextCharCount:     minTextCharCount,
+			MinLongParagraphLen:  minLongParagraphLen,
+			MinNumBlocks:         minNumBlocks,
+			MinDominantSelector:  minDominantSelector,
+			HighQuoteScore:       highQuoteScore,
+			StructuredQuoteScore: structuredQuoteScore,
+			MaxPageSelectors:     maxPageSelectors,
+			SingleAuthorRatio:    singleAuthorRatio,
+			NarrativeRatio:       narrativeRatio,
+			GreetingRatio:        greetingRatio,
+		},
+	}
+}
+
+// ParseProfile decodes a profile from JSON, missing fields keep the built-in defaults
+func ParseProfile(jsonStr string) (*ClassifierProfile, error) {
+	profile := DefaultProfile()
+	if err := decodeProfile(jsonStr, profile); err != nil {
+		return nil, err
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ResolveProfile builds the effective profile for a target from the global profile JSON
+// and the target override JSON, either may be empty
+func ResolveProfile(globalJSON, overrideJSON string) (*ClassifierProfile, error) {
+	base := DefaultProfile()
+	if strings.TrimSpace(globalJSON) != "" {
+		if err := decodeProfile(globalJSON, base); err != nil {
+			return nil, fmt.Errorf("global profile: %w", err)
+		}
+		if err := base.Validate(); err != nil {
+			return nil, fmt.Errorf("global profile: %w", err)
+		}
+	}
+	if strings.TrimSpace(overrideJSON) == "" {
+		return base, nil
+	}
+
+	// Decoding into a copy of the base only replaces the fields present in the override
+	merged := *base
+	merged.Rules = append([]string(nil), base.Rules...)
+	merged.Name = ""
+	if err := decodeProfile(overrideJSON, &merged); err != nil {
+		return nil, fmt.Errorf("target override: %w", err)
+	}
+	if merged.Name == "" {
+		merged.Name = base.Name + "+override"
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("target override: %w", err)
+	}
+	return &merged, nil
+}
+
+func decodeProfile(jsonStr string, profile *ClassifierProfile) error {
+	dec := json.NewDecoder(strings.NewReader(jsonStr))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(profile); err != nil {
+		return fmt.Errorf("invalid profile JSON: %w", err)
+	}
+	return nil
+}
+
+// Validate checks rule names and threshold ranges
+func (p *ClassifierProfile) Validate() error {
+	var errs []error
+	if strings.TrimSpace(p.Name) == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(p.Rules) == 0 {
+		errs = append(errs, errors.New("at least one rule is required"))
+	}
+	seen := map[string]bool{}
+	for _, rule := range p.Rules {
+		if _, ok := decisionRules[rule]; !ok {
+			errs = append(errs, fmt.Errorf("unknown rule %q", rule))
+		}
+		if seen[rule] {
+			errs = append(errs, fmt.Errorf("duplicate rule %q", rule))
+		}
+		seen[rule] = true
+	}
+
+	t := p.Thresholds
+	if t.MinTextCharCount < 0 {
+		errs = append(errs, errors.New("min_text_char_count must be >= 0"))
+	}
+	if t.MinLongParagraphLen < 0 {
+		errs = append(errs, errors.New("min_long_paragraph_len must be >= 0"))
+	}
+	if t.MinNumBlocks < 1 {
+		errs = append(errs, errors.New("min_num_blocks must be >= 1"))
+	}
+	if t.MaxPageSelectors < 1 {
+		errs = append(errs, errors.New("max_page_selectors must be >= 1"))
+	}
+	for name, v := range map[string]float64{
+		"min_dominant_selector":  t.MinDominantSelector,
+		"high_quote_score":       t.HighQuoteScore,
+		"structured_quote_score": t.StructuredQuoteScore,
+		"single_author_ratio":    t.SingleAuthorRatio,
+		"narrative_ratio":        t.NarrativeRatio,
+		"greeting_ratio":         t.GreetingRatio,
+	} {
+		if v < 0 || v > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1", name))
+		}
+	}
+	if t.StructuredQuoteScore > t.HighQuoteScore {
+		errs = append(errs, errors.New("structured_quote_score must not exceed high_quote_score"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid classifier profile %q: %w", p.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// MarshalProfile marshals the profile to JSON for DB storage
+func (p *ClassifierProfile) MarshalProfile() (string, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
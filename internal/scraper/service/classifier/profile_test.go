@@ -0,0 +1,105 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// shortQuotesPage builds a page with n short quote blocks, below the default text length threshold
+func shortQuotesPage(n int) string {
+	var sb strings.Builder
+	sb.WriteString("<html><body>")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `<p class="quote">Short quote %d is wise enough for today.</p>`, i)
+	}
+	sb.WriteString("</body></html>")
+	return sb.String()
+}
+
+func TestDefaultProfile_Valid(t *testing.T) {
+	p := DefaultProfile()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("default profile should be valid: %v", err)
+	}
+	if p.Thresholds.MinTextCharCount != minTextCharCount || len(p.Rules) != len(decisionRules) {
+		t.Errorf("default profile does not match built-in constants: %+v", p)
+	}
+}
+
+func TestProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown rule", `{"rules":["short_main_text","no_such_rule"]}`, "unknown rule"},
+		{"duplicate rule", `{"rules":["short_main_text","short_main_text"]}`, "duplicate rule"},
+		{"ratio out of range", `{"thresholds":{"min_dominant_selector":1.5}}`, "min_dominant_selector"},
+		{"no blocks", `{"thresholds":{"min_num_blocks":0}}`, "min_num_blocks"},
+		{"empty name", `{"name":""}`, "name is required"},
+		{"unknown field", `{"treshold":{}}`, "invalid profile JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProfile(tt.json)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestResolveProfile_MergesOverride(t *testing.T) {
+	global := `{"name":"strict","thresholds":{"min_text_char_count":800,"min_long_paragraph_len":400,"min_num_blocks":4,"min_dominant_selector":0.7,"high_quote_score":0.7,"structured_quote_score":0.5,"max_page_selectors":2}}`
+	p, err := ResolveProfile(global, `{"thresholds":{"min_text_char_count":100}}`)
+	if err != nil {
+		t.Fatalf("ResolveProfile error: %v", err)
+	}
+	if p.Name != "strict+override" {
+		t.Errorf("expected derived profile name, got %q", p.Name)
+	}
+	if p.Thresholds.MinTextCharCount != 100 || p.Thresholds.MinNumBlocks != 4 {
+		t.Errorf("expected override merged onto global thresholds, got %+v", p.Thresholds)
+	}
+	if len(p.Rules) != len(defaultRuleOrder) {
+		t.Errorf("expected rules inherited from global profile, got %v", p.Rules)
+	}
+
+	if p, err := ResolveProfile("", ""); err != nil || p.Name != DefaultProfileName {
+		t.Errorf("expected built-in default without config, got %+v, %v", p, err)
+	}
+	if _, err := ResolveProfile("", `{"rules":[]}`); err == nil {
+		t.Error("expected error for override without rules")
+	}
+}
+
+func TestProfile_ShortQuotesOverride(t *testing.T) {
+	page := shortQuotesPage(6)
+
+	decision, err := NewQuotePageClassifierService().ClassifyPage("http://example.com", page)
+	if err != nil {
+		t.Fatalf("ClassifyPage error: %v", err)
+	}
+	if decision.Decision.Processable || decision.Decision.DecisionReason != DecisionShortMainText {
+		t.Fatalf("expected default profile to reject short page, got %+v", decision.Decision)
+	}
+	if decision.Decision.Profile != DefaultProfileName {
+		t.Errorf("expected default profile name in decision, got %q", decision.Decision.Profile)
+	}
+
+	profile, err := ResolveProfile("", `{"name":"short-quotes","thresholds":{"min_text_char_count":150}}`)
+	if err != nil {
+		t.Fatalf("ResolveProfile error: %v", err)
+	}
+	decision, err = NewQuotePageClassifierServiceWithProfile(profile).ClassifyPage("http://example.com", page)
+	if err != nil {
+		t.Fatalf("ClassifyPage error: %v", err)
+	}
+	if !decision.Decision.Processable {
+		t.Errorf("expected short quotes page to pass with override, got %+v", decision.Decision)
+	}
+	if decision.Decision.Profile != "short-quotes" {
+		t.Errorf("expected override profile name in decision, got %q", decision.Decision.Profile)
+	}
+}
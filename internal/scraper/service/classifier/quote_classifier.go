@@ -3,8 +3,12 @@ package classifier
 import (
 	"encoding/json"
 	"math"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
 )
 
 // Quote block length thresholds
@@ -13,9 +17,31 @@ const (
 	maxQuoteLength    = 300
 	introParagraphMax = 120
 	shallowDepthMax   = 4
-	dialogPrefix      = "–"
 )
 
+// Attribution, dialog and message detection constants
+const (
+	maxAuthorWords        = 6 // longer dash suffixes are treated as prose, not an attribution
+	minDialogRun          = 3 // consecutive dialog lines that make a page a dialog
+	minNarrativeSentences = 3 // sentences a long block needs to count as narrative
+)
+
+// dialogPrefixes start a line of dialog ("– Where are you going?")
+var dialogPrefixes = []string{"–", "—", "―", "- "}
+
+// attributionSuffix matches a trailing "— Author" or "- Author, Source" after the quote text
+var attributionSuffix = regexp.MustCompile(`(?:^|[\s"”'’»“.!?…])([—–―-]{1,2})\s*(\p{Lu}[\p{L}\p{M}\p{N}.,'’ -]{0,80})$`)
+
+// greetingPhrases mark birthday, holiday and congratulation messages
+var greetingPhrases = []string{
+	"happy birthday", "birthday wishes", "many happy returns", "happy anniversary",
+	"congratulations on", "merry christmas", "happy new year", "best wishes",
+	"wishing you", "wish you a",
+	"с днём рождения", "с днем рождения", "з днем народження", "поздравляю",
+	"alles gute zum geburtstag", "feliz cumpleaños", "joyeux anniversaire", "buon compleanno",
+	"wszystkiego najlepszego",
+}
+
 // DecisionReason enumerates possible classifier outcomes
 // See design doc for full list
 const (
@@ -40,11 +66,14 @@ const (
 // decision_reason: enum value
 // classified_at: timestamp
 // processable: true/false
+// profile: name of the classifier profile that made the decision
 // features: extracted features
 // url: page url
+// language: ISO 639-1 code of the page text, "und" if undetermined
 // (see design doc for full feature list)
 type QuoteClassifierDecision struct {
 	URL      string                 `json:"url"`
+	Language string                 `json:"language"`
 	Features map[string]interface{} `json:"features"`
 	Decision QuoteDecision          `json:"decision"`
 }
@@ -55,6 +84,7 @@ type QuoteDecision struct {
 	Confidence     float64  `json:"confidence"`
 	DecisionReason string   `json:"decision_reason"`
 	ClassifiedAt   string   `json:"classified_at"`
+	Profile        string   `json:"profile,omitempty"`
 }
 
 // NewQuoteClassifierDecision creates a new decision struct
@@ -84,10 +114,26 @@ func (d *QuoteClassifierDecision) MarshalDecision() (string, error) {
 // QuotePageClassifierService provides feature extraction and decision logic
 // Implements full feature extraction and classification per design doc
 
-type QuotePageClassifierService struct{}
+type QuotePageClassifierService struct {
+	profile *ClassifierProfile
+}
 
 func NewQuotePageClassifierService() *QuotePageClassifierService {
-	return &QuotePageClassifierService{}
+	return NewQuotePageClassifierServiceWithProfile(nil)
+}
+
+// NewQuotePageClassifierServiceWithProfile creates a classifier using the given profile,
+// nil selects the built-in default profile
+func NewQuotePageClassifierServiceWithProfile(profile *ClassifierProfile) *QuotePageClassifierService {
+	if profile == nil {
+		profile = DefaultProfile()
+	}
+	return &QuotePageClassifierService{profile: profile}
+}
+
+// Profile returns the classifier profile used by the service
+func (s *QuotePageClassifierService) Profile() *ClassifierProfile {
+	return s.profile
 }
 
 // PatternStats holds statistics for pattern mining
@@ -103,36 +149,149 @@ type PatternStats struct {
 	BlockSelectors  []string
 	BlockPaths      map[string]bool
 	DialogPattern   bool
+	// Blocks with an author attribution, long multi-sentence prose and greeting messages
+	AttributedBlocks int
+	NarrativeBlocks  int
+	GreetingBlocks   int
 }
 
 // Refactored ClassifyPage to use PageFeatureExtractor for HTML traversal and feature extraction
 func (s *QuotePageClassifierService) ClassifyPage(url string, htmlStr string) (*QuoteClassifierDecision, error) {
-	extractor := NewPageFeatureExtractor()
-	_, stats, err := extractor.ExtractFeatures(htmlStr)
+	doc, err := html.Parse(strings.NewReader(htmlStr))
 	if err != nil {
 		return nil, err
 	}
+	return s.ClassifyContent(url, NewContentExtractor().MainContent(doc)), nil
+}
+
+// ClassifyContent classifies a page from its main content tree, see ContentExtractor.MainContent
+func (s *QuotePageClassifierService) ClassifyContent(url string, root *html.Node) *QuoteClassifierDecision {
+	blocks, stats := NewPageFeatureExtractor().ExtractContentFeatures(root)
 
 	// 3. Decision Tree
-	decisionReason, processable, selectors, confidence, features := makeClassificationDecision(stats)
+	decisionReason, processable, selectors, confidence, features := makeClassificationDecision(s.profile, stats)
 
-	return NewQuoteClassifierDecision(url, features, processable, selectors, confidence, decisionReason), nil
+	decision := NewQuoteClassifierDecision(url, features, processable, selectors, confidence, decisionReason)
+	decision.Decision.Profile = s.profile.Name
+	decision.Language = DetectLanguage(blockText(blocks))
+	return decision
 }
 
-// Heuristic helpers for quote-likeness
+// blockText joins the block texts for page level analysis such as language detection
+func blockText(blocks []textBlock) string {
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Heuristic helpers for quote-likeness, all lengths are counted in characters, not bytes
+
+// hasExplicitSentenceEnd looks past closing quotes and a trailing attribution: «Text.» — Author
 func hasExplicitSentenceEnd(text string) bool {
-	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?")
+	text = trimClosingQuotes(stripAttribution(text))
+	return text != "" && strings.ContainsRune(sentenceEnders, lastRune(text))
 }
 
+// startsWithCapital checks the first letter after opening quotes and dashes,
+// letters of scripts without case (CJK, Arabic, Hebrew...) always qualify
 func startsWithCapital(text string) bool {
-	if len(text) == 0 {
+	r, ok := firstLetter(text)
+	if !ok {
 		return false
 	}
-	return strings.ToUpper(string(text[0])) == string(text[0])
+	return unicode.IsUpper(r) || unicode.IsTitle(r) || !unicode.IsLower(r)
 }
 
+// isDialogFormat reports lines of dialog, a bare "— Author" attribution line is not dialog
 func isDialogFormat(text string) bool {
-	return strings.HasPrefix(text, dialogPrefix)
+	for _, prefix := range dialogPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return suffixAuthor(text) == "" || len(strings.Fields(text)) > maxAuthorWords+1
+		}
+	}
+	return false
+}
+
+// suffixAuthor extracts the author from a trailing dash attribution, empty if there is none
+func suffixAuthor(text string) string {
+	author, _ := splitAttribution(text)
+	return author
+}
+
+// stripAttribution removes a trailing dash attribution, returning the quote text
+func stripAttribution(text string) string {
+	if author, body := splitAttribution(text); author != "" {
+		return body
+	}
+	return text
+}
+
+// SplitQuote splits an extracted quote block into its author, empty when unattributed, and the quote text
+func SplitQuote(text string) (author string, body string) {
+	return splitAttribution(text)
+}
+
+// splitAttribution splits "Quote. — Author" into the author and the text before the dash
+func splitAttribution(text string) (author string, body string) {
+	text = trimClosingQuotes(strings.TrimSpace(text))
+	m := attributionSuffix.FindStringSubmatchIndex(text)
+	if m == nil {
+		return "", text
+	}
+	// Group 1 is the dash, group 2 the author
+	author = normalizeAuthor(text[m[4]:m[5]])
+	words := strings.Fields(author)
+	if len(words) == 0 || len(words) > maxAuthorWords {
+		return "", text
+	}
+	// Names end in a capitalized word, "- then we left" style prose does not
+	last := []rune(words[len(words)-1])
+	if !unicode.IsUpper(last[0]) && !unicode.IsDigit(last[0]) {
+		return "", text
+	}
+	return author, strings.TrimRightFunc(text[:m[2]], unicode.IsSpace)
+}
+
+// normalizeAuthor strips dashes, sources ("Author, Book") and surrounding punctuation
+func normalizeAuthor(s string) string {
+	s = strings.TrimLeft(strings.TrimSpace(s), "—–―- ")
+	if i := strings.IndexAny(s, ",("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimRight(strings.TrimSpace(s), ".;:")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isNarrativeBlock reports long multi-sentence prose, typical for articles and stories
+func isNarrativeBlock(text string) bool {
+	return textLength(text) > maxQuoteLength && countSentences(text) >= minNarrativeSentences
+}
+
+func countSentences(text string) int {
+	count := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if !strings.ContainsRune(sentenceEnders, r) {
+			continue
+		}
+		if i == len(runes)-1 || unicode.IsSpace(runes[i+1]) {
+			count++
+		}
+	}
+	return count
+}
+
+// isGreetingMessage reports birthday, holiday and congratulation messages
+func isGreetingMessage(text string) bool {
+	lower := strings.ToLower(text)
+	for _, phrase := range greetingPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
 }
 
 func isShallow(depth int) bool {
@@ -151,11 +310,13 @@ func buildPatternStats(blocks []textBlock) PatternStats {
 		IntroParagraph:  false,
 		ColorizedBlocks: false,
 	}
+	dialogRun := 0
 	for i, b := range blocks {
-		stats.TextCharCount += len(b.Text)
-		stats.BlockLens = append(stats.BlockLens, len(b.Text))
-		if len(b.Text) > stats.LongestBlockLen {
-			stats.LongestBlockLen = len(b.Text)
+		length := textLength(b.Text)
+		stats.TextCharCount += length
+		stats.BlockLens = append(stats.BlockLens, length)
+		if length > stats.LongestBlockLen {
+			stats.LongestBlockLen = length
 		}
 		stats.BlockSelectors = append(stats.BlockSelectors, b.Selector)
 		stats.SelectorCount[b.Selector]++
@@ -163,12 +324,32 @@ func buildPatternStats(blocks []textBlock) PatternStats {
 
 		qs := computeQuoteScore(b, stats.BlockAuthors)
 		stats.QuoteScores = append(stats.QuoteScores, qs)
-		if i == 0 && len(b.Text) < introParagraphMax {
+		if i == 0 && length < introParagraphMax {
 			stats.IntroParagraph = true
 		}
 		if b.Colorized {
 			stats.ColorizedBlocks = true
 		}
+
+		if b.Author != "" {
+			stats.BlockAuthors[strings.ToLower(b.Author)]++
+			stats.AttributedBlocks++
+		}
+		if isNarrativeBlock(b.Text) {
+			stats.NarrativeBlocks++
+		}
+		if isGreetingMessage(b.Text) {
+			stats.GreetingBlocks++
+		}
+		// Dialog is a run of consecutive dialog lines, a single dashed line is not
+		if isDialogFormat(b.Text) {
+			dialogRun++
+			if dialogRun >= minDialogRun {
+				stats.DialogPattern = true
+			}
+		} else {
+			dialogRun = 0
+		}
 	}
 	return stats
 }
@@ -184,6 +365,9 @@ func computeQuoteScore(b textBlock, blockAuthors map[string]int) float64 {
 	if startsWithCapital(b.Text) {
 		qs += 0.1
 	}
+	if isQuoted(b.Text) {
+		qs += 0.1
+	}
 	// Penalize dialog format, do not consider as quote
 	if isDialogFormat(b.Text) {
 		qs -= 0.1
@@ -191,11 +375,15 @@ func computeQuoteScore(b textBlock, blockAuthors map[string]int) float64 {
 	if isShallow(b.Depth) {
 		qs += 0.1
 	}
+	// An author attribution is the strongest sign of a quote
+	if b.Author != "" {
+		qs += 0.1
+	}
 	return qs
 }
 
 // Updated makeClassificationDecision to use DecisionStats from decision_tree.go
-func makeClassificationDecision(stats PatternStats) (decisionReason string, processable bool, selectors []string, confidence float64, features map[string]interface{}) {
+func makeClassificationDecision(profile *ClassifierProfile, stats PatternStats) (decisionReason string, processable bool, selectors []string, confidence float64, features map[string]interface{}) {
 	numTextBlocks := len(stats.BlockLens)
 	avgBlockLen := 0
 	if numTextBlocks > 0 {
@@ -240,6 +428,7 @@ func makeClassificationDecision(stats PatternStats) (decisionReason string, proc
 		}
 		stddevQuoteScore = math.Sqrt(stddevQuoteScore / float64(numTextBlocks))
 	}
+	// Single author bias is measured among attributed blocks, wrapper blocks never carry an author
 	singleAuthorBias := false
 	maxAuthor := 0
 	for _, cnt := range stats.BlockAuthors {
@@ -247,11 +436,12 @@ func makeClassificationDecision(stats PatternStats) (decisionReason string, proc
 			maxAuthor = cnt
 		}
 	}
-	if numTextBlocks > 0 && maxAuthor > int(0.8*float64(numTextBlocks)) {
+	if stats.AttributedBlocks >= profile.Thresholds.MinNumBlocks &&
+		float64(maxAuthor) >= profile.Thresholds.SingleAuthorRatio*float64(stats.AttributedBlocks) {
 		singleAuthorBias = true
 	}
 
-	decision := processableDecision(stats, numTextBlocks, dominantSelectorRatio, avgQuoteScore, singleAuthorBias)
+	decision := profileDecision(profile, stats, numTextBlocks, dominantSelectorRatio, avgQuoteScore, singleAuthorBias)
 
 	features = map[string]interface{}{
 		"text_char_count":               stats.TextCharCount,
@@ -267,10 +457,22 @@ func makeClassificationDecision(stats PatternStats) (decisionReason string, proc
 		"has_intro_paragraph":           stats.IntroParagraph,
 		"page_contains_dialog_patterns": stats.DialogPattern,
 		"has_colorized_blocks":          stats.ColorizedBlocks,
+		"num_attributed_blocks":         stats.AttributedBlocks,
+		"num_distinct_authors":          len(stats.BlockAuthors),
+		"narrative_block_ratio":         blockRatio(stats.NarrativeBlocks, numTextBlocks),
+		"greeting_block_ratio":          blockRatio(stats.GreetingBlocks, numTextBlocks),
 	}
 	return decision.Reason, decision.Processable, decision.Selectors, decision.Confidence, features
 }
 
+func blockRatio(count, numTextBlocks int) float64 {
+	if numTextBlocks == 0 {
+		return 0
+	}
+	return float64(count) / float64(numTextBlocks)
+}
+
 func isProperLength(text string) bool {
-	return len(text) >= minQuoteLength && len(text) <= maxQuoteLength
+	length := textLength(stripAttribution(text))
+	return length >= minQuoteLength && length <= maxQuoteLength
 }
@@ -0,0 +1,86 @@
+package classifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestSuffixAuthor(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Be yourself. — Oscar Wilde", "Oscar Wilde"},
+		{`"A fourth quote. – Author4"`, "Author4"},
+		{"Stay hungry. —Steve Jobs, Stanford 2005", "Steve Jobs"},
+		{"Art is never finished. - Leonardo da Vinci", "Leonardo da Vinci"},
+		{"— Albert Einstein", "Albert Einstein"},
+		{"We waited for hours - Then we left", ""},
+		{"A well-known self-made man.", ""},
+		{"No attribution here.", ""},
+	}
+	for _, tt := range tests {
+		if got := suffixAuthor(tt.text); got != tt.want {
+			t.Errorf("suffixAuthor(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsDialogFormat(t *testing.T) {
+	if !isDialogFormat("– Where are you going?") {
+		t.Error("expected dash-prefixed line to be dialog")
+	}
+	if isDialogFormat("— Albert Einstein") {
+		t.Error("expected bare attribution line not to be dialog")
+	}
+}
+
+func TestFeatureExtractor_Authors(t *testing.T) {
+	_, stats, err := NewPageFeatureExtractor().ExtractFeatures(loadFixture(t, "blockquote_cite.html"))
+	if err != nil {
+		t.Fatalf("ExtractFeatures error: %v", err)
+	}
+	if stats.AttributedBlocks != 6 || len(stats.BlockAuthors) != 6 {
+		t.Errorf("expected 6 distinct attributed blocks, got %d blocks, authors %v", stats.AttributedBlocks, stats.BlockAuthors)
+	}
+	if stats.BlockAuthors["albert einstein"] != 1 {
+		t.Errorf("expected footer attribution without cite, got %v", stats.BlockAuthors)
+	}
+}
+
+func TestClassifyPage_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		reason      string
+		processable bool
+	}{
+		{"blockquote_cite.html", DecisionStructuredDiverse, true},
+		{"single_author.html", DecisionSingleAuthorBias, false},
+		{"dialog.html", DecisionDialogPattern, false},
+		{"narrative.html", DecisionLongNarrative, false},
+		{"birthday.html", DecisionBirthdayMessages, false},
+	}
+	service := NewQuotePageClassifierService()
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			result, err := service.ClassifyPage("http://example.com/"+tt.fixture, loadFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("ClassifyPage error: %v", err)
+			}
+			if result.Decision.DecisionReason != tt.reason || result.Decision.Processable != tt.processable {
+				t.Errorf("expected %s (processable=%t), got %s (processable=%t), features %v",
+					tt.reason, tt.processable, result.Decision.DecisionReason, result.Decision.Processable, result.Features)
+			}
+		})
+	}
+}
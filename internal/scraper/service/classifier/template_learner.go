@@ -0,0 +1,306 @@
+package classifier
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Template learning configuration constants
+const (
+	minTemplateSamples     = 5    // processable pages required before a template is trusted
+	minTemplateAgreement   = 0.8  // share of samples that must agree on the same selector set
+	maxTemplateMisses      = 3    // consecutive non-matching pages before the template is dropped
+	templateConfidenceStep = 0.05 // confidence changes smaller than a step aren't worth storing
+)
+
+// Learned template states
+const (
+	TemplateStatusLearning = "learning"
+	TemplateStatusStable   = "stable"
+	TemplateStatusDrifted  = "drifted"
+)
+
+// DecisionLearnedTemplate is reported when a page was extracted with the target's learned template
+const DecisionLearnedTemplate = "LEARNED_TEMPLATE"
+
+// TemplateCandidate is one selector set proposed by per-page classification
+type TemplateCandidate struct {
+	Selectors     []string `json:"selectors"`
+	Votes         int      `json:"votes"`
+	ConfidenceSum float64  `json:"confidence_sum"`
+}
+
+// LearnedTemplate is the per-target selector template
+// This is stored as JSON in scraper_targets.learned_template_json
+// status: learning until enough pages agree, stable once converged, drifted after repeated misses
+// selectors: converged selector set, empty while learning
+// confidence: agreement ratio multiplied by the mean classifier confidence of agreeing pages
+type LearnedTemplate struct {
+	Status            string              `json:"status"`
+	Selectors         []string            `json:"selectors"`
+	Confidence        float64             `json:"confidence"`
+	Samples           int                 `json:"samples"`
+	Candidates        []TemplateCandidate `json:"candidates"`
+	Hits              int                 `json:"hits"`
+	ConsecutiveMisses int                 `json:"consecutive_misses"`
+	Drifts            int                 `json:"drifts"`
+	UpdatedAt         string              `json:"updated_at"`
+}
+
+// ParseLearnedTemplate restores a template from its DB JSON, empty input yields a fresh template
+func ParseLearnedTemplate(jsonStr string) (*LearnedTemplate, error) {
+	t := &LearnedTemplate{Status: TemplateStatusLearning}
+	if strings.TrimSpace(jsonStr) == "" {
+		return t, nil
+	}
+	if err := json.Unmarshal([]byte(jsonStr), t); err != nil {
+		return nil, err
+	}
+	if t.Status == "" {
+		t.Status = TemplateStatusLearning
+	}
+	return t, nil
+}
+
+// MarshalTemplate marshals the template to JSON for DB storage
+func (t *LearnedTemplate) MarshalTemplate() (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// IsStable reports whether subsequent pages can be extracted with the template directly
+func (t *LearnedTemplate) IsStable() bool {
+	return t.Status == TemplateStatusStable && len(t.Selectors) > 0
+}
+
+// ChangedFrom reports whether the template differs from prev in what matters to
+// extraction: its status, selectors, confidence bucket or drift count. Hits,
+// misses and learning votes alone don't count.
+func (t *LearnedTemplate) ChangedFrom(prev *LearnedTemplate) bool {
+	return t.Status != prev.Status ||
+		!slices.Equal(t.Selectors, prev.Selectors) ||
+		confidenceBucket(t.Confidence) != confidenceBucket(prev.Confidence) ||
+		t.Drifts != prev.Drifts
+}
+
+func confidenceBucket(confidence float64) int {
+	return int(math.Floor(confidence / templateConfidenceStep))
+}
+
+// TemplateLearner aggregates classifier outcomes across a target's pages and
+// converges on a stable selector set. It is safe for concurrent use by workers.
+type TemplateLearner struct {
+	mu         sync.Mutex
+	template   LearnedTemplate
+	classifier *QuotePageClassifierService
+}
+
+// NewTemplateLearner creates a learner seeded with a previously stored template (may be nil)
+// classifying fallback pages with the given profile (nil selects the built-in default)
+func NewTemplateLearner(template *LearnedTemplate, profile *ClassifierProfile) *TemplateLearner {
+	l := &TemplateLearner{
+		template:   LearnedTemplate{Status: TemplateStatusLearning},
+		classifier: NewQuotePageClassifierServiceWithProfile(profile),
+	}
+	if template != nil {
+		l.template = *template
+	}
+	return l
+}
+
+// Template returns a snapshot of the current template
+func (l *TemplateLearner) Template() LearnedTemplate {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := l.template
+	t.Selectors = slices.Clone(l.template.Selectors)
+	t.Candidates = slices.Clone(l.template.Candidates)
+	return t
+}
+
+// ClassifyPage extracts the page with the learned template when it is stable,
+// falling back to per-page classification (and learning from it) otherwise.
+func (l *TemplateLearner) ClassifyPage(url string, htmlStr string) (*QuoteClassifierDecision, error) {
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, err
+	}
+	return l.ClassifyContent(url, NewContentExtractor().MainContent(doc)), nil
+}
+
+// ClassifyContent is ClassifyPage for an already extracted main content tree
+func (l *TemplateLearner) ClassifyContent(url string, root *html.Node) *QuoteClassifierDecision {
+	l.mu.Lock()
+	stable := l.template.IsStable()
+	selectors := slices.Clone(l.template.Selectors)
+	l.mu.Unlock()
+
+	if stable {
+		blocks := ExtractContentWithSelectors(root, selectors)
+		// A template hit needs as many blocks as the profile requires of a quotes page
+		if len(blocks) >= l.classifier.Profile().Thresholds.MinNumBlocks {
+			decision := l.recordHit(url, len(blocks))
+			decision.Language = DetectLanguage(strings.Join(blocks, "\n"))
+			return decision
+		}
+	}
+
+	decision := l.classifier.ClassifyContent(url, root)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if stable {
+		decision.Features["template_miss"] = true
+		if l.recordMiss() {
+			decision.Features["template_drift"] = true
+		}
+		return decision
+	}
+	l.observe(decision)
+	decision.Features["template_status"] = l.template.Status
+	return decision
+}
+
+// recordHit counts a page extracted with the stable template and builds its decision
+func (l *TemplateLearner) recordHit(url string, matches int) *QuoteClassifierDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.template.Hits++
+	l.template.ConsecutiveMisses = 0
+	l.template.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	features := map[string]interface{}{
+		"template_matches": matches,
+		"template_samples": l.template.Samples,
+		"template_status":  l.template.Status,
+	}
+	decision := NewQuoteClassifierDecision(url, features, true, slices.Clone(l.template.Selectors), l.template.Confidence, DecisionLearnedTemplate)
+	decision.Decision.Profile = l.classifier.Profile().Name
+	return decision
+}
+
+// recordMiss counts a page the stable template did not match, returns true when the template drifted
+func (l *TemplateLearner) recordMiss() bool {
+	l.template.ConsecutiveMisses++
+	l.template.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if l.template.ConsecutiveMisses < maxTemplateMisses {
+		return false
+	}
+	// The site template changed: forget the votes and relearn from scratch
+	l.template = LearnedTemplate{
+		Status:    TemplateStatusDrifted,
+		Hits:      l.template.Hits,
+		Drifts:    l.template.Drifts + 1,
+		UpdatedAt: l.template.UpdatedAt,
+	}
+	return true
+}
+
+// observe adds a per-page decision to the candidate votes and checks for convergence
+func (l *TemplateLearner) observe(decision *QuoteClassifierDecision) {
+	l.template.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if !decision.Decision.Processable || len(decision.Decision.Selectors) == 0 {
+		return
+	}
+	l.template.Samples++
+
+	idx := slices.IndexFunc(l.template.Candidates, func(c TemplateCandidate) bool {
+		return slices.Equal(c.Selectors, decision.Decision.Selectors)
+	})
+	if idx < 0 {
+		l.template.Candidates = append(l.template.Candidates, TemplateCandidate{Selectors: slices.Clone(decision.Decision.Selectors)})
+		idx = len(l.template.Candidates) - 1
+	}
+	l.template.Candidates[idx].Votes++
+	l.template.Candidates[idx].ConfidenceSum += decision.Decision.Confidence
+
+	if l.template.Samples < minTemplateSamples {
+		return
+	}
+	best := l.template.Candidates[0]
+	for _, c := range l.template.Candidates[1:] {
+		if c.Votes > best.Votes {
+			best = c
+		}
+	}
+	agreement := float64(best.Votes) / float64(l.template.Samples)
+	if agreement < minTemplateAgreement {
+		return
+	}
+	l.template.Status = TemplateStatusStable
+	l.template.Selectors = slices.Clone(best.Selectors)
+	l.template.Confidence = agreement * (best.ConfidenceSum / float64(best.Votes))
+	l.template.ConsecutiveMisses = 0
+}
+
+// ExtractWithSelectors returns the text of blocks matching the primary selector,
+// additional selectors (e.g. colorized blocks) contribute blocks not already matched
+func ExtractWithSelectors(htmlStr string, selectors []string) ([]string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, err
+	}
+	return ExtractContentWithSelectors(NewContentExtractor().MainContent(doc), selectors), nil
+}
+
+// ExtractContentWithSelectors is ExtractWithSelectors for an already extracted main content tree
+func ExtractContentWithSelectors(root *html.Node, selectors []string) []string {
+	var texts []string
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, sel := range selectors {
+				if matchesBuiltSelector(n, sel) {
+					if text := strings.TrimSpace(extractNodeText(n)); text != "" {
+						texts = append(texts, text)
+					}
+					// Do not descend into a matched block, nested matches would double count
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+	return texts
+}
+
+// matchesBuiltSelector matches selectors produced by buildSelector: tag, tag#id or tag.class1.class2
+func matchesBuiltSelector(n *html.Node, sel string) bool {
+	if n.Type != html.ElementNode || sel == "" {
+		return false
+	}
+	if sel == colorizedSelector {
+		return hasColorStyle(n)
+	}
+	tag := sel
+	rest := ""
+	if i := strings.IndexAny(sel, "#."); i >= 0 {
+		tag, rest = sel[:i], sel[i:]
+	}
+	if !strings.EqualFold(n.Data, tag) {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	if strings.HasPrefix(rest, "#") {
+		return nodeMatchesSelector(n, rest)
+	}
+	for _, class := range strings.Split(strings.TrimPrefix(rest, "."), ".") {
+		if class != "" && !nodeMatchesSelector(n, "."+class) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// quotePage builds a page with n quote blocks using the given class
+func quotePage(class string, n int) string {
+	var sb strings.Builder
+	sb.WriteString("<html><body>")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `<div class="%s">Quote number %d is a short and wise thought about life, patience and the passing of time.</div>`, class, i)
+	}
+	sb.WriteString("</body></html>")
+	return sb.String()
+}
+
+func TestTemplateLearner_ConvergesOnStableSelectors(t *testing.T) {
+	learner := NewTemplateLearner(nil, nil)
+	for i := 0; i < minTemplateSamples; i++ {
+		decision, err := learner.ClassifyPage(fmt.Sprintf("http://example.com/%d", i), quotePage("quote", 6))
+		if err != nil {
+			t.Fatalf("ClassifyPage error: %v", err)
+		}
+		if !decision.Decision.Processable {
+			t.Fatalf("expected fixture page to be processable, got %s", decision.Decision.DecisionReason)
+		}
+	}
+
+	tmpl := learner.Template()
+	if !tmpl.IsStable() {
+		t.Fatalf("expected stable template after %d samples, got %+v", minTemplateSamples, tmpl)
+	}
+	if tmpl.Selectors[0] != "div.quote" {
+		t.Errorf("expected div.quote as primary selector, got %v", tmpl.Selectors)
+	}
+	if tmpl.Confidence <= 0 || tmpl.Confidence > 1 {
+		t.Errorf("expected confidence in (0, 1], got %f", tmpl.Confidence)
+	}
+
+	decision, err := learner.ClassifyPage("http://example.com/next", quotePage("quote", 4))
+	if err != nil {
+		t.Fatalf("ClassifyPage error: %v", err)
+	}
+	if decision.Decision.DecisionReason != DecisionLearnedTemplate || !decision.Decision.Processable {
+		t.Errorf("expected page extracted with learned template, got %+v", decision.Decision)
+	}
+	if decision.Features["template_matches"] != 4 {
+		t.Errorf("expected 4 template matches, got %v", decision.Features["template_matches"])
+	}
+}
+
+func TestTemplateLearner_NoConvergenceWithoutAgreement(t *testing.T) {
+	learner := NewTemplateLearner(nil, nil)
+	classes := []string{"quote", "saying", "quote", "saying", "quote", "saying"}
+	for i, class := range classes {
+		if _, err := learner.ClassifyPage(fmt.Sprintf("http://example.com/%d", i), quotePage(class, 6)); err != nil {
+			t.Fatalf("ClassifyPage error: %v", err)
+		}
+	}
+	if tmpl := learner.Template(); tmpl.IsStable() {
+		t.Errorf("expected template to keep learning on disagreeing pages, got %+v", tmpl)
+	}
+}
+
+func TestTemplateLearner_DriftDetection(t *testing.T) {
+	stable := &LearnedTemplate{Status: TemplateStatusStable, Selectors: []string{"div.quote"}, Confidence: 0.9, Samples: 10}
+	learner := NewTemplateLearner(stable, nil)
+
+	for i := 0; i < maxTemplateMisses; i++ {
+		decision, err := learner.ClassifyPage(fmt.Sprintf("http://example.com/%d", i), quotePage("redesigned", 6))
+		if err != nil {
+			t.Fatalf("ClassifyPage error: %v", err)
+		}
+		if decision.Decision.DecisionReason == DecisionLearnedTemplate {
+			t.Fatalf("learned template should not match a redesigned page")
+		}
+		drifted := decision.Features["template_drift"] == true
+		if drifted != (i == maxTemplateMisses-1) {
+			t.Errorf("miss %d: unexpected drift flag %v", i+1, drifted)
+		}
+	}
+
+	tmpl := learner.Template()
+	if tmpl.Status != TemplateStatusDrifted || tmpl.Drifts != 1 || len(tmpl.Selectors) != 0 {
+		t.Errorf("expected drifted template with cleared selectors, got %+v", tmpl)
+	}
+
+	// Relearning converges on the new layout
+	for i := 0; i < minTemplateSamples; i++ {
+		if _, err := learner.ClassifyPage(fmt.Sprintf("http://example.com/new/%d", i), quotePage("redesigned", 6)); err != nil {
+			t.Fatalf("ClassifyPage error: %v", err)
+		}
+	}
+	if tmpl := learner.Template(); !tmpl.IsStable() || tmpl.Selectors[0] != "div.redesigned" {
+		t.Errorf("expected template relearned on div.redesigned, got %+v", tmpl)
+	}
+}
+
+func TestLearnedTemplate_MarshalRoundTrip(t *testing.T) {
+	empty, err := ParseLearnedTemplate("")
+	if err != nil || empty.Status != TemplateStatusLearning {
+		t.Fatalf("expected fresh learning template, got %+v, %v", empty, err)
+	}
+
+	orig := &LearnedTemplate{Status: TemplateStatusStable, Selectors: []string{"div.quote", colorizedSelector}, Confidence: 0.8, Samples: 7}
+	jsonStr, err := orig.MarshalTemplate()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	parsed, err := ParseLearnedTemplate(jsonStr)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !parsed.IsStable() || parsed.Samples != 7 || len(parsed.Selectors) != 2 {
+		t.Errorf("round trip mismatch: %+v", parsed)
+	}
+
+	if _, err := ParseLearnedTemplate("{not json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestExtractWithSelectors(t *testing.T) {
+	page := `<div class="quote big">One quote here.</div><div class="quote">Two.</div><p class="quote">Not a div.</p><div id="x" style="color:red">Red text.</div>`
+	blocks, err := ExtractWithSelectors(page, []string{"div.quote"})
+	if err != nil {
+		t.Fatalf("ExtractWithSelectors error: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Errorf("expected 2 div.quote blocks, got %d: %v", len(blocks), blocks)
+	}
+	blocks, _ = ExtractWithSelectors(page, []string{"div.quote.big", colorizedSelector})
+	if len(blocks) != 2 || blocks[1] != "Red text." {
+		t.Errorf("expected compound class and colorized matches, got %v", blocks)
+	}
+}
+
+func TestLearnedTemplate_ChangedFrom(t *testing.T) {
+	base := LearnedTemplate{Status: TemplateStatusStable, Selectors: []string{"div.quote"}, Confidence: 0.91, Hits: 3}
+	for name, tc := range map[string]struct {
+		change func(*LearnedTemplate)
+		want   bool
+	}{
+		"hits":              {func(t *LearnedTemplate) { t.Hits += 10; t.ConsecutiveMisses = 1 }, false},
+		"confidence nudged": {func(t *LearnedTemplate) { t.Confidence = 0.93 }, false},
+		"confidence bucket": {func(t *LearnedTemplate) { t.Confidence = 0.96 }, true},
+		"selectors":         {func(t *LearnedTemplate) { t.Selectors = []string{"div.quote", colorizedSelector} }, true},
+		"status":            {func(t *LearnedTemplate) { t.Status = TemplateStatusLearning }, true},
+		"drift":             {func(t *LearnedTemplate) { t.Drifts++ }, true},
+	} {
+		changed := base
+		changed.Selectors = append([]string(nil), base.Selectors...)
+		tc.change(&changed)
+		if got := changed.ChangedFrom(&base); got != tc.want {
+			t.Errorf("%s: expected changed %v, got %v", name, tc.want, got)
+		}
+	}
+}
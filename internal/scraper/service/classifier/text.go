@@ -0,0 +1,82 @@
+package classifier
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Language-specific punctuation used by the text heuristics
+const (
+	// sentenceEnders end a sentence in Latin, Cyrillic, CJK, Arabic and Devanagari text
+	sentenceEnders = ".!?…。！？؟।"
+	// openingQuotes and closingQuotes cover "…", “…”, „…“, «…», ‹…›, ‚…‘ and CJK brackets
+	openingQuotes   = "\"'“„«‹‚‘「『"
+	closingQuotes   = "\"'”“»›‘’」』"
+	zeroWidthJoiner = '\u200d'
+)
+
+// textLength counts user-perceived characters: combining marks, variation selectors
+// and characters joined by a zero-width joiner belong to the preceding grapheme
+func textLength(text string) int {
+	n := 0
+	joined := false
+	for _, r := range text {
+		switch {
+		case r == zeroWidthJoiner:
+			joined = true
+			continue
+		case unicode.In(r, unicode.Mn, unicode.Me) || unicode.Is(unicode.Variation_Selector, r):
+			continue
+		case joined:
+			joined = false
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// firstLetter returns the first letter of the text, skipping opening quotes, dashes and spaces
+func firstLetter(text string) (rune, bool) {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return r, true
+		}
+		if unicode.IsDigit(r) {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// trimClosingQuotes strips trailing whitespace and closing quote marks
+func trimClosingQuotes(text string) string {
+	return strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(closingQuotes, r)
+	})
+}
+
+// lastRune returns the last rune of the text, utf8.RuneError for empty text
+func lastRune(text string) rune {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return r
+}
+
+// isQuoted reports text wrapped in language-specific quote marks, e.g. «…», „…“ or “…”
+func isQuoted(text string) bool {
+	text = strings.TrimSpace(text)
+	first, size := utf8.DecodeRuneInString(text)
+	if size == 0 || !strings.ContainsRune(openingQuotes, first) {
+		return false
+	}
+	rest := text[size:]
+	if rest != "" && strings.ContainsRune(closingQuotes, lastRune(rest)) {
+		return true
+	}
+	// The closing quote may be followed by an attribution: «…» — Author
+	if author, body := splitAttribution(rest); author != "" {
+		return body != "" && strings.ContainsRune(closingQuotes, lastRune(body))
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"привет", 6},
+		{"e\u0301te\u0301", 3},               // combining acute accents
+		{"\U0001F469\u200d\U0001F4BB ok", 4}, // zero-width joiner sequence
+		{"\u2764\ufe0f", 1},                  // variation selector
+		{"名言", 2},
+	}
+	for _, tt := range tests {
+		if got := textLength(tt.text); got != tt.want {
+			t.Errorf("textLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStartsWithCapital(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Hello", true},
+		{"hello", false},
+		{"Жизнь прекрасна", true},
+		{"жизнь прекрасна", false},
+		{"«Ёлка в лесу»", true},
+		{"„Über alles“", true},
+		{"– Где ты?", true},
+		{"学而时习之", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := startsWithCapital(tt.text); got != tt.want {
+			t.Errorf("startsWithCapital(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHasExplicitSentenceEnd(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"It ends.", true},
+		{"«Всё пройдёт.»", true},
+		{"„Alles wird gut!“", true},
+		{"«Всё пройдёт.» — Соломон", true},
+		{"学而时习之，不亦说乎？", true},
+		{"Wait for it…", true},
+		{"No ending", false},
+		{"«Без точки»", false},
+	}
+	for _, tt := range tests {
+		if got := hasExplicitSentenceEnd(tt.text); got != tt.want {
+			t.Errorf("hasExplicitSentenceEnd(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsQuoted(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{`"Plain quotes."`, true},
+		{"«Ёлочки»", true},
+		{"„Gänsefüßchen“", true},
+		{"“Curly quotes” — Mark Twain", true},
+		{"«Ёлочки» — Пушкин", true},
+		{"Not quoted at all.", false},
+		{"«Unclosed", false},
+	}
+	for _, tt := range tests {
+		if got := isQuoted(tt.text); got != tt.want {
+			t.Errorf("isQuoted(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsProperLength_CountsCharacters(t *testing.T) {
+	// 200 Cyrillic letters are 400 bytes, above maxQuoteLength in bytes but not in characters
+	text := strings.Repeat("ж", 200)
+	if !isProperLength(text) {
+		t.Errorf("expected %d-character Cyrillic text to have proper length", textLength(text))
+	}
+}
+
+func TestIsDialogFormat_RussianDashes(t *testing.T) {
+	if !isDialogFormat("— Привет, — сказал он. — Как дела?") {
+		t.Error("expected em-dash dialog line to be detected")
+	}
+}
+
+func TestClassifyPage_CyrillicQuotes(t *testing.T) {
+	result, err := NewQuotePageClassifierService().ClassifyPage("http://example.com/ru", loadFixture(t, "cyrillic_quotes.html"))
+	if err != nil {
+		t.Fatalf("ClassifyPage error: %v", err)
+	}
+	if !result.Decision.Processable || result.Decision.DecisionReason != DecisionQuoteStructure {
+		t.Errorf("expected Cyrillic quotes to be processable quote structure, got %+v, features %v", result.Decision, result.Features)
+	}
+	if result.Language != "ru" {
+		t.Errorf("expected language ru, got %q", result.Language)
+	}
+	if result.Features["num_distinct_authors"] != 5 {
+		t.Errorf("expected 5 Cyrillic authors, got %v", result.Features["num_distinct_authors"])
+	}
+}
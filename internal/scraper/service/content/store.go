@@ -0,0 +1,140 @@
+package content
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"app/internal/scraper/db"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Encodings of stored bodies
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+)
+
+// ErrNotFound is returned when no body is stored for a content key
+var ErrNotFound = errors.New("content not found")
+
+// Store keeps page bodies content-addressed: identical bodies share one entry
+type Store interface {
+	// Put stores the body unless an identical one is stored already and returns its key
+	Put(ctx context.Context, body []byte) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Queries defines the db.Queries methods used by DBStore
+type Queries interface {
+	PutContent(ctx context.Context, arg db.PutContentParams) error
+	GetContent(ctx context.Context, contentKey string) (db.GetContentRow, error)
+}
+
+// DBStore stores gzip compressed bodies in the scraper_contents table
+type DBStore struct {
+	queries Queries
+}
+
+func NewDBStore(queries Queries) *DBStore {
+	return &DBStore{queries: queries}
+}
+
+// Hash returns the change-detection hash of a body, stored in scraper_pages.content_hash.
+// It is fast but not collision resistant, so it never addresses a stored body.
+func Hash(body []byte) string {
+	return fmt.Sprintf("%x", xxhash.Sum64(body))
+}
+
+// Key returns the SHA-256 of a body, the key of the stored body and scraper_pages.content_key
+func Key(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *DBStore) Put(ctx context.Context, body []byte) (string, error) {
+	key := Key(body)
+	compressed, err := Compress(body)
+	if err != nil {
+		return "", err
+	}
+	err = s.queries.PutContent(ctx, db.PutContentParams{
+		ContentKey: key,
+		Encoding:   EncodingGzip,
+		Body:       compressed,
+		RawSize:    int64(len(body)),
+		StoredSize: int64(len(compressed)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store content: %w", err)
+	}
+	return key, nil
+}
+
+func (s *DBStore) Get(ctx context.Context, key string) ([]byte, error) {
+	row, err := s.queries.GetContent(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content %s: %w", key, err)
+	}
+	return Decode(row.Encoding, row.Body)
+}
+
+// PageBody returns a page's HTML: the inline html_content of pages not moved
+// to the store yet, otherwise the stored body of its content_key
+func PageBody(ctx context.Context, store Store, inline, key sql.NullString) (string, error) {
+	if inline.Valid {
+		return inline.String, nil
+	}
+	if !key.Valid || key.String == "" {
+		return "", fmt.Errorf("%w: page has no stored body", ErrNotFound)
+	}
+	body, err := store.Get(ctx, key.String)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Compress gzips a body
+func Compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to compress content: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress content: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode returns the raw body of a stored body in the given encoding
+func Decode(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case EncodingIdentity:
+		return data, nil
+	case EncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress content: %w", err)
+		}
+		defer func() { _ = r.Close() }()
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress content: %w", err)
+		}
+		return body, nil
+	default:
+		return nil, fmt.Errorf("unknown content encoding %q", encoding)
+	}
+}
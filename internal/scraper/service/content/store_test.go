@@ -0,0 +1,87 @@
+package content
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"app/internal/scraper/db"
+)
+
+// memQueries keeps stored contents in a map, like the scraper_contents table
+type memQueries struct {
+	rows map[string]db.PutContentParams
+	puts int
+}
+
+func (m *memQueries) PutContent(ctx context.Context, arg db.PutContentParams) error {
+	m.puts++
+	if _, ok := m.rows[arg.ContentKey]; !ok {
+		m.rows[arg.ContentKey] = arg
+	}
+	return nil
+}
+
+func (m *memQueries) GetContent(ctx context.Context, contentKey string) (db.GetContentRow, error) {
+	row, ok := m.rows[contentKey]
+	if !ok {
+		return db.GetContentRow{}, sql.ErrNoRows
+	}
+	return db.GetContentRow{Encoding: row.Encoding, Body: row.Body}, nil
+}
+
+func TestDBStore_PutGetDeduplicates(t *testing.T) {
+	q := &memQueries{rows: map[string]db.PutContentParams{}}
+	store := NewDBStore(q)
+	ctx := context.Background()
+	body := []byte("<html><body>" + strings.Repeat("<p>The same quote, again and again.</p>", 50) + "</body></html>")
+
+	first, err := store.Put(ctx, body)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	second, _ := store.Put(ctx, body)
+	if first != second || len(q.rows) != 1 {
+		t.Errorf("expected identical bodies stored once, got keys %s %s and %d rows", first, second, len(q.rows))
+	}
+	row := q.rows[first]
+	if row.Encoding != EncodingGzip || row.StoredSize >= row.RawSize {
+		t.Errorf("expected a compressed body, got %s %d/%d", row.Encoding, row.StoredSize, row.RawSize)
+	}
+
+	got, err := store.Get(ctx, first)
+	if err != nil || string(got) != string(body) {
+		t.Errorf("round trip failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPageBody(t *testing.T) {
+	q := &memQueries{rows: map[string]db.PutContentParams{}}
+	store := NewDBStore(q)
+	ctx := context.Background()
+	hash, _ := store.Put(ctx, []byte("stored"))
+
+	if body, _ := PageBody(ctx, store, sql.NullString{String: "inline", Valid: true}, sql.NullString{String: hash, Valid: true}); body != "inline" {
+		t.Errorf("expected the inline body of an unmoved page, got %q", body)
+	}
+	if body, _ := PageBody(ctx, store, sql.NullString{}, sql.NullString{String: hash, Valid: true}); body != "stored" {
+		t.Errorf("expected the stored body, got %q", body)
+	}
+	if _, err := PageBody(ctx, store, sql.NullString{}, sql.NullString{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound without body and hash, got %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if body, err := Decode(EncodingIdentity, []byte("raw")); err != nil || string(body) != "raw" {
+		t.Errorf("identity decode failed: %q %v", body, err)
+	}
+	if _, err := Decode("zstd", nil); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
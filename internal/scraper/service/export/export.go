@@ -0,0 +1,307 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/classifier"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/service/pipeline"
+)
+
+// Datasets that can be exported
+const (
+	DatasetPages           = "pages"
+	DatasetClassifications = "classifications"
+	DatasetQuotes          = "quotes"
+)
+
+// Datasets lists the exportable datasets
+var Datasets = []string{DatasetPages, DatasetClassifications, DatasetQuotes}
+
+// Output formats
+const (
+	FormatJSONL   = "jsonl"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatJSONL, FormatCSV, FormatParquet}
+
+// batchSize is the number of pages loaded per query, rows are written before
+// the next batch is loaded so memory stays bounded however large the export
+const batchSize = 100
+
+// Queries defines the db.Queries methods used by Exporter
+type Queries interface {
+	ListPagesForExport(ctx context.Context, arg db.ListPagesForExportParams) ([]db.ListPagesForExportRow, error)
+	content.Queries
+}
+
+// Options selects the dataset, format and pages to export
+type Options struct {
+	Dataset     string
+	Format      string
+	TargetID    int64     // 0 for all targets
+	Since       time.Time // only pages visited at or after this time, zero for any
+	Until       time.Time // only pages visited before this time, zero for any
+	Processable *bool     // nil for any
+	IncludeHTML bool      // add the page HTML to the pages dataset
+}
+
+// Validate checks the dataset, format and date range
+func (o Options) Validate() error {
+	if !slices.Contains(Datasets, o.Dataset) {
+		return fmt.Errorf("unknown dataset %q, expected one of %v", o.Dataset, Datasets)
+	}
+	if !slices.Contains(Formats, o.Format) {
+		return fmt.Errorf("unknown format %q, expected one of %v", o.Format, Formats)
+	}
+	if !o.Since.IsZero() && !o.Until.IsZero() && !o.Until.After(o.Since) {
+		return fmt.Errorf("until must be after since")
+	}
+	return nil
+}
+
+// FileName is the suggested name of the export file, e.g. pages.jsonl
+func (o Options) FileName() string {
+	return o.Dataset + "." + o.Format
+}
+
+// ContentType is the MIME type of the export format
+func (o Options) ContentType() string {
+	switch o.Format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Exporter streams stored pages and their extracted data to a writer
+type Exporter struct {
+	queries  Queries
+	contents content.Store
+}
+
+func NewExporter(queries Queries) *Exporter {
+	return &Exporter{queries: queries, contents: content.NewDBStore(queries)}
+}
+
+// Export writes the selected dataset to w and returns the number of records written
+func (e *Exporter) Export(ctx context.Context, w io.Writer, opts Options) (int, error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+	switch opts.Dataset {
+	case DatasetClassifications:
+		return export(ctx, e, w, opts, classificationRecords)
+	case DatasetQuotes:
+		return export(ctx, e, w, opts, quoteRecords)
+	default:
+		return export(ctx, e, w, opts, e.pageRecords)
+	}
+}
+
+// export converts the matching pages batch by batch and writes their records
+func export[T record](ctx context.Context, e *Exporter, w io.Writer, opts Options, convert func(context.Context, db.ListPagesForExportRow, Options) ([]T, error)) (int, error) {
+	out, err := newWriter[T](opts.Format, w)
+	if err != nil {
+		return 0, err
+	}
+	params := db.ListPagesForExportParams{
+		TargetID:  sql.NullInt64{Int64: opts.TargetID, Valid: opts.TargetID > 0},
+		Since:     sql.NullTime{Time: opts.Since.UTC(), Valid: !opts.Since.IsZero()},
+		Until:     sql.NullTime{Time: opts.Until.UTC(), Valid: !opts.Until.IsZero()},
+		BatchSize: batchSize,
+	}
+	if opts.Processable != nil {
+		params.Processable = sql.NullBool{Bool: *opts.Processable, Valid: true}
+	}
+
+	total := 0
+	for {
+		pages, err := e.queries.ListPagesForExport(ctx, params)
+		if err != nil {
+			return total, fmt.Errorf("failed to list pages: %w", err)
+		}
+		var records []T
+		for _, page := range pages {
+			converted, err := convert(ctx, page, opts)
+			if err != nil {
+				return total, fmt.Errorf("page %d: %w", page.ID, err)
+			}
+			records = append(records, converted...)
+		}
+		if err := out.Write(records); err != nil {
+			return total, fmt.Errorf("failed to write %s: %w", opts.Format, err)
+		}
+		total += len(records)
+		if len(pages) < batchSize {
+			break
+		}
+		params.AfterID = pages[len(pages)-1].ID
+	}
+	if err := out.Close(); err != nil {
+		return total, fmt.Errorf("failed to write %s: %w", opts.Format, err)
+	}
+	return total, nil
+}
+
+// PageRecord is a stored page's metadata and, when requested, its HTML
+type PageRecord struct {
+	ID                int64      `json:"id" parquet:"id"`
+	TargetID          int64      `json:"target_id" parquet:"target_id"`
+	URLPath           string     `json:"url_path" parquet:"url_path"`
+	FullURL           string     `json:"full_url" parquet:"full_url"`
+	HTTPStatusCode    *int64     `json:"http_status_code" parquet:"http_status_code,optional"`
+	ResponseTimeMs    *int64     `json:"response_time_ms" parquet:"response_time_ms,optional"`
+	ContentLength     *int64     `json:"content_length" parquet:"content_length,optional"`
+	ContentHash       *string    `json:"content_hash" parquet:"content_hash,optional"`
+	FirstDiscoveredAt *time.Time `json:"first_discovered_at" parquet:"first_discovered_at,optional"`
+	LastVisitedAt     *time.Time `json:"last_visited_at" parquet:"last_visited_at,optional"`
+	LastUpdatedAt     *time.Time `json:"last_updated_at" parquet:"last_updated_at,optional"`
+	VisitCount        int64      `json:"visit_count" parquet:"visit_count"`
+	Processable       *bool      `json:"processable" parquet:"processable,optional"`
+	Language          *string    `json:"language" parquet:"language,optional"`
+	HTML              *string    `json:"html,omitempty" parquet:"html,optional"`
+}
+
+func (e *Exporter) pageRecords(ctx context.Context, page db.ListPagesForExportRow, opts Options) ([]PageRecord, error) {
+	rec := PageRecord{
+		ID:                page.ID,
+		TargetID:          page.TargetID,
+		URLPath:           page.UrlPath,
+		FullURL:           page.FullUrl,
+		HTTPStatusCode:    nullInt(page.HttpStatusCode),
+		ResponseTimeMs:    nullInt(page.ResponseTimeMs),
+		ContentLength:     nullInt(page.ContentLength),
+		ContentHash:       nullString(page.ContentHash),
+		FirstDiscoveredAt: nullTime(page.FirstDiscoveredAt),
+		LastVisitedAt:     nullTime(page.LastVisitedAt),
+		LastUpdatedAt:     nullTime(page.LastUpdatedAt),
+		VisitCount:        page.VisitCount.Int64,
+		Processable:       nullBool(page.Processable),
+		Language:          nullString(page.Language),
+	}
+	if opts.IncludeHTML && (page.HtmlContent.Valid || page.ContentKey.Valid) {
+		body, err := content.PageBody(ctx, e.contents, page.HtmlContent, page.ContentKey)
+		if err != nil {
+			return nil, err
+		}
+		rec.HTML = &body
+	}
+	return []PageRecord{rec}, nil
+}
+
+// ClassificationRecord is the stored classifier decision of a page
+type ClassificationRecord struct {
+	PageID         int64    `json:"page_id" parquet:"page_id"`
+	TargetID       int64    `json:"target_id" parquet:"target_id"`
+	FullURL        string   `json:"full_url" parquet:"full_url"`
+	Processable    bool     `json:"processable" parquet:"processable"`
+	Confidence     float64  `json:"confidence" parquet:"confidence"`
+	DecisionReason string   `json:"decision_reason" parquet:"decision_reason"`
+	Selectors      []string `json:"selectors" parquet:"selectors,list"`
+	Profile        string   `json:"profile" parquet:"profile"`
+	Language       string   `json:"language" parquet:"language"`
+	ClassifiedAt   string   `json:"classified_at" parquet:"classified_at"`
+}
+
+// classificationRecords skips pages stored without a classifier result
+func classificationRecords(_ context.Context, page db.ListPagesForExportRow, _ Options) ([]ClassificationRecord, error) {
+	if !page.QuoteClassifierJson.Valid || page.QuoteClassifierJson.String == "" {
+		return nil, nil
+	}
+	var decision classifier.QuoteClassifierDecision
+	if err := json.Unmarshal([]byte(page.QuoteClassifierJson.String), &decision); err != nil {
+		return nil, fmt.Errorf("invalid classifier result: %w", err)
+	}
+	selectors := decision.Decision.Selectors
+	if selectors == nil {
+		selectors = []string{}
+	}
+	return []ClassificationRecord{{
+		PageID:         page.ID,
+		TargetID:       page.TargetID,
+		FullURL:        page.FullUrl,
+		Processable:    decision.Decision.Processable,
+		Confidence:     decision.Decision.Confidence,
+		DecisionReason: decision.Decision.DecisionReason,
+		Selectors:      selectors,
+		Profile:        decision.Decision.Profile,
+		Language:       decision.Language,
+		ClassifiedAt:   decision.Decision.ClassifiedAt,
+	}}, nil
+}
+
+// QuoteRecord is one quote extracted from a page
+type QuoteRecord struct {
+	PageID   int64  `json:"page_id" parquet:"page_id"`
+	TargetID int64  `json:"target_id" parquet:"target_id"`
+	FullURL  string `json:"full_url" parquet:"full_url"`
+	Position int    `json:"position" parquet:"position"`
+	Text     string `json:"text" parquet:"text"`
+	Author   string `json:"author" parquet:"author"`
+}
+
+// quoteRecords returns one record per quote, in page order
+func quoteRecords(_ context.Context, page db.ListPagesForExportRow, _ Options) ([]QuoteRecord, error) {
+	if !page.QuotesJson.Valid || page.QuotesJson.String == "" {
+		return nil, nil
+	}
+	var quotes []pipeline.Quote
+	if err := json.Unmarshal([]byte(page.QuotesJson.String), &quotes); err != nil {
+		return nil, fmt.Errorf("invalid quotes: %w", err)
+	}
+	records := make([]QuoteRecord, 0, len(quotes))
+	for i, q := range quotes {
+		records = append(records, QuoteRecord{
+			PageID:   page.ID,
+			TargetID: page.TargetID,
+			FullURL:  page.FullUrl,
+			Position: i + 1,
+			Text:     q.Text,
+			Author:   q.Author,
+		})
+	}
+	return records, nil
+}
+
+func nullInt(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullBool(v sql.NullBool) *bool {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Bool
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time.UTC()
+	return &t
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/service/content"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/parquet-go/parquet-go"
+)
+
+func newTestDB(t *testing.T) (*sql.DB, *db.Queries) {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	if err := migrate.EnsureSchema(context.Background(), storage.NewSQLite(dbConn), true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return dbConn, db.New(dbConn)
+}
+
+// seed stores three pages: a processable one with quotes and its HTML in the
+// content store, an unprocessable one with inline HTML and an unclassified one
+func seed(t *testing.T, dbConn *sql.DB, queries *db.Queries) (int64, int64) {
+	t.Helper()
+	ctx := context.Background()
+	first, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	second, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://other.example"})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	key, err := content.NewDBStore(queries).Put(ctx, []byte("<html>quotes</html>"))
+	if err != nil {
+		t.Fatalf("put content: %v", err)
+	}
+
+	pages := []struct {
+		target      int64
+		path        string
+		html        any
+		key         any
+		classifier  any
+		processable any
+		quotes      any
+		visited     string
+	}{
+		{first.ID, "/quotes", nil, key, `{"url":"/quotes","language":"en","decision":{"processable":true,"selectors":["div.quote"],"confidence":0.9,"decision_reason":"QUOTE_STRUCTURE","profile":"default"}}`, true,
+			`[{"text":"Be yourself.","author":"Oscar Wilde"},{"text":"Stay hungry, \"stay\" foolish."}]`, "2024-06-02 10:00:00"},
+		{first.ID, "/about", "<html>about</html>", nil, `{"url":"/about","decision":{"processable":false,"selectors":[],"confidence":0.1,"decision_reason":"SHORT_MAIN_TEXT"}}`, false, nil, "2024-05-01 10:00:00"},
+		{second.ID, "/new", nil, nil, nil, nil, nil, "2024-06-03 10:00:00"},
+	}
+	for _, p := range pages {
+		_, err := dbConn.Exec(`INSERT INTO scraper_pages (target_id, url_path, full_url, html_content, content_key, quote_classifier_json, processable, quotes_json, http_status_code, last_visited_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, 200, ?)`, p.target, p.path, "https://quotes.example"+p.path, p.html, p.key, p.classifier, p.processable, p.quotes, p.visited)
+		if err != nil {
+			t.Fatalf("insert page: %v", err)
+		}
+	}
+	return first.ID, second.ID
+}
+
+func exportString(t *testing.T, queries *db.Queries, opts Options) (string, int) {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := NewExporter(queries).Export(context.Background(), &buf, opts)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return buf.String(), n
+}
+
+func TestExport_PagesJSONL(t *testing.T) {
+	dbConn, queries := newTestDB(t)
+	seed(t, dbConn, queries)
+
+	out, n := exportString(t, queries, Options{Dataset: DatasetPages, Format: FormatJSONL, IncludeHTML: true})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if n != 3 || len(lines) != 3 {
+		t.Fatalf("expected 3 pages, got %d records and %d lines", n, len(lines))
+	}
+	var page PageRecord
+	if err := json.Unmarshal([]byte(lines[0]), &page); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if page.URLPath != "/quotes" || page.HTML == nil || *page.HTML != "<html>quotes</html>" {
+		t.Errorf("expected the stored HTML of /quotes, got %+v", page)
+	}
+	if page.HTTPStatusCode == nil || *page.HTTPStatusCode != 200 || page.LastVisitedAt == nil {
+		t.Errorf("expected page metadata, got %+v", page)
+	}
+	if !strings.Contains(lines[1], `"html":"<html>about</html>"`) {
+		t.Errorf("expected the inline HTML unescaped, got %s", lines[1])
+	}
+
+	out, _ = exportString(t, queries, Options{Dataset: DatasetPages, Format: FormatJSONL})
+	if strings.Contains(out, `"html"`) {
+		t.Errorf("expected no HTML without IncludeHTML, got %s", out)
+	}
+}
+
+func TestExport_Filters(t *testing.T) {
+	dbConn, queries := newTestDB(t)
+	firstID, secondID := seed(t, dbConn, queries)
+	yes, no := true, false
+
+	tests := []struct {
+		name string
+		opts Options
+		want int
+	}{
+		{"target", Options{TargetID: firstID}, 2},
+		{"other target", Options{TargetID: secondID}, 1},
+		{"processable", Options{Processable: &yes}, 1},
+		{"unprocessable", Options{Processable: &no}, 1},
+		{"since", Options{Since: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, 2},
+		{"until", Options{Until: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, 1},
+		{"range", Options{Since: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dataset, tt.opts.Format = DatasetPages, FormatJSONL
+			if _, n := exportString(t, queries, tt.opts); n != tt.want {
+				t.Errorf("expected %d pages, got %d", tt.want, n)
+			}
+		})
+	}
+}
+
+func TestExport_ClassificationsCSV(t *testing.T) {
+	dbConn, queries := newTestDB(t)
+	seed(t, dbConn, queries)
+
+	out, n := exportString(t, queries, Options{Dataset: DatasetClassifications, Format: FormatCSV})
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	// Unclassified pages are skipped
+	if n != 2 || len(rows) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d records and %v", n, rows)
+	}
+	if strings.Join(rows[0][:4], ",") != "page_id,target_id,full_url,processable" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	if rows[1][3] != "true" || rows[1][5] != "QUOTE_STRUCTURE" || rows[1][6] != `["div.quote"]` || rows[1][8] != "en" {
+		t.Errorf("unexpected row %v", rows[1])
+	}
+}
+
+func TestExport_EmptyCSVHasHeader(t *testing.T) {
+	_, queries := newTestDB(t)
+	out, n := exportString(t, queries, Options{Dataset: DatasetQuotes, Format: FormatCSV})
+	if n != 0 || out != "page_id,target_id,full_url,position,text,author\n" {
+		t.Errorf("expected only the header, got %d records and %q", n, out)
+	}
+}
+
+func TestExport_QuotesParquet(t *testing.T) {
+	dbConn, queries := newTestDB(t)
+	seed(t, dbConn, queries)
+
+	out, n := exportString(t, queries, Options{Dataset: DatasetQuotes, Format: FormatParquet})
+	if n != 2 {
+		t.Fatalf("expected 2 quotes, got %d", n)
+	}
+	quotes, err := parquet.Read[QuoteRecord](strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("invalid parquet: %v", err)
+	}
+	if len(quotes) != 2 || quotes[0].Author != "Oscar Wilde" || quotes[1].Position != 2 || quotes[1].Text != `Stay hungry, "stay" foolish.` {
+		t.Errorf("unexpected quotes %+v", quotes)
+	}
+}
+
+func TestExport_PagesParquet(t *testing.T) {
+	dbConn, queries := newTestDB(t)
+	seed(t, dbConn, queries)
+
+	out, _ := exportString(t, queries, Options{Dataset: DatasetPages, Format: FormatParquet})
+	pages, err := parquet.Read[PageRecord](strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("invalid parquet: %v", err)
+	}
+	if len(pages) != 3 || pages[2].Processable != nil || pages[0].Processable == nil || !*pages[0].Processable {
+		t.Errorf("expected NULL processable kept, got %+v", pages)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	invalid := []Options{
+		{Dataset: "links", Format: FormatJSONL},
+		{Dataset: DatasetPages, Format: "xml"},
+		{Dataset: DatasetPages, Format: FormatCSV, Since: since, Until: since},
+	}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", opts)
+		}
+	}
+	if err := (Options{Dataset: DatasetQuotes, Format: FormatParquet}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// record is an exported row, JSONL and Parquet use its struct tags, CSV its columns
+type record interface {
+	csvHeader() []string
+	csvRecord() []string
+}
+
+// writer encodes records in one output format. Write is called once per batch
+// and must not keep the records, Close finishes the output.
+type writer[T record] interface {
+	Write(records []T) error
+	Close() error
+}
+
+func newWriter[T record](format string, w io.Writer) (writer[T], error) {
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonlWriter[T]{enc: enc}, nil
+	case FormatCSV:
+		return &csvWriter[T]{w: csv.NewWriter(w)}, nil
+	case FormatParquet:
+		return &parquetWriter[T]{w: parquet.NewGenericWriter[T](w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+type jsonlWriter[T record] struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter[T]) Write(records []T) error {
+	for _, r := range records {
+		if err := j.enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonlWriter[T]) Close() error { return nil }
+
+// csvWriter writes the header before the first row, or on Close for an empty export
+type csvWriter[T record] struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter[T]) Write(records []T) error {
+	if !c.header {
+		var zero T
+		if err := c.w.Write(zero.csvHeader()); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	for _, r := range records {
+		if err := c.w.Write(r.csvRecord()); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter[T]) Close() error {
+	if !c.header {
+		return c.Write(nil)
+	}
+	return nil
+}
+
+// parquetWriter flushes a row group per batch so buffered rows stay bounded
+type parquetWriter[T record] struct {
+	w *parquet.GenericWriter[T]
+}
+
+func (p *parquetWriter[T]) Write(records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if _, err := p.w.Write(records); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
+
+func (p *parquetWriter[T]) Close() error { return p.w.Close() }
+
+func (PageRecord) csvHeader() []string {
+	return []string{
+		"id", "target_id", "url_path", "full_url", "http_status_code", "response_time_ms", "content_length",
+		"content_hash", "first_discovered_at", "last_visited_at", "last_updated_at", "visit_count",
+		"processable", "language", "html",
+	}
+}
+
+func (r PageRecord) csvRecord() []string {
+	return []string{
+		strconv.FormatInt(r.ID, 10),
+		strconv.FormatInt(r.TargetID, 10),
+		r.URLPath,
+		r.FullURL,
+		formatInt(r.HTTPStatusCode),
+		formatInt(r.ResponseTimeMs),
+		formatInt(r.ContentLength),
+		formatString(r.ContentHash),
+		formatTime(r.FirstDiscoveredAt),
+		formatTime(r.LastVisitedAt),
+		formatTime(r.LastUpdatedAt),
+		strconv.FormatInt(r.VisitCount, 10),
+		formatBool(r.Processable),
+		formatString(r.Language),
+		formatString(r.HTML),
+	}
+}
+
+func (ClassificationRecord) csvHeader() []string {
+	return []string{
+		"page_id", "target_id", "full_url", "processable", "confidence", "decision_reason", "selectors",
+		"profile", "language", "classified_at",
+	}
+}
+
+// csvRecord encodes the selectors as a JSON array, selectors may contain any separator
+func (r ClassificationRecord) csvRecord() []string {
+	selectors, _ := json.Marshal(r.Selectors)
+	return []string{
+		strconv.FormatInt(r.PageID, 10),
+		strconv.FormatInt(r.TargetID, 10),
+		r.FullURL,
+		strconv.FormatBool(r.Processable),
+		strconv.FormatFloat(r.Confidence, 'f', -1, 64),
+		r.DecisionReason,
+		string(selectors),
+		r.Profile,
+		r.Language,
+		r.ClassifiedAt,
+	}
+}
+
+func (QuoteRecord) csvHeader() []string {
+	return []string{"page_id", "target_id", "full_url", "position", "text", "author"}
+}
+
+func (r QuoteRecord) csvRecord() []string {
+	return []string{
+		strconv.FormatInt(r.PageID, 10),
+		strconv.FormatInt(r.TargetID, 10),
+		r.FullURL,
+		strconv.Itoa(r.Position),
+		r.Text,
+		r.Author,
+	}
+}
+
+// CSV leaves NULL values empty
+
+func formatInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func formatString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func formatBool(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
+
+func formatTime(v *time.Time) string {
+	if v == nil {
+		return ""
+	}
+	return v.Format(time.RFC3339)
+}
@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// ErrDropped is returned for log rows not buffered because the buffer is full
+// or the writer is closed
+var ErrDropped = errors.New("log buffer full, row dropped")
+
+// dropped counts the rows dropped by every BatchWriter of the process
+var dropped atomic.Int64
+
+// Dropped returns the number of log rows the process dropped
+func Dropped() int64 { return dropped.Load() }
+
+// BatchOptions tunes a BatchWriter, zero fields take the defaults
+type BatchOptions struct {
+	// Buffer is the number of rows waiting to be written, rows logged while
+	// it's full are dropped rather than blocking the caller. Default 1000.
+	Buffer int
+	// BatchSize is the most rows inserted in one transaction. Default 100.
+	BatchSize int
+	// FlushInterval is the longest a row waits for its batch. Default 1s.
+	FlushInterval time.Duration
+	// Retention is applied after flushes, at most every PruneInterval
+	Retention Retention
+	// PruneInterval defaults to an hour
+	PruneInterval time.Duration
+}
+
+func (o *BatchOptions) defaults() {
+	if o.Buffer <= 0 {
+		o.Buffer = 1000
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.PruneInterval <= 0 {
+		o.PruneInterval = time.Hour
+	}
+}
+
+// BatchWriter is the LoggerQueries of the DBHandler that keeps logging off
+// the crawl's path: LogMessage only buffers the row and a goroutine inserts
+// the buffer in batches, one transaction each, so logging takes the SQLite
+// write lock once per batch instead of once per row.
+type BatchWriter struct {
+	store    storage.Store
+	opts     BatchOptions
+	rows     chan db.LogMessageParams
+	flushReq chan chan struct{}
+	done     chan struct{}
+	mu       sync.RWMutex // guards closing rows against LogMessage
+	closed   bool
+	dropped  atomic.Int64
+}
+
+// NewBatchWriter starts a writer to the store's scraper_logs. Close it to
+// write the rows still buffered.
+func NewBatchWriter(store storage.Store, opts BatchOptions) *BatchWriter {
+	opts.defaults()
+	w := &BatchWriter{
+		store:    store,
+		opts:     opts,
+		rows:     make(chan db.LogMessageParams, opts.Buffer),
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// LogMessage buffers a row without waiting for the database
+func (w *BatchWriter) LogMessage(ctx context.Context, params db.LogMessageParams) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.closed {
+		select {
+		case w.rows <- params:
+			return nil
+		default:
+		}
+	}
+	w.drop(1)
+	return ErrDropped
+}
+
+// Dropped returns the number of rows this writer dropped
+func (w *BatchWriter) Dropped() int64 { return w.dropped.Load() }
+
+// Flush writes the rows buffered so far, and prunes when it's due
+func (w *BatchWriter) Flush() {
+	flushed := make(chan struct{})
+	select {
+	case w.flushReq <- flushed:
+		<-flushed
+	case <-w.done:
+	}
+}
+
+// Close writes the buffered rows and stops the writer
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.rows)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+func (w *BatchWriter) drop(n int) {
+	w.dropped.Add(int64(n))
+	dropped.Add(int64(n))
+}
+
+func (w *BatchWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	var lastPrune time.Time
+	batch := make([]db.LogMessageParams, 0, w.opts.BatchSize)
+	for {
+		var flushed chan struct{}
+		select {
+		case row, ok := <-w.rows:
+			if !ok {
+				w.write(batch)
+				return
+			}
+			if batch = append(batch, row); len(batch) < w.opts.BatchSize {
+				continue
+			}
+		case flushed = <-w.flushReq:
+			// Take what's buffered, the channel may hold several batches
+			for len(w.rows) > 0 {
+				if batch = append(batch, <-w.rows); len(batch) == w.opts.BatchSize {
+					batch = w.write(batch)
+				}
+			}
+		case <-ticker.C:
+		}
+		batch = w.write(batch)
+		if w.opts.Retention.Enabled() && time.Since(lastPrune) >= w.opts.PruneInterval {
+			lastPrune = time.Now()
+			w.prune()
+		}
+		if flushed != nil {
+			close(flushed)
+		}
+	}
+}
+
+// write inserts a batch in one transaction and returns it emptied. Rows of a
+// failed batch are dropped, reported on stderr only since the table is what failed.
+func (w *BatchWriter) write(batch []db.LogMessageParams) []db.LogMessageParams {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := func() error {
+		tx, err := w.store.DB().BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		q := w.store.WithTx(tx)
+		for _, row := range batch {
+			if err := q.LogMessage(ctx, row); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		w.drop(len(batch))
+		slog.Default().Error("Failed to write log batch", "rows", len(batch), Err(err))
+	}
+	return batch[:0]
+}
+
+func (w *BatchWriter) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	n, err := Prune(ctx, w.store, w.opts.Retention)
+	if err != nil {
+		slog.Default().Error("Failed to prune logs", Err(err))
+		return
+	}
+	if n > 0 {
+		slog.Default().Info("Pruned old logs", "rows", n)
+	}
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestStore(t *testing.T) storage.Store {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	store := storage.NewSQLite(dbConn)
+	if err := migrate.EnsureSchema(context.Background(), store, true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+func countLogs(t *testing.T, store storage.Store) int64 {
+	t.Helper()
+	var n int64
+	if err := store.DB().QueryRow("SELECT COUNT(*) FROM scraper_logs").Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func logRows(t *testing.T, w *BatchWriter, n int) {
+	t.Helper()
+	for i := range n {
+		_ = w.LogMessage(context.Background(), db.LogMessageParams{LogType: LevelInfo, Message: fmt.Sprintf("row %d", i)})
+	}
+}
+
+func TestBatchWriter_Writes(t *testing.T) {
+	store := newTestStore(t)
+	w := NewBatchWriter(store, BatchOptions{BatchSize: 3, FlushInterval: time.Hour})
+
+	logRows(t, w, 7)
+	w.Flush()
+	if n := countLogs(t, store); n != 7 {
+		t.Errorf("expected the 7 rows after a flush, got %d", n)
+	}
+
+	// Close writes what's left and refuses later rows
+	logRows(t, w, 2)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n := countLogs(t, store); n != 9 {
+		t.Errorf("expected the 9 rows after closing, got %d", n)
+	}
+	if err := w.LogMessage(context.Background(), db.LogMessageParams{LogType: LevelInfo, Message: "late"}); !errors.Is(err, ErrDropped) {
+		t.Errorf("expected ErrDropped after Close, got %v", err)
+	}
+	if w.Dropped() != 1 {
+		t.Errorf("expected 1 dropped row, got %d", w.Dropped())
+	}
+}
+
+func TestBatchWriter_DropsWhenFull(t *testing.T) {
+	store := newTestStore(t)
+	before := Dropped()
+
+	// Holding the only connection stalls the writer, so the buffer fills
+	tx, err := store.DB().Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	w := NewBatchWriter(store, BatchOptions{Buffer: 2, BatchSize: 1, FlushInterval: time.Hour})
+	logRows(t, w, 10)
+	if w.Dropped() == 0 {
+		t.Error("expected rows dropped while the buffer was full")
+	}
+	_ = tx.Rollback()
+	_ = w.Close()
+
+	if written := countLogs(t, store); written+w.Dropped() != 10 {
+		t.Errorf("expected every row written or dropped, got %d written and %d dropped", written, w.Dropped())
+	}
+	if Dropped()-before != w.Dropped() {
+		t.Errorf("expected the process count to include the writer's %d drops, got %d", w.Dropped(), Dropped()-before)
+	}
+}
+
+func TestBatchWriter_Prunes(t *testing.T) {
+	store := newTestStore(t)
+	w := NewBatchWriter(store, BatchOptions{FlushInterval: time.Hour, Retention: Retention{MaxRows: 2}})
+	defer func() { _ = w.Close() }()
+
+	logRows(t, w, 5)
+	w.Flush()
+	if n := countLogs(t, store); n != 2 {
+		t.Errorf("expected the flush to prune to the 2 newest rows, got %d", n)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"log/slog"
+	"slices"
 
 	"app/internal/scraper/db"
 )
@@ -13,77 +15,146 @@ type LoggerQueries interface {
 	LogMessage(ctx context.Context, params db.LogMessageParams) error
 }
 
-// Level represents log levels
-type Level string
-
+// Level names stored in scraper_logs.log_type
 const (
-	LevelInfo  Level = "info"
-	LevelWarn  Level = "warn"
-	LevelError Level = "error"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
 )
 
-// DBLogger logs to the scraper_logs table
-type DBLogger struct {
+// LevelName returns the scraper_logs name of a slog level
+func LevelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// DBHandler is a slog.Handler writing records of at least info to the
+// scraper_logs table. The target_id, run_id and url attributes fill their
+// columns, the others are stored as JSON details, the component is left out.
+type DBHandler struct {
 	queries LoggerQueries
+	attrs   []slog.Attr
+	groups  []string
+}
+
+func NewDBHandler(queries LoggerQueries) *DBHandler {
+	return &DBHandler{queries: queries}
 }
 
-// NewDBLogger creates a new database logger
-func NewDBLogger(queries LoggerQueries) *DBLogger {
-	return &DBLogger{queries: queries}
+func (h *DBHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
 }
 
-// Log writes a log entry to the database
-func (l *DBLogger) Log(ctx context.Context, level Level, targetID *int64, url, message string, details interface{}) {
-	var detailsJSON sql.NullString
-	if details != nil {
-		if jsonBytes, err := json.Marshal(details); err == nil {
-			detailsJSON = sql.NullString{String: string(jsonBytes), Valid: true}
+func (h *DBHandler) Handle(ctx context.Context, r slog.Record) error {
+	params := db.LogMessageParams{LogType: LevelName(r.Level), Message: r.Message}
+	details := map[string]any{}
+	add := func(a slog.Attr) bool {
+		h.add(details, &params, a)
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if len(h.groups) == 0 {
+			return add(a)
+		}
+		return add(slog.Attr{Key: h.groups[0], Value: slog.GroupValue(nest(h.groups[1:], a)...)})
+	})
+	if len(details) > 0 {
+		if b, err := json.Marshal(details); err == nil {
+			params.Details = sql.NullString{String: string(b), Valid: true}
 		}
 	}
+	return h.queries.LogMessage(ctx, params)
+}
 
-	var targetIDNull sql.NullInt64
-	if targetID != nil {
-		targetIDNull = sql.NullInt64{Int64: *targetID, Valid: true}
+// add stores an attribute in its column or the details
+func (h *DBHandler) add(details map[string]any, params *db.LogMessageParams, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	switch {
+	case a.Equal(slog.Attr{}):
+	case a.Key == KeyComponent:
+	case a.Key == KeyTargetID && a.Value.Kind() == slog.KindInt64:
+		params.TargetID = sql.NullInt64{Int64: a.Value.Int64(), Valid: true}
+	case a.Key == KeyRunID && a.Value.Kind() == slog.KindInt64:
+		params.RunID = sql.NullInt64{Int64: a.Value.Int64(), Valid: true}
+	case a.Key == KeyURL && a.Value.Kind() == slog.KindString:
+		if url := a.Value.String(); url != "" {
+			params.Url = sql.NullString{String: url, Valid: true}
+		}
+	default:
+		merge(details, a.Key, value(a.Value))
 	}
+}
 
-	var urlNull sql.NullString
-	if url != "" {
-		urlNull = sql.NullString{String: url, Valid: true}
+// merge sets a detail, merging the attributes of groups logged in several attributes
+func merge(details map[string]any, key string, v any) {
+	group, ok := v.(map[string]any)
+	existing, isGroup := details[key].(map[string]any)
+	if !ok || !isGroup {
+		details[key] = v
+		return
+	}
+	for k, v := range group {
+		merge(existing, k, v)
 	}
+}
 
-	// Log to database - don't block on errors
-	_ = l.queries.LogMessage(ctx, db.LogMessageParams{
-		LogType:  string(level),
-		TargetID: targetIDNull,
-		Url:      urlNull,
-		Message:  message,
-		Details:  detailsJSON,
-	})
+// value converts an attribute value to what encodes as its JSON
+func value(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := map[string]any{}
+		for _, a := range v.Group() {
+			merge(group, a.Key, value(a.Value.Resolve()))
+		}
+		return group
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	case slog.KindDuration:
+		return v.Duration().String()
+	}
+	return v.Any()
 }
 
-// Info logs an info message
-func (l *DBLogger) Info(ctx context.Context, targetID *int64, url, message string, details ...interface{}) {
-	var det interface{}
-	if len(details) > 0 {
-		det = details[0]
+// nest wraps an attribute in the groups, innermost last
+func nest(groups []string, a slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		a = slog.Attr{Key: groups[i], Value: slog.GroupValue(a)}
 	}
-	l.Log(ctx, LevelInfo, targetID, url, message, det)
+	return []slog.Attr{a}
 }
 
-// Warn logs a warning message
-func (l *DBLogger) Warn(ctx context.Context, targetID *int64, url, message string, details ...interface{}) {
-	var det interface{}
-	if len(details) > 0 {
-		det = details[0]
+func (h *DBHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := *h
+	if len(h.groups) == 0 {
+		out.attrs = append(slices.Clone(h.attrs), attrs...)
+	} else {
+		grouped := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			grouped = append(grouped, slog.Attr{Key: h.groups[0], Value: slog.GroupValue(nest(h.groups[1:], a)...)})
+		}
+		out.attrs = append(slices.Clone(h.attrs), grouped...)
 	}
-	l.Log(ctx, LevelWarn, targetID, url, message, det)
+	return &out
 }
 
-// Error logs an error message
-func (l *DBLogger) Error(ctx context.Context, targetID *int64, url, message string, details ...interface{}) {
-	var det interface{}
-	if len(details) > 0 {
-		det = details[0]
+func (h *DBHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
 	}
-	l.Log(ctx, LevelError, targetID, url, message, det)
+	out := *h
+	out.groups = append(slices.Clone(h.groups), name)
+	return &out
 }
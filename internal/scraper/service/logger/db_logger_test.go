@@ -3,6 +3,8 @@ package logger
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"log/slog"
 	"testing"
 
 	"app/internal/scraper/db"
@@ -11,6 +13,7 @@ import (
 // MockQueries implements LoggerQueries interface for testing
 type MockQueries struct {
 	lastLogMessage db.LogMessageParams
+	messages       int
 	shouldError    bool
 }
 
@@ -19,113 +22,79 @@ func (m *MockQueries) LogMessage(ctx context.Context, params db.LogMessageParams
 		return sql.ErrConnDone
 	}
 	m.lastLogMessage = params
+	m.messages++
 	return nil
 }
 
-func TestDBLogger_Log(t *testing.T) {
+func TestDBHandler_Columns(t *testing.T) {
 	mockQueries := &MockQueries{}
-	logger := NewDBLogger(mockQueries)
+	log := slog.New(NewDBHandler(mockQueries))
 
-	ctx := context.Background()
-	targetID := int64(123)
-
-	logger.Log(ctx, LevelInfo, &targetID, "http://example.com", "test message", map[string]string{"key": "value"})
+	log.Info("test message", Target(123), URL("http://example.com"), "key", "value")
 
-	// Verify the mock received the correct parameters
-	if mockQueries.lastLogMessage.LogType != "info" {
-		t.Errorf("Expected LogType 'info', got %q", mockQueries.lastLogMessage.LogType)
+	got := mockQueries.lastLogMessage
+	if got.LogType != "info" {
+		t.Errorf("Expected LogType 'info', got %q", got.LogType)
 	}
-
-	if !mockQueries.lastLogMessage.TargetID.Valid || mockQueries.lastLogMessage.TargetID.Int64 != 123 {
+	if !got.TargetID.Valid || got.TargetID.Int64 != 123 {
 		t.Error("TargetID not set correctly")
 	}
-
-	if !mockQueries.lastLogMessage.Url.Valid || mockQueries.lastLogMessage.Url.String != "http://example.com" {
+	if !got.Url.Valid || got.Url.String != "http://example.com" {
 		t.Error("URL not set correctly")
 	}
-
-	if mockQueries.lastLogMessage.Message != "test message" {
-		t.Errorf("Expected message 'test message', got %q", mockQueries.lastLogMessage.Message)
+	if got.Message != "test message" {
+		t.Errorf("Expected message 'test message', got %q", got.Message)
 	}
-
-	if !mockQueries.lastLogMessage.Details.Valid {
-		t.Error("Details should be valid when provided")
+	if got.Details.String != `{"key":"value"}` {
+		t.Errorf("Expected the other attributes as details, got %q", got.Details.String)
 	}
 }
 
-func TestDBLogger_LogLevels(t *testing.T) {
+func TestDBHandler_Levels(t *testing.T) {
 	mockQueries := &MockQueries{}
-	logger := NewDBLogger(mockQueries)
-
+	log := slog.New(NewDBHandler(mockQueries))
 	ctx := context.Background()
-	targetID := int64(123)
 
-	tests := []struct {
-		name     string
-		logFunc  func()
-		expected string
-	}{
-		{
-			name:     "info level",
-			logFunc:  func() { logger.Info(ctx, &targetID, "", "info message") },
-			expected: "info",
-		},
-		{
-			name:     "warn level",
-			logFunc:  func() { logger.Warn(ctx, &targetID, "", "warn message") },
-			expected: "warn",
-		},
-		{
-			name:     "error level",
-			logFunc:  func() { logger.Error(ctx, &targetID, "", "error message") },
-			expected: "error",
-		},
+	for level, want := range map[slog.Level]string{
+		slog.LevelInfo:  "info",
+		slog.LevelWarn:  "warn",
+		slog.LevelError: "error",
+	} {
+		log.Log(ctx, level, "message")
+		if mockQueries.lastLogMessage.LogType != want {
+			t.Errorf("Expected LogType %q, got %q", want, mockQueries.lastLogMessage.LogType)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.logFunc()
-			if mockQueries.lastLogMessage.LogType != tt.expected {
-				t.Errorf("Expected LogType %q, got %q", tt.expected, mockQueries.lastLogMessage.LogType)
-			}
-		})
+	log.Debug("debug message")
+	if mockQueries.messages != 3 {
+		t.Errorf("Expected debug records kept out of the table, got %d messages", mockQueries.messages)
 	}
 }
 
-func TestDBLogger_ErrorHandling(t *testing.T) {
-	mockQueries := &MockQueries{shouldError: true}
-	logger := NewDBLogger(mockQueries)
-
-	ctx := context.Background()
-
-	// Should not panic even if database logging fails
-	logger.Info(ctx, nil, "", "this should not panic")
-}
-
-func TestDBLogger_NilDetails(t *testing.T) {
+func TestDBHandler_Details(t *testing.T) {
 	mockQueries := &MockQueries{}
-	logger := NewDBLogger(mockQueries)
+	log := slog.New(NewDBHandler(mockQueries)).With(KeyComponent, ComponentSitemap, "sitemap", "a.xml")
 
-	ctx := context.Background()
-
-	// Test with nil details
-	logger.Info(ctx, nil, "", "no details")
+	log.WithGroup("http").Error("request failed", "status", 503, Err(errors.New("timeout")), "status_text", "Service Unavailable")
+	if want := `{"http":{"error":"timeout","status":503,"status_text":"Service Unavailable"},"sitemap":"a.xml"}`; mockQueries.lastLogMessage.Details.String != want {
+		t.Errorf("Expected details %s, got %s", want, mockQueries.lastLogMessage.Details.String)
+	}
 
+	// The component alone is no detail, empty URLs no URL
+	log = slog.New(NewDBHandler(mockQueries)).With(KeyComponent, ComponentSitemap)
+	log.Info("no details", URL(""))
 	if mockQueries.lastLogMessage.Details.Valid {
-		t.Error("Details should be invalid when nil")
+		t.Error("Details should be invalid without attributes")
 	}
-}
-
-func TestDBLogger_EmptyURL(t *testing.T) {
-	mockQueries := &MockQueries{}
-	logger := NewDBLogger(mockQueries)
-
-	ctx := context.Background()
-
-	// Test with empty URL
-	logger.Info(ctx, nil, "", "empty url test")
-
 	if mockQueries.lastLogMessage.Url.Valid {
 		t.Error("URL should be invalid when empty")
 	}
 }
+
+func TestDBHandler_ErrorHandling(t *testing.T) {
+	mockQueries := &MockQueries{shouldError: true}
+
+	// Should not panic even if database logging fails
+	slog.New(NewDBHandler(mockQueries)).Info("this should not panic")
+}
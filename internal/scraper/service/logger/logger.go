@@ -0,0 +1,253 @@
+// Package logger sets up the scraper's log/slog logging: text or JSON records
+// on stderr filtered by per component levels, and the scraper_logs table as a
+// second sink for components that log crawl activity for the admin UI.
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Components log at their own level, e.g. LOG_LEVEL=warn,fetcher=debug
+const (
+	ComponentFetcher = "fetcher"
+	ComponentSitemap = "sitemap"
+	ComponentUI      = "ui"
+	ComponentSearch  = "search"
+	ComponentMetrics = "metrics"
+)
+
+// Components lists the components accepted in level specs
+var Components = []string{ComponentFetcher, ComponentSitemap, ComponentUI, ComponentSearch, ComponentMetrics}
+
+// Attribute keys stored in their own scraper_logs columns
+const (
+	KeyComponent = "component"
+	KeyTargetID  = "target_id"
+	KeyURL       = "url"
+	KeyRunID     = "run_id"
+)
+
+// Target is the attribute of the target a record is about
+func Target(id int64) slog.Attr { return slog.Int64(KeyTargetID, id) }
+
+// URL is the attribute of the page or sitemap a record is about
+func URL(url string) slog.Attr { return slog.String(KeyURL, url) }
+
+// Run is the attribute of the crawl run a record was logged by
+func Run(id int64) slog.Attr { return slog.Int64(KeyRunID, id) }
+
+type runKey struct{}
+
+// WithRun returns a context whose records carry the run attribute, so the
+// parser and fetcher logs of a crawl link to its scraper_runs row
+func WithRun(ctx context.Context, runID int64) context.Context {
+	return context.WithValue(ctx, runKey{}, runID)
+}
+
+// RunFrom returns the run ID of a context, 0 outside runs
+func RunFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(runKey{}).(int64)
+	return id
+}
+
+// Err is the attribute of an error
+func Err(err error) slog.Attr { return slog.Any("error", err) }
+
+// Options configures the stderr output
+type Options struct {
+	// Level is the level of every component, optionally followed by
+	// component=level overrides: "info,fetcher=debug,ui=warn"
+	Level string
+	// Format is "text" (default) or "json"
+	Format string
+	// Output defaults to stderr
+	Output io.Writer
+}
+
+// OptionsFromEnv reads LOG_LEVEL and LOG_FORMAT
+func OptionsFromEnv() Options {
+	return Options{Level: os.Getenv("LOG_LEVEL"), Format: os.Getenv("LOG_FORMAT")}
+}
+
+// Levels are the minimum levels of the components
+type Levels struct {
+	Default    slog.Level
+	Components map[string]slog.Level
+}
+
+// For returns the level of a component
+func (l Levels) For(component string) slog.Level {
+	if level, ok := l.Components[component]; ok {
+		return level
+	}
+	return l.Default
+}
+
+// lowest is the lowest level of any component, what the output must let through
+func (l Levels) lowest() slog.Level {
+	lowest := l.Default
+	for _, level := range l.Components {
+		lowest = min(lowest, level)
+	}
+	return lowest
+}
+
+// ParseLevels reads a level spec, see Options.Level. Empty selects info.
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo, Components: map[string]slog.Level{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, name, ok := strings.Cut(part, "=")
+		if !ok {
+			name = component
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return levels, fmt.Errorf("invalid log level %q, use debug, info, warn or error", name)
+		}
+		if !ok {
+			levels.Default = level
+			continue
+		}
+		component = strings.TrimSpace(component)
+		if !slices.Contains(Components, component) {
+			return levels, fmt.Errorf("unknown log component %q, use one of %s", component, strings.Join(Components, ", "))
+		}
+		levels.Components[component] = level
+	}
+	return levels, nil
+}
+
+// output is the process's stderr handler and component levels
+type output struct {
+	handler slog.Handler
+	levels  Levels
+}
+
+var (
+	mu      sync.Mutex
+	current = output{
+		handler: slog.NewTextHandler(os.Stderr, nil),
+		levels:  Levels{Default: slog.LevelInfo},
+	}
+)
+
+// Setup configures the output of loggers created afterwards and makes it the
+// slog and log default. Call it once at startup, before creating loggers.
+func Setup(opts Options) error {
+	levels, err := ParseLevels(opts.Level)
+	if err != nil {
+		return err
+	}
+	w := opts.Output
+	if w == nil {
+		w = os.Stderr
+	}
+	handlerOpts := &slog.HandlerOptions{Level: levels.lowest()}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("invalid log format %q, use text or json", opts.Format)
+	}
+
+	mu.Lock()
+	current = output{handler: handler, levels: levels}
+	mu.Unlock()
+	slog.SetDefault(slog.New(&levelHandler{level: levels.Default, next: handler}))
+	return nil
+}
+
+// New returns the logger of a component. Records go to stderr and, when
+// queries isn't nil, those of at least info also to scraper_logs.
+func New(component string, queries LoggerQueries) *slog.Logger {
+	mu.Lock()
+	out := current
+	mu.Unlock()
+
+	var next slog.Handler = out.handler
+	if queries != nil {
+		next = tee{out.handler, NewDBHandler(queries)}
+	}
+	handler := &levelHandler{level: out.levels.For(component), next: next}
+	return slog.New(handler).With(KeyComponent, component)
+}
+
+// Lazy returns the stderr logger of a component created on first use, for
+// package level loggers that must be created after Setup
+func Lazy(component string) func() *slog.Logger {
+	return sync.OnceValue(func() *slog.Logger { return New(component, nil) })
+}
+
+// levelHandler drops the records below a component's level
+type levelHandler struct {
+	level slog.Level
+	next  slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RunFrom(ctx); id != 0 {
+		r = r.Clone()
+		r.AddAttrs(Run(id))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+// tee sends records to every handler enabled for their level
+type tee []slog.Handler
+
+func (t tee) Enabled(ctx context.Context, level slog.Level) bool {
+	return slices.ContainsFunc(t, func(h slog.Handler) bool { return h.Enabled(ctx, level) })
+}
+
+func (t tee) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t tee) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(tee, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t tee) WithGroup(name string) slog.Handler {
+	out := make(tee, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
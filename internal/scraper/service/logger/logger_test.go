@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("warn, fetcher=debug,ui=ERROR")
+	if err != nil {
+		t.Fatalf("ParseLevels: %v", err)
+	}
+	for component, want := range map[string]slog.Level{
+		ComponentFetcher: slog.LevelDebug,
+		ComponentUI:      slog.LevelError,
+		ComponentSitemap: slog.LevelWarn,
+	} {
+		if got := levels.For(component); got != want {
+			t.Errorf("%s: got %v, want %v", component, got, want)
+		}
+	}
+
+	if levels, err := ParseLevels(""); err != nil || levels.Default != slog.LevelInfo {
+		t.Errorf("expected info by default, got %v (%v)", levels.Default, err)
+	}
+	for _, spec := range []string{"loud", "fetcher=loud", "crawler=debug"} {
+		if _, err := ParseLevels(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defer func(log *slog.Logger) { slog.SetDefault(log) }(slog.Default())
+	var out bytes.Buffer
+	if err := Setup(Options{Level: "warn,fetcher=debug", Format: "json", Output: &out}); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer func() { _ = Setup(Options{}) }()
+
+	queries := &MockQueries{}
+	fetcher := New(ComponentFetcher, queries)
+	fetcher.Debug("checking page", Target(1), URL("https://example.com/"))
+	fetcher.WarnContext(WithRun(context.Background(), 7), "page failed", Target(1), URL("https://example.com/"))
+	New(ComponentUI, nil).Info("dropped below the ui level")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the 2 fetcher records on the output, got:\n%s", out.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("expected JSON records: %v", err)
+	}
+	if record["level"] != "DEBUG" || record["component"] != "fetcher" || record["target_id"] != float64(1) {
+		t.Errorf("unexpected record %v", record)
+	}
+	// The table gets the same call, above its info floor
+	if queries.messages != 1 || queries.lastLogMessage.LogType != "warn" || queries.lastLogMessage.Message != "page failed" {
+		t.Errorf("expected the warning in scraper_logs, got %d messages, last %+v", queries.messages, queries.lastLogMessage)
+	}
+	// The run of the context is in both
+	if !strings.Contains(lines[1], `"run_id":7`) || queries.lastLogMessage.RunID.Int64 != 7 {
+		t.Errorf("expected the run ID of the context, got %s and %+v", lines[1], queries.lastLogMessage.RunID)
+	}
+
+	if err := Setup(Options{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
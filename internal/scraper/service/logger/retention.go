@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"app/internal/scraper/storage"
+)
+
+// Retention bounds the scraper_logs table, zero fields don't limit it
+type Retention struct {
+	// MaxAge prunes the rows older than it
+	MaxAge time.Duration
+	// MaxRows prunes all but the newest rows
+	MaxRows int64
+}
+
+// Enabled reports whether the retention prunes anything
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxRows > 0
+}
+
+// where selects the rows outside the retention
+func (r Retention) where(dialect storage.Dialect) *storage.Where {
+	w := storage.NewWhere(dialect)
+	switch {
+	case r.MaxAge > 0 && r.MaxRows > 0:
+		w.Add("(created_at < ? OR id <= (SELECT id FROM scraper_logs ORDER BY id DESC LIMIT 1 OFFSET ?))",
+			w.Time(time.Now().Add(-r.MaxAge)), r.MaxRows)
+	case r.MaxAge > 0:
+		w.Add("created_at < ?", w.Time(time.Now().Add(-r.MaxAge)))
+	case r.MaxRows > 0:
+		// The id of the first row past the newest MaxRows, none when there are fewer
+		w.Add("id <= (SELECT id FROM scraper_logs ORDER BY id DESC LIMIT 1 OFFSET ?)", r.MaxRows)
+	}
+	return w
+}
+
+// CountPrunable returns the number of log rows Prune would delete
+func CountPrunable(ctx context.Context, store storage.Store, r Retention) (int64, error) {
+	if !r.Enabled() {
+		return 0, nil
+	}
+	w := r.where(store.Dialect())
+	var n int64
+	err := store.DB().QueryRowContext(ctx, "SELECT COUNT(*) FROM scraper_logs"+w.String(), w.Args()...).Scan(&n)
+	return n, err
+}
+
+// Prune deletes the log rows outside the retention and returns how many
+func Prune(ctx context.Context, store storage.Store, r Retention) (int64, error) {
+	if !r.Enabled() {
+		return 0, nil
+	}
+	w := r.where(store.Dialect())
+	tx, err := store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if store.Dialect() == storage.DialectSQLite {
+		if err := pruneSearchIndex(ctx, tx, w); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM scraper_logs"+w.String(), w.Args()...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// searchIndex is the SQLite full text index of the log search, see package logsearch
+const searchIndex = "scraper_logs_fts"
+
+// pruneSearchIndex removes the rows about to be pruned from the log search
+// index. The index is contentless, so each row is deleted with the values it
+// was indexed with, and only up to the last row the search indexed.
+func pruneSearchIndex(ctx context.Context, tx *sql.Tx, w *storage.Where) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = ?)", searchIndex).Scan(&exists)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+searchIndex+` (`+searchIndex+`, rowid, message, details)
+SELECT 'delete', id, message, COALESCE(details, '') FROM scraper_logs`+w.String()+`
+AND id <= (SELECT COALESCE(MAX(rowid), 0) FROM `+searchIndex+`)`, w.Args()...)
+	// A binary built without FTS5 can't touch the index. Log ids aren't
+	// reused, so the rows left behind match nothing, they only take space.
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		retention Retention
+		want      []string
+	}{
+		"age":     {Retention{MaxAge: 30 * 24 * time.Hour}, []string{"week", "today", "now"}},
+		"rows":    {Retention{MaxRows: 2}, []string{"today", "now"}},
+		"both":    {Retention{MaxAge: 30 * 24 * time.Hour, MaxRows: 4}, []string{"week", "today", "now"}},
+		"neither": {Retention{}, []string{"year", "month", "week", "today", "now"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newTestStore(t)
+			for _, row := range []struct{ message, age string }{
+				{"year", "-400 days"}, {"month", "-40 days"}, {"week", "-7 days"}, {"today", "-1 hours"}, {"now", "0 days"},
+			} {
+				if _, err := store.DB().Exec("INSERT INTO scraper_logs (log_type, message, created_at) VALUES ('info', ?, datetime('now', ?))", row.message, row.age); err != nil {
+					t.Fatalf("insert: %v", err)
+				}
+			}
+
+			count, err := CountPrunable(ctx, store, tc.retention)
+			if err != nil {
+				t.Fatalf("CountPrunable: %v", err)
+			}
+			n, err := Prune(ctx, store, tc.retention)
+			if err != nil {
+				t.Fatalf("Prune: %v", err)
+			}
+			if want := int64(5 - len(tc.want)); n != want || count != want {
+				t.Errorf("expected %d rows counted and pruned, got %d and %d", want, count, n)
+			}
+
+			rows, err := store.DB().Query("SELECT message FROM scraper_logs ORDER BY id")
+			if err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			defer func() { _ = rows.Close() }()
+			var kept []string
+			for rows.Next() {
+				var message string
+				_ = rows.Scan(&message)
+				kept = append(kept, message)
+			}
+			if !slices.Equal(kept, tc.want) {
+				t.Errorf("expected %v kept, got %v", tc.want, kept)
+			}
+		})
+	}
+}
+
+// go test -tags sqlite_fts5 covers the log search index
+func TestPrune_SearchIndex(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	if _, err := store.DB().Exec("CREATE VIRTUAL TABLE " + searchIndex + " USING fts5(message, details, content='')"); err != nil {
+		t.Skipf("SQLite without FTS5: %v", err)
+	}
+	for _, row := range []struct{ message, age string }{
+		{"fetch failed old", "-40 days"}, {"fetch failed new", "0 days"}, {"fetch failed unindexed", "-40 days"},
+	} {
+		if _, err := store.DB().Exec("INSERT INTO scraper_logs (log_type, message, created_at) VALUES ('error', ?, datetime('now', ?))", row.message, row.age); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	// The search indexed the first two logs only
+	if _, err := store.DB().Exec("INSERT INTO " + searchIndex + " (rowid, message, details) SELECT id, message, COALESCE(details, '') FROM scraper_logs WHERE id <= 2"); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	n, err := Prune(ctx, store, Retention{MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 rows pruned, got %d", n)
+	}
+	var indexed []int64
+	rows, err := store.DB().Query("SELECT rowid FROM " + searchIndex + " WHERE " + searchIndex + " MATCH 'fetch' ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id int64
+		_ = rows.Scan(&id)
+		indexed = append(indexed, id)
+	}
+	if !slices.Equal(indexed, []int64{2}) {
+		t.Errorf("expected only the kept log indexed, got %v", indexed)
+	}
+}
@@ -0,0 +1,210 @@
+// Package logsearch filters and searches scraper_logs for the log browser.
+//
+// On SQLite the message and details are indexed in the scraper_logs_fts FTS5
+// table. The index is contentless and filled here: each search first indexes
+// the logs added since the last one, so loggers stay plain inserts and
+// binaries built without FTS5 can keep writing to a database that has the
+// index. The log retention deletes pruned logs from it. go-sqlite3 compiles FTS5 in with the sqlite_fts5 build
+// tag; without it searches fall back to substring matching. On PostgreSQL
+// the 010_log_search migration adds a full text index instead.
+package logsearch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/service/logger"
+	"app/internal/scraper/storage"
+)
+
+// MaxLimit caps the logs returned by one search
+const MaxLimit = 500
+
+const ftsTable = "scraper_logs_fts"
+
+var searchLog = logger.Lazy(logger.ComponentSearch)
+
+// Filter selects logs, zero fields don't filter. Logs come newest first:
+// Before pages back from the oldest id shown, After fetches the logs newer
+// than the newest one for live tailing.
+type Filter struct {
+	Level    string
+	TargetID int64
+	RunID    int64
+	// URL matches logs whose URL contains it
+	URL string
+	// Query matches words of the message or details
+	Query  string
+	Since  time.Time
+	Until  time.Time
+	Before int64
+	After  int64
+	Limit  int
+}
+
+// Result is a page of logs, Next is the Before of the following page, 0 on the last one
+type Result struct {
+	Logs []db.ScraperLog
+	Next int64
+}
+
+// Searcher runs log searches against a store
+type Searcher struct {
+	db      *sql.DB
+	dialect storage.Dialect
+
+	// mu serializes setting up and catching up the index, so concurrent
+	// searches don't index a row twice
+	mu       sync.Mutex
+	prepared bool
+	fts      bool
+}
+
+// New returns a searcher for the store's logs, the SQLite full text index is
+// set up by the first search
+func New(store storage.Store) *Searcher {
+	return &Searcher{db: store.DB(), dialect: store.Dialect()}
+}
+
+// FullText reports whether searches use a full text index, known on SQLite
+// once a search ran
+func (s *Searcher) FullText() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fts || s.dialect == storage.DialectPostgres
+}
+
+// prepareIndex creates the FTS5 table if FTS5 is available and indexes the
+// logs written since the last search. Ids only grow, so the index holds every
+// log up to its highest rowid.
+func (s *Searcher) prepareIndex(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.prepared {
+		_, err := s.db.ExecContext(ctx, "CREATE VIRTUAL TABLE IF NOT EXISTS "+ftsTable+" USING fts5(message, details, content='')")
+		if err == nil {
+			// The table may exist already, created by a binary with FTS5
+			_, err = s.db.ExecContext(ctx, "SELECT rowid FROM "+ftsTable+" LIMIT 0")
+		}
+		switch {
+		case err == nil:
+			s.fts = true
+		case strings.Contains(err.Error(), "no such module: fts5"):
+			searchLog().WarnContext(ctx, "SQLite was built without FTS5, log search matches substrings instead")
+		default:
+			return fmt.Errorf("failed to create log search index: %w", err)
+		}
+		s.prepared = true
+	}
+	if !s.fts {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO `+ftsTable+` (rowid, message, details)
+SELECT id, message, COALESCE(details, '') FROM scraper_logs
+WHERE id > (SELECT COALESCE(MAX(rowid), 0) FROM `+ftsTable+`)
+ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to update log search index: %w", err)
+	}
+	return nil
+}
+
+// Search returns the logs matching the filter
+func (s *Searcher) Search(ctx context.Context, f Filter) (Result, error) {
+	if f.Limit <= 0 || f.Limit > MaxLimit {
+		f.Limit = MaxLimit
+	}
+	query := strings.TrimSpace(f.Query)
+	fts := false
+	if query != "" && s.dialect == storage.DialectSQLite {
+		if err := s.prepareIndex(ctx); err != nil {
+			return Result{}, err
+		}
+		fts = s.FullText()
+	}
+
+	sqlText, args := s.searchQuery(f, query, fts)
+	rows, err := s.db.QueryContext(ctx, sqlText, args...)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to search logs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result Result
+	for rows.Next() {
+		var l db.ScraperLog
+		if err := rows.Scan(&l.ID, &l.LogType, &l.TargetID, &l.Url, &l.Message, &l.Details, &l.CreatedAt, &l.RunID); err != nil {
+			return Result{}, fmt.Errorf("failed to read logs: %w", err)
+		}
+		result.Logs = append(result.Logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return Result{}, fmt.Errorf("failed to read logs: %w", err)
+	}
+	if len(result.Logs) > f.Limit {
+		result.Logs = result.Logs[:f.Limit]
+		result.Next = result.Logs[f.Limit-1].ID
+	}
+	return result, nil
+}
+
+// searchQuery builds the statement selecting the logs matching the filter,
+// one more than the limit to tell whether there's a next page
+func (s *Searcher) searchQuery(f Filter, query string, fts bool) (string, []any) {
+	w := storage.NewWhere(s.dialect)
+	if f.Before > 0 {
+		w.Add("id < ?", f.Before)
+	}
+	if f.After > 0 {
+		w.Add("id > ?", f.After)
+	}
+	if f.Level != "" {
+		w.Add("log_type = ?", f.Level)
+	}
+	if f.TargetID != 0 {
+		w.Add("target_id = ?", f.TargetID)
+	}
+	if f.RunID != 0 {
+		w.Add("run_id = ?", f.RunID)
+	}
+	if f.URL != "" {
+		w.Contains("url", f.URL)
+	}
+	if !f.Since.IsZero() {
+		w.Add("created_at >= ?", w.Time(f.Since))
+	}
+	if !f.Until.IsZero() {
+		w.Add("created_at < ?", w.Time(f.Until))
+	}
+	if query != "" {
+		switch {
+		case w.Postgres():
+			w.Add("to_tsvector('simple', message || ' ' || COALESCE(details, '')) @@ plainto_tsquery('simple', ?)", query)
+		case fts:
+			w.Add("id IN (SELECT rowid FROM "+ftsTable+" WHERE "+ftsTable+" MATCH ?)", matchExpr(query))
+		default:
+			for _, term := range strings.Fields(query) {
+				w.Add("(instr(lower(message), lower(?)) > 0 OR instr(lower(COALESCE(details, '')), lower(?)) > 0)", term, term)
+			}
+		}
+	}
+
+	return "SELECT id, log_type, target_id, url, message, details, created_at, run_id FROM scraper_logs" +
+		w.String() + " ORDER BY id DESC LIMIT " + strconv.Itoa(f.Limit+1), w.Args()
+}
+
+// matchExpr turns free text into an FTS5 query matching all its words, each
+// as a prefix, with FTS5 operators taken literally
+func matchExpr(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,169 @@
+package logsearch
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The same cases run with and without FTS5: go test -tags sqlite_fts5 covers the index
+func newTestSearcher(t *testing.T) (*Searcher, *db.Queries) {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	store := storage.NewSQLite(dbConn)
+	if err := migrate.EnsureSchema(context.Background(), store, true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(store), db.New(dbConn)
+}
+
+func ids(r Result) []int64 {
+	out := make([]int64, len(r.Logs))
+	for i, l := range r.Logs {
+		out[i] = l.ID
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	s, queries := newTestSearcher(t)
+	ctx := context.Background()
+	target, err := queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://quotes.example"})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	run, err := queries.CreateRun(ctx, db.CreateRunParams{TriggerSource: "cli", TargetIds: "[1]"})
+	if err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	logs := []db.LogMessageParams{
+		{LogType: "info", Message: "Crawl started"},
+		{LogType: "error", TargetID: sql.NullInt64{Int64: target.ID, Valid: true}, Url: sql.NullString{String: "https://quotes.example/page/2", Valid: true},
+			Message: "Fetch failed", Details: sql.NullString{String: `{"error":"connection timeout"}`, Valid: true}},
+		{LogType: "warn", TargetID: sql.NullInt64{Int64: target.ID, Valid: true}, Url: sql.NullString{String: "https://quotes.example/login", Valid: true},
+			Message: "Robots.txt disallows the page", RunID: sql.NullInt64{Int64: run.ID, Valid: true}},
+		{LogType: "info", TargetID: sql.NullInt64{Int64: target.ID, Valid: true}, Message: "Sitemap parsing completed"},
+	}
+	for _, l := range logs {
+		if err := queries.LogMessage(ctx, l); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int64
+	}{
+		{"everything newest first", Filter{}, []int64{4, 3, 2, 1}},
+		{"level", Filter{Level: "info"}, []int64{4, 1}},
+		{"target", Filter{TargetID: target.ID}, []int64{4, 3, 2}},
+		{"run", Filter{RunID: run.ID}, []int64{3}},
+		{"url substring", Filter{URL: "/page/"}, []int64{2}},
+		{"message word", Filter{Query: "robots"}, []int64{3}},
+		{"details word", Filter{Query: "timeout"}, []int64{2}},
+		{"word prefix", Filter{Query: "Sitem"}, []int64{4}},
+		{"all words", Filter{Query: "crawl started"}, []int64{1}},
+		{"words in any order", Filter{Query: "completed parsing"}, []int64{4}},
+		{"no match", Filter{Query: "crawl completed"}, nil},
+		{"operators are literal", Filter{Query: `"fetch OR NEAR(`}, nil},
+		{"combined", Filter{Level: "error", TargetID: target.ID, Query: "fetch"}, []int64{2}},
+		{"before", Filter{Before: 3}, []int64{2, 1}},
+		{"after", Filter{After: 2}, []int64{4, 3}},
+		{"since", Filter{Since: time.Now().Add(-time.Hour)}, []int64{4, 3, 2, 1}},
+		{"until", Filter{Until: time.Now().Add(-time.Hour)}, nil},
+	}
+	t.Cleanup(func() { t.Logf("full text index: %t", s.FullText()) })
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.Search(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(got.Logs) != len(tc.want) {
+				t.Fatalf("got %v, want %v", ids(got), tc.want)
+			}
+			for i, id := range tc.want {
+				if got.Logs[i].ID != id {
+					t.Fatalf("got %v, want %v", ids(got), tc.want)
+				}
+			}
+		})
+	}
+
+	// Logs written after the first search are found too
+	if err := queries.LogMessage(ctx, db.LogMessageParams{LogType: "info", Message: "Crawl stopped"}); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if got, err := s.Search(ctx, Filter{Query: "stopped"}); err != nil || len(got.Logs) != 1 || got.Logs[0].ID != 5 {
+		t.Errorf("expected the new log, got %v (%v)", ids(got), err)
+	}
+}
+
+func TestSearch_Pages(t *testing.T) {
+	s, queries := newTestSearcher(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := queries.LogMessage(ctx, db.LogMessageParams{LogType: "info", Message: "Page saved"}); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+
+	var seen []int64
+	filter := Filter{Query: "saved", Limit: 2}
+	for {
+		page, err := s.Search(ctx, filter)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		seen = append(seen, ids(page)...)
+		if page.Next == 0 {
+			break
+		}
+		filter.Before = page.Next
+	}
+	if len(seen) != 5 || seen[0] != 5 || seen[4] != 1 {
+		t.Errorf("expected every log once newest first, got %v", seen)
+	}
+}
+
+func TestMatchExpr(t *testing.T) {
+	if got := matchExpr(`fetch "quoted`); got != `"fetch"* """quoted"*` {
+		t.Errorf("unexpected match expression %s", got)
+	}
+}
+
+func TestSearchQuery_PostgresPlaceholders(t *testing.T) {
+	s := &Searcher{dialect: storage.DialectPostgres}
+	got, args := s.searchQuery(Filter{Before: 9, Level: "error", URL: "/page", Limit: 50}, "timeout", false)
+	want := "SELECT id, log_type, target_id, url, message, details, created_at, run_id FROM scraper_logs" +
+		" WHERE id < $1 AND log_type = $2 AND strpos(url, $3) > 0" +
+		" AND to_tsvector('simple', message || ' ' || COALESCE(details, '')) @@ plainto_tsquery('simple', $4)" +
+		" ORDER BY id DESC LIMIT 51"
+	if got != want || len(args) != 4 {
+		t.Errorf("unexpected query %q with %v", got, args)
+	}
+}
+
+func TestSearchQuery_SQLiteTerms(t *testing.T) {
+	s := &Searcher{dialect: storage.DialectSQLite}
+	got, args := s.searchQuery(Filter{Before: 9, Limit: 50}, "fetch failed", false)
+	want := " WHERE id < ? AND (instr(lower(message), lower(?)) > 0 OR instr(lower(COALESCE(details, '')), lower(?)) > 0)" +
+		" AND (instr(lower(message), lower(?)) > 0 OR instr(lower(COALESCE(details, '')), lower(?)) > 0)"
+	if !strings.Contains(got, want) || len(args) != 5 {
+		t.Errorf("unexpected query %q with %v", got, args)
+	}
+}
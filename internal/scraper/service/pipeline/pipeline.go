@@ -0,0 +1,227 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"app/internal/scraper/service/classifier"
+
+	"golang.org/x/net/html"
+)
+
+// Stage names, in pipeline order
+const (
+	StageContentExtraction = "content_extraction"
+	StageClassification    = "classification"
+	StageQuoteExtraction   = "quote_extraction"
+	StageLinkDiscovery     = "link_discovery"
+)
+
+// Stage statuses recorded on the page
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// ErrSkipped is returned (wrapped) by stages that have nothing to do for a page,
+// e.g. quote extraction on a page classified as unprocessable
+var ErrSkipped = errors.New("skipped")
+
+// PageProcessor is one stage of the post-processing pipeline
+type PageProcessor interface {
+	Name() string
+	Process(ctx context.Context, page *Page) error
+}
+
+// Store persists stage results and provides the per-target state stages depend on
+type Store interface {
+	TemplateLearner(ctx context.Context, targetID int64) *classifier.TemplateLearner
+	SaveClassification(ctx context.Context, page *Page, learner *classifier.TemplateLearner) error
+	SaveQuotes(ctx context.Context, page *Page) error
+	URLPatterns(ctx context.Context, targetID int64) ([]*regexp.Regexp, error)
+	EnqueueLinks(ctx context.Context, targetID int64, urls []string) (int, error)
+	SaveStatus(ctx context.Context, pageID int64, status Status) error
+}
+
+// Quote is a quote extracted from a processable page
+type Quote struct {
+	Text   string `json:"text"`
+	Author string `json:"author,omitempty"`
+}
+
+// Page is a saved page flowing through the pipeline, stages fill in their results
+type Page struct {
+	ID       int64
+	TargetID int64
+	URLPath  string
+	URL      string
+	HTML     string
+
+	// Results of earlier stages, loaded from storage when a stage is re-run on its own
+	Classification *classifier.QuoteClassifierDecision
+	Quotes         []Quote
+	Links          []string
+	Status         Status
+
+	doc         *html.Node
+	mainContent *html.Node
+}
+
+// Document returns the parsed page, parsing it on first use
+func (p *Page) Document() (*html.Node, error) {
+	if p.doc == nil {
+		doc, err := html.Parse(strings.NewReader(p.HTML))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		p.doc = doc
+	}
+	return p.doc, nil
+}
+
+// MainContent returns the main content tree, extracting it when the content stage did not run
+func (p *Page) MainContent() (*html.Node, error) {
+	if p.mainContent == nil {
+		doc, err := p.Document()
+		if err != nil {
+			return nil, err
+		}
+		p.mainContent = classifier.NewContentExtractor().MainContent(doc)
+	}
+	return p.mainContent, nil
+}
+
+// StageResult is the outcome of a stage for one page
+type StageResult struct {
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// Status maps stage names to their last result, stored as scraper_pages.pipeline_status_json
+type Status map[string]StageResult
+
+// ParseStatus parses stored pipeline status JSON, empty input yields an empty status
+func ParseStatus(jsonStr string) (Status, error) {
+	status := Status{}
+	if strings.TrimSpace(jsonStr) == "" {
+		return status, nil
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &status); err != nil {
+		return nil, fmt.Errorf("invalid pipeline status: %w", err)
+	}
+	return status, nil
+}
+
+// MarshalStatus serializes the status to JSON
+func (s Status) MarshalStatus() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Failed returns the names of the failed stages, sorted
+func (s Status) Failed() []string {
+	var failed []string
+	for name, result := range s {
+		if result.Status == StatusFailed {
+			failed = append(failed, name)
+		}
+	}
+	slices.Sort(failed)
+	return failed
+}
+
+// Pipeline runs the page processors in order and records their status on the page
+type Pipeline struct {
+	stages []PageProcessor
+	store  Store
+}
+
+func New(store Store, stages ...PageProcessor) *Pipeline {
+	return &Pipeline{stages: stages, store: store}
+}
+
+// NewDefault creates the standard pipeline: content extraction, classification,
+// quote extraction and link discovery
+func NewDefault(store Store) *Pipeline {
+	return New(store,
+		NewContentStage(),
+		NewClassificationStage(store),
+		NewQuoteStage(store),
+		NewLinkStage(store),
+	)
+}
+
+// DefaultStages returns the stage names of the NewDefault pipeline, in order
+func DefaultStages() []string {
+	return []string{StageContentExtraction, StageClassification, StageQuoteExtraction, StageLinkDiscovery}
+}
+
+// Stages returns the stage names in pipeline order
+func (p *Pipeline) Stages() []string {
+	names := make([]string, len(p.stages))
+	for i, stage := range p.stages {
+		names[i] = stage.Name()
+	}
+	return names
+}
+
+// CheckStages validates stage names given for a partial run
+func (p *Pipeline) CheckStages(names []string) error {
+	known := p.Stages()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("unknown pipeline stage %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// Run processes the page through all stages, or only the named ones, in pipeline order.
+// A failing stage does not stop the pipeline, later stages check their own inputs.
+// Results are merged into page.Status and saved; the returned error is only set
+// when the status could not be saved.
+func (p *Pipeline) Run(ctx context.Context, page *Page, only ...string) (Status, error) {
+	if page.Status == nil {
+		page.Status = Status{}
+	}
+	for _, stage := range p.stages {
+		if len(only) > 0 && !slices.Contains(only, stage.Name()) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		err := stage.Process(ctx, page)
+		result := StageResult{
+			Status:      StatusOK,
+			DurationMs:  time.Since(start).Milliseconds(),
+			ProcessedAt: start.UTC(),
+		}
+		switch {
+		case errors.Is(err, ErrSkipped):
+			result.Status = StatusSkipped
+			result.Error = err.Error()
+		case err != nil:
+			result.Status = StatusFailed
+			result.Error = err.Error()
+		}
+		page.Status[stage.Name()] = result
+	}
+	if err := p.store.SaveStatus(ctx, page.ID, page.Status); err != nil {
+		return page.Status, fmt.Errorf("failed to save pipeline status: %w", err)
+	}
+	return page.Status, nil
+}
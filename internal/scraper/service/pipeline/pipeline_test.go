@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"app/internal/scraper/service/classifier"
+)
+
+// fakeStore records what the stages persist
+type fakeStore struct {
+	mu            sync.Mutex
+	learner       *classifier.TemplateLearner
+	classified    []string
+	quotes        map[int64][]Quote
+	queued        []string
+	statuses      map[int64]Status
+	saveQuotesErr error
+	saveStatusErr error
+	patterns      []*regexp.Regexp
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		learner:  classifier.NewTemplateLearner(nil, nil),
+		quotes:   map[int64][]Quote{},
+		statuses: map[int64]Status{},
+		patterns: []*regexp.Regexp{regexp.MustCompile(`/quotes/[^/]+/$`)},
+	}
+}
+
+func (s *fakeStore) TemplateLearner(ctx context.Context, targetID int64) *classifier.TemplateLearner {
+	return s.learner
+}
+func (s *fakeStore) SaveClassification(ctx context.Context, page *Page, learner *classifier.TemplateLearner) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.classified = append(s.classified, page.URLPath)
+	return nil
+}
+func (s *fakeStore) SaveQuotes(ctx context.Context, page *Page) error {
+	if s.saveQuotesErr != nil {
+		return s.saveQuotesErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[page.ID] = page.Quotes
+	return nil
+}
+func (s *fakeStore) URLPatterns(ctx context.Context, targetID int64) ([]*regexp.Regexp, error) {
+	return s.patterns, nil
+}
+func (s *fakeStore) EnqueueLinks(ctx context.Context, targetID int64, urls []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued = append(s.queued, urls...)
+	return len(urls), nil
+}
+func (s *fakeStore) SaveStatus(ctx context.Context, pageID int64, status Status) error {
+	if s.saveStatusErr != nil {
+		return s.saveStatusErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[pageID] = status
+	return nil
+}
+
+func quotesPage() string {
+	var sb strings.Builder
+	sb.WriteString(`<html><body><nav><a href="/">Home</a> <a href="/quotes/love/">Love</a></nav><div class="quotes">`)
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&sb, `<div class="quote">Quote number %d is a short and wise thought about life, patience and time. — Author %c</div>`, i, 'A'+i)
+	}
+	sb.WriteString(`</div><p><a href="/quotes/hope/#top">Hope</a> <a href="https://other.example.com/quotes/x/">Elsewhere</a> <a href="/about">About</a></p></body></html>`)
+	return sb.String()
+}
+
+func TestPipeline_RunAllStages(t *testing.T) {
+	store := newFakeStore()
+	page := &Page{ID: 1, TargetID: 1, URLPath: "/quotes/life/", URL: "https://example.com/quotes/life/", HTML: quotesPage()}
+
+	status, err := NewDefault(store).Run(context.Background(), page)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, stage := range DefaultStages() {
+		if status[stage].Status != StatusOK {
+			t.Errorf("stage %s: expected ok, got %+v", stage, status[stage])
+		}
+	}
+	if page.Classification == nil || !page.Classification.Decision.Processable {
+		t.Fatalf("expected processable classification, got %+v", page.Classification)
+	}
+	quotes := store.quotes[1]
+	if len(quotes) != 6 || quotes[0].Author != "Author A" || strings.Contains(quotes[0].Text, "Author A") {
+		t.Errorf("expected 6 quotes split from their authors, got %+v", quotes)
+	}
+	want := []string{"https://example.com/quotes/love/", "https://example.com/quotes/hope/"}
+	if !slices.Equal(store.queued, want) {
+		t.Errorf("expected same-site quote links %v, got %v", want, store.queued)
+	}
+	if _, ok := store.statuses[1]; !ok {
+		t.Error("expected status saved")
+	}
+}
+
+func TestPipeline_UnprocessablePageSkipsQuotes(t *testing.T) {
+	store := newFakeStore()
+	store.quotes[2] = []Quote{{Text: "stale"}}
+	page := &Page{ID: 2, TargetID: 1, URLPath: "/about", URL: "https://example.com/about", HTML: "<html><body><p>About us.</p></body></html>"}
+
+	status, _ := NewDefault(store).Run(context.Background(), page)
+	if status[StageQuoteExtraction].Status != StatusSkipped || !strings.Contains(status[StageQuoteExtraction].Error, "not processable") {
+		t.Errorf("expected quote extraction skipped, got %+v", status[StageQuoteExtraction])
+	}
+	if len(store.quotes[2]) != 0 {
+		t.Errorf("expected stale quotes cleared, got %+v", store.quotes[2])
+	}
+}
+
+func TestPipeline_RerunSingleStageKeepsOtherStatus(t *testing.T) {
+	store := newFakeStore()
+	store.saveQuotesErr = errors.New("disk full")
+	p := NewDefault(store)
+	page := &Page{ID: 3, TargetID: 1, URLPath: "/quotes/life/", URL: "https://example.com/quotes/life/", HTML: quotesPage()}
+
+	status, _ := p.Run(context.Background(), page)
+	if got := status.Failed(); !slices.Equal(got, []string{StageQuoteExtraction}) {
+		t.Fatalf("expected only quote extraction failed, got %v (%+v)", got, status)
+	}
+	if status[StageLinkDiscovery].Status != StatusOK {
+		t.Errorf("a failed stage must not stop later stages, got %+v", status[StageLinkDiscovery])
+	}
+
+	// Re-run only the failed stage on a page restored from storage
+	store.saveQuotesErr = nil
+	classifiedBefore := len(store.classified)
+	restored := &Page{ID: 3, TargetID: 1, URLPath: page.URLPath, URL: page.URL, HTML: page.HTML,
+		Classification: page.Classification, Status: status}
+	status, err := p.Run(context.Background(), restored, StageQuoteExtraction)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(status.Failed()) != 0 || len(store.quotes[3]) != 6 {
+		t.Errorf("expected quotes extracted on re-run, got status %+v quotes %d", status, len(store.quotes[3]))
+	}
+	if len(store.classified) != classifiedBefore {
+		t.Error("re-running quote extraction must not re-classify")
+	}
+	if _, ok := status[StageClassification]; !ok {
+		t.Error("expected the earlier classification status kept")
+	}
+}
+
+func TestPipeline_CheckStages(t *testing.T) {
+	p := NewDefault(newFakeStore())
+	if err := p.CheckStages([]string{StageClassification, StageLinkDiscovery}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := p.CheckStages([]string{"quotes"}); err == nil {
+		t.Error("expected error for unknown stage")
+	}
+}
+
+func TestPipeline_StatusSaveError(t *testing.T) {
+	store := newFakeStore()
+	store.saveStatusErr = errors.New("locked")
+	_, err := NewDefault(store).Run(context.Background(), &Page{ID: 4, URL: "https://example.com/x", HTML: "<p>x</p>"})
+	if err == nil {
+		t.Error("expected status save error")
+	}
+}
+
+func TestStatus_RoundTrip(t *testing.T) {
+	status := Status{StageClassification: {Status: StatusFailed, Error: "boom"}}
+	jsonStr, err := status.MarshalStatus()
+	if err != nil {
+		t.Fatalf("MarshalStatus failed: %v", err)
+	}
+	parsed, err := ParseStatus(jsonStr)
+	if err != nil || parsed[StageClassification].Error != "boom" {
+		t.Errorf("round trip failed: %+v %v", parsed, err)
+	}
+	if empty, err := ParseStatus(""); err != nil || len(empty) != 0 {
+		t.Errorf("expected empty status, got %+v %v", empty, err)
+	}
+}
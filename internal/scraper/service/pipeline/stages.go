@@ -0,0 +1,192 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/metrics"
+	"app/internal/scraper/service/classifier"
+
+	"golang.org/x/net/html"
+)
+
+// ContentStage extracts the main content of the page for the later stages
+type ContentStage struct {
+	extractor *classifier.ContentExtractor
+}
+
+func NewContentStage() *ContentStage {
+	return &ContentStage{extractor: classifier.NewContentExtractor()}
+}
+
+func (s *ContentStage) Name() string { return StageContentExtraction }
+
+func (s *ContentStage) Process(ctx context.Context, page *Page) error {
+	doc, err := page.Document()
+	if err != nil {
+		return err
+	}
+	page.mainContent = s.extractor.MainContent(doc)
+	if strings.TrimSpace(nodeText(page.mainContent)) == "" {
+		return fmt.Errorf("%w: page has no text content", ErrSkipped)
+	}
+	return nil
+}
+
+// ClassificationStage classifies the main content with the target's template learner
+type ClassificationStage struct {
+	store Store
+}
+
+func NewClassificationStage(store Store) *ClassificationStage {
+	return &ClassificationStage{store: store}
+}
+
+func (s *ClassificationStage) Name() string { return StageClassification }
+
+func (s *ClassificationStage) Process(ctx context.Context, page *Page) error {
+	root, err := page.MainContent()
+	if err != nil {
+		return err
+	}
+	learner := s.store.TemplateLearner(ctx, page.TargetID)
+	start := time.Now()
+	page.Classification = learner.ClassifyContent(page.URL, root)
+	metrics.ClassifierDuration.Observe(metrics.Since(start))
+	decision := page.Classification.Decision
+	metrics.ClassifierDecisions.Inc(decision.DecisionReason, strconv.FormatBool(decision.Processable))
+	if err := s.store.SaveClassification(ctx, page, learner); err != nil {
+		return fmt.Errorf("failed to save classifier result: %w", err)
+	}
+	return nil
+}
+
+// QuoteStage extracts the quotes of processable pages with the classifier's selectors
+type QuoteStage struct {
+	store Store
+}
+
+func NewQuoteStage(store Store) *QuoteStage {
+	return &QuoteStage{store: store}
+}
+
+func (s *QuoteStage) Name() string { return StageQuoteExtraction }
+
+func (s *QuoteStage) Process(ctx context.Context, page *Page) error {
+	if page.Classification == nil {
+		return fmt.Errorf("%w: page is not classified", ErrSkipped)
+	}
+	page.Quotes = nil
+	if page.Classification.Decision.Processable {
+		root, err := page.MainContent()
+		if err != nil {
+			return err
+		}
+		for _, text := range classifier.ExtractContentWithSelectors(root, page.Classification.Decision.Selectors) {
+			author, body := classifier.SplitQuote(text)
+			page.Quotes = append(page.Quotes, Quote{Text: body, Author: author})
+		}
+	}
+	// Saved even when empty so quotes of a page that is no longer processable are cleared
+	if err := s.store.SaveQuotes(ctx, page); err != nil {
+		return fmt.Errorf("failed to save quotes: %w", err)
+	}
+	if !page.Classification.Decision.Processable {
+		return fmt.Errorf("%w: page is not processable (%s)", ErrSkipped, page.Classification.Decision.DecisionReason)
+	}
+	return nil
+}
+
+// LinkStage queues same-site links matching the target's URL patterns
+type LinkStage struct {
+	store Store
+}
+
+func NewLinkStage(store Store) *LinkStage {
+	return &LinkStage{store: store}
+}
+
+func (s *LinkStage) Name() string { return StageLinkDiscovery }
+
+func (s *LinkStage) Process(ctx context.Context, page *Page) error {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return fmt.Errorf("invalid page URL: %w", err)
+	}
+	// Links are taken from the whole document, navigation and pagination lead to more quote pages
+	doc, err := page.Document()
+	if err != nil {
+		return err
+	}
+	patterns, err := s.store.URLPatterns(ctx, page.TargetID)
+	if err != nil {
+		return err
+	}
+
+	page.Links = nil
+	for _, link := range discoverLinks(doc, base) {
+		for _, pattern := range patterns {
+			if pattern.MatchString(link) {
+				page.Links = append(page.Links, link)
+				break
+			}
+		}
+	}
+	if len(page.Links) == 0 {
+		return nil
+	}
+	if _, err := s.store.EnqueueLinks(ctx, page.TargetID, page.Links); err != nil {
+		return fmt.Errorf("failed to queue links: %w", err)
+	}
+	return nil
+}
+
+// discoverLinks returns the distinct http(s) links of the document on the page's host, without fragments
+func discoverLinks(doc *html.Node, base *url.URL) []string {
+	seen := map[string]bool{base.String(): true}
+	var links []string
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && strings.EqualFold(n.Data, "a") {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				ref, err := url.Parse(strings.TrimSpace(attr.Val))
+				if err != nil {
+					break
+				}
+				link := base.ResolveReference(ref)
+				link.Fragment = ""
+				if (link.Scheme != "http" && link.Scheme != "https") || !strings.EqualFold(link.Host, base.Host) {
+					break
+				}
+				if s := link.String(); !seen[s] {
+					seen[s] = true
+					links = append(links, s)
+				}
+				break
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return links
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,281 @@
+// Package queue inspects and manages crawl queue items in bulk for
+// `scraper-cli queue` and the admin UI's queue page.
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/storage"
+)
+
+// Statuses are the states of a queue item, skipped ones were left alone
+// because the page hadn't changed since the last crawl
+var Statuses = []string{"pending", "processing", "completed", "skipped", "failed"}
+
+// MaxLimit caps the items returned by one List
+const MaxLimit = 500
+
+const columns = "id, target_id, url, priority, attempts, max_attempts, status, created_at, processed_at, error_message"
+
+// Filter selects queue items, zero fields don't filter
+type Filter struct {
+	TargetID int64
+	Statuses []string
+	// ErrorContains matches items whose error message contains it
+	ErrorContains string
+	// URLContains matches items whose URL contains it
+	URLContains string
+	// OlderThan matches items queued before it
+	OlderThan time.Time
+}
+
+// ParseStatuses reads a comma separated status list, "all" selects every status
+func ParseStatuses(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "all" {
+		return Statuses, nil
+	}
+	var statuses []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !slices.Contains(Statuses, s) {
+			return nil, fmt.Errorf("invalid queue status %q, use one of %s or all", s, strings.Join(Statuses, ", "))
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// ParseAge reads an item age for OlderThan, a duration with d for days on
+// top of time.ParseDuration's units
+func ParseAge(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(raw)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, use a duration like 36h or 7d", raw)
+	}
+	return age, nil
+}
+
+// Service runs queue operations against a store
+type Service struct {
+	db      *sql.DB
+	queries db.Querier
+	dialect storage.Dialect
+}
+
+func NewService(store storage.Store) *Service {
+	return &Service{db: store.DB(), queries: store.Queries(), dialect: store.Dialect()}
+}
+
+func (s *Service) where(f Filter) (*storage.Where, error) {
+	w := storage.NewWhere(s.dialect)
+	if f.TargetID != 0 {
+		w.Add("target_id = ?", f.TargetID)
+	}
+	if len(f.Statuses) > 0 {
+		for _, status := range f.Statuses {
+			if !slices.Contains(Statuses, status) {
+				return nil, fmt.Errorf("invalid queue status %q", status)
+			}
+		}
+		w.In("status", f.Statuses)
+	}
+	if f.ErrorContains != "" {
+		w.Contains("COALESCE(error_message, '')", f.ErrorContains)
+	}
+	if f.URLContains != "" {
+		w.Contains("url", f.URLContains)
+	}
+	if !f.OlderThan.IsZero() {
+		w.Add("created_at < ?", w.Time(f.OlderThan))
+	}
+	return w, nil
+}
+
+// only narrows the filter to one status, refusing filters asking for others
+func only(f Filter, status, operation string) (Filter, error) {
+	for _, s := range f.Statuses {
+		if s != status {
+			return f, fmt.Errorf("%s applies to %s items only", operation, status)
+		}
+	}
+	f.Statuses = []string{status}
+	return f, nil
+}
+
+// List returns the items matching the filter with ids after the cursor, in
+// queue order of ids, and the cursor of the following ones, 0 on the last page
+func (s *Service) List(ctx context.Context, f Filter, after int64, limit int) ([]db.ScraperQueue, int64, error) {
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	w, err := s.where(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	if after > 0 {
+		w.Add("id > ?", after)
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+columns+" FROM scraper_queue"+w.String()+
+		" ORDER BY id LIMIT "+strconv.Itoa(limit+1), w.Args()...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list queue items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []db.ScraperQueue
+	for rows.Next() {
+		var i db.ScraperQueue
+		if err := rows.Scan(&i.ID, &i.TargetID, &i.Url, &i.Priority, &i.Attempts, &i.MaxAttempts,
+			&i.Status, &i.CreatedAt, &i.ProcessedAt, &i.ErrorMessage); err != nil {
+			return nil, 0, fmt.Errorf("failed to read queue items: %w", err)
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read queue items: %w", err)
+	}
+	var next int64
+	if len(items) > limit {
+		items = items[:limit]
+		next = items[limit-1].ID
+	}
+	return items, next, nil
+}
+
+// Count returns how many items match the filter
+func (s *Service) Count(ctx context.Context, f Filter) (int64, error) {
+	w, err := s.where(f)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM scraper_queue"+w.String(), w.Args()...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count queue items: %w", err)
+	}
+	return n, nil
+}
+
+// Retry puts the failed items matching the filter back to pending. Items that
+// used all their attempts stay failed unless resetAttempts, which gives every
+// retried item its attempts back.
+func (s *Service) Retry(ctx context.Context, f Filter, resetAttempts bool) (int64, error) {
+	f, err := only(f, "failed", "retry")
+	if err != nil {
+		return 0, err
+	}
+	w, err := s.where(f)
+	if err != nil {
+		return 0, err
+	}
+	set := "status = 'pending', processed_at = NULL, error_message = NULL"
+	if resetAttempts {
+		set += ", attempts = 0"
+	} else {
+		w.Add("attempts < max_attempts")
+	}
+	return s.exec(ctx, "retry", "UPDATE scraper_queue SET "+set+w.String(), w)
+}
+
+// Prioritize sets the priority of the pending items matching the filter,
+// higher priorities are crawled first
+func (s *Service) Prioritize(ctx context.Context, f Filter, priority int64) (int64, error) {
+	f, err := only(f, "pending", "prioritize")
+	if err != nil {
+		return 0, err
+	}
+	w, err := s.where(f)
+	if err != nil {
+		return 0, err
+	}
+	return s.exec(ctx, "prioritize", "UPDATE scraper_queue SET priority = "+strconv.FormatInt(priority, 10)+w.String(), w)
+}
+
+// Purge deletes the items matching the filter. The filter must name the
+// statuses to delete, so a purge never empties the queue by accident.
+func (s *Service) Purge(ctx context.Context, f Filter) (int64, error) {
+	if len(f.Statuses) == 0 {
+		return 0, fmt.Errorf("purge needs the statuses to delete")
+	}
+	w, err := s.where(f)
+	if err != nil {
+		return 0, err
+	}
+	return s.exec(ctx, "purge", "DELETE FROM scraper_queue"+w.String(), w)
+}
+
+func (s *Service) exec(ctx context.Context, operation, query string, w *storage.Where) (int64, error) {
+	res, err := s.db.ExecContext(ctx, query, w.Args()...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to %s queue items: %w", operation, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to %s queue items: %w", operation, err)
+	}
+	return n, nil
+}
+
+// RequeueResult counts what Requeue did with each URL
+type RequeueResult struct {
+	// Requeued items went back to pending
+	Requeued int64
+	// Enqueued URLs were not in the queue
+	Enqueued int64
+	// Processing items are being crawled and were left alone
+	Processing []string
+}
+
+// Requeue puts the target's queue items for the URLs back to pending with
+// their attempts reset, whatever their status, so they are crawled again.
+// URLs missing from the queue are enqueued.
+func (s *Service) Requeue(ctx context.Context, targetID int64, urls []string) (RequeueResult, error) {
+	var result RequeueResult
+	for _, u := range urls {
+		w := storage.NewWhere(s.dialect)
+		w.Add("target_id = ?", targetID)
+		w.Add("url = ?", u)
+		w.Add("status <> 'processing'")
+		n, err := s.exec(ctx, "requeue", "UPDATE scraper_queue SET status = 'pending', attempts = 0, processed_at = NULL, error_message = NULL"+w.String(), w)
+		if err != nil {
+			return result, err
+		}
+		if n > 0 {
+			result.Requeued += n
+			continue
+		}
+		// Not updated but queued means it's being crawled
+		w = storage.NewWhere(s.dialect)
+		w.Add("target_id = ?", targetID)
+		w.Add("url = ?", u)
+		var queued int64
+		if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM scraper_queue"+w.String(), w.Args()...).Scan(&queued); err != nil {
+			return result, fmt.Errorf("failed to requeue %s: %w", u, err)
+		}
+		if queued > 0 {
+			result.Processing = append(result.Processing, u)
+			continue
+		}
+		if _, err := s.queries.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: targetID, Url: u, Priority: sql.NullInt64{Int64: 0, Valid: true}}); err != nil {
+			return result, fmt.Errorf("failed to enqueue %s: %w", u, err)
+		}
+		result.Enqueued++
+	}
+	return result, nil
+}
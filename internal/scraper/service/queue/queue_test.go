@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+	"app/internal/scraper/migrate"
+	"app/internal/scraper/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testQueue struct {
+	t       *testing.T
+	s       *Service
+	db      *sql.DB
+	queries db.Querier
+}
+
+// newTestQueue returns a queue of two targets: target 1 has items 1-4, one per
+// status with item 4 failed on a timeout, target 2 has item 5 failed on a 404
+// with no attempts left
+func newTestQueue(t *testing.T) *testQueue {
+	t.Helper()
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = dbConn.Close() })
+	store := storage.NewSQLite(dbConn)
+	ctx := context.Background()
+	if err := migrate.EnsureSchema(ctx, store, true); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	q := &testQueue{t: t, s: NewService(store), db: dbConn, queries: store.Queries()}
+	for _, site := range []string{"https://a.example", "https://b.example"} {
+		if _, err := q.queries.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: site}); err != nil {
+			t.Fatalf("create target: %v", err)
+		}
+	}
+	for i, item := range []struct {
+		target int64
+		status string
+		err    string
+	}{{1, "pending", ""}, {1, "processing", ""}, {1, "completed", ""}, {1, "failed", "Get: i/o timeout"}, {2, "failed", "HTTP 404"}} {
+		if _, err := q.queries.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: item.target, Url: fmt.Sprintf("https://x.example/%d", i+1)}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		q.exec("UPDATE scraper_queue SET status = ?, error_message = NULLIF(?, '') WHERE id = ?", item.status, item.err, i+1)
+	}
+	q.exec("UPDATE scraper_queue SET attempts = max_attempts WHERE id = 5")
+	return q
+}
+
+func (q *testQueue) exec(query string, args ...any) {
+	q.t.Helper()
+	if _, err := q.db.Exec(query, args...); err != nil {
+		q.t.Fatalf("exec: %v", err)
+	}
+}
+
+// statuses returns the status of every item by id, "-" for deleted ones
+func (q *testQueue) statuses() string {
+	q.t.Helper()
+	out := ""
+	for id := int64(1); id <= 6; id++ {
+		item, err := q.queries.GetQueueItem(context.Background(), id)
+		switch {
+		case err == sql.ErrNoRows:
+			out += "- "
+		case err != nil:
+			q.t.Fatalf("get: %v", err)
+		default:
+			out += item.Status.String + " "
+		}
+	}
+	return out
+}
+
+func TestListAndCount(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+	tests := []struct {
+		filter Filter
+		want   string
+	}{
+		{Filter{}, "[1 2 3 4 5]"},
+		{Filter{TargetID: 1, Statuses: []string{"failed", "completed"}}, "[3 4]"},
+		{Filter{ErrorContains: "timeout"}, "[4]"},
+		{Filter{URLContains: "/5"}, "[5]"},
+		{Filter{OlderThan: time.Now().Add(time.Hour)}, "[1 2 3 4 5]"},
+		{Filter{OlderThan: time.Now().Add(-time.Hour)}, "[]"},
+	}
+	for _, tc := range tests {
+		items, next, err := q.s.List(ctx, tc.filter, 0, 0)
+		if err != nil {
+			t.Fatalf("list %+v: %v", tc.filter, err)
+		}
+		ids := make([]int64, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		if fmt.Sprint(ids) != tc.want || next != 0 {
+			t.Errorf("list %+v: got %v next %d, want %s", tc.filter, ids, next, tc.want)
+		}
+		if n, err := q.s.Count(ctx, tc.filter); err != nil || n != int64(len(ids)) {
+			t.Errorf("count %+v: got %d (%v)", tc.filter, n, err)
+		}
+	}
+
+	items, next, err := q.s.List(ctx, Filter{}, 0, 2)
+	if err != nil || len(items) != 2 || next != 2 {
+		t.Fatalf("expected a first page of 2, got %d next %d (%v)", len(items), next, err)
+	}
+	if items, next, _ = q.s.List(ctx, Filter{}, next, 2); items[0].ID != 3 || next != 4 {
+		t.Errorf("expected the second page from item 3, got %d next %d", items[0].ID, next)
+	}
+	if _, _, err := q.s.List(ctx, Filter{Statuses: []string{"done"}}, 0, 0); err == nil {
+		t.Error("expected an invalid status rejected")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+	if _, err := q.s.Retry(ctx, Filter{Statuses: []string{"completed"}}, false); err == nil {
+		t.Error("expected retrying completed items refused")
+	}
+	if n, err := q.s.Retry(ctx, Filter{}, false); err != nil || n != 1 {
+		t.Fatalf("expected only the item with attempts left retried, got %d (%v)", n, err)
+	}
+	if got := q.statuses(); got != "pending processing completed pending failed - " {
+		t.Errorf("unexpected statuses %s", got)
+	}
+	if n, err := q.s.Retry(ctx, Filter{TargetID: 2}, true); err != nil || n != 1 {
+		t.Fatalf("expected the exhausted item retried with reset attempts, got %d (%v)", n, err)
+	}
+	if item, _ := q.queries.GetQueueItem(ctx, 5); item.Status.String != "pending" || item.Attempts.Int64 != 0 || item.ErrorMessage.Valid {
+		t.Errorf("unexpected retried item %+v", item)
+	}
+}
+
+func TestPrioritizeAndPurge(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+	if n, err := q.s.Prioritize(ctx, Filter{TargetID: 1}, 10); err != nil || n != 1 {
+		t.Fatalf("expected the pending item prioritized, got %d (%v)", n, err)
+	}
+	if item, _ := q.queries.GetQueueItem(ctx, 1); item.Priority.Int64 != 10 {
+		t.Errorf("expected priority 10, got %d", item.Priority.Int64)
+	}
+	if _, err := q.s.Prioritize(ctx, Filter{Statuses: []string{"failed"}}, 1); err == nil {
+		t.Error("expected prioritizing failed items refused")
+	}
+
+	if _, err := q.s.Purge(ctx, Filter{TargetID: 1}); err == nil {
+		t.Error("expected a purge without statuses refused")
+	}
+	if n, err := q.s.Purge(ctx, Filter{Statuses: []string{"failed"}, ErrorContains: "404"}); err != nil || n != 1 {
+		t.Fatalf("expected the 404 purged, got %d (%v)", n, err)
+	}
+	if n, err := q.s.Purge(ctx, Filter{TargetID: 1, Statuses: []string{"completed", "failed"}}); err != nil || n != 2 {
+		t.Fatalf("expected target 1's finished items purged, got %d (%v)", n, err)
+	}
+	if got := q.statuses(); got != "pending processing - - - - " {
+		t.Errorf("unexpected statuses %s", got)
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	q := newTestQueue(t)
+	result, err := q.s.Requeue(context.Background(), 1, []string{
+		"https://x.example/3", "https://x.example/4", "https://x.example/2", "https://x.example/new",
+	})
+	if err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if result.Requeued != 2 || result.Enqueued != 1 || fmt.Sprint(result.Processing) != "[https://x.example/2]" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := q.statuses(); got != "pending processing pending pending failed pending " {
+		t.Errorf("unexpected statuses %s", got)
+	}
+}
+
+func TestRequeueKeepsPriorityOrder(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+	q.exec("UPDATE scraper_queue SET status = 'completed' WHERE id = 1")
+	q.exec("UPDATE scraper_queue SET priority = 5 WHERE id = 3")
+	if _, err := q.s.Requeue(ctx, 1, []string{"https://x.example/new"}); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	item, err := q.queries.GetQueueItem(ctx, 6)
+	if err != nil || !item.Priority.Valid || item.Priority.Int64 != 0 {
+		t.Fatalf("expected the enqueued URL at priority 0, got %+v (%v)", item.Priority, err)
+	}
+	if _, err := q.s.Requeue(ctx, 1, []string{"https://x.example/3"}); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	for _, want := range []int64{3, 6} {
+		got, err := q.queries.DequeuePendingURL(ctx, sql.NullInt64{})
+		if err != nil || got.ID != want {
+			t.Fatalf("expected item %d dequeued, got %d (%v)", want, got.ID, err)
+		}
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	if got, err := ParseStatuses("completed, failed"); err != nil || fmt.Sprint(got) != "[completed failed]" {
+		t.Errorf("got %v (%v)", got, err)
+	}
+	if got, _ := ParseStatuses("all"); len(got) != len(Statuses) {
+		t.Errorf("expected every status, got %v", got)
+	}
+	if _, err := ParseStatuses("completed,done"); err == nil {
+		t.Error("expected an unknown status rejected")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "36h": 36 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := ParseAge(raw); err != nil || got != want {
+			t.Errorf("%s: got %v (%v)", raw, got, err)
+		}
+	}
+	for _, raw := range []string{"d", "-1d", "week", "-2h"} {
+		if _, err := ParseAge(raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
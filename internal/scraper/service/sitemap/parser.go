@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"time"
@@ -66,30 +67,36 @@ type ParsedSitemap struct {
 type Parser struct {
 	client  *http.Client
 	queries ParserQueries
-	logger  *logger.DBLogger
+	log     *slog.Logger
+	// Sent for targets without their own user agent
+	userAgent string
 }
 
 // NewParser creates a new sitemap parser with database access
-func NewParser(queries ParserQueries, timeout time.Duration) *Parser {
+func NewParser(queries ParserQueries, timeout time.Duration, userAgent string) *Parser {
 	return &Parser{
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		queries: queries,
-		logger:  logger.NewDBLogger(queries.(logger.LoggerQueries)), // Type assertion for logger
+		queries:   queries,
+		userAgent: userAgent,
+		log:       logger.New(logger.ComponentSitemap, queries.(logger.LoggerQueries)),
 	}
 }
 
+// SetTransport replaces the HTTP transport, e.g. with a cassette replayer in tests
+func (p *Parser) SetTransport(rt http.RoundTripper) {
+	p.client.Transport = rt
+}
+
 // ParseSitemapForTarget parses sitemap using target-specific patterns from database
 func (p *Parser) ParseSitemapForTarget(ctx context.Context, targetID int64) (*ParsedSitemap, error) {
-	p.logger.Info(ctx, &targetID, "", fmt.Sprintf("Starting sitemap parsing for target %d", targetID))
+	p.log.InfoContext(ctx, fmt.Sprintf("Starting sitemap parsing for target %d", targetID), logger.Target(targetID))
 
 	// Get target configuration from database
 	target, err := p.queries.GetTarget(ctx, targetID)
 	if err != nil {
-		p.logger.Error(ctx, &targetID, "", "Failed to get target from database", map[string]interface{}{
-			"error": err.Error(),
-		})
+		p.log.ErrorContext(ctx, "Failed to get target from database", logger.Target(targetID), logger.Err(err))
 		return nil, fmt.Errorf("failed to get target: %w", err)
 	}
 
@@ -98,13 +105,11 @@ func (p *Parser) ParseSitemapForTarget(ctx context.Context, targetID int64) (*Pa
 	if target.SitemapUrl.Valid {
 		sitemapURL = target.SitemapUrl.String
 	} else {
-		p.logger.Error(ctx, &targetID, "", "Target has no sitemap URL configured")
+		p.log.ErrorContext(ctx, "Target has no sitemap URL configured", logger.Target(targetID))
 		return nil, fmt.Errorf("target has no sitemap URL configured")
 	}
 
-	p.logger.Info(ctx, &targetID, sitemapURL, "Starting sitemap parsing", map[string]interface{}{
-		"website_url": target.WebsiteUrl,
-	})
+	p.log.InfoContext(ctx, "Starting sitemap parsing", logger.Target(targetID), logger.URL(sitemapURL), "website_url", target.WebsiteUrl)
 
 	// Parse pattern configuration
 	var sitemapPatterns, urlPatterns []string
@@ -114,10 +119,7 @@ func (p *Parser) ParseSitemapForTarget(ctx context.Context, targetID int64) (*Pa
 		if err := json.Unmarshal([]byte(target.SitemapPatterns.String), &patterns); err == nil {
 			sitemapPatterns = patterns
 		} else {
-			p.logger.Warn(ctx, &targetID, "", "Failed to parse sitemap patterns", map[string]interface{}{
-				"error":    err.Error(),
-				"patterns": target.SitemapPatterns.String,
-			})
+			p.log.WarnContext(ctx, "Failed to parse sitemap patterns", logger.Target(targetID), logger.Err(err), "patterns", target.SitemapPatterns.String)
 		}
 	}
 
@@ -126,10 +128,7 @@ func (p *Parser) ParseSitemapForTarget(ctx context.Context, targetID int64) (*Pa
 		if err := json.Unmarshal([]byte(target.UrlPatterns.String), &patterns); err == nil {
 			urlPatterns = patterns
 		} else {
-			p.logger.Warn(ctx, &targetID, "", "Failed to parse URL patterns", map[string]interface{}{
-				"error":    err.Error(),
-				"patterns": target.UrlPatterns.String,
-			})
+			p.log.WarnContext(ctx, "Failed to parse URL patterns", logger.Target(targetID), logger.Err(err), "patterns", target.UrlPatterns.String)
 		}
 	}
 
@@ -141,40 +140,29 @@ func (p *Parser) ParseSitemapForTarget(ctx context.Context, targetID int64) (*Pa
 	// Compile patterns using config package
 	compiledSitemapPatterns, err := config.CompilePatterns(sitemapPatterns)
 	if err != nil {
-		p.logger.Error(ctx, &targetID, sitemapURL, "Invalid sitemap patterns", map[string]interface{}{
-			"error":    err.Error(),
-			"patterns": sitemapPatterns,
-		})
+		p.log.ErrorContext(ctx, "Invalid sitemap patterns", logger.Target(targetID), logger.URL(sitemapURL), logger.Err(err), "patterns", sitemapPatterns)
 		return nil, fmt.Errorf("invalid sitemap patterns: %w", err)
 	}
 
 	compiledURLPatterns, err := config.CompilePatterns(urlPatterns)
 	if err != nil {
-		p.logger.Error(ctx, &targetID, sitemapURL, "Invalid URL patterns", map[string]interface{}{
-			"error":    err.Error(),
-			"patterns": urlPatterns,
-		})
+		p.log.ErrorContext(ctx, "Invalid URL patterns", logger.Target(targetID), logger.URL(sitemapURL), logger.Err(err), "patterns", urlPatterns)
 		return nil, fmt.Errorf("invalid URL patterns: %w", err)
 	}
 
 	// Parse sitemap with target-specific configuration
 	userAgent := target.UserAgent.String
 	if userAgent == "" {
-		userAgent = "QuotesBot/1.0"
+		userAgent = p.userAgent
 	}
 
 	result, err := p.parseSitemapWithPatterns(ctx, targetID, sitemapURL, userAgent, compiledSitemapPatterns, compiledURLPatterns)
 	if err != nil {
-		p.logger.Error(ctx, &targetID, sitemapURL, "Sitemap parsing failed", map[string]interface{}{
-			"error": err.Error(),
-		})
+		p.log.ErrorContext(ctx, "Sitemap parsing failed", logger.Target(targetID), logger.URL(sitemapURL), logger.Err(err))
 		return nil, err
 	}
 
-	p.logger.Info(ctx, &targetID, sitemapURL, "Sitemap parsing completed", map[string]interface{}{
-		"url_count":     len(result.URLs),
-		"sitemap_count": len(result.SubSitemaps),
-	})
+	p.log.InfoContext(ctx, "Sitemap parsing completed", logger.Target(targetID), logger.URL(sitemapURL), "url_count", len(result.URLs), "sitemap_count", len(result.SubSitemaps))
 
 	return result, nil
 }
@@ -211,23 +199,17 @@ func (p *Parser) fetchSitemapIndex(ctx context.Context, targetID int64, url, use
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			p.logger.Warn(ctx, &targetID, url, "Failed to close response body", map[string]interface{}{
-				"error": closeErr.Error(),
-			})
+			p.log.WarnContext(ctx, "Failed to close response body", logger.Target(targetID), logger.URL(url), logger.Err(closeErr))
 		}
 	}()
 
 	var sitemapIndex SitemapIndex
 	if err := xml.NewDecoder(resp.Body).Decode(&sitemapIndex); err != nil {
-		p.logger.Error(ctx, &targetID, url, "Failed to decode sitemap index", map[string]interface{}{
-			"error": err.Error(),
-		})
+		p.log.ErrorContext(ctx, "Failed to decode sitemap index", logger.Target(targetID), logger.URL(url), logger.Err(err))
 		return nil, fmt.Errorf("failed to decode sitemap index: %w", err)
 	}
 
-	p.logger.Info(ctx, &targetID, url, "Successfully parsed sitemap index", map[string]interface{}{
-		"sitemap_count": len(sitemapIndex.Sitemaps),
-	})
+	p.log.InfoContext(ctx, "Successfully parsed sitemap index", logger.Target(targetID), logger.URL(url), "sitemap_count", len(sitemapIndex.Sitemaps))
 
 	return &sitemapIndex, nil
 }
@@ -268,27 +250,18 @@ func (p *Parser) fetchURL(ctx context.Context, targetID int64, url, userAgent st
 	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept", "application/xml, text/xml, */*")
 
-	p.logger.Info(ctx, &targetID, url, "Fetching sitemap", map[string]interface{}{
-		"user_agent": userAgent,
-	})
+	p.log.InfoContext(ctx, "Fetching sitemap", logger.Target(targetID), logger.URL(url), "user_agent", userAgent)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		p.logger.Error(ctx, &targetID, url, "HTTP request failed", map[string]interface{}{
-			"error": err.Error(),
-		})
+		p.log.ErrorContext(ctx, "HTTP request failed", logger.Target(targetID), logger.URL(url), logger.Err(err))
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		p.logger.Error(ctx, &targetID, url, "HTTP request returned error status", map[string]interface{}{
-			"status_code": resp.StatusCode,
-			"status":      resp.Status,
-		})
+		p.log.ErrorContext(ctx, "HTTP request returned error status", logger.Target(targetID), logger.URL(url), "status_code", resp.StatusCode, "status", resp.Status)
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			p.logger.Warn(ctx, &targetID, url, "Failed to close response body after error", map[string]interface{}{
-				"error": closeErr.Error(),
-			})
+			p.log.WarnContext(ctx, "Failed to close response body after error", logger.Target(targetID), logger.URL(url), logger.Err(closeErr))
 		}
 		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
@@ -304,18 +277,13 @@ func (p *Parser) processSitemapIndex(ctx context.Context, targetID int64, index
 	// Filter relevant sub-sitemaps
 	relevantSitemaps := filterSitemaps(index.Sitemaps, sitemapPatterns)
 
-	p.logger.Info(ctx, &targetID, "", "Processing sitemap index", map[string]interface{}{
-		"total_sitemaps":    len(index.Sitemaps),
-		"relevant_sitemaps": len(relevantSitemaps),
-	})
+	p.log.InfoContext(ctx, "Processing sitemap index", logger.Target(targetID), "total_sitemaps", len(index.Sitemaps), "relevant_sitemaps", len(relevantSitemaps))
 
 	for _, sitemap := range relevantSitemaps {
 		// Fetch each relevant sub-sitemap
 		urlSet, err := p.fetchURLSet(ctx, targetID, sitemap.Loc, userAgent)
 		if err != nil {
-			p.logger.Warn(ctx, &targetID, sitemap.Loc, "Failed to fetch sub-sitemap, continuing with others", map[string]interface{}{
-				"error": err.Error(),
-			})
+			p.log.WarnContext(ctx, "Failed to fetch sub-sitemap, continuing with others", logger.Target(targetID), logger.URL(sitemap.Loc), logger.Err(err))
 			continue
 		}
 
@@ -323,10 +291,7 @@ func (p *Parser) processSitemapIndex(ctx context.Context, targetID int64, index
 		filteredURLs := filterURLs(urlSet.URLs, urlPatterns)
 		result.URLs = append(result.URLs, filteredURLs...)
 
-		p.logger.Info(ctx, &targetID, sitemap.Loc, "Processed sub-sitemap", map[string]interface{}{
-			"total_urls":    len(urlSet.URLs),
-			"filtered_urls": len(filteredURLs),
-		})
+		p.log.InfoContext(ctx, "Processed sub-sitemap", logger.Target(targetID), logger.URL(sitemap.Loc), "total_urls", len(urlSet.URLs), "filtered_urls", len(filteredURLs))
 	}
 
 	result.SubSitemaps = relevantSitemaps
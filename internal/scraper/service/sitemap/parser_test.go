@@ -126,7 +126,7 @@ func TestFilterURLs(t *testing.T) {
 
 func TestNewParser(t *testing.T) {
 	mockQueries := &MockQueries{}
-	parser := NewParser(mockQueries, 30*time.Second)
+	parser := NewParser(mockQueries, 30*time.Second, "ScraperBot/1.0")
 
 	if parser == nil {
 		t.Error("NewParser should return a valid parser")
@@ -152,7 +152,7 @@ func TestParseError_NoSitemapURL(t *testing.T) {
 		},
 	}
 
-	parser := NewParser(mockQueries, 10*time.Second)
+	parser := NewParser(mockQueries, 10*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 
 	_, err := parser.ParseSitemapForTarget(ctx, 1)
@@ -170,7 +170,7 @@ func TestParseError_DatabaseError(t *testing.T) {
 		getTargetError: sql.ErrConnDone,
 	}
 
-	parser := NewParser(mockQueries, 10*time.Second)
+	parser := NewParser(mockQueries, 10*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 
 	_, err := parser.ParseSitemapForTarget(ctx, 1)
@@ -189,7 +189,7 @@ func TestParseError_InvalidPatterns(t *testing.T) {
 		},
 	}
 
-	parser := NewParser(mockQueries, 10*time.Second)
+	parser := NewParser(mockQueries, 10*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 
 	_, err := parser.ParseSitemapForTarget(ctx, 1)
@@ -243,7 +243,7 @@ func TestParseWithHTTPServer(t *testing.T) {
 		},
 	}
 
-	parser := NewParser(mockQueries, 10*time.Second)
+	parser := NewParser(mockQueries, 10*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 
 	result, err := parser.ParseSitemapForTarget(ctx, 1)
@@ -278,7 +278,7 @@ func TestHTTPError(t *testing.T) {
 		},
 	}
 
-	parser := NewParser(mockQueries, 10*time.Second)
+	parser := NewParser(mockQueries, 10*time.Second, "ScraperBot/1.0")
 	ctx := context.Background()
 
 	_, err := parser.ParseSitemapForTarget(ctx, 1)
@@ -8,18 +8,30 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"app/internal/scraper/service/logger"
 )
 
+var sitemapLog = logger.Lazy(logger.ComponentSitemap)
+
 type SitemapService struct {
 	client *http.Client
+	// Sent when the caller passes no user agent
+	userAgent string
 }
 
-func NewSitemapService(timeout time.Duration) *SitemapService {
+func NewSitemapService(timeout time.Duration, userAgent string) *SitemapService {
 	return &SitemapService{
-		client: &http.Client{Timeout: timeout},
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
 	}
 }
 
+// SetTransport replaces the HTTP transport, e.g. with a cassette replayer in tests
+func (s *SitemapService) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = rt
+}
+
 // AutoDiscoverSitemap tries common sitemap locations and returns the first valid one
 func (s *SitemapService) AutoDiscoverSitemap(websiteURL string) (string, error) {
 	commonPaths := []string{
@@ -37,12 +49,19 @@ func (s *SitemapService) AutoDiscoverSitemap(websiteURL string) (string, error)
 
 	for _, path := range commonPaths {
 		testURL := baseURL + path
-		resp, err := s.client.Get(testURL)
+		req, err := http.NewRequest("GET", testURL, nil)
+		if err != nil {
+			continue
+		}
+		if s.userAgent != "" {
+			req.Header.Set("User-Agent", s.userAgent)
+		}
+		resp, err := s.client.Do(req)
 		if err != nil {
 			continue
 		}
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("failed to close response body: %v\n", err)
+			sitemapLog().Warn("Failed to close response body", logger.Err(err))
 		}
 		if resp.StatusCode == 200 {
 			if path == "/robots.txt" {
@@ -61,6 +80,9 @@ func (s *SitemapService) ValidateSitemap(ctx context.Context, sitemapURL, userAg
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	if userAgent == "" {
+		userAgent = s.userAgent
+	}
 	if userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
 	}
@@ -70,7 +92,7 @@ func (s *SitemapService) ValidateSitemap(ctx context.Context, sitemapURL, userAg
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("failed to close response body: %v\n", err)
+			sitemapLog().Warn("Failed to close response body", logger.Err(err))
 		}
 	}()
 	if resp.StatusCode != 200 {
@@ -85,6 +107,9 @@ func (s *SitemapService) ParseSitemapURL(ctx context.Context, sitemapURL, userAg
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if userAgent == "" {
+		userAgent = s.userAgent
+	}
 	if userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
 	}
@@ -94,7 +119,7 @@ func (s *SitemapService) ParseSitemapURL(ctx context.Context, sitemapURL, userAg
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("failed to close response body: %v\n", err)
+			sitemapLog().Warn("Failed to close response body", logger.Err(err))
 		}
 	}()
 	if resp.StatusCode != 200 {
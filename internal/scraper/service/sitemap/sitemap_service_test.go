@@ -7,6 +7,9 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
+
+	"app/internal/scraper/cassette"
 )
 
 type mockRoundTripper struct {
@@ -254,3 +257,22 @@ func TestParseSitemapURL_UserAgent(t *testing.T) {
 		t.Errorf("expected User-Agent 'test-agent', got '%s'", gotUA)
 	}
 }
+
+func TestSitemapService_ReplaysCassette(t *testing.T) {
+	// Only the sitemap index was recorded, the other locations fail like an offline host
+	replayer := cassette.NewReplayer(&cassette.Cassette{Interactions: []cassette.Interaction{{
+		Request:  cassette.Request{Method: "GET", URL: "https://quotes.example.com/sitemap_index.xml"},
+		Response: cassette.Response{StatusCode: 200, Body: `<urlset><url><loc>https://quotes.example.com/be-yourself/</loc></url></urlset>`},
+	}}})
+	service := NewSitemapService(time.Second, "TestBot/1.0")
+	service.SetTransport(replayer)
+
+	found, err := service.AutoDiscoverSitemap("https://quotes.example.com/")
+	if err != nil || found != "https://quotes.example.com/sitemap_index.xml" {
+		t.Fatalf("expected the recorded sitemap index, got %q (%v)", found, err)
+	}
+	urls, err := service.ParseSitemapURL(context.Background(), found, "")
+	if err != nil || len(urls) != 1 || urls[0].Loc != "https://quotes.example.com/be-yourself/" {
+		t.Errorf("expected the recorded URL, got %v (%v)", urls, err)
+	}
+}
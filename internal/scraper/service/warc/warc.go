@@ -0,0 +1,191 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the WARC format version written
+const Version = "WARC/1.1"
+
+// Record types
+const (
+	TypeWarcinfo = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+)
+
+// Named fields, textproto canonicalizes them when read so Get accepts any case
+const (
+	FieldType          = "WARC-Type"
+	FieldRecordID      = "WARC-Record-ID"
+	FieldDate          = "WARC-Date"
+	FieldTargetURI     = "WARC-Target-URI"
+	FieldWarcinfoID    = "WARC-Warcinfo-ID"
+	FieldConcurrentTo  = "WARC-Concurrent-To"
+	FieldFilename      = "WARC-Filename"
+	FieldBlockDigest   = "WARC-Block-Digest"
+	FieldPayloadDigest = "WARC-Payload-Digest"
+	FieldContentType   = "Content-Type"
+	FieldContentLength = "Content-Length"
+)
+
+// Record is a WARC record: named fields and a block of Content-Length bytes
+type Record struct {
+	Header textproto.MIMEHeader
+	Block  []byte
+}
+
+// Type is the WARC-Type of the record
+func (r *Record) Type() string { return r.Header.Get(FieldType) }
+
+// TargetURI is the URI the record was captured from
+func (r *Record) TargetURI() string { return r.Header.Get(FieldTargetURI) }
+
+// Date is the capture time of the record
+func (r *Record) Date() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, r.Header.Get(FieldDate))
+}
+
+// HTTPResponse parses the block of a response record and returns the
+// response with its decoded payload. Chunked and gzip encoded payloads, as
+// written by other crawlers, are decoded.
+func (r *Record) HTTPResponse() (*http.Response, []byte, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(r.Block)), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid HTTP response: %w", err)
+	}
+	defer resp.Body.Close()
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid gzip payload: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read HTTP payload: %w", err)
+	}
+	return resp, payload, nil
+}
+
+// field is a named field in write order
+type field struct {
+	name, value string
+}
+
+// encode writes a record with the fields in order, the block digest and
+// length are added
+func encode(w io.Writer, fields []field, contentType string, block []byte) error {
+	var buf bytes.Buffer
+	buf.WriteString(Version + "\r\n")
+	fields = append(fields,
+		field{FieldBlockDigest, Digest(block)},
+		field{FieldContentType, contentType},
+		field{FieldContentLength, strconv.Itoa(len(block))},
+	)
+	for _, f := range fields {
+		buf.WriteString(f.name + ": " + f.value + "\r\n")
+	}
+	buf.WriteString("\r\n")
+	buf.Write(block)
+	buf.WriteString("\r\n\r\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Digest is the labelled base32 SHA-1 digest used by WARC-Block-Digest and WARC-Payload-Digest
+func Digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// newRecordID returns a urn:uuid record id from a random version 4 UUID
+func newRecordID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Reader reads the records of a WARC file, compressed per record or as a
+// whole with gzip, or uncompressed
+type Reader struct {
+	br *bufio.Reader
+	tp *textproto.Reader
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		// gzip.Reader reads concatenated members as one stream
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip stream: %w", err)
+		}
+		br = bufio.NewReader(gz)
+	}
+	return &Reader{br: br, tp: textproto.NewReader(br)}, nil
+}
+
+// Next returns the next record, io.EOF after the last one
+func (r *Reader) Next() (*Record, error) {
+	var version string
+	for version == "" {
+		line, err := r.tp.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, fmt.Errorf("invalid WARC record: expected version line, got %q", truncate(version, 40))
+	}
+	header, err := r.tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid WARC record header: %w", err)
+	}
+	length, err := strconv.ParseInt(header.Get(FieldContentLength), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid WARC record %s: bad Content-Length %q", header.Get(FieldRecordID), header.Get(FieldContentLength))
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r.br, block); err != nil {
+		return nil, fmt.Errorf("truncated WARC record %s: %w", header.Get(FieldRecordID), err)
+	}
+	return &Record{Header: header, Block: block}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fetch(t *testing.T, url string) (*http.Request, *http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	req.Header.Set("User-Agent", "TestBot/1.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return req, resp, body
+}
+
+func readAll(t *testing.T, path string) []*Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	var records []*Record
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Last-Modified", "Sat, 01 Jun 2024 10:00:00 GMT")
+		_, _ = w.Write([]byte("<html>" + r.URL.Path + "</html>"))
+	}))
+	defer srv.Close()
+
+	w, err := NewWriter(t.TempDir(), "crawl", 1<<20)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	fetched := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	req, resp, body := fetch(t, srv.URL+"/quotes?page=2")
+	if err := w.WriteExchange(req, resp, body, fetched); err != nil {
+		t.Fatalf("WriteExchange: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := w.Files()
+	if len(files) != 1 || !strings.HasSuffix(files[0], "-00001.warc.gz") {
+		t.Fatalf("expected one file, got %v", files)
+	}
+	records := readAll(t, files[0])
+	if len(records) != 3 {
+		t.Fatalf("expected warcinfo, response and request records, got %d", len(records))
+	}
+	info, response, request := records[0], records[1], records[2]
+	if info.Type() != TypeWarcinfo || !strings.Contains(string(info.Block), "software: scraper-cli") {
+		t.Errorf("unexpected warcinfo record %v", info.Header)
+	}
+	if response.Type() != TypeResponse || response.TargetURI() != srv.URL+"/quotes?page=2" {
+		t.Errorf("unexpected response record %v", response.Header)
+	}
+	if response.Header.Get(FieldWarcinfoID) != info.Header.Get(FieldRecordID) {
+		t.Error("expected the response to refer to the warcinfo record")
+	}
+	if response.Header.Get(FieldBlockDigest) != Digest(response.Block) || response.Header.Get(FieldPayloadDigest) != Digest(body) {
+		t.Error("expected block and payload digests to match")
+	}
+	if date, err := response.Date(); err != nil || !date.Equal(fetched) {
+		t.Errorf("expected WARC-Date %v, got %v (%v)", fetched, date, err)
+	}
+
+	parsed, payload, err := response.HTTPResponse()
+	if err != nil {
+		t.Fatalf("HTTPResponse: %v", err)
+	}
+	if parsed.StatusCode != 200 || string(payload) != "<html>/quotes</html>" || parsed.Header.Get("Last-Modified") == "" {
+		t.Errorf("unexpected response %d %q %v", parsed.StatusCode, payload, parsed.Header)
+	}
+
+	if request.Type() != TypeRequest || request.Header.Get(FieldConcurrentTo) != response.Header.Get(FieldRecordID) {
+		t.Errorf("unexpected request record %v", request.Header)
+	}
+	if !strings.HasPrefix(string(request.Block), "GET /quotes?page=2 HTTP/1.1\r\n") || !strings.Contains(string(request.Block), "User-Agent: TestBot/1.0") {
+		t.Errorf("unexpected request block %q", request.Block)
+	}
+}
+
+func TestWriter_RotatesBySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 2000)))
+	}))
+	defer srv.Close()
+
+	w, err := NewWriter(t.TempDir(), "crawl", 500)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		req, resp, body := fetch(t, srv.URL)
+		if err := w.WriteExchange(req, resp, body, time.Now()); err != nil {
+			t.Fatalf("WriteExchange: %v", err)
+		}
+	}
+	_ = w.Close()
+	files := w.Files()
+	if len(files) != 3 {
+		t.Fatalf("expected a file per exchange over the size limit, got %v", files)
+	}
+	for _, f := range files {
+		if records := readAll(t, f); len(records) != 3 || records[0].Type() != TypeWarcinfo {
+			t.Errorf("%s: expected warcinfo, response and request, got %d records", f, len(records))
+		}
+	}
+}
+
+func TestReader_ForeignRecords(t *testing.T) {
+	// An uncompressed WARC with a chunked, gzip encoded payload as other crawlers write
+	var gzBody bytes.Buffer
+	gz := gzip.NewWriter(&gzBody)
+	_, _ = gz.Write([]byte("<html>old crawl</html>"))
+	_ = gz.Close()
+	block := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nTransfer-Encoding: chunked\r\n\r\n%x\r\n%s\r\n0\r\n\r\n", gzBody.Len(), gzBody.String())
+	data := fmt.Sprintf("WARC/1.0\r\nwarc-type: response\r\nWARC-Target-URI: https://example.com/old\r\nWARC-Date: 2020-01-02T03:04:05Z\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", len(block), block)
+
+	r, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if rec.Type() != TypeResponse || rec.TargetURI() != "https://example.com/old" {
+		t.Errorf("unexpected record %v", rec.Header)
+	}
+	_, payload, err := rec.HTTPResponse()
+	if err != nil || string(payload) != "<html>old crawl</html>" {
+		t.Errorf("expected the decoded payload, got %q (%v)", payload, err)
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestReader_Invalid(t *testing.T) {
+	r, err := NewReader(strings.NewReader("<html>not a warc</html>"))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if _, err := r.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("expected an invalid record error, got %v", err)
+	}
+}
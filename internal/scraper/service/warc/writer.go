@@ -0,0 +1,160 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Software is recorded in the warcinfo record of every file
+const Software = "scraper-cli"
+
+// Writer archives HTTP exchanges as request and response records in
+// gzip-compressed WARC files. A new file is started once the current one
+// reaches maxSize, each file begins with a warcinfo record. Safe for
+// concurrent use by the fetch workers.
+type Writer struct {
+	mu      sync.Mutex
+	dir     string
+	prefix  string
+	maxSize int64
+
+	file     *os.File
+	size     int64
+	infoID   string
+	seq      int
+	now      func() time.Time
+	filesOut []string
+}
+
+// NewWriter creates dir if needed, files are named <prefix>-<timestamp>-<seq>.warc.gz
+func NewWriter(dir, prefix string, maxSize int64) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WARC directory: %w", err)
+	}
+	return &Writer{dir: dir, prefix: prefix, maxSize: maxSize, now: time.Now}, nil
+}
+
+// Files lists the files written so far, oldest first
+func (w *Writer) Files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.filesOut...)
+}
+
+// WriteExchange archives a fetch: the request as sent and the response with
+// body as read. Go's transport removes Content-Encoding when it decompresses,
+// so the recorded headers match the recorded payload.
+func (w *Writer) WriteExchange(req *http.Request, resp *http.Response, body []byte, fetched time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil || w.size >= w.maxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	uri := req.URL.String()
+	date := formatDate(fetched)
+	responseID := newRecordID()
+
+	var respBlock bytes.Buffer
+	fmt.Fprintf(&respBlock, "HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+	if err := resp.Header.Write(&respBlock); err != nil {
+		return err
+	}
+	respBlock.WriteString("\r\n")
+	respBlock.Write(body)
+
+	var reqBlock bytes.Buffer
+	fmt.Fprintf(&reqBlock, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.URL.Host)
+	if err := req.Header.Write(&reqBlock); err != nil {
+		return err
+	}
+	reqBlock.WriteString("\r\n")
+
+	err := w.writeRecord([]field{
+		{FieldType, TypeResponse},
+		{FieldRecordID, responseID},
+		{FieldDate, date},
+		{FieldTargetURI, uri},
+		{FieldWarcinfoID, w.infoID},
+		{FieldPayloadDigest, Digest(body)},
+	}, "application/http;msgtype=response", respBlock.Bytes())
+	if err != nil {
+		return err
+	}
+	return w.writeRecord([]field{
+		{FieldType, TypeRequest},
+		{FieldRecordID, newRecordID()},
+		{FieldDate, date},
+		{FieldTargetURI, uri},
+		{FieldWarcinfoID, w.infoID},
+		{FieldConcurrentTo, responseID},
+	}, "application/http;msgtype=request", reqBlock.Bytes())
+}
+
+// writeRecord writes the record as its own gzip member, readers can seek to any record
+func (w *Writer) writeRecord(fields []field, contentType string, block []byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := encode(gz, fields, contentType, block); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	n, err := w.file.Write(buf.Bytes())
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write WARC record: %w", err)
+	}
+	return nil
+}
+
+// rotate closes the current file and starts the next one with a warcinfo record
+func (w *Writer) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	w.seq++
+	name := fmt.Sprintf("%s-%s-%05d.warc.gz", w.prefix, w.now().UTC().Format("20060102150405"), w.seq)
+	f, err := os.OpenFile(filepath.Join(w.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create WARC file: %w", err)
+	}
+	w.file, w.size, w.infoID = f, 0, newRecordID()
+	w.filesOut = append(w.filesOut, f.Name())
+
+	info := fmt.Sprintf("software: %s\r\nformat: WARC File Format 1.1\r\n", Software)
+	return w.writeRecord([]field{
+		{FieldType, TypeWarcinfo},
+		{FieldRecordID, w.infoID},
+		{FieldDate, formatDate(w.now())},
+		{FieldFilename, name},
+	}, "application/warc-fields", []byte(info))
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close WARC file: %w", err)
+	}
+	return nil
+}
+
+// Close finishes the current file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeFile()
+}
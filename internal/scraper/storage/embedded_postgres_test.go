@@ -0,0 +1,66 @@
+//go:build embeddedpg
+
+package storage
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// TestMain starts a throwaway PostgreSQL server for the PostgreSQL tests
+// unless postgresDSNEnv already points at one. The server binaries are
+// downloaded on first use and cached in ~/.embedded-postgres-go:
+//
+//	go test -tags embeddedpg ./internal/scraper/storage/
+func TestMain(m *testing.M) {
+	if os.Getenv(postgresDSNEnv) != "" {
+		os.Exit(m.Run())
+	}
+	os.Exit(runWithEmbeddedPostgres(m))
+}
+
+func runWithEmbeddedPostgres(m *testing.M) int {
+	port, err := freePort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "embedded postgres: %v\n", err)
+		return 1
+	}
+	dir, err := os.MkdirTemp("", "scraper-pg-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "embedded postgres: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	config := embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("scraper").
+		Username("scraper").
+		Password("scraper").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		Logger(nil)
+	server := embeddedpostgres.NewDatabase(config)
+	if err := server.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "embedded postgres: %v\n", err)
+		return 1
+	}
+	defer server.Stop()
+
+	os.Setenv(postgresDSNEnv, config.GetConnectionURL()+"?sslmode=disable")
+	return m.Run()
+}
+
+func freePort() (uint32, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
@@ -0,0 +1,461 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"app/internal/scraper/db"
+	pgdb "app/internal/scraper/db/postgres"
+)
+
+// postgresStore runs the queries generated from db/postgres/queries
+type postgresStore struct {
+	db      *sql.DB
+	queries *pgdb.Queries
+}
+
+// NewPostgres wraps an open PostgreSQL database
+func NewPostgres(database *sql.DB) Store {
+	return &postgresStore{db: database, queries: pgdb.New(database)}
+}
+
+func (s *postgresStore) DB() *sql.DB         { return s.db }
+func (s *postgresStore) Queries() db.Querier { return &postgresQueries{q: s.queries} }
+func (s *postgresStore) WithTx(tx *sql.Tx) db.Querier {
+	return &postgresQueries{q: s.queries.WithTx(tx)}
+}
+func (s *postgresStore) Dialect() Dialect { return DialectPostgres }
+func (s *postgresStore) Close() error     { return s.db.Close() }
+
+// postgresQueries implements db.Querier with the PostgreSQL queries. Both
+// schemas use the same column types, so the generated params, rows and
+// models only differ in their package and convert directly.
+type postgresQueries struct {
+	q *pgdb.Queries
+}
+
+var _ db.Querier = (*postgresQueries)(nil)
+
+func convertRows[P, D any](rows []P, convert func(P) D) []D {
+	if rows == nil {
+		return nil
+	}
+	out := make([]D, len(rows))
+	for i, row := range rows {
+		out[i] = convert(row)
+	}
+	return out
+}
+
+func (q *postgresQueries) CompleteQueueItem(ctx context.Context, id int64) error {
+	return q.q.CompleteQueueItem(ctx, id)
+}
+
+func (q *postgresQueries) CountActiveUsers(ctx context.Context) (int64, error) {
+	return q.q.CountActiveUsers(ctx)
+}
+
+func (q *postgresQueries) CountClassifierDecisions(ctx context.Context, targetID int64) ([]db.CountClassifierDecisionsRow, error) {
+	rows, err := q.q.CountClassifierDecisions(ctx, targetID)
+	return convertRows(rows, func(r pgdb.CountClassifierDecisionsRow) db.CountClassifierDecisionsRow {
+		return db.CountClassifierDecisionsRow(r)
+	}), err
+}
+
+func (q *postgresQueries) CountRunLogs(ctx context.Context, runID sql.NullInt64) ([]db.CountRunLogsRow, error) {
+	rows, err := q.q.CountRunLogs(ctx, runID)
+	return convertRows(rows, func(r pgdb.CountRunLogsRow) db.CountRunLogsRow {
+		return db.CountRunLogsRow(r)
+	}), err
+}
+
+func (q *postgresQueries) CountRunQueueItems(ctx context.Context, runID sql.NullInt64) ([]db.CountRunQueueItemsRow, error) {
+	rows, err := q.q.CountRunQueueItems(ctx, runID)
+	return convertRows(rows, func(r pgdb.CountRunQueueItemsRow) db.CountRunQueueItemsRow {
+		return db.CountRunQueueItemsRow(r)
+	}), err
+}
+
+func (q *postgresQueries) CreateAPIToken(ctx context.Context, arg db.CreateAPITokenParams) (db.ScraperApiToken, error) {
+	row, err := q.q.CreateAPIToken(ctx, pgdb.CreateAPITokenParams(arg))
+	return db.ScraperApiToken(row), err
+}
+
+func (q *postgresQueries) CreateRun(ctx context.Context, arg db.CreateRunParams) (db.ScraperRun, error) {
+	row, err := q.q.CreateRun(ctx, pgdb.CreateRunParams(arg))
+	return db.ScraperRun(row), err
+}
+
+func (q *postgresQueries) CreateSession(ctx context.Context, arg db.CreateSessionParams) error {
+	return q.q.CreateSession(ctx, pgdb.CreateSessionParams(arg))
+}
+
+func (q *postgresQueries) CreateTarget(ctx context.Context, arg db.CreateTargetParams) (db.ScraperTarget, error) {
+	row, err := q.q.CreateTarget(ctx, pgdb.CreateTargetParams(arg))
+	return db.ScraperTarget(row), err
+}
+
+func (q *postgresQueries) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.ScraperUser, error) {
+	row, err := q.q.CreateUser(ctx, pgdb.CreateUserParams(arg))
+	return db.ScraperUser(row), err
+}
+
+func (q *postgresQueries) DeactivateTarget(ctx context.Context, id int64) error {
+	return q.q.DeactivateTarget(ctx, id)
+}
+
+func (q *postgresQueries) DeleteAPIToken(ctx context.Context, id int64) (int64, error) {
+	return q.q.DeleteAPIToken(ctx, id)
+}
+
+func (q *postgresQueries) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error) {
+	return q.q.DeleteExpiredSessions(ctx, expiresAt)
+}
+
+func (q *postgresQueries) DeleteOrphanContents(ctx context.Context) (int64, error) {
+	return q.q.DeleteOrphanContents(ctx)
+}
+
+func (q *postgresQueries) DeleteQueueItem(ctx context.Context, id int64) (int64, error) {
+	return q.q.DeleteQueueItem(ctx, id)
+}
+
+func (q *postgresQueries) DeleteSession(ctx context.Context, id string) error {
+	return q.q.DeleteSession(ctx, id)
+}
+
+func (q *postgresQueries) DeleteUserSessions(ctx context.Context, userID int64) error {
+	return q.q.DeleteUserSessions(ctx, userID)
+}
+
+func (q *postgresQueries) DequeuePendingURL(ctx context.Context, runID sql.NullInt64) (db.ScraperQueue, error) {
+	row, err := q.q.DequeuePendingURL(ctx, runID)
+	return db.ScraperQueue(row), err
+}
+
+func (q *postgresQueries) EnqueueDiscoveredURL(ctx context.Context, arg db.EnqueueDiscoveredURLParams) (int64, error) {
+	return q.q.EnqueueDiscoveredURL(ctx, pgdb.EnqueueDiscoveredURLParams(arg))
+}
+
+func (q *postgresQueries) EnqueueURL(ctx context.Context, arg db.EnqueueURLParams) (db.ScraperQueue, error) {
+	row, err := q.q.EnqueueURL(ctx, pgdb.EnqueueURLParams(arg))
+	return db.ScraperQueue(row), err
+}
+
+func (q *postgresQueries) FailQueueItem(ctx context.Context, arg db.FailQueueItemParams) error {
+	return q.q.FailQueueItem(ctx, pgdb.FailQueueItemParams(arg))
+}
+
+func (q *postgresQueries) FinishRun(ctx context.Context, arg db.FinishRunParams) error {
+	return q.q.FinishRun(ctx, pgdb.FinishRunParams(arg))
+}
+
+func (q *postgresQueries) GetAPITokenUser(ctx context.Context, tokenHash string) (db.GetAPITokenUserRow, error) {
+	row, err := q.q.GetAPITokenUser(ctx, tokenHash)
+	return db.GetAPITokenUserRow(row), err
+}
+
+func (q *postgresQueries) GetConfig(ctx context.Context, key string) (string, error) {
+	return q.q.GetConfig(ctx, key)
+}
+
+func (q *postgresQueries) GetContent(ctx context.Context, contentHash string) (db.GetContentRow, error) {
+	row, err := q.q.GetContent(ctx, contentHash)
+	return db.GetContentRow(row), err
+}
+
+func (q *postgresQueries) GetContentStats(ctx context.Context) (db.GetContentStatsRow, error) {
+	row, err := q.q.GetContentStats(ctx)
+	return db.GetContentStatsRow(row), err
+}
+
+func (q *postgresQueries) GetLogsByLevel(ctx context.Context, arg db.GetLogsByLevelParams) ([]db.ScraperLog, error) {
+	rows, err := q.q.GetLogsByLevel(ctx, pgdb.GetLogsByLevelParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperLog) db.ScraperLog { return db.ScraperLog(r) }), err
+}
+
+func (q *postgresQueries) GetLogsByTarget(ctx context.Context, arg db.GetLogsByTargetParams) ([]db.ScraperLog, error) {
+	rows, err := q.q.GetLogsByTarget(ctx, pgdb.GetLogsByTargetParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperLog) db.ScraperLog { return db.ScraperLog(r) }), err
+}
+
+func (q *postgresQueries) GetPage(ctx context.Context, id int64) (db.ScraperPage, error) {
+	row, err := q.q.GetPage(ctx, id)
+	return db.ScraperPage(row), err
+}
+
+func (q *postgresQueries) GetPageByPath(ctx context.Context, arg db.GetPageByPathParams) (db.ScraperPage, error) {
+	row, err := q.q.GetPageByPath(ctx, pgdb.GetPageByPathParams(arg))
+	return db.ScraperPage(row), err
+}
+
+func (q *postgresQueries) GetPageClassifier(ctx context.Context, arg db.GetPageClassifierParams) (db.GetPageClassifierRow, error) {
+	row, err := q.q.GetPageClassifier(ctx, pgdb.GetPageClassifierParams(arg))
+	return db.GetPageClassifierRow(row), err
+}
+
+func (q *postgresQueries) GetPageContentHash(ctx context.Context, arg db.GetPageContentHashParams) (sql.NullString, error) {
+	return q.q.GetPageContentHash(ctx, pgdb.GetPageContentHashParams(arg))
+}
+
+func (q *postgresQueries) GetPendingQueueCount(ctx context.Context) (int64, error) {
+	return q.q.GetPendingQueueCount(ctx)
+}
+
+func (q *postgresQueries) GetQueueItem(ctx context.Context, id int64) (db.ScraperQueue, error) {
+	row, err := q.q.GetQueueItem(ctx, id)
+	return db.ScraperQueue(row), err
+}
+
+func (q *postgresQueries) GetQueueStats(ctx context.Context) (db.GetQueueStatsRow, error) {
+	row, err := q.q.GetQueueStats(ctx)
+	return db.GetQueueStatsRow(row), err
+}
+
+func (q *postgresQueries) GetRecentErrorsCount(ctx context.Context) (int64, error) {
+	return q.q.GetRecentErrorsCount(ctx)
+}
+
+func (q *postgresQueries) GetRecentLogs(ctx context.Context, limit int64) ([]db.ScraperLog, error) {
+	rows, err := q.q.GetRecentLogs(ctx, limit)
+	return convertRows(rows, func(r pgdb.ScraperLog) db.ScraperLog { return db.ScraperLog(r) }), err
+}
+
+func (q *postgresQueries) GetRun(ctx context.Context, id int64) (db.ScraperRun, error) {
+	row, err := q.q.GetRun(ctx, id)
+	return db.ScraperRun(row), err
+}
+
+func (q *postgresQueries) GetRunningRun(ctx context.Context) (db.ScraperRun, error) {
+	row, err := q.q.GetRunningRun(ctx)
+	return db.ScraperRun(row), err
+}
+
+func (q *postgresQueries) GetSessionUser(ctx context.Context, id string) (db.GetSessionUserRow, error) {
+	row, err := q.q.GetSessionUser(ctx, id)
+	return db.GetSessionUserRow(row), err
+}
+
+func (q *postgresQueries) GetTarget(ctx context.Context, id int64) (db.ScraperTarget, error) {
+	row, err := q.q.GetTarget(ctx, id)
+	return db.ScraperTarget(row), err
+}
+
+func (q *postgresQueries) GetTargetByDomain(ctx context.Context, domainName sql.NullString) (db.ScraperTarget, error) {
+	row, err := q.q.GetTargetByDomain(ctx, domainName)
+	return db.ScraperTarget(row), err
+}
+
+func (q *postgresQueries) GetTargetByURL(ctx context.Context, websiteUrl string) (db.ScraperTarget, error) {
+	row, err := q.q.GetTargetByURL(ctx, websiteUrl)
+	return db.ScraperTarget(row), err
+}
+
+func (q *postgresQueries) GetTargetCount(ctx context.Context) (int64, error) {
+	return q.q.GetTargetCount(ctx)
+}
+
+func (q *postgresQueries) GetTargetQueueStats(ctx context.Context, targetID int64) (db.GetTargetQueueStatsRow, error) {
+	row, err := q.q.GetTargetQueueStats(ctx, targetID)
+	return db.GetTargetQueueStatsRow(row), err
+}
+
+func (q *postgresQueries) GetTotalPagesCount(ctx context.Context) (int64, error) {
+	return q.q.GetTotalPagesCount(ctx)
+}
+
+func (q *postgresQueries) GetUser(ctx context.Context, id int64) (db.ScraperUser, error) {
+	row, err := q.q.GetUser(ctx, id)
+	return db.ScraperUser(row), err
+}
+
+func (q *postgresQueries) GetUserByUsername(ctx context.Context, username string) (db.ScraperUser, error) {
+	row, err := q.q.GetUserByUsername(ctx, username)
+	return db.ScraperUser(row), err
+}
+
+func (q *postgresQueries) ImportPage(ctx context.Context, arg db.ImportPageParams) (int64, error) {
+	return q.q.ImportPage(ctx, pgdb.ImportPageParams(arg))
+}
+
+func (q *postgresQueries) ListAPITokens(ctx context.Context) ([]db.ListAPITokensRow, error) {
+	rows, err := q.q.ListAPITokens(ctx)
+	return convertRows(rows, func(r pgdb.ListAPITokensRow) db.ListAPITokensRow {
+		return db.ListAPITokensRow(r)
+	}), err
+}
+
+func (q *postgresQueries) ListActiveTargets(ctx context.Context) ([]db.ScraperTarget, error) {
+	rows, err := q.q.ListActiveTargets(ctx)
+	return convertRows(rows, func(r pgdb.ScraperTarget) db.ScraperTarget { return db.ScraperTarget(r) }), err
+}
+
+func (q *postgresQueries) ListAllConfig(ctx context.Context) ([]db.ScraperConfig, error) {
+	rows, err := q.q.ListAllConfig(ctx)
+	return convertRows(rows, func(r pgdb.ScraperConfig) db.ScraperConfig { return db.ScraperConfig(r) }), err
+}
+
+func (q *postgresQueries) ListAllTargets(ctx context.Context) ([]db.ScraperTarget, error) {
+	rows, err := q.q.ListAllTargets(ctx)
+	return convertRows(rows, func(r pgdb.ScraperTarget) db.ScraperTarget { return db.ScraperTarget(r) }), err
+}
+
+func (q *postgresQueries) ListLogs(ctx context.Context, arg db.ListLogsParams) ([]db.ScraperLog, error) {
+	rows, err := q.q.ListLogs(ctx, pgdb.ListLogsParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperLog) db.ScraperLog {
+		return db.ScraperLog(r)
+	}), err
+}
+
+func (q *postgresQueries) ListPagePaths(ctx context.Context, arg db.ListPagePathsParams) ([]string, error) {
+	return q.q.ListPagePaths(ctx, pgdb.ListPagePathsParams(arg))
+}
+
+func (q *postgresQueries) ListPageSummaries(ctx context.Context, arg db.ListPageSummariesParams) ([]db.ListPageSummariesRow, error) {
+	rows, err := q.q.ListPageSummaries(ctx, pgdb.ListPageSummariesParams(arg))
+	return convertRows(rows, func(r pgdb.ListPageSummariesRow) db.ListPageSummariesRow {
+		return db.ListPageSummariesRow(r)
+	}), err
+}
+
+func (q *postgresQueries) ListPagesByTarget(ctx context.Context, arg db.ListPagesByTargetParams) ([]db.ScraperPage, error) {
+	rows, err := q.q.ListPagesByTarget(ctx, pgdb.ListPagesByTargetParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperPage) db.ScraperPage { return db.ScraperPage(r) }), err
+}
+
+func (q *postgresQueries) ListPagesForExport(ctx context.Context, arg db.ListPagesForExportParams) ([]db.ListPagesForExportRow, error) {
+	rows, err := q.q.ListPagesForExport(ctx, pgdb.ListPagesForExportParams(arg))
+	return convertRows(rows, func(r pgdb.ListPagesForExportRow) db.ListPagesForExportRow {
+		return db.ListPagesForExportRow(r)
+	}), err
+}
+
+func (q *postgresQueries) ListPagesForPipeline(ctx context.Context, arg db.ListPagesForPipelineParams) ([]db.ListPagesForPipelineRow, error) {
+	rows, err := q.q.ListPagesForPipeline(ctx, pgdb.ListPagesForPipelineParams(arg))
+	return convertRows(rows, func(r pgdb.ListPagesForPipelineRow) db.ListPagesForPipelineRow { return db.ListPagesForPipelineRow(r) }), err
+}
+
+func (q *postgresQueries) ListPagesForReclassify(ctx context.Context, arg db.ListPagesForReclassifyParams) ([]db.ListPagesForReclassifyRow, error) {
+	rows, err := q.q.ListPagesForReclassify(ctx, pgdb.ListPagesForReclassifyParams(arg))
+	return convertRows(rows, func(r pgdb.ListPagesForReclassifyRow) db.ListPagesForReclassifyRow {
+		return db.ListPagesForReclassifyRow(r)
+	}), err
+}
+
+func (q *postgresQueries) ListQueueItems(ctx context.Context, arg db.ListQueueItemsParams) ([]db.ScraperQueue, error) {
+	rows, err := q.q.ListQueueItems(ctx, pgdb.ListQueueItemsParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperQueue) db.ScraperQueue {
+		return db.ScraperQueue(r)
+	}), err
+}
+
+func (q *postgresQueries) ListRecentQueueFailures(ctx context.Context, arg db.ListRecentQueueFailuresParams) ([]db.ScraperQueue, error) {
+	rows, err := q.q.ListRecentQueueFailures(ctx, pgdb.ListRecentQueueFailuresParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperQueue) db.ScraperQueue {
+		return db.ScraperQueue(r)
+	}), err
+}
+
+func (q *postgresQueries) ListRuns(ctx context.Context, limit int64) ([]db.ScraperRun, error) {
+	rows, err := q.q.ListRuns(ctx, limit)
+	return convertRows(rows, func(r pgdb.ScraperRun) db.ScraperRun {
+		return db.ScraperRun(r)
+	}), err
+}
+
+func (q *postgresQueries) ListTargetsPage(ctx context.Context, arg db.ListTargetsPageParams) ([]db.ScraperTarget, error) {
+	rows, err := q.q.ListTargetsPage(ctx, pgdb.ListTargetsPageParams(arg))
+	return convertRows(rows, func(r pgdb.ScraperTarget) db.ScraperTarget {
+		return db.ScraperTarget(r)
+	}), err
+}
+
+func (q *postgresQueries) ListUsers(ctx context.Context) ([]db.ScraperUser, error) {
+	rows, err := q.q.ListUsers(ctx)
+	return convertRows(rows, func(r pgdb.ScraperUser) db.ScraperUser {
+		return db.ScraperUser(r)
+	}), err
+}
+
+func (q *postgresQueries) LogMessage(ctx context.Context, arg db.LogMessageParams) error {
+	return q.q.LogMessage(ctx, pgdb.LogMessageParams(arg))
+}
+
+func (q *postgresQueries) MarkPageVisited(ctx context.Context, arg db.MarkPageVisitedParams) error {
+	return q.q.MarkPageVisited(ctx, pgdb.MarkPageVisitedParams(arg))
+}
+
+func (q *postgresQueries) PutContent(ctx context.Context, arg db.PutContentParams) error {
+	return q.q.PutContent(ctx, pgdb.PutContentParams(arg))
+}
+
+func (q *postgresQueries) RecordUserLogin(ctx context.Context, id int64) error {
+	return q.q.RecordUserLogin(ctx, id)
+}
+
+func (q *postgresQueries) RetryFailedItem(ctx context.Context, id int64) error {
+	return q.q.RetryFailedItem(ctx, id)
+}
+
+func (q *postgresQueries) SavePage(ctx context.Context, arg db.SavePageParams) (db.ScraperPage, error) {
+	row, err := q.q.SavePage(ctx, pgdb.SavePageParams(arg))
+	return db.ScraperPage(row), err
+}
+
+func (q *postgresQueries) SavePageClassifier(ctx context.Context, arg db.SavePageClassifierParams) error {
+	return q.q.SavePageClassifier(ctx, pgdb.SavePageClassifierParams(arg))
+}
+
+func (q *postgresQueries) SavePagePipelineStatus(ctx context.Context, arg db.SavePagePipelineStatusParams) error {
+	return q.q.SavePagePipelineStatus(ctx, pgdb.SavePagePipelineStatusParams(arg))
+}
+
+func (q *postgresQueries) SavePageQuotes(ctx context.Context, arg db.SavePageQuotesParams) error {
+	return q.q.SavePageQuotes(ctx, pgdb.SavePageQuotesParams(arg))
+}
+
+func (q *postgresQueries) SetConfig(ctx context.Context, arg db.SetConfigParams) error {
+	return q.q.SetConfig(ctx, pgdb.SetConfigParams(arg))
+}
+
+func (q *postgresQueries) SetUserActive(ctx context.Context, arg db.SetUserActiveParams) (int64, error) {
+	return q.q.SetUserActive(ctx, pgdb.SetUserActiveParams(arg))
+}
+
+func (q *postgresQueries) SkipQueueItem(ctx context.Context, id int64) error {
+	return q.q.SkipQueueItem(ctx, id)
+}
+
+func (q *postgresQueries) TouchAPIToken(ctx context.Context, id int64) error {
+	return q.q.TouchAPIToken(ctx, id)
+}
+
+func (q *postgresQueries) UpdateTarget(ctx context.Context, arg db.UpdateTargetParams) (db.ScraperTarget, error) {
+	row, err := q.q.UpdateTarget(ctx, pgdb.UpdateTargetParams(arg))
+	return db.ScraperTarget(row), err
+}
+
+func (q *postgresQueries) UpdateTargetClassifierOverrides(ctx context.Context, arg db.UpdateTargetClassifierOverridesParams) error {
+	return q.q.UpdateTargetClassifierOverrides(ctx, pgdb.UpdateTargetClassifierOverridesParams(arg))
+}
+
+func (q *postgresQueries) UpdateTargetLastVisited(ctx context.Context, id int64) error {
+	return q.q.UpdateTargetLastVisited(ctx, id)
+}
+
+func (q *postgresQueries) UpdateTargetLearnedTemplate(ctx context.Context, arg db.UpdateTargetLearnedTemplateParams) error {
+	return q.q.UpdateTargetLearnedTemplate(ctx, pgdb.UpdateTargetLearnedTemplateParams(arg))
+}
+
+func (q *postgresQueries) UpdateTargetPatterns(ctx context.Context, arg db.UpdateTargetPatternsParams) error {
+	return q.q.UpdateTargetPatterns(ctx, pgdb.UpdateTargetPatternsParams(arg))
+}
+
+func (q *postgresQueries) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (int64, error) {
+	return q.q.UpdateUserPassword(ctx, pgdb.UpdateUserPasswordParams(arg))
+}
+
+func (q *postgresQueries) UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (int64, error) {
+	return q.q.UpdateUserRole(ctx, pgdb.UpdateUserRoleParams(arg))
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"app/internal/scraper/db"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DSNEnv is the environment variable selecting the storage backend
+const DSNEnv = "SCRAPER_DATABASE_URL"
+
+// DefaultDSN is the local SQLite database used when no DSN is configured
+const DefaultDSN = "data/scraper.db?_journal_mode=WAL"
+
+// Dialect is the SQL dialect of a storage backend
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+func (d Dialect) driverName() string {
+	if d == DialectPostgres {
+		return "pgx"
+	}
+	return "sqlite3"
+}
+
+// Store is a storage backend: a database connection and the sqlc queries of its dialect
+type Store interface {
+	DB() *sql.DB
+	Queries() db.Querier
+	// WithTx returns queries running inside tx
+	WithTx(tx *sql.Tx) db.Querier
+	Dialect() Dialect
+	Close() error
+}
+
+// ParseDSN returns the dialect selected by a DSN and the data source name for its driver.
+// postgres:// and postgresql:// URLs select PostgreSQL; sqlite3:// or sqlite:// URLs
+// and plain file paths select SQLite.
+func ParseDSN(dsn string) (Dialect, string, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return "", "", fmt.Errorf("empty database DSN")
+	}
+	scheme, rest, hasScheme := strings.Cut(dsn, "://")
+	if !hasScheme {
+		return DialectSQLite, dsn, nil
+	}
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return DialectPostgres, dsn, nil
+	case "sqlite", "sqlite3":
+		if rest == "" {
+			return "", "", fmt.Errorf("database DSN %q has no file path", dsn)
+		}
+		return DialectSQLite, rest, nil
+	default:
+		return "", "", fmt.Errorf("unsupported database DSN scheme %q", scheme)
+	}
+}
+
+// Open opens the storage backend selected by the DSN
+func Open(dsn string) (Store, error) {
+	dialect, source, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		if err := ensureSQLiteDir(source); err != nil {
+			return nil, err
+		}
+		source = withWAL(source)
+	}
+	database, err := sql.Open(dialect.driverName(), source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if dialect == DialectPostgres {
+		return NewPostgres(database), nil
+	}
+	return NewSQLite(database), nil
+}
+
+// ensureSQLiteDir creates the directory of a SQLite database file, a fresh checkout has no data/
+func ensureSQLiteDir(source string) error {
+	path, _, _ := strings.Cut(strings.TrimPrefix(source, "file:"), "?")
+	if path == "" || path == ":memory:" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+	return nil
+}
+
+// withWAL enables WAL mode unless the DSN selects a journal mode, so readers
+// like the UI don't block a running crawl
+func withWAL(source string) string {
+	path, query, _ := strings.Cut(source, "?")
+	if path == ":memory:" || strings.Contains(query, "_journal") {
+		return source
+	}
+	if query == "" {
+		return path + "?_journal_mode=WAL"
+	}
+	return source + "&_journal_mode=WAL"
+}
+
+// sqliteStore runs the queries generated from db/queries
+type sqliteStore struct {
+	db      *sql.DB
+	queries *db.Queries
+}
+
+// NewSQLite wraps an open SQLite database
+func NewSQLite(database *sql.DB) Store {
+	return &sqliteStore{db: database, queries: db.New(database)}
+}
+
+func (s *sqliteStore) DB() *sql.DB                  { return s.db }
+func (s *sqliteStore) Queries() db.Querier          { return s.queries }
+func (s *sqliteStore) WithTx(tx *sql.Tx) db.Querier { return s.queries.WithTx(tx) }
+func (s *sqliteStore) Dialect() Dialect             { return DialectSQLite }
+func (s *sqliteStore) Close() error                 { return s.db.Close() }
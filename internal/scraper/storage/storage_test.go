@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/scraper/db"
+)
+
+const (
+	sqliteMigrations   = "../db/migrations"
+	postgresMigrations = "../db/postgres/migrations"
+)
+
+// postgresDSNEnv points the PostgreSQL tests at a server, e.g. the compose
+// service: docker compose --profile postgres up postgres. Without it they are
+// skipped unless run with -tags embeddedpg, which starts a throwaway server.
+const postgresDSNEnv = "SCRAPER_TEST_POSTGRES_DSN"
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn     string
+		dialect Dialect
+		source  string
+		wantErr bool
+	}{
+		{dsn: DefaultDSN, dialect: DialectSQLite, source: DefaultDSN},
+		{dsn: ":memory:", dialect: DialectSQLite, source: ":memory:"},
+		{dsn: "sqlite3://data/scraper.db", dialect: DialectSQLite, source: "data/scraper.db"},
+		{dsn: "sqlite:///var/lib/scraper.db", dialect: DialectSQLite, source: "/var/lib/scraper.db"},
+		{dsn: "postgres://u:p@db:5432/scraper?sslmode=disable", dialect: DialectPostgres, source: "postgres://u:p@db:5432/scraper?sslmode=disable"},
+		{dsn: "postgresql://db/scraper", dialect: DialectPostgres, source: "postgresql://db/scraper"},
+		{dsn: "mysql://db/scraper", wantErr: true},
+		{dsn: "sqlite3://", wantErr: true},
+		{dsn: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		dialect, source, err := ParseDSN(tt.dsn)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDSN(%q): expected error", tt.dsn)
+			}
+			continue
+		}
+		if err != nil || dialect != tt.dialect || source != tt.source {
+			t.Errorf("ParseDSN(%q) = %q, %q, %v; want %q, %q", tt.dsn, dialect, source, err, tt.dialect, tt.source)
+		}
+	}
+}
+
+func TestWithWAL(t *testing.T) {
+	tests := map[string]string{
+		"data/scraper.db":                      "data/scraper.db?_journal_mode=WAL",
+		"data/scraper.db?_busy_timeout=5000":   "data/scraper.db?_busy_timeout=5000&_journal_mode=WAL",
+		"data/scraper.db?_journal_mode=WAL":    "data/scraper.db?_journal_mode=WAL",
+		"data/scraper.db?_journal_mode=DELETE": "data/scraper.db?_journal_mode=DELETE",
+		":memory:":                             ":memory:",
+	}
+	for source, want := range tests {
+		if got := withWAL(source); got != want {
+			t.Errorf("withWAL(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+// Every migration and query must exist for both dialects
+func TestDialectParity(t *testing.T) {
+	for _, pattern := range []string{"*.up.sql", "*.down.sql"} {
+		sqlite := globNames(t, filepath.Join(sqliteMigrations, pattern))
+		postgres := globNames(t, filepath.Join(postgresMigrations, pattern))
+		if strings.Join(sqlite, ",") != strings.Join(postgres, ",") {
+			t.Errorf("migrations differ:\nsqlite:   %v\npostgres: %v", sqlite, postgres)
+		}
+	}
+
+	sqliteQueries := queryNames(t, "../db/queries")
+	postgresQueries := queryNames(t, "../db/postgres/queries")
+	for name, cmd := range sqliteQueries {
+		if postgresQueries[name] != cmd {
+			t.Errorf("query %s %s: postgres has %q", name, cmd, postgresQueries[name])
+		}
+	}
+	for name := range postgresQueries {
+		if _, ok := sqliteQueries[name]; !ok {
+			t.Errorf("query %s only exists for postgres", name)
+		}
+	}
+}
+
+func globNames(t *testing.T, pattern string) []string {
+	t.Helper()
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", pattern, err)
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = filepath.Base(f)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var queryNameRe = regexp.MustCompile(`(?m)^-- name: (\w+) (:\w+)`)
+
+// queryNames returns the sqlc query names of a queries directory with their command
+func queryNames(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no queries in %s: %v", dir, err)
+	}
+	names := map[string]string{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f, err)
+		}
+		for _, m := range queryNameRe.FindAllStringSubmatch(string(data), -1) {
+			names[m[1]] = m[2]
+		}
+	}
+	return names
+}
+
+func applyMigrations(t *testing.T, database *sql.DB, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(files)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read migration %s: %v", path, err)
+		}
+		if _, err := database.Exec(string(data)); err != nil {
+			t.Fatalf("failed to exec migration %s: %v", path, err)
+		}
+	}
+}
+
+func TestOpen_SQLite(t *testing.T) {
+	store, err := Open("sqlite3://" + filepath.Join(t.TempDir(), "data", "scraper.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if store.Dialect() != DialectSQLite {
+		t.Fatalf("expected sqlite store, got %s", store.Dialect())
+	}
+	applyMigrations(t, store.DB(), sqliteMigrations)
+	exerciseQueue(t, store)
+}
+
+// newPostgresStore opens a store on a fresh schema of the test server
+func newPostgresStore(t *testing.T) Store {
+	t.Helper()
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set; set it or run with -tags embeddedpg", postgresDSNEnv)
+	}
+	admin, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	schema := fmt.Sprintf("scraper_test_%d", time.Now().UnixNano())
+	if _, err := admin.DB().Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.DB().Exec("DROP SCHEMA " + schema + " CASCADE")
+		_ = admin.Close()
+	})
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	store, err := Open(dsn + sep + "search_path=" + schema)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if store.Dialect() != DialectPostgres {
+		t.Fatalf("expected postgres store, got %s", store.Dialect())
+	}
+	applyMigrations(t, store.DB(), postgresMigrations)
+	return store
+}
+
+func TestPostgresStore_Queries(t *testing.T) {
+	store := newPostgresStore(t)
+	exerciseQueue(t, store)
+
+	ctx := context.Background()
+	q := store.Queries()
+	target, err := q.GetTargetByURL(ctx, "https://example.com")
+	if err != nil {
+		t.Fatalf("GetTargetByURL failed: %v", err)
+	}
+	page, err := q.SavePage(ctx, db.SavePageParams{
+		TargetID:    target.ID,
+		UrlPath:     "/quotes",
+		FullUrl:     "https://example.com/quotes",
+		ContentHash: sql.NullString{String: "abc", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("SavePage failed: %v", err)
+	}
+	if page, err = q.SavePage(ctx, db.SavePageParams{TargetID: target.ID, UrlPath: "/quotes", FullUrl: page.FullUrl, ContentHash: page.ContentHash}); err != nil || page.VisitCount.Int64 != 2 {
+		t.Fatalf("expected upsert to count the visit, got %+v %v", page.VisitCount, err)
+	}
+	if err := q.MarkPageVisited(ctx, db.MarkPageVisitedParams{ID: page.ID, HttpStatusCode: sql.NullInt64{Int64: 200, Valid: true}}); err != nil {
+		t.Fatalf("MarkPageVisited failed: %v", err)
+	}
+	if page, err = q.GetPageByPath(ctx, db.GetPageByPathParams{TargetID: target.ID, UrlPath: "/quotes"}); err != nil || page.VisitCount.Int64 != 3 || page.HttpStatusCode.Int64 != 200 {
+		t.Fatalf("expected the visit recorded, got %+v %+v %v", page.VisitCount, page.HttpStatusCode, err)
+	}
+	if err := q.PutContent(ctx, db.PutContentParams{ContentKey: "abc", Encoding: "identity", Body: []byte("<p>x</p>"), RawSize: 8, StoredSize: 8}); err != nil {
+		t.Fatalf("PutContent failed: %v", err)
+	}
+	if row, err := q.GetContent(ctx, "abc"); err != nil || string(row.Body) != "<p>x</p>" {
+		t.Fatalf("GetContent = %q, %v", row.Body, err)
+	}
+
+	err = q.SavePageClassifier(ctx, db.SavePageClassifierParams{
+		QuoteClassifierJson: sql.NullString{String: `{"decision":{"decision_reason":"SHORT_MAIN_TEXT"}}`, Valid: true},
+		Processable:         sql.NullBool{Valid: true},
+		TargetID:            target.ID,
+		UrlPath:             "/quotes",
+	})
+	if err != nil {
+		t.Fatalf("SavePageClassifier failed: %v", err)
+	}
+	reclassify, err := q.ListPagesForReclassify(ctx, db.ListPagesForReclassifyParams{
+		Reason:    sql.NullString{String: "SHORT_MAIN_TEXT", Valid: true},
+		BatchSize: 10,
+	})
+	if err != nil || len(reclassify) != 1 {
+		t.Fatalf("expected page selected by decision reason, got %d %v", len(reclassify), err)
+	}
+
+	err = q.SavePagePipelineStatus(ctx, db.SavePagePipelineStatusParams{
+		PipelineStatusJson: sql.NullString{String: `{"quote_extraction":{"status":"failed"}}`, Valid: true},
+		ID:                 page.ID,
+	})
+	if err != nil {
+		t.Fatalf("SavePagePipelineStatus failed: %v", err)
+	}
+	failed, err := q.ListPagesForPipeline(ctx, db.ListPagesForPipelineParams{
+		FailedStage: sql.NullString{String: "quote_extraction", Valid: true},
+		BatchSize:   10,
+	})
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("expected page selected by failed stage, got %d %v", len(failed), err)
+	}
+
+	queued, err := q.EnqueueDiscoveredURL(ctx, db.EnqueueDiscoveredURLParams{TargetID: target.ID, Url: "/quotes", Priority: sql.NullInt64{Int64: -1, Valid: true}})
+	if err != nil || queued != 0 {
+		t.Errorf("expected stored page not re-queued, got %d %v", queued, err)
+	}
+	if stats, err := q.GetContentStats(ctx); err != nil || stats.Contents != 1 || stats.RawBytes != 8 {
+		t.Errorf("unexpected content stats %+v %v", stats, err)
+	}
+	if _, err := q.GetRecentErrorsCount(ctx); err != nil {
+		t.Errorf("GetRecentErrorsCount failed: %v", err)
+	}
+}
+
+// A URL claimed in an open transaction is skipped, not waited for, by other crawlers
+func TestPostgresStore_DequeueSkipsLockedRows(t *testing.T) {
+	store := newPostgresStore(t)
+	ctx := context.Background()
+	q := store.Queries()
+	target, err := q.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		if _, err := q.EnqueueURL(ctx, db.EnqueueURLParams{TargetID: target.ID, Url: url}); err != nil {
+			t.Fatalf("EnqueueURL failed: %v", err)
+		}
+	}
+
+	tx, err := store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	first, err := store.WithTx(tx).DequeuePendingURL(ctx, sql.NullInt64{})
+	if err != nil {
+		t.Fatalf("DequeuePendingURL in transaction failed: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	second, err := q.DequeuePendingURL(waitCtx, sql.NullInt64{})
+	if err != nil {
+		t.Fatalf("DequeuePendingURL blocked on the claimed row: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("both crawlers dequeued %s", first.Url)
+	}
+}
+
+// exerciseQueue runs a URL through the queue of a migrated store
+func exerciseQueue(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	q := store.Queries()
+	target, err := q.CreateTarget(ctx, db.CreateTargetParams{WebsiteUrl: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+
+	tx, err := store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	for _, url := range []string{"https://example.com/low", "https://example.com/high"} {
+		priority := sql.NullInt64{Valid: true}
+		if strings.HasSuffix(url, "high") {
+			priority.Int64 = 10
+		}
+		if _, err := store.WithTx(tx).EnqueueURL(ctx, db.EnqueueURLParams{TargetID: target.ID, Url: url, Priority: priority}); err != nil {
+			t.Fatalf("EnqueueURL failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	run, err := q.CreateRun(ctx, db.CreateRunParams{TriggerSource: "cli", TargetIds: "[1]"})
+	if err != nil || run.Status != "running" {
+		t.Fatalf("expected a running run, got %+v %v", run, err)
+	}
+	runID := sql.NullInt64{Int64: run.ID, Valid: true}
+	item, err := q.DequeuePendingURL(ctx, runID)
+	if err != nil || item.Url != "https://example.com/high" || item.Status.String != "processing" || item.RunID != runID {
+		t.Fatalf("expected highest priority URL dequeued by the run, got %+v %v", item, err)
+	}
+	if err := q.CompleteQueueItem(ctx, item.ID); err != nil {
+		t.Fatalf("CompleteQueueItem failed: %v", err)
+	}
+	item, err = q.DequeuePendingURL(ctx, runID)
+	if err != nil {
+		t.Fatalf("DequeuePendingURL failed: %v", err)
+	}
+	if err := q.SkipQueueItem(ctx, item.ID); err != nil {
+		t.Fatalf("SkipQueueItem failed: %v", err)
+	}
+	stats, err := q.GetQueueStats(ctx)
+	if err != nil || stats.Pending != 0 || stats.Completed != 1 || stats.Skipped != 1 {
+		t.Errorf("unexpected queue stats %+v %v", stats, err)
+	}
+
+	if err := q.FinishRun(ctx, db.FinishRunParams{ID: run.ID, Status: "completed", TotalUrls: 2, Processed: 1}); err != nil {
+		t.Fatalf("FinishRun failed: %v", err)
+	}
+	run, err = q.GetRun(ctx, run.ID)
+	if err != nil || run.Status != "completed" || run.Processed != 1 || !run.FinishedAt.Valid {
+		t.Errorf("expected the finished run, got %+v %v", run, err)
+	}
+	counts, err := q.CountRunQueueItems(ctx, runID)
+	if err != nil || len(counts) != 2 || counts[0].Count+counts[1].Count != 2 {
+		t.Errorf("expected the run's completed and skipped items counted, got %+v %v", counts, err)
+	}
+}
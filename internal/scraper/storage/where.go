@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where builds the WHERE clause of a query filtered at runtime, for the
+// filters sqlc can't express. Conditions use ? placeholders, numbered for
+// PostgreSQL as they're added.
+type Where struct {
+	dialect Dialect
+	conds   []string
+	args    []any
+}
+
+func NewWhere(dialect Dialect) *Where {
+	return &Where{dialect: dialect}
+}
+
+// Add adds a condition with one argument per placeholder
+func (w *Where) Add(cond string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		if w.dialect == DialectPostgres {
+			cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(w.args)), 1)
+		}
+	}
+	w.conds = append(w.conds, cond)
+}
+
+// Contains adds a condition matching rows whose column contains substr
+func (w *Where) Contains(column, substr string) {
+	if w.dialect == DialectPostgres {
+		w.Add("strpos("+column+", ?) > 0", substr)
+	} else {
+		w.Add("instr("+column+", ?) > 0", substr)
+	}
+}
+
+// In adds a condition matching rows whose column is one of values
+func (w *Where) In(column string, values []string) {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	w.Add(column+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")+")", args...)
+}
+
+// Time returns t as an argument comparable with the timestamps the dialect
+// stores: SQLite's CURRENT_TIMESTAMP is UTC text
+func (w *Where) Time(t time.Time) any {
+	if w.dialect == DialectPostgres {
+		return t
+	}
+	return t.UTC().Format(time.DateTime)
+}
+
+// Postgres reports whether the conditions are for PostgreSQL
+func (w *Where) Postgres() bool {
+	return w.dialect == DialectPostgres
+}
+
+// Args returns the arguments of the placeholders in order
+func (w *Where) Args() []any {
+	return w.args
+}
+
+// String returns the clause with a leading space, empty without conditions
+func (w *Where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
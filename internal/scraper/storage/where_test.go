@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWhere(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		dialect Dialect
+		want    string
+		args    string
+	}{
+		{DialectSQLite, " WHERE id < ? AND instr(url, ?) > 0 AND status IN (?, ?) AND created_at < ?", "[9 /a failed completed 2026-03-01 11:00:00]"},
+		{DialectPostgres, " WHERE id < $1 AND strpos(url, $2) > 0 AND status IN ($3, $4) AND created_at < $5", fmt.Sprint([]any{9, "/a", "failed", "completed", at})},
+	}
+	for _, tc := range tests {
+		w := NewWhere(tc.dialect)
+		if w.String() != "" {
+			t.Errorf("%s: expected no clause without conditions", tc.dialect)
+		}
+		w.Add("id < ?", 9)
+		w.Contains("url", "/a")
+		w.In("status", []string{"failed", "completed"})
+		w.Add("created_at < ?", w.Time(at))
+		if got := w.String(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.dialect, got, tc.want)
+		}
+		if got := fmt.Sprint(w.Args()); got != tc.args {
+			t.Errorf("%s: got args %s, want %s", tc.dialect, got, tc.args)
+		}
+	}
+}
@@ -3,20 +3,9 @@ set -e
 
 sqlc generate -f ./internal/scraper/db/sqlc.yaml
 
-DB_PATH="./data/scraper.db"
-MIGRATIONS_PATH="./internal/scraper/db/migrations"
+# sqlite_fts5 compiles in SQLite full text search for the log browser
+go build -tags sqlite_fts5 -o ./bin/scraper-cli ./cmd/scraper/cli
 
-# Create DB file if missing
-if [ ! -f "$DB_PATH" ]; then
-    mkdir -p ./data
-fi
-
-# Apply all migrations using golang-migrate
-if command -v migrate >/dev/null 2>&1; then
-    migrate -database "sqlite3://$DB_PATH" -path "$MIGRATIONS_PATH" up
-else
-    echo "Please install golang-migrate: go install -tags 'sqlite3' github.com/golang-migrate/migrate/v4/cmd/migrate@latest"
-    exit 1
-fi
-
-go build -o ./bin/scraper-cli ./cmd/scraper/cli
\ No newline at end of file
+# Apply pending migrations to the database selected by SCRAPER_DATABASE_URL
+# (default ./data/scraper.db), the migrations are embedded in the binary
+./bin/scraper-cli db migrate up
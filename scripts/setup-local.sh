@@ -40,7 +40,7 @@ go install github.com/air-verse/air@latest
 go install github.com/sqlc-dev/sqlc/cmd/sqlc@latest
 go install github.com/go-delve/delve/cmd/dlv@latest
 go install github.com/swaggo/swag/cmd/swag@latest
-go install -tags 'sqlite3' github.com/golang-migrate/migrate/v4/cmd/migrate@latest
+go install -tags 'sqlite3 postgres' github.com/golang-migrate/migrate/v4/cmd/migrate@latest
 go install honnef.co/go/tools/cmd/staticcheck@latest
 go install github.com/google/yamlfmt/cmd/yamlfmt@latest
 go install github.com/a-h/templ/cmd/templ@latest